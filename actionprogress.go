@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// actionProgress follows the delete, move or link phase. On a terminal it
+// draws one progress line with the files done, the space freed (or staged,
+// when moving, trashing or quarantining) and the failures in place of a log line per file; either way
+// the failures are summed up by category at the end.
+type actionProgress struct {
+	doing    string // "Deleting", "Moving" or "Linking"
+	total    int    // files planned
+	done     int
+	failed   int
+	freed    int64
+	space    string // "freed", or "staged" for moves, which free nothing yet
+	failures []SkippedFile
+	live     bool      // draw the progress line instead of per-file lines
+	out      io.Writer // where the line is drawn
+	start    time.Time
+	drawn    time.Time
+}
+
+// newActionProgress starts following total planned actions. The line is
+// drawn only when stderr is a terminal outside -verbose, -json and
+// -interactive, which print or ask about every file.
+func newActionProgress(c Config, total int) *actionProgress {
+	doing, done := c.actionVerbs()
+	fd := os.Stderr.Fd()
+	live := !c.Verbose && !c.JSON && !c.Interactive && (isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd))
+	space := "freed"
+	if stagedActions[strings.ToLower(done)] {
+		space = "staged"
+	}
+	return &actionProgress{doing: doing, total: total, space: space, live: live, out: os.Stderr, start: time.Now()}
+}
+
+// succeeded counts a file acted on; line is its log line when not drawing
+func (p *actionProgress) succeeded(fh FileHash, line string) {
+	p.done++
+	p.freed += fh.Size
+	if !p.live {
+		log.Print(line)
+	}
+	p.draw(false)
+}
+
+// failedOn counts a file that could not be acted on; line, if any, is its
+// log line when not drawing
+func (p *actionProgress) failedOn(fh FileHash, err error, line string) {
+	p.failed++
+	p.failures = append(p.failures, SkippedFile{Path: fh.Path, Category: skipCategory(err), Reason: err.Error()})
+	if !p.live && line != "" {
+		log.Print(line)
+	}
+	p.draw(false)
+}
+
+// logf logs a message that must be seen even while the line is drawn
+func (p *actionProgress) logf(format string, args ...interface{}) {
+	p.breakLine()
+	log.Printf(format, args...)
+}
+
+// breakLine moves past a drawn line so log output starts on its own line
+func (p *actionProgress) breakLine() {
+	if p.live && !p.drawn.IsZero() {
+		fmt.Fprintln(p.out)
+		p.drawn = time.Time{}
+	}
+}
+
+// draw redraws the progress line at most every progressUpdateInterval,
+// or now when force is set
+func (p *actionProgress) draw(force bool) {
+	if !p.live || (!force && time.Since(p.drawn) < progressUpdateInterval) {
+		return
+	}
+	p.drawn = time.Now()
+	handled := p.done + p.failed
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(handled) * 100 / float64(p.total)
+	}
+	eta := "..."
+	if handled > 0 {
+		eta = formatDuration(time.Since(p.start).Seconds() / float64(handled) * float64(max(p.total-handled, 0)))
+	}
+	fmt.Fprintf(p.out, "\r%s%s: %d/%d (%.1f%%), %s %s, %d failed, ETA: %s   ",
+		emoji("🗑️ "), p.doing, handled, p.total, percent, p.space, formatBytes(p.freed), p.failed, eta)
+}
+
+// finish draws the final line and sums up the failures by category
+func (p *actionProgress) finish() {
+	if p.live && p.done+p.failed > 0 {
+		p.draw(true)
+		p.breakLine()
+	}
+	if len(p.failures) == 0 {
+		return
+	}
+	sortByCategory(p.failures)
+	log.Printf("%s%d files could not be processed:", emoji("❌"), len(p.failures))
+	logByCategory(p.failures)
+}
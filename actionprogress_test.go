@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActionProgress(t *testing.T) {
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+
+	var line bytes.Buffer
+	p := &actionProgress{doing: "Deleting", total: 4, space: "freed", live: true, out: &line, start: time.Now()}
+	p.succeeded(FileHash{Path: "a", Size: 1024}, "✓ Deleted a")
+	p.failedOn(FileHash{Path: "b"}, fmt.Errorf("remove b: %w", errFileLocked), "")
+	p.failedOn(FileHash{Path: "c"}, fs.ErrPermission, "❌ Failed to process c")
+	p.succeeded(FileHash{Path: "d", Size: 1024}, "✓ Deleted d")
+	p.finish()
+
+	if !strings.Contains(line.String(), "Deleting: 4/4 (100.0%), freed 2.0 KB, 2 failed") {
+		t.Errorf("progress line = %q", line.String())
+	}
+	got := logs.String()
+	if strings.Contains(got, "✓ Deleted") {
+		t.Errorf("per-file lines were logged while drawing:\n%s", got)
+	}
+	for _, want := range []string{"2 files could not be processed", "in use by another program: 1", "permission denied: 1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary is missing %q:\n%s", want, got)
+		}
+	}
+
+	// Without a terminal every file keeps its log line
+	logs.Reset()
+	p = &actionProgress{doing: "Deleting", total: 1, start: time.Now()}
+	p.succeeded(FileHash{Path: "a"}, "✓ Deleted a")
+	if !strings.Contains(logs.String(), "✓ Deleted a") {
+		t.Errorf("log = %q, want the per-file line", logs.String())
+	}
+}
+
+func TestActionProgressStaged(t *testing.T) {
+	for name, want := range map[string]string{"delete": "freed", "link": "freed", "move": "staged", "trash": "staged", "quarantine": "staged"} {
+		c := DefaultConfig()
+		switch name {
+		case "link":
+			c.Link = linkHardlink
+		case "move":
+			c.MoveTo = "moved"
+		case "trash":
+			c.Trash = true
+		case "quarantine":
+			c.QuarantineDeletes = true
+		}
+		if p := newActionProgress(c, 1); p.space != want {
+			t.Errorf("%s: progress line says %q, want %q", name, p.space, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// fileAge is a file age limit, as in -min-age and -max-age. Besides Go
+// durations such as 12h it takes whole days and weeks, e.g. 30d or 2w; in
+// config files it is a string in the same form.
+type fileAge time.Duration
+
+func (a *fileAge) String() string {
+	if a == nil || *a == 0 {
+		return "0"
+	}
+	d := time.Duration(*a)
+	switch {
+	case d%(7*day) == 0:
+		return strconv.FormatInt(int64(d/(7*day)), 10) + "w"
+	case d%day == 0:
+		return strconv.FormatInt(int64(d/day), 10) + "d"
+	}
+	return d.String()
+}
+
+func (a *fileAge) Set(value string) error {
+	value = strings.TrimSpace(value)
+	d, err := time.ParseDuration(value)
+	for suffix, unit := range map[string]time.Duration{"d": day, "w": 7 * day} {
+		if count, ok := strings.CutSuffix(value, suffix); ok {
+			var n int64
+			n, err = strconv.ParseInt(count, 10, 64)
+			d = time.Duration(n) * unit
+		}
+	}
+	if err != nil || d < 0 {
+		return fmt.Errorf("invalid age %q: want a duration such as 30d, 2w or 12h", value)
+	}
+	*a = fileAge(d)
+	return nil
+}
+
+func (a fileAge) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+func (a *fileAge) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return a.Set(s)
+}
+
+// rejectFile is reject for a file that has been statted: on top of size,
+// name and extension it checks -min-age and -max-age against the file's
+// modification time
+func (f *fileFilter) rejectFile(path string, info os.FileInfo) string {
+	if reason := f.reject(path, info.Size()); reason != "" {
+		return reason
+	}
+	age := time.Since(info.ModTime())
+	if f.minAge > 0 && age < time.Duration(f.minAge) {
+		return fmt.Sprintf("recent file (modified less than %s ago)", f.minAge.String())
+	}
+	if f.maxAge > 0 && age > time.Duration(f.maxAge) {
+		return fmt.Sprintf("old file (modified more than %s ago)", f.maxAge.String())
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileAgeSet(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		str   string
+	}{
+		{"30d", 30 * day, "30d"},
+		{"2w", 14 * day, "2w"},
+		{"12h", 12 * time.Hour, "12h0m0s"},
+		{"48h", 2 * day, "2d"},
+		{"0", 0, "0"},
+	}
+	for _, tt := range tests {
+		var a fileAge
+		if err := a.Set(tt.value); err != nil {
+			t.Errorf("Set(%q) = %v", tt.value, err)
+			continue
+		}
+		if time.Duration(a) != tt.want || a.String() != tt.str {
+			t.Errorf("Set(%q) = %v (%s), want %v (%s)", tt.value, time.Duration(a), a.String(), tt.want, tt.str)
+		}
+	}
+
+	for _, bad := range []string{"", "d", "30days", "-1d", "1.5w", "soon"} {
+		var a fileAge
+		if err := a.Set(bad); err == nil {
+			t.Errorf("Set(%q) should fail", bad)
+		}
+	}
+
+	var c struct{ MinAge fileAge }
+	if err := json.Unmarshal([]byte(`{"MinAge": "30d"}`), &c); err != nil || c.MinAge != fileAge(30*day) {
+		t.Errorf("config MinAge = %v, %v; want 30d", c.MinAge, err)
+	}
+	if data, _ := json.Marshal(c); string(data) != `{"MinAge":"30d"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+}
+
+func TestRejectFileAge(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	ages := map[string]time.Duration{"fresh.txt": time.Hour, "month.txt": 40 * day, "ancient.txt": 800 * day}
+	for name, age := range ages {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("content"), 0644)
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+	}
+
+	filter, err := newFileFilter(Config{MinSize: 1, MinAge: fileAge(30 * day), MaxAge: fileAge(365 * day)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"fresh.txt": false, "month.txt": true, "ancient.txt": false} {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filter.rejectFile(path, info) == ""; got != want {
+			t.Errorf("rejectFile(%s) passed = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+// hashIndex groups hashed files by size and content hash as they arrive.
+// It knows how many files of each size the scan will hash, so once the last
+// file of a size is in, every hash in that size seen only once is dropped.
+// Only duplicate candidates stay in memory, however large the scan.
+type hashIndex struct {
+	pending map[int64]int                   // files of each size still to come
+	buckets map[int64]map[string][]FileHash // sizes still waiting for files
+	kept    []FileHash                      // files of finished sizes that share a hash
+	added   int                             // files added so far
+	onGroup func(files []FileHash)          // called with each group of a finished size, may be nil
+}
+
+// newHashIndex creates an index expecting sizes[n] files of size n
+func newHashIndex(sizes map[int64]int) *hashIndex {
+	return &hashIndex{
+		pending: sizes,
+		buckets: make(map[int64]map[string][]FileHash),
+	}
+}
+
+// add inserts a hashed file and releases its size once it is complete.
+// A file whose size changed since the scan counted it stays until candidates.
+func (ix *hashIndex) add(fh FileHash) {
+	ix.added++
+	bucket := ix.buckets[fh.Size]
+	if bucket == nil {
+		bucket = make(map[string][]FileHash)
+		ix.buckets[fh.Size] = bucket
+	}
+	bucket[fh.Hash] = append(bucket[fh.Hash], fh)
+
+	n, ok := ix.pending[fh.Size]
+	if !ok {
+		return
+	}
+	if n > 1 {
+		ix.pending[fh.Size] = n - 1
+		return
+	}
+	delete(ix.pending, fh.Size)
+	ix.release(fh.Size)
+}
+
+// release keeps the files of a size that share a hash and forgets the rest
+func (ix *hashIndex) release(size int64) {
+	for _, files := range ix.buckets[size] {
+		if len(files) > 1 {
+			ix.kept = append(ix.kept, files...)
+			if ix.onGroup != nil {
+				ix.onGroup(files)
+			}
+		}
+	}
+	delete(ix.buckets, size)
+}
+
+// candidates releases every unfinished size (files that failed to hash or
+// changed size never arrive) and returns the files that may be duplicates
+func (ix *hashIndex) candidates() []FileHash {
+	for size := range ix.buckets {
+		ix.release(size)
+	}
+	ix.pending = nil
+	return ix.kept
+}
+
+// streamable reports whether a duplicate search only needs the files that
+// share a hash. Perceptual matching, size-only triage, -known-db, imported
+// indexes and remote agents all compare against every scanned file, and
+// -normalize-svg matches files of different sizes.
+func (e *Engine) streamable() bool {
+	c := e.cfg
+	return !c.PerceptualMode && !c.NoHash && !c.NormalizeSVG && c.KnownDB == "" &&
+		len(c.ImportIndex) == 0 && len(c.Remotes) == 0 && len(c.ReferenceReadOnly) == 0
+}
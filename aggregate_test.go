@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashIndexReleasesUniqueFiles(t *testing.T) {
+	ix := newHashIndex(map[int64]int{10: 3, 20: 1})
+
+	ix.add(FileHash{Path: "a", Size: 10, Hash: "x"})
+	ix.add(FileHash{Path: "b", Size: 10, Hash: "y"})
+	ix.add(FileHash{Path: "lone", Size: 20, Hash: "z"})
+	if len(ix.buckets) != 1 {
+		t.Errorf("a finished size should be released, buckets = %v", ix.buckets)
+	}
+
+	ix.add(FileHash{Path: "c", Size: 10, Hash: "x"})
+	if len(ix.buckets) != 0 || len(ix.kept) != 2 {
+		t.Errorf("after the last file of a size: buckets = %v, kept = %v", ix.buckets, ix.kept)
+	}
+
+	// A file that changed size after the scan counted it is kept until the end
+	ix.add(FileHash{Path: "grown", Size: 30, Hash: "w"})
+	ix.add(FileHash{Path: "grown2", Size: 30, Hash: "w"})
+
+	got := ix.candidates()
+	if len(got) != 4 || ix.added != 6 {
+		t.Errorf("candidates() = %v (added %d), want a, c, grown and grown2", got, ix.added)
+	}
+}
+
+func TestCollectDuplicatesStreaming(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":      "same content",
+		"b.txt":      "same content",
+		"c.txt":      "other content",
+		"d.txt":      "same-length!",
+		"unique.txt": "a file of a size nothing else has",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.Checkpoint = ""
+	c.JSON = true
+	engine := NewEngine(c, nil)
+	if !engine.streamable() {
+		t.Fatal("an exact scan should stream")
+	}
+
+	candidates, err := engine.collectHashes(context.Background(), true)
+	if err != nil {
+		t.Fatalf("collectHashes() error = %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Errorf("collectHashes() kept %d files, want only the 2 duplicates", len(candidates))
+	}
+
+	groups, err := engine.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatalf("collectDuplicates() error = %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Errorf("collectDuplicates() = %+v, want one pair", groups)
+	}
+
+	c.PerceptualMode = true
+	if NewEngine(c, nil).streamable() {
+		t.Error("perceptual scans compare every image and must not stream")
+	}
+}
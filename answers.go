@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// answerBook holds pre-recorded interactive decisions loaded from -answers.
+//
+// The file is line based; blank lines and lines starting with # are ignored:
+//
+//	group <hash> skip          leave the whole group untouched
+//	group <hash> auto          keep the designated keeper, remove the rest
+//	group <hash> keep <path>   keep <path>, remove every other file in the group
+//	file <path> delete         remove this file without asking
+//	file <path> keep           keep this file without asking
+type answerBook struct {
+	groups map[string]groupAnswer
+	files  map[string]bool // path -> delete
+}
+
+// groupAnswer is a recorded decision for a whole group
+type groupAnswer struct {
+	action string // "skip", "auto" or "keep"
+	path   string // file to keep for "keep"
+}
+
+// loadAnswers parses an answers file
+func loadAnswers(path string) (*answerBook, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open answers file: %w", err)
+	}
+	defer f.Close()
+
+	book := &answerBook{
+		groups: make(map[string]groupAnswer),
+		files:  make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kind, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		switch kind {
+		case "group":
+			hash, action, _ := strings.Cut(rest, " ")
+			action, target, _ := strings.Cut(strings.TrimSpace(action), " ")
+			switch action {
+			case "skip", "auto":
+				book.groups[hash] = groupAnswer{action: action}
+			case "keep":
+				target = strings.TrimSpace(target)
+				if target == "" {
+					return nil, fmt.Errorf("%s:%d: group keep needs a path", path, lineNum)
+				}
+				book.groups[hash] = groupAnswer{action: action, path: filepath.Clean(target)}
+			default:
+				return nil, fmt.Errorf("%s:%d: unknown group action %q", path, lineNum, action)
+			}
+		case "file":
+			idx := strings.LastIndex(rest, " ")
+			if idx < 0 {
+				return nil, fmt.Errorf("%s:%d: file entry needs a path and an action", path, lineNum)
+			}
+			target, action := filepath.Clean(strings.TrimSpace(rest[:idx])), rest[idx+1:]
+			switch action {
+			case "delete":
+				book.files[target] = true
+			case "keep":
+				book.files[target] = false
+			default:
+				return nil, fmt.Errorf("%s:%d: unknown file action %q", path, lineNum, action)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: expected 'group' or 'file', got %q", path, lineNum, kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// forGroup returns the files to remove according to a recorded group answer.
+// ok is false when the group has no recorded answer.
+func (b *answerBook) forGroup(group DuplicateGroup, keepIdx int) (remove []int, ok bool) {
+	if b == nil {
+		return nil, false
+	}
+	answer, found := b.groups[group.Hash]
+	if !found {
+		return nil, false
+	}
+
+	switch answer.action {
+	case "skip":
+		return nil, true
+	case "keep":
+		for i, fh := range group.Files {
+			if filepath.Clean(fh.Path) == answer.path {
+				return othersThan(len(group.Files), i), true
+			}
+		}
+		return nil, false // recorded keeper is not in this group anymore: ask
+	default:
+		return othersThan(len(group.Files), keepIdx), true
+	}
+}
+
+// forFile returns the recorded decision for a single file
+func (b *answerBook) forFile(path string) (remove bool, ok bool) {
+	if b == nil {
+		return false, false
+	}
+	remove, ok = b.files[filepath.Clean(path)]
+	return remove, ok
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadAnswers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.txt")
+	content := `# recorded decisions
+group aaa skip
+group bbb keep /photos/keep me.jpg
+group ccc auto
+
+file /downloads/setup (1).exe delete
+file /downloads/setup.exe keep
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write answers file: %v", err)
+	}
+
+	book, err := loadAnswers(path)
+	if err != nil {
+		t.Fatalf("loadAnswers() error = %v", err)
+	}
+	if len(book.groups) != 3 || len(book.files) != 2 {
+		t.Fatalf("loadAnswers() got %d groups and %d files, want 3 and 2", len(book.groups), len(book.files))
+	}
+	if got := book.groups["bbb"].path; got != "/photos/keep me.jpg" {
+		t.Errorf("group keep path = %q, want path with spaces preserved", got)
+	}
+	if del, ok := book.forFile("/downloads/setup (1).exe"); !ok || !del {
+		t.Errorf("forFile(setup (1).exe) = %v, %v; want delete", del, ok)
+	}
+}
+
+func TestLoadAnswersInvalid(t *testing.T) {
+	for _, content := range []string{
+		"group aaa explode\n",
+		"group aaa keep\n",
+		"file /a.txt maybe\n",
+		"folder /a skip\n",
+	} {
+		path := filepath.Join(t.TempDir(), "answers.txt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write answers file: %v", err)
+		}
+		if _, err := loadAnswers(path); err == nil {
+			t.Errorf("loadAnswers(%q) should fail", content)
+		}
+	}
+}
+
+func TestDecideGroupWithAnswers(t *testing.T) {
+	group := DuplicateGroup{
+		Hash:  "bbb",
+		Files: []FileHash{{Path: "/a.jpg"}, {Path: "/b.jpg"}, {Path: "/c.jpg"}},
+	}
+
+	// Group answer: keep /c.jpg
+	session := newTestSession("")
+	session.answers = &answerBook{
+		groups: map[string]groupAnswer{"bbb": {action: "keep", path: "/c.jpg"}},
+		files:  map[string]bool{},
+	}
+	got, quit := session.decideGroup(1, 1, group, 0)
+	if quit || !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("decideGroup() with group answer = %v, quit=%v; want [0 1]", got, quit)
+	}
+
+	// Partial file answers: /b.jpg recorded, /c.jpg prompted
+	session = newTestSession("y\n")
+	session.answers = &answerBook{
+		groups: map[string]groupAnswer{},
+		files:  map[string]bool{"/b.jpg": false},
+	}
+	got, quit = session.decideGroup(1, 1, group, 0)
+	if quit || !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("decideGroup() with file answers = %v, quit=%v; want [2]", got, quit)
+	}
+}
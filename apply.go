@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// exportedReport is what -apply reads back from an -export report
+type exportedReport struct {
+	Config        Config           `json:"config"`
+	Duplicates    []DuplicateGroup `json:"duplicates"`
+	HashAlgorithm string           `json:"hash_algorithm"`
+	Redacted      bool             `json:"redacted"`
+}
+
+// readReport loads an -export report
+func readReport(path string) (*exportedReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report exportedReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("not a JSON report: %w", err)
+	}
+	if report.Redacted {
+		return nil, errors.New("the report was written with -redact and names no real files")
+	}
+	return &report, nil
+}
+
+// plannedGroups turns a report's groups into groups of the copy to keep
+// followed by the copies to remove, keeping by -keep when it is given and by
+// the report's own -keep otherwise, so the plan reviewed is the plan carried
+// out. Every file must still have the size, modification time and content
+// hash the report recorded; a file that changed is left alone, and so is a
+// group whose kept copy changed. Remote and reference copies are never
+// touched, and groups from -no-hash were never confirmed, so they are
+// skipped.
+func plannedGroups(report *exportedReport, criteria, algorithm string) []DuplicateGroup {
+	var groups []DuplicateGroup
+	for i, group := range report.Duplicates {
+		if group.Unverified {
+			log.Printf("%sSkipping group %d: same size only, content never compared", emoji("⚠️"), i+1)
+			continue
+		}
+		keepIdx := selectFileToKeep(group, criteria)
+		keeper := group.Files[keepIdx]
+		if keeper.Host == "" && !keeper.Reference {
+			if err := revalidate(keeper, algorithm); err != nil {
+				log.Printf("%sLeaving group %d alone: kept copy %s: %v", emoji("⚠️"), i+1, keeper.Path, err)
+				continue
+			}
+		}
+
+		planned := DuplicateGroup{Hash: group.Hash, Size: group.Size, Similarity: group.Similarity, Files: []FileHash{keeper}}
+		for j, fh := range group.Files {
+			if j == keepIdx || fh.Host != "" || fh.Reference {
+				continue
+			}
+			if err := revalidate(fh, algorithm); err != nil {
+				log.Printf("%sNot touching %s: %v", emoji("⚠️"), fh.Path, err)
+				continue
+			}
+			fh.Links = stillLinked(fh)
+			planned.Files = append(planned.Files, fh)
+		}
+		if len(planned.Files) > 1 {
+			groups = append(groups, planned)
+		}
+	}
+	return groups
+}
+
+// applyReport carries out the plan of an -export report written earlier,
+// without scanning again: each group's duplicates are deleted, moved
+// (-move-to) or linked (-link) in favour of the copy the report keeps, once
+// they are confirmed unchanged since, by carryOut
+func (e *Engine) applyReport(ctx context.Context, path string, keepGiven bool) error {
+	report, err := readReport(path)
+	if err != nil {
+		return fmt.Errorf("cannot read report %s: %w", path, err)
+	}
+	criteria := e.cfg.KeepCriteria
+	if !keepGiven && report.Config.KeepCriteria != "" {
+		criteria = report.Config.KeepCriteria
+	}
+	// Hashes are only comparable under the algorithm that made them
+	c := e.cfg
+	if report.HashAlgorithm != "" {
+		c.HashAlgorithm = strings.ToLower(report.HashAlgorithm)
+	}
+	if !c.JSON {
+		log.Printf("%sVerifying %d groups from %s against the disk (-keep %s, %s)...", emoji("🔍"), len(report.Duplicates), path, criteria, c.hashName())
+	}
+	groups := plannedGroups(report, criteria, c.hashName())
+	return NewEngine(c, e.events).carryOut(ctx, groups, "still duplicates", path)
+}
+
+// stillLinked returns the hard links a report listed for fh that still are
+// the same file as it
+func stillLinked(fh FileHash) []string {
+	info, err := os.Stat(fh.Path)
+	if err != nil {
+		return nil
+	}
+	var links []string
+	for _, name := range fh.Links {
+		if other, err := os.Stat(name); err == nil && os.SameFile(info, other) {
+			links = append(links, name)
+		}
+	}
+	return links
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyReport(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) FileHash {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		hash, size, mod, err := hashFile(path, getHasher("sha256"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return FileHash{Path: path, Hash: hash, Size: size, ModTime: mod}
+	}
+	a1, a2 := write("a1.txt", "alpha"), write("a2.txt", "alpha")
+	b1, b2 := write("b1.txt", "bravo"), write("b2.txt", "bravo")
+	c1, c2 := write("c1.txt", "charlie"), write("c2.txt", "charlie")
+
+	report := exportedReport{HashAlgorithm: "sha256", Duplicates: []DuplicateGroup{
+		{Hash: a1.Hash, Size: 5, Similarity: 100, Files: []FileHash{a1, a2}},
+		{Hash: b1.Hash, Size: 5, Similarity: 100, Files: []FileHash{b1, b2}},
+		{Hash: c1.Hash, Size: 7, Similarity: 100, Files: []FileHash{c1, c2}},
+	}}
+	report.Config.KeepCriteria = "first"
+	data, _ := json.Marshal(report)
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	os.WriteFile(reportPath, data, 0644)
+
+	// Overnight, a duplicate and a kept copy change
+	os.WriteFile(b2.Path, []byte("BRAVO"), 0644)
+	os.WriteFile(c1.Path, []byte("CHARLIE"), 0644)
+
+	c := DefaultConfig()
+	c.UndoLog = filepath.Join(t.TempDir(), "undo.json")
+	c.KeepCriteria = "newest" // the report's -keep wins unless -keep is given
+	if err := NewEngine(c, nil).applyReport(context.Background(), reportPath, false); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{a1.Path: true, a2.Path: false, b1.Path: true, b2.Path: true, c1.Path: true, c2.Path: true} {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(path), err == nil, want)
+		}
+	}
+
+	report.Redacted = true
+	data, _ = json.Marshal(report)
+	os.WriteFile(reportPath, data, 0644)
+	if err := NewEngine(c, nil).applyReport(context.Background(), reportPath, false); err == nil {
+		t.Error("a redacted report should be refused")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// auditInfo is the "audit" block of an -audit report: what was scanned,
+// from where, and the promise that the run changed nothing
+type auditInfo struct {
+	Roots        []string  `json:"roots"`
+	Host         string    `json:"host,omitempty"`
+	Started      time.Time `json:"started"`
+	ReadOnly     bool      `json:"read_only"`     // always true: nothing was changed, cached or logged
+	ChecksumFile string    `json:"checksum_file"` // SHA-256 of this report, in sha256sum format
+}
+
+// setupAudit validates -audit and turns off what a scan writes on its own:
+// changes to duplicates, the checkpoint and the journal. Options that would
+// write anything else are refused rather than quietly dropped, and so is a
+// report path the scan itself would walk into.
+func setupAudit(c *Config) error {
+	if c.Audit == "" {
+		return nil
+	}
+	writers := []struct {
+		flag string
+		set  bool
+	}{
+		{"-move-to", c.MoveTo != ""},
+		{"-link", c.Link != ""},
+		{"-trash", c.Trash},
+		{"-quarantine-deletes", c.QuarantineDeletes},
+		{"-interactive", c.Interactive},
+		{"-tui", c.TUI},
+		{"-watch", c.WatchMode},
+		{"-resume", c.Resume},
+		{"-export", c.ExportReport},
+		{"-export-csv", c.ExportCSV},
+		{"-export-decisions", c.ExportDecisions != ""},
+		{"-export-index", c.ExportIndex != ""},
+		{"-treemap", c.Treemap != ""},
+		{"-similarity-matrix", c.SimilarityMatrix != ""},
+		{"-cache", c.Cache != ""},
+		{"-known-db", c.KnownDB != ""},
+		{"-spill-dir", c.SpillDir != ""},
+		{"-redact", c.Redact}, // creates its key file on first use
+		{"-remote", len(c.Remotes) > 0},
+	}
+	for _, w := range writers {
+		if w.set {
+			return fmt.Errorf("-audit writes nothing but its report and cannot be combined with %s", w.flag)
+		}
+	}
+	modes := []struct {
+		flag string
+		set  bool
+	}{
+		{"-agent", c.Agent},
+		{"-apply-decisions", c.ApplyDecisions != ""},
+		{"-apply", c.ApplyReport != ""},
+		{"-bench", c.Bench},
+		{"-estimate", c.Estimate},
+		{"-explore", c.Explore},
+		{"-index-server", c.IndexServer != ""},
+		{"-name-variants", c.NameVariants},
+		{"-probe", c.Probe},
+		{"-push-index", c.PushIndex != ""},
+		{"-reintroduced", c.Reintroduced},
+		{"-restore", c.Restore},
+		{"-reconcile", c.Reconcile},
+		{"-robot", c.Robot},
+		{"-rpc", c.RPC != ""},
+		{"-undo", c.UndoLast || c.UndoRestore != ""},
+		{"-purge-staged", c.PurgeStaged > 0},
+		{"-ignore", len(c.Ignore) > 0 || len(c.Unignore) > 0 || len(c.NotSimilar) > 0},
+		{"-import-tags", c.ImportTags != ""},
+	}
+	for _, m := range modes {
+		if m.set {
+			return fmt.Errorf("-audit reports on a duplicate scan and cannot be combined with %s", m.flag)
+		}
+	}
+	if root := c.auditRoot(); root != "" {
+		return fmt.Errorf("-audit %s is inside the scanned folder %s; write the report somewhere else", c.Audit, root)
+	}
+	if info, err := os.Stat(filepath.Dir(absPath(c.Audit))); err != nil || !info.IsDir() {
+		return fmt.Errorf("-audit %s: its folder does not exist", c.Audit)
+	}
+
+	c.DryRun = true
+	c.Checkpoint = ""
+	c.Journal = ""
+	return nil
+}
+
+// auditRoot returns the scanned directory the -audit report would land in,
+// or "" if none. Every root counts, whatever -recursive or the filters say:
+// the report must not change the tree it describes.
+func (c Config) auditRoot() string {
+	p := resolvedPath(c.Audit)
+	for _, root := range c.scanDirs() {
+		rel, err := filepath.Rel(resolvedPath(root), p)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return root
+		}
+	}
+	return ""
+}
+
+// exportAudit writes the -audit report of duplicates with its checksum and
+// returns the checksum
+func (e *Engine) exportAudit(duplicates []DuplicateGroup, started time.Time) (string, error) {
+	info := &auditInfo{
+		Started:      started,
+		ReadOnly:     true,
+		ChecksumFile: filepath.Base(e.cfg.Audit) + ".sha256",
+	}
+	for _, root := range e.cfg.scanDirs() {
+		info.Roots = append(info.Roots, absPath(root))
+	}
+	if host, err := os.Hostname(); err == nil {
+		info.Host = host
+	}
+	data, err := e.marshalReport(duplicates, info)
+	if err != nil {
+		return "", err
+	}
+	return writeAudit(e.cfg.Audit, data)
+}
+
+// writeAudit writes report to path and its SHA-256 checksum to path.sha256,
+// in the format of sha256sum so that "sha256sum -c" verifies it later. It
+// returns the checksum.
+func writeAudit(path string, report []byte) (string, error) {
+	sum := sha256.Sum256(report)
+	checksum := hex.EncodeToString(sum[:])
+	if err := writeFileAtomic(path, report, 0644); err != nil {
+		return "", err
+	}
+	line := fmt.Sprintf("%s  %s\n", checksum, filepath.Base(path))
+	if err := writeFileAtomic(path+".sha256", []byte(line), 0644); err != nil {
+		return "", err
+	}
+	return checksum, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetupAudit(t *testing.T) {
+	root, out := t.TempDir(), t.TempDir()
+
+	c := DefaultConfig()
+	c.Dir = stringList{root}
+	if err := setupAudit(&c); err != nil || c.DryRun {
+		t.Fatalf("setupAudit() without -audit = %v, DryRun %v; want no change", err, c.DryRun)
+	}
+
+	c.Audit = filepath.Join(out, "audit.json")
+	c.Journal = "journal.jsonl"
+	if err := setupAudit(&c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.DryRun || c.Checkpoint != "" || c.Journal != "" {
+		t.Errorf("after setupAudit DryRun = %v, Checkpoint = %q, Journal = %q; want a dry run writing neither", c.DryRun, c.Checkpoint, c.Journal)
+	}
+
+	for name, bad := range map[string]func(c *Config){
+		"move-to":     func(c *Config) { c.MoveTo = out },
+		"cache":       func(c *Config) { c.Cache = filepath.Join(out, "cache.json") },
+		"export":      func(c *Config) { c.ExportReport = true },
+		"remote":      func(c *Config) { c.Remotes = stringList{"host:/srv"} },
+		"probe":       func(c *Config) { c.Probe = true },
+		"inside root": func(c *Config) { c.Audit = filepath.Join(root, "sub", "audit.json") },
+		"reference":   func(c *Config) { c.ReferenceReadOnly = stringList{out} },
+		"no folder":   func(c *Config) { c.Audit = filepath.Join(out, "missing", "audit.json") },
+	} {
+		c := DefaultConfig()
+		c.Dir = stringList{root}
+		c.Audit = filepath.Join(out, "audit.json")
+		bad(&c)
+		if err := setupAudit(&c); err == nil {
+			t.Errorf("%s: setupAudit() should fail", name)
+		}
+	}
+}
+
+func TestExportAudit(t *testing.T) {
+	root, out := t.TempDir(), t.TempDir()
+	for name, content := range map[string]string{"a.txt": "same", "b.txt": "same", "c.txt": "other"} {
+		os.WriteFile(filepath.Join(root, name), []byte(content), 0644)
+	}
+	c := DefaultConfig()
+	c.Dir = stringList{root}
+	c.MinSize = 1
+	c.IgnoreList = "off"
+	c.Audit = filepath.Join(out, "audit.json")
+	if err := setupAudit(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEngine(c, nil)
+	duplicates, err := e.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := e.exportAudit(duplicates, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 3 {
+		t.Errorf("scanned folder has %d entries after the audit, want the 3 files only", len(entries))
+	}
+	data, err := os.ReadFile(c.Audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sha256.Sum256(data)
+	if sum != hex.EncodeToString(want[:]) {
+		t.Errorf("checksum = %s, want the SHA-256 of the report", sum)
+	}
+	line, err := os.ReadFile(c.Audit + ".sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(line); got != sum+"  audit.json\n" {
+		t.Errorf("checksum file = %q, want sha256sum format", got)
+	}
+
+	var report struct {
+		DuplicateCount int       `json:"duplicate_count"`
+		Audit          auditInfo `json:"audit"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.DuplicateCount != 1 || !report.Audit.ReadOnly || len(report.Audit.Roots) != 1 || !strings.HasSuffix(report.Audit.ChecksumFile, ".sha256") {
+		t.Errorf("report = %+v, want 1 group and the audit block", report)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
+)
+
+// benchSampleBytes caps how much file content each -bench read test hashes
+const benchSampleBytes = 256 << 20
+
+// Benchmark is the result of -bench
+type Benchmark struct {
+	Dir              string             `json:"dir"`
+	WalkFiles        int                `json:"walk_files"`
+	WalkFilesPerSec  float64            `json:"walk_files_per_sec"`
+	HashMBPerSec     map[string]float64 `json:"hash_mb_per_sec"` // in memory, one core
+	ReadMBPerSec     map[int]float64    `json:"read_mb_per_sec"` // hashing files from disk, by worker count
+	PHashPerSec      map[string]float64 `json:"phash_per_sec"`   // images per second, one core
+	PHashSource      string             `json:"phash_source"`    // "volume" or "synthetic"
+	SuggestedHash    string             `json:"suggested_hash"`
+	SuggestedWorkers int                `json:"suggested_workers"` // 0 when there were too few files to tell
+}
+
+// runBench measures walker speed, hash throughput and perceptual hashing
+// rate on the first -dir so users can pick -hash and -workers for their
+// hardware
+func (e *Engine) runBench(ctx context.Context) error {
+	root := e.cfg.roots()[0]
+	b := Benchmark{
+		Dir:          root,
+		HashMBPerSec: make(map[string]float64),
+		ReadMBPerSec: make(map[int]float64),
+		PHashPerSec:  make(map[string]float64),
+	}
+
+	// Walker speed on the target volume
+	if !e.cfg.JSON {
+		log.Printf("%sBenchmarking directory walk of %s...", emoji("⏱️"), root)
+	}
+	quiet := e.cfg
+	quiet.JSON = true
+	start := time.Now()
+	files, err := NewEngine(quiet, nil).scanRoots(ctx, []string{root}, e.cfg.Recursive)
+	if err != nil {
+		return fmt.Errorf("failed to scan files: %w", err)
+	}
+	b.WalkFiles = len(files)
+	b.WalkFilesPerSec = perSecond(float64(len(files)), time.Since(start))
+
+	// Raw hash speed, independent of the disk
+	if !e.cfg.JSON {
+		log.Printf("%sBenchmarking hash algorithms...", emoji("⏱️"))
+	}
+	buf := make([]byte, 32<<20)
+	for i := range buf {
+		buf[i] = byte(i * 7)
+	}
+	for _, algorithm := range dedup.Algorithms {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hasher := getHasher(algorithm)
+		start := time.Now()
+		hasher.Write(buf)
+		hasher.Sum(nil)
+		b.HashMBPerSec[algorithm] = perSecond(float64(len(buf))/(1<<20), time.Since(start))
+		if b.SuggestedHash == "" || b.HashMBPerSec[algorithm] > b.HashMBPerSec[b.SuggestedHash] {
+			b.SuggestedHash = algorithm
+		}
+	}
+	// Prefer sha256 unless another algorithm is much faster
+	if b.HashMBPerSec["sha256"]*1.5 >= b.HashMBPerSec[b.SuggestedHash] {
+		b.SuggestedHash = "sha256"
+	}
+
+	// Read speed by worker count. Each count gets its own files so the
+	// page cache from one run does not flatter the next.
+	workerCounts := benchWorkerCounts()
+	sets := benchFileSets(files, len(workerCounts), benchSampleBytes)
+	if !e.cfg.JSON {
+		log.Printf("%sBenchmarking reads with %v workers...", emoji("⏱️"), workerCounts)
+	}
+	for i, workers := range workerCounts {
+		if len(sets[i]) == 0 {
+			break
+		}
+		mbps, err := benchRead(ctx, sets[i], workers, e.cfg.HashAlgorithm, e.cfg.ReadBuffer)
+		if err != nil {
+			return err
+		}
+		b.ReadMBPerSec[workers] = mbps
+		if b.SuggestedWorkers == 0 || mbps > b.ReadMBPerSec[b.SuggestedWorkers]*1.1 {
+			b.SuggestedWorkers = workers
+		}
+	}
+
+	// Perceptual hashing rate, on real images when the volume has some
+	if !e.cfg.JSON {
+		log.Printf("%sBenchmarking perceptual hashing...", emoji("⏱️"))
+	}
+	var images []string
+	for _, file := range files {
+		if isImageFile(file) {
+			images = append(images, file)
+			if len(images) == 20 {
+				break
+			}
+		}
+	}
+	b.PHashSource = "volume"
+	if len(images) == 0 {
+		synthetic, err := writeBenchImage()
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(filepath.Dir(synthetic))
+		images = []string{synthetic, synthetic, synthetic, synthetic, synthetic}
+		b.PHashSource = "synthetic"
+	}
+	for _, algorithm := range []string{"dhash", "ahash", "phash"} {
+		start := time.Now()
+		hashed := 0
+		for _, img := range images {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, err := computePerceptualHash(img, algorithm); err == nil {
+				hashed++
+			}
+		}
+		b.PHashPerSec[algorithm] = perSecond(float64(hashed), time.Since(start))
+	}
+
+	if e.cfg.JSON {
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	log.Printf("")
+	log.Printf("%sBenchmark (%s):", emoji("📊"), root)
+	log.Println(strings.Repeat("=", 70))
+	log.Printf("  Directory walk:  %d files, %.0f files/s", b.WalkFiles, b.WalkFilesPerSec)
+	for _, algorithm := range dedup.Algorithms {
+		log.Printf("  Hash %-7s      %.0f MB/s (in memory, one core)", algorithm+":", b.HashMBPerSec[algorithm])
+	}
+	for _, workers := range workerCounts {
+		if mbps, ok := b.ReadMBPerSec[workers]; ok {
+			log.Printf("  Read, %2d workers: %.0f MB/s", workers, mbps)
+		}
+	}
+	for _, algorithm := range []string{"dhash", "ahash", "phash"} {
+		log.Printf("  Perceptual %-6s %.1f images/s (%s images)", algorithm+":", b.PHashPerSec[algorithm], b.PHashSource)
+	}
+	log.Println(strings.Repeat("=", 70))
+	if b.SuggestedWorkers > 0 {
+		log.Printf("%sSuggested: -hash %s -workers %d", emoji("💡"), b.SuggestedHash, b.SuggestedWorkers)
+	} else {
+		log.Printf("%sSuggested: -hash %s (too few files to compare worker counts)", emoji("💡"), b.SuggestedHash)
+	}
+	return nil
+}
+
+// benchWorkerCounts returns the worker counts to compare: powers of two up
+// to the CPU count, plus the CPU count itself
+func benchWorkerCounts() []int {
+	var counts []int
+	for n := 1; n < runtime.NumCPU(); n *= 2 {
+		counts = append(counts, n)
+	}
+	return append(counts, runtime.NumCPU())
+}
+
+// benchFileSets deals files round-robin into n disjoint sets of at most
+// maxBytes each. Sets stay empty when there are not enough files.
+func benchFileSets(files []string, n int, maxBytes int64) [][]string {
+	sets := make([][]string, n)
+	sizes := make([]int64, n)
+	i := 0
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || info.Size() == 0 {
+			continue
+		}
+		// Find the next set that still has room
+		placed := false
+		for tries := 0; tries < n; tries++ {
+			set := (i + tries) % n
+			if sizes[set] < maxBytes {
+				sets[set] = append(sets[set], file)
+				sizes[set] += info.Size()
+				i = set + 1
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			break
+		}
+	}
+	// A set needs a few files for the worker count to matter
+	for k := range sets {
+		if len(sets[k]) < 4 {
+			sets[k] = nil
+		}
+	}
+	return sets
+}
+
+// benchRead hashes files with the given number of workers and returns MB/s
+func benchRead(ctx context.Context, files []string, workers int, algorithm string, bufSize int) (float64, error) {
+	fileChan := make(chan string)
+	var mu sync.Mutex
+	var total int64
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range fileChan {
+				if _, size, _, err := hashFileBuffer(ctx, file, getHasher(algorithm), bufSize); err == nil {
+					mu.Lock()
+					total += size
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, file := range files {
+		fileChan <- file
+	}
+	close(fileChan)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return perSecond(float64(total)/(1<<20), time.Since(start)), nil
+}
+
+// writeBenchImage writes a 1024x768 gradient PNG to a temporary directory
+func writeBenchImage() (string, error) {
+	dir, err := os.MkdirTemp("", "dedup-bench")
+	if err != nil {
+		return "", err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 1024, 768))
+	for y := 0; y < 768; y++ {
+		for x := 0; x < 1024; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+	path := filepath.Join(dir, "bench.png")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// perSecond returns amount divided by elapsed seconds
+func perSecond(amount float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return amount / elapsed.Seconds()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestBenchWorkerCounts(t *testing.T) {
+	counts := benchWorkerCounts()
+	if len(counts) == 0 || counts[0] != 1 {
+		t.Fatalf("expected counts to start at 1, got %v", counts)
+	}
+	if counts[len(counts)-1] != runtime.NumCPU() {
+		t.Errorf("expected counts to end at NumCPU %d, got %v", runtime.NumCPU(), counts)
+	}
+	for i := 1; i < len(counts); i++ {
+		if counts[i] <= counts[i-1] {
+			t.Errorf("counts not increasing: %v", counts)
+		}
+	}
+}
+
+func TestBenchFileSets(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < 12; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("f%02d", i))
+		if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	empty := filepath.Join(dir, "empty")
+	os.WriteFile(empty, nil, 0644)
+	files = append(files, empty)
+
+	sets := benchFileSets(files, 2, 1<<20)
+	if len(sets) != 2 || len(sets[0]) != 6 || len(sets[1]) != 6 {
+		t.Fatalf("expected two sets of 6, got %v", sets)
+	}
+	seen := make(map[string]bool)
+	for _, set := range sets {
+		for _, f := range set {
+			if seen[f] {
+				t.Errorf("%s in more than one set", f)
+			}
+			if f == empty {
+				t.Error("empty file should be skipped")
+			}
+			seen[f] = true
+		}
+	}
+
+	// Each set stops taking files once it reaches maxBytes
+	sets = benchFileSets(files, 2, 400)
+	if len(sets[0]) != 4 || len(sets[1]) != 4 {
+		t.Errorf("expected sets capped at 4 files, got %d and %d", len(sets[0]), len(sets[1]))
+	}
+
+	// Too few files per set leaves the sets empty
+	sets = benchFileSets(files, 4, 1<<20)
+	for i, set := range sets {
+		if set != nil {
+			t.Errorf("set %d should be empty with 3 files, got %v", i, set)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// errBudgetSpent stops a walk once -max-files or -max-bytes is reached
+var errBudgetSpent = errors.New("scan budget spent")
+
+// scanBudget is how many files and bytes -max-files and -max-bytes let a
+// scan take in, in the order the scan finds them. A nil scanBudget takes in
+// everything.
+type scanBudget struct {
+	maxFiles int
+	maxBytes int64
+	files    int
+	bytes    int64
+	spent    string // the limit that stopped the scan, once one has
+}
+
+// scanBudget returns the budget for a scan, or nil without -max-files and
+// -max-bytes
+func (c Config) scanBudget() *scanBudget {
+	if c.MaxFiles == 0 && c.MaxBytes == 0 {
+		return nil
+	}
+	return &scanBudget{maxFiles: c.MaxFiles, maxBytes: c.MaxBytes}
+}
+
+// admit takes in a file of the given size, or returns false once the budget
+// is spent. The first file that does not fit spends it, so the scan stops
+// at one place rather than picking smaller files from further on.
+func (b *scanBudget) admit(size int64) bool {
+	if b == nil {
+		return true
+	}
+	if b.spent != "" {
+		return false
+	}
+	switch {
+	case b.maxFiles > 0 && b.files >= b.maxFiles:
+		b.spent = fmt.Sprintf("-max-files %d", b.maxFiles)
+	case b.maxBytes > 0 && b.bytes+size > b.maxBytes:
+		b.spent = fmt.Sprintf("-max-bytes %d", b.maxBytes)
+	default:
+		b.files++
+		b.bytes += size
+		return true
+	}
+	return false
+}
+
+// partial says where the budget stopped the scan, or "" if every file fit
+func (b *scanBudget) partial() string {
+	if b == nil || b.spent == "" {
+		return ""
+	}
+	return fmt.Sprintf("stopped at %s after %d files (%s)", b.spent, b.files, formatBytes(b.bytes))
+}
+
+// Partial says why the last scan compared only some of the files, or
+// returns "" if it compared all of them. Duplicates among the files left
+// out, or between them and the files compared, are not reported.
+func (e *Engine) Partial() string {
+	return e.partial
+}
+
+// logPartial warns, outside JSON mode, that the scan stopped at its budget
+func (e *Engine) logPartial() {
+	if e.partial != "" && !e.cfg.JSON {
+		log.Printf("%sScan budget reached: %s; the rest was not scanned and the report is partial", emoji("⚠️"), e.partial)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanBudget(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x", 100)), 0644)
+	}
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.JSON = true
+	c.Checkpoint = ""
+
+	tests := []struct {
+		name     string
+		maxFiles int
+		maxBytes int64
+		want     int
+		partial  string
+	}{
+		{"no budget", 0, 0, 4, ""},
+		{"files", 2, 0, 2, "-max-files 2"},
+		{"bytes", 0, 250, 2, "-max-bytes 250"},
+		{"exactly enough", 4, 400, 4, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.MaxFiles, c.MaxBytes = tt.maxFiles, tt.maxBytes
+			e := NewEngine(c, nil)
+			files, err := e.collectFiles(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.want {
+				t.Errorf("collectFiles() = %d files, want %d", len(files), tt.want)
+			}
+			if tt.partial == "" && e.Partial() != "" || !strings.Contains(e.Partial(), tt.partial) {
+				t.Errorf("Partial() = %q, want it to mention %q", e.Partial(), tt.partial)
+			}
+			if e.scanned != 4 {
+				t.Errorf("scanned = %d, want all 4 files counted", e.scanned)
+			}
+		})
+	}
+}
+
+func TestScanBudgetStopsAtFirstMisfit(t *testing.T) {
+	b := Config{MaxBytes: 100}.scanBudget()
+	if !b.admit(60) || b.admit(50) || b.admit(10) {
+		t.Error("once a file does not fit, no later file should be taken in")
+	}
+	if got := b.partial(); !strings.Contains(got, "after 1 files") {
+		t.Errorf("partial() = %q", got)
+	}
+	if (Config{}).scanBudget() != nil {
+		t.Error("no limits should give a nil budget")
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"hash"
+	"time"
+
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
+)
+
+// defaultReadBuffer is the -read-buffer default: large enough that big files
+// are hashed in few syscalls, small enough to keep one per worker cheap
+const defaultReadBuffer = dedup.DefaultBufferSize
+
+// hashFileBuffer hashes a file reading bufSize bytes at a time with a pooled
+// buffer (defaultReadBuffer when bufSize <= 0). Like hashFileContext it gives
+// up between reads once ctx is cancelled, and holds reads while paused.
+func hashFileBuffer(ctx context.Context, path string, hasher hash.Hash, bufSize int) (string, int64, time.Time, error) {
+	h := dedup.Hasher{BufferSize: bufSize, Wait: waitIfPaused}
+	f, err := h.HashFileWith(ctx, path, hasher)
+	return f.Hash, f.Size, f.ModTime, err
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashFileBuffer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	data := make([]byte, 100000)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	want := hex.EncodeToString(sum[:])
+
+	// Every buffer size, including ones smaller than the file and the default, gives the same hash
+	for _, size := range []int{0, 1, 4096, 65536, defaultReadBuffer, 4 << 20} {
+		hash, n, _, err := hashFileBuffer(context.Background(), path, getHasher("sha256"), size)
+		if err != nil || hash != want || n != int64(len(data)) {
+			t.Errorf("hashFileBuffer(%d) = %s, %d, %v; want %s", size, hash, n, err, want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, _, err := hashFileBuffer(ctx, path, getHasher("sha256"), 1024); err == nil {
+		t.Error("expected an error with a cancelled context")
+	}
+}
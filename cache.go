@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheVersion is bumped whenever cached values stop being comparable
+const cacheVersion = 1
+
+// cacheEntry is what the -cache remembers about one file. It is only used
+// while the file still has the same size and modification time.
+type cacheEntry struct {
+	Size    int64             `json:"size"`
+	ModTime time.Time         `json:"mod_time"`
+	PHashes map[string]string `json:"phashes,omitempty"` // by -phash-algorithm
+	Hashes  map[string]string `json:"hashes,omitempty"`  // content hashes by -hash
+}
+
+// hashCache is the persistent -cache file, keyed by path. Its methods are
+// safe for concurrent use by hashing workers and do nothing on a nil cache.
+type hashCache struct {
+	mu      sync.Mutex
+	path    string
+	dirty   bool
+	Version int                    `json:"version"`
+	Files   map[string]*cacheEntry `json:"files"`
+	Dirs    map[string]string      `json:"dirs,omitempty"` // fingerprint of each directory's scanned files, see dirFingerprints
+}
+
+// loadHashCache opens the cache at path. A missing file, or one written by
+// an incompatible version, starts an empty cache.
+func loadHashCache(path string) (*hashCache, error) {
+	c := &hashCache{path: path, Version: cacheVersion, Files: make(map[string]*cacheEntry)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read cache: %w", err)
+	}
+	var stored hashCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion {
+		return c, nil
+	}
+	if stored.Files != nil {
+		c.Files = stored.Files
+	}
+	c.Dirs = stored.Dirs
+	return c, nil
+}
+
+// entry returns the cached entry for an unchanged file, or nil. The caller
+// must hold c.mu.
+func (c *hashCache) entry(path string, size int64, modTime time.Time) *cacheEntry {
+	entry := c.Files[path]
+	if entry == nil || entry.Size != size || !entry.ModTime.Equal(modTime) {
+		return nil
+	}
+	return entry
+}
+
+// perceptual returns the cached perceptual hash of an unchanged file
+func (c *hashCache) perceptual(path string, size int64, modTime time.Time, algorithm string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.entry(path, size, modTime)
+	if entry == nil {
+		return "", false
+	}
+	hash, ok := entry.PHashes[algorithm]
+	return hash, ok
+}
+
+// storePerceptual caches a file's perceptual hash, replacing anything cached
+// for an older version of the file
+func (c *hashCache) storePerceptual(path string, size int64, modTime time.Time, algorithm, hash string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.entry(path, size, modTime)
+	if entry == nil {
+		entry = &cacheEntry{Size: size, ModTime: modTime}
+		c.Files[path] = entry
+	}
+	if entry.PHashes == nil {
+		entry.PHashes = make(map[string]string)
+	}
+	entry.PHashes[algorithm] = hash
+	c.dirty = true
+}
+
+// checkContent records a file's content hash. When the cache already holds a
+// different hash for the same size and modification time, the content changed
+// without its metadata: the cached hash is kept, so the file is reported
+// again on later runs, and returned.
+func (c *hashCache) checkContent(path string, size int64, modTime time.Time, algorithm, hash string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.entry(path, size, modTime)
+	if entry == nil {
+		entry = &cacheEntry{Size: size, ModTime: modTime}
+		c.Files[path] = entry
+	}
+	if previous, ok := entry.Hashes[algorithm]; ok {
+		return previous, previous != hash
+	}
+	if entry.Hashes == nil {
+		entry.Hashes = make(map[string]string)
+	}
+	entry.Hashes[algorithm] = hash
+	c.dirty = true
+	return "", false
+}
+
+// save writes the cache if anything was added since it was loaded
+func (c *hashCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(c.path, data, 0600); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// openCache loads the -cache for this engine's scans, if configured
+func (e *Engine) openCache() {
+	if e.cfg.Cache == "" || e.cache != nil {
+		return
+	}
+	cache, err := loadHashCache(e.cfg.Cache)
+	if err != nil {
+		log.Printf("%s%v (continuing without it)", emoji("⚠️"), err)
+		return
+	}
+	e.cache = cache
+}
+
+// perceptualHash returns an image's perceptual hash from the -cache, or
+// computes and caches it once a -decode-limit slot is free
+func (e *Engine) perceptualHash(ctx context.Context, path string, size int64, modTime time.Time) (string, error) {
+	if hash, ok := e.cache.perceptual(path, size, modTime, e.cfg.PHashAlgorithm); ok {
+		return hash, nil
+	}
+	release, err := e.decodes.acquire(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	hash, err := computePerceptualHash(path, e.cfg.PHashAlgorithm)
+	release()
+	if err != nil {
+		return "", err
+	}
+	e.cache.storePerceptual(path, size, modTime, e.cfg.PHashAlgorithm, hash)
+	return hash, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHashCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "cache.json")
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	c, err := loadHashCache(path)
+	if err != nil {
+		t.Fatalf("loadHashCache() error = %v", err)
+	}
+	c.storePerceptual("/a.jpg", 10, modTime, "dhash", "ff00")
+	if err := c.save(); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	c, err = loadHashCache(path)
+	if err != nil {
+		t.Fatalf("loadHashCache() error = %v", err)
+	}
+	if hash, ok := c.perceptual("/a.jpg", 10, modTime, "dhash"); !ok || hash != "ff00" {
+		t.Errorf("perceptual() = %q, %v; want ff00", hash, ok)
+	}
+	if _, ok := c.perceptual("/a.jpg", 10, modTime, "phash"); ok {
+		t.Error("other algorithms should miss")
+	}
+	if _, ok := c.perceptual("/a.jpg", 11, modTime, "dhash"); ok {
+		t.Error("a changed size should miss")
+	}
+	if _, ok := c.perceptual("/a.jpg", 10, modTime.Add(time.Second), "dhash"); ok {
+		t.Error("a changed modification time should miss")
+	}
+
+	// A nil cache is a no-op
+	var none *hashCache
+	none.storePerceptual("/a.jpg", 10, modTime, "dhash", "ff00")
+	if _, ok := none.perceptual("/a.jpg", 10, modTime, "dhash"); ok || none.save() != nil {
+		t.Error("nil cache should never hit or fail")
+	}
+}
+
+func TestHashCacheIgnoresOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	os.WriteFile(path, []byte(`{"version":999,"files":{"/a.jpg":{"size":1}}}`), 0600)
+	c, err := loadHashCache(path)
+	if err != nil {
+		t.Fatalf("loadHashCache() error = %v", err)
+	}
+	if len(c.Files) != 0 {
+		t.Errorf("expected an empty cache, got %v", c.Files)
+	}
+}
+
+func TestCollectFilesUsesPerceptualCache(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	f, err := os.Create(filepath.Join(dir, "image.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, img)
+	f.Close()
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.JSON = true
+	c.PerceptualMode = true
+	c.Cache = cachePath
+	c.MinSize = 1
+	c.Checkpoint = ""
+
+	first, err := NewEngine(c, nil).collectFiles(context.Background())
+	if err != nil || len(first) != 1 || first[0].PHash == "" {
+		t.Fatalf("collectFiles() = %+v, %v", first, err)
+	}
+
+	// Tamper with the cached value: a second scan must use it unread
+	cache, _ := loadHashCache(cachePath)
+	for path, entry := range cache.Files {
+		cache.storePerceptual(path, entry.Size, entry.ModTime, c.PHashAlgorithm, "cached")
+	}
+	cache.save()
+
+	second, err := NewEngine(c, nil).collectFiles(context.Background())
+	if err != nil || len(second) != 1 || second[0].PHash != "cached" {
+		t.Errorf("second collectFiles() = %+v, %v; want the cached hash", second, err)
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "archive.bin")
+	if err := os.WriteFile(path, []byte("original content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.JSON = true
+	c.Cache = filepath.Join(t.TempDir(), "cache.json")
+	c.CheckIntegrity = true
+	c.MinSize = 1
+	c.Checkpoint = ""
+
+	scan := func() []IntegrityIssue {
+		t.Helper()
+		engine := NewEngine(c, nil)
+		if _, err := engine.collectFiles(context.Background()); err != nil {
+			t.Fatalf("collectFiles() error = %v", err)
+		}
+		return engine.IntegrityIssues()
+	}
+
+	if issues := scan(); len(issues) != 0 {
+		t.Fatalf("first scan flagged %+v", issues)
+	}
+
+	// Same size, same mtime, different bytes
+	os.WriteFile(path, []byte("corrupt content!"), 0644)
+	os.Chtimes(path, info.ModTime(), info.ModTime())
+	issues := scan()
+	if len(issues) != 1 || issues[0].Path != path || issues[0].Expected == issues[0].Actual {
+		t.Fatalf("scan after corruption = %+v, want the file flagged", issues)
+	}
+	if again := scan(); len(again) != 1 {
+		t.Errorf("a flagged file should stay flagged, got %+v", again)
+	}
+
+	// A normal edit moves the mtime and is not corruption
+	os.WriteFile(path, []byte("edited on purpose"), 0644)
+	os.Chtimes(path, info.ModTime().Add(time.Hour), info.ModTime().Add(time.Hour))
+	if issues := scan(); len(issues) != 0 {
+		t.Errorf("an edited file was flagged: %+v", issues)
+	}
+}
+
+func TestCollectHashesReusesUnchangedDirs(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("same text"), 0644)
+	os.WriteFile(b, []byte("same text"), 0644)
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.JSON = true
+	c.IgnoreList = "off"
+	c.Cache = filepath.Join(t.TempDir(), "cache.json")
+	hashOf := func() map[string]string {
+		files, err := NewEngine(c, nil).collectHashes(context.Background(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes := make(map[string]string)
+		for _, fh := range files {
+			hashes[filepath.Base(fh.Path)] = fh.Hash
+		}
+		return hashes
+	}
+	first := hashOf()
+
+	// Rewritten in place with the same size and time: only a reused hash misses it
+	info, _ := os.Stat(b)
+	os.WriteFile(b, []byte("new! text"), 0644)
+	os.Chtimes(b, info.ModTime(), info.ModTime())
+	if second := hashOf(); second["b.txt"] != first["b.txt"] {
+		t.Errorf("b.txt was hashed again in an unchanged folder")
+	}
+
+	// A new file changes the folder, so everything in it is hashed again
+	os.WriteFile(filepath.Join(dir, "c.txt"), []byte("other"), 0644)
+	if third := hashOf(); third["b.txt"] == first["b.txt"] {
+		t.Errorf("b.txt should have been hashed again once its folder changed")
+	}
+
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// partialReport is written next to the checkpoint when a run is interrupted
+type partialReport struct {
+	Interrupted time.Time        `json:"interrupted"`
+	Reason      string           `json:"reason"`
+	HashedFiles int              `json:"hashed_files"`
+	TotalFiles  int              `json:"total_files"`
+	Algorithm   string           `json:"hash_algorithm"`
+	Groups      []DuplicateGroup `json:"groups"`
+}
+
+// partialReportPath is where the partial report goes: next to the checkpoint
+func (c Config) partialReportPath() string {
+	return filepath.Join(filepath.Dir(c.Checkpoint), partialReportFile)
+}
+
+// atomicFile is written under a temporary name and only renamed over its
+// destination once complete and flushed to disk, so a crash, Ctrl+C or full
+// disk during a write leaves the previous file (or none) rather than a
+// truncated one that later breaks -undo or an import
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+// createAtomic starts writing path; the file appears there on Commit
+func createAtomic(path string, perm os.FileMode) (*atomicFile, error) {
+	f, err := os.OpenFile(path+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: f, path: path}, nil
+}
+
+// Commit syncs the file to disk and renames it into place
+func (f *atomicFile) Commit() error {
+	if err := f.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	// Make the rename itself durable; not every platform can sync a directory
+	if dir, err := os.Open(filepath.Dir(f.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// Abort discards an unfinished file. It does nothing after Commit, so it
+// can be deferred right after createAtomic.
+func (f *atomicFile) Abort() {
+	if f.File.Close() == nil {
+		os.Remove(f.Name())
+	}
+}
+
+// writeFileAtomic is os.WriteFile through an atomicFile
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := createAtomic(path, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+// loadCheckpoint returns the hashes saved by an interrupted run, by path.
+// A missing, unreadable or incompatible checkpoint is simply ignored, unless
+// -resume insists on one. A record cut short by a crash while the run was
+// appending to it is dropped.
+func (e *Engine) loadCheckpoint() (map[string]FileHash, error) {
+	if e.cfg.Checkpoint == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(e.cfg.Checkpoint)
+	if err != nil {
+		if e.cfg.Resume {
+			return nil, fmt.Errorf("-resume: no checkpoint to resume from: %w", err)
+		}
+		return nil, nil
+	}
+	if end := bytes.LastIndexByte(data, '\n'); end+1 < len(data) {
+		data = data[:end+1]
+	}
+
+	fileHashes, err := e.readAgent(bytes.NewReader(data), "")
+	if err != nil {
+		if e.cfg.Resume {
+			return nil, fmt.Errorf("-resume: cannot use checkpoint %s: %w", e.cfg.Checkpoint, err)
+		}
+		if !e.cfg.JSON {
+			log.Printf("%sIgnoring checkpoint %s: %v", emoji("⚠️"), e.cfg.Checkpoint, err)
+		}
+		return nil, nil
+	}
+	saved := make(map[string]FileHash, len(fileHashes))
+	for _, fh := range fileHashes {
+		saved[fh.Path] = fh
+	}
+	return saved, nil
+}
+
+// reuseCheckpoint splits files into ones whose checkpointed hash is still
+// valid (same size and modification time) and ones that must be hashed
+func (e *Engine) reuseCheckpoint(files []string) (reused []FileHash, toHash []string, err error) {
+	saved, err := e.loadCheckpoint()
+	if err != nil || len(saved) == 0 {
+		return nil, files, err
+	}
+	for _, file := range files {
+		fh, ok := saved[file]
+		if ok && (!e.cfg.perceptualCandidate(file) || fh.PHash != "") {
+			if info, err := os.Stat(file); err == nil && info.Size() == fh.Size && info.ModTime().Equal(fh.ModTime) {
+				reused = append(reused, fh)
+				continue
+			}
+		}
+		toHash = append(toHash, file)
+	}
+	if len(reused) > 0 && !e.cfg.JSON {
+		log.Printf("%sResuming: %d hashes reused from %s", emoji("♻️"), len(reused), e.cfg.Checkpoint)
+	}
+	return reused, toHash, nil
+}
+
+// checkpointLog appends every hash to the checkpoint as it is computed and
+// syncs it every checkpointSync, so a run that is killed, crashes or loses
+// power still leaves the next one its finished hashes. Its methods do
+// nothing on a nil log.
+type checkpointLog struct {
+	f      *os.File
+	w      *bufio.Writer
+	synced time.Time
+	count  int   // hashes in the checkpoint
+	err    error // the first write that failed; nothing is written after it
+}
+
+// checkpointSync is how often a checkpointLog syncs to disk
+const checkpointSync = 10 * time.Second
+
+// openCheckpointLog starts a new checkpoint holding the reused hashes and
+// sets it to log the ones computed next. It replaces the previous
+// checkpoint atomically, so a failure keeps that one.
+func (e *Engine) openCheckpointLog(reused []FileHash) error {
+	if e.cfg.Checkpoint == "" {
+		return nil
+	}
+	f, err := createAtomic(e.cfg.Checkpoint, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	defer f.Abort()
+	enc := json.NewEncoder(f)
+	enc.Encode(agentHeader{Agent: version, Hash: e.cfg.hashName()})
+	for _, fh := range reused {
+		if err := enc.Encode(fh); err != nil {
+			return fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+	}
+	if err := f.Commit(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	appended, err := os.OpenFile(e.cfg.Checkpoint, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	e.checkpoint = &checkpointLog{f: appended, w: bufio.NewWriter(appended), synced: time.Now(), count: len(reused)}
+	return nil
+}
+
+// add appends a computed hash, syncing once checkpointSync has passed
+func (l *checkpointLog) add(fh FileHash) {
+	if l == nil || l.err != nil {
+		return
+	}
+	data, err := json.Marshal(fh)
+	if err == nil {
+		l.w.Write(append(data, '\n'))
+		l.count++
+		if time.Since(l.synced) > checkpointSync {
+			l.synced = time.Now()
+			if err = l.w.Flush(); err == nil {
+				err = l.f.Sync()
+			}
+		}
+	}
+	l.err = err
+}
+
+// close writes out and syncs what is left and closes the checkpoint. It
+// returns the first error writing it.
+func (l *checkpointLog) close() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	if l.err == nil {
+		if l.err = l.w.Flush(); l.err == nil {
+			l.err = l.f.Sync()
+		}
+	}
+	if err := l.f.Close(); l.err == nil {
+		l.err = err
+	}
+	l.f = nil
+	if l.err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", l.err)
+	}
+	return nil
+}
+
+// saveCheckpoint records the hashes an interrupted run completed, and a
+// report of the duplicates among them, so the next run can pick up here
+func (e *Engine) saveCheckpoint(fileHashes []FileHash, totalFiles int, reason error) error {
+	if e.cfg.Checkpoint == "" {
+		return nil
+	}
+
+	// A logged run has every hash in the checkpoint already, including the
+	// unique files its index let go of
+	hashed := len(fileHashes)
+	if e.checkpoint != nil {
+		hashed = e.checkpoint.count
+		if err := e.checkpoint.close(); err != nil {
+			return err
+		}
+	} else if hashed > 0 {
+		// Same format as -agent and -export-index
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.Encode(agentHeader{Agent: version, Hash: e.cfg.hashName()})
+		for _, fh := range fileHashes {
+			if err := enc.Encode(fh); err != nil {
+				return err
+			}
+		}
+		if err := writeFileAtomic(e.cfg.Checkpoint, buf.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+	}
+	if hashed == 0 {
+		e.clearCheckpoint()
+		return nil
+	}
+
+	report := partialReport{
+		Interrupted: time.Now(),
+		Reason:      reason.Error(),
+		HashedFiles: hashed,
+		TotalFiles:  totalFiles,
+		Algorithm:   e.cfg.hashName(),
+		Groups:      e.findDuplicates(fileHashes),
+	}
+	if report.Groups == nil {
+		report.Groups = []DuplicateGroup{}
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(e.cfg.partialReportPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to write partial report: %w", err)
+	}
+
+	if !e.cfg.JSON {
+		log.Printf("%sCheckpoint saved: %d of %d files hashed (%s), partial report in %s",
+			emoji("💾"), hashed, totalFiles, e.cfg.Checkpoint, e.cfg.partialReportPath())
+	}
+	return nil
+}
+
+// clearCheckpoint removes the checkpoint once every file has been hashed
+func (e *Engine) clearCheckpoint() {
+	if e.cfg.Checkpoint == "" {
+		return
+	}
+	e.checkpoint.close()
+	if err := os.Remove(e.cfg.Checkpoint); err == nil {
+		os.Remove(e.cfg.partialReportPath())
+	}
+}
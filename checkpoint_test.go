@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	tmpDir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		files = append(files, path)
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{tmpDir}
+	c.MinSize = 1
+	c.JSON = true
+	c.Checkpoint = filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	engine := NewEngine(c, nil)
+
+	// An interrupted run managed to hash a.txt and b.txt
+	finished, err := engine.computeHashes(context.Background(), files[:2])
+	if err != nil {
+		t.Fatalf("computeHashes() error = %v", err)
+	}
+	if err := engine.saveCheckpoint(finished, len(files), context.Canceled); err != nil {
+		t.Fatalf("saveCheckpoint() error = %v", err)
+	}
+
+	data, err := os.ReadFile(c.partialReportPath())
+	if err != nil {
+		t.Fatalf("partial report missing: %v", err)
+	}
+	var report partialReport
+	if err := json.Unmarshal(data, &report); err != nil || report.HashedFiles != 2 || report.TotalFiles != 3 || len(report.Groups) != 1 {
+		t.Errorf("partial report = %+v, %v", report, err)
+	}
+
+	// b.txt changed since, so only a.txt can be reused
+	if err := os.WriteFile(files[1], []byte("same content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := finished[1].ModTime.Add(1e9)
+	os.Chtimes(files[1], future, future)
+
+	reused, toHash, err := engine.reuseCheckpoint(files)
+	if err != nil || len(reused) != 1 || reused[0].Path != files[0] || len(toHash) != 2 {
+		t.Errorf("reuseCheckpoint() reused %v, hashing %v, %v", reused, toHash, err)
+	}
+
+	// A completed run removes the checkpoint and the partial report
+	duplicates, err := engine.collectDuplicates(context.Background())
+	if err != nil || len(duplicates) != 1 || len(duplicates[0].Files) != 3 {
+		t.Fatalf("collectDuplicates() = %v, %v", duplicates, err)
+	}
+	if _, err := os.Stat(c.Checkpoint); !os.IsNotExist(err) {
+		t.Error("checkpoint should be removed after a complete run")
+	}
+	if _, err := os.Stat(c.partialReportPath()); !os.IsNotExist(err) {
+		t.Error("partial report should be removed after a complete run")
+	}
+}
+
+func TestCheckpointLog(t *testing.T) {
+	c := DefaultConfig()
+	c.JSON = true
+	c.Checkpoint = filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	engine := NewEngine(c, nil)
+
+	// A run resumed with one hash computes another and is then killed
+	// halfway through appending a third
+	reused := []FileHash{{Path: "a", Size: 1, Hash: "x"}}
+	if err := engine.openCheckpointLog(reused); err != nil {
+		t.Fatal(err)
+	}
+	engine.checkpoint.add(FileHash{Path: "b", Size: 2, Hash: "y"})
+	if err := engine.checkpoint.close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(c.Checkpoint, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"Path":"c","Si`)
+	f.Close()
+
+	saved, err := engine.loadCheckpoint()
+	if err != nil || len(saved) != 2 || saved["b"].Hash != "y" {
+		t.Errorf("loadCheckpoint() = %v, %v, want a and b", saved, err)
+	}
+
+	// -resume refuses to start over
+	c.Resume = true
+	c.Checkpoint = filepath.Join(t.TempDir(), "missing.jsonl")
+	if _, err := NewEngine(c, nil).loadCheckpoint(); err == nil {
+		t.Error("loadCheckpoint() with -resume and no checkpoint should fail")
+	}
+}
+
+func TestAtomicFileKeepsOldContentUntilCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := writeFileAtomic(path, []byte(`{"old":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An export that fails halfway leaves the previous file untouched
+	f, err := createAtomic(path, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(`{"new":`))
+	f.Abort()
+	if data, _ := os.ReadFile(path); string(data) != `{"old":true}` {
+		t.Errorf("aborted write changed the file to %q", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("aborted write left its temporary file behind")
+	}
+
+	f, _ = createAtomic(path, 0644)
+	f.Write([]byte(`{"new":true}`))
+	if err := f.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	f.Abort()
+	if data, _ := os.ReadFile(path); string(data) != `{"new":true}` {
+		t.Errorf("committed file = %q", data)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile creates dst as a copy-on-write clone of src with clonefile(2),
+// which APFS supports. dst must not exist yet.
+func cloneFile(src, dst string) error {
+	err := unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, unix.EXDEV):
+		return fmt.Errorf("files on different volumes cannot share blocks")
+	case errors.Is(err, unix.ENOTSUP):
+		return fmt.Errorf("the volume does not support clones (APFS is needed)")
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile creates dst as a copy-on-write clone of src with the FICLONE
+// ioctl, which btrfs, XFS (formatted with reflink=1), bcachefs and OCFS2
+// support. dst must not exist yet, and is not left behind on failure.
+func cloneFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = unix.IoctlFileClone(int(out.Fd()), int(in.Fd()))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return cloneError(err)
+	}
+	return nil
+}
+
+// cloneError explains the errors FICLONE gives on filesystems or file pairs
+// it cannot clone
+func cloneError(err error) error {
+	switch {
+	case errors.Is(err, unix.EXDEV):
+		return fmt.Errorf("files on different filesystems cannot share blocks")
+	case errors.Is(err, unix.EOPNOTSUPP), errors.Is(err, unix.ENOTTY), errors.Is(err, unix.EINVAL):
+		return fmt.Errorf("the filesystem does not support reflinks (btrfs, or XFS with reflink=1, is needed)")
+	}
+	return err
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package main
+
+import "errors"
+
+// cloneFile is not implemented on this platform: reflinks need FICLONE on
+// Linux or clonefile on macOS
+func cloneFile(src, dst string) error {
+	return errors.New("reflinks are only supported on Linux (btrfs, XFS) and macOS (APFS)")
+}
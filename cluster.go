@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Perceptual clustering strategies for -cluster
+const (
+	clusterGreedy     = "greedy"     // each image joins the first earlier image within the threshold
+	clusterComponents = "components" // images linked by any chain of close pairs form one group
+	clusterCentroid   = "centroid"   // greedy seeds, then images move to their closest group centre
+)
+
+// maxCentroidRounds bounds how often centroid clustering re-links images
+const maxCentroidRounds = 10
+
+// checkClusterStrategy validates a -cluster value
+func checkClusterStrategy(strategy string) error {
+	switch strings.ToLower(strategy) {
+	case clusterGreedy, clusterComponents, clusterCentroid:
+		return nil
+	}
+	return fmt.Errorf("invalid -cluster %q: expected greedy, components or centroid", strategy)
+}
+
+// clusterImages groups images whose perceptual hashes are within threshold
+// of each other using the given strategy. match reports whether two images
+// may share a group at all. Only groups of two or more are returned.
+func clusterImages(images []FileHash, threshold int, strategy string, match func(a, b FileHash) bool) [][]FileHash {
+	near := func(i, j int) bool {
+		if !match(images[i], images[j]) {
+			return false
+		}
+		dist := hammingDistance(images[i].PHash, images[j].PHash)
+		return dist >= 0 && dist <= threshold
+	}
+
+	var clusters [][]int
+	switch strings.ToLower(strategy) {
+	case clusterComponents:
+		clusters = componentClusters(len(images), near)
+	case clusterCentroid:
+		clusters = centroidClusters(images, greedyClusters(len(images), near), threshold, match)
+	default:
+		clusters = greedyClusters(len(images), near)
+	}
+
+	var groups [][]FileHash
+	for _, cluster := range clusters {
+		if len(cluster) < 2 {
+			continue
+		}
+		group := make([]FileHash, len(cluster))
+		for k, i := range cluster {
+			group[k] = images[i]
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// greedyClusters seeds a group with the first unassigned image and adds
+// every later unassigned image close to that seed
+func greedyClusters(n int, near func(i, j int) bool) [][]int {
+	var clusters [][]int
+	visited := make([]bool, n)
+	for i := 0; i < n; i++ {
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+		cluster := []int{i}
+		for j := i + 1; j < n; j++ {
+			if !visited[j] && near(i, j) {
+				cluster = append(cluster, j)
+				visited[j] = true
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// componentClusters joins every close pair, so chains of similar images
+// (a~b, b~c) end up in one group even when the ends are far apart
+func componentClusters(n int, near func(i, j int) bool) [][]int {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if near(i, j) {
+				if ri, rj := find(i), find(j); ri != rj {
+					parent[rj] = ri
+				}
+			}
+		}
+	}
+
+	index := make(map[int]int)
+	var clusters [][]int
+	for i := 0; i < n; i++ {
+		root := find(i)
+		k, ok := index[root]
+		if !ok {
+			k = len(clusters)
+			index[root] = k
+			clusters = append(clusters, nil)
+		}
+		clusters[k] = append(clusters[k], i)
+	}
+	return clusters
+}
+
+// centroidClusters starts from the greedy groups and repeatedly moves each
+// image to the group whose centre (the per-bit majority of its members'
+// hashes) is closest, as long as that centre is within threshold, until
+// nothing moves
+func centroidClusters(images []FileHash, clusters [][]int, threshold int, match func(a, b FileHash) bool) [][]int {
+	assignment := make([]int, len(images))
+	for k, cluster := range clusters {
+		for _, i := range cluster {
+			assignment[i] = k
+		}
+	}
+
+	for round := 0; round < maxCentroidRounds; round++ {
+		centres := make([]string, len(clusters))
+		for k, cluster := range clusters {
+			centres[k] = majorityHash(images, cluster)
+		}
+
+		moved := false
+		next := make([][]int, len(clusters))
+		for i, fh := range images {
+			best, bestDist := assignment[i], hammingDistance(fh.PHash, centres[assignment[i]])
+			for k, centre := range centres {
+				if centre == "" || k == best || !match(fh, images[clusters[k][0]]) {
+					continue
+				}
+				if dist := hammingDistance(fh.PHash, centre); dist >= 0 && dist <= threshold && (bestDist < 0 || dist < bestDist) {
+					best, bestDist = k, dist
+				}
+			}
+			if best != assignment[i] {
+				assignment[i] = best
+				moved = true
+			}
+			next[best] = append(next[best], i)
+		}
+		clusters = next
+		if !moved {
+			break
+		}
+	}
+	return clusters
+}
+
+// majorityHash returns the per-bit majority of the members' hashes, or ""
+// for an empty group
+func majorityHash(images []FileHash, members []int) string {
+	if len(members) == 0 {
+		return ""
+	}
+	centre := []byte(images[members[0]].PHash)
+	for bit := range centre {
+		ones := 0
+		for _, i := range members {
+			if bit < len(images[i].PHash) && images[i].PHash[bit] == '1' {
+				ones++
+			}
+		}
+		if ones*2 > len(members) {
+			centre[bit] = '1'
+		} else {
+			centre[bit] = '0'
+		}
+	}
+	return string(centre)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterImages(t *testing.T) {
+	// x~y and y~z are within the threshold of 4, x and z are not
+	bits := func(ones int) string { return strings.Repeat("1", ones) + strings.Repeat("0", 64-ones) }
+	images := []FileHash{
+		{Path: "x", PHash: bits(0)},
+		{Path: "y", PHash: bits(4)},
+		{Path: "z", PHash: bits(5)},
+		{Path: "far", PHash: bits(40)},
+	}
+	any := func(a, b FileHash) bool { return true }
+	paths := func(groups [][]FileHash) []string {
+		var out []string
+		for _, group := range groups {
+			var names []string
+			for _, fh := range group {
+				names = append(names, fh.Path)
+			}
+			out = append(out, strings.Join(names, ","))
+		}
+		return out
+	}
+
+	tests := []struct {
+		strategy string
+		want     string
+	}{
+		{clusterGreedy, "x,y"},       // y joins the first seed, z is left out
+		{clusterComponents, "x,y,z"}, // the chain joins all three
+		{clusterCentroid, "y,z"},     // y moves to the group it is closest to
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			got := paths(clusterImages(images, 4, tt.strategy, any))
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("clusterImages(%s) = %v, want [%s]", tt.strategy, got, tt.want)
+			}
+		})
+	}
+
+	// match can veto pairs
+	none := func(a, b FileHash) bool { return false }
+	for _, strategy := range []string{clusterGreedy, clusterComponents, clusterCentroid} {
+		if groups := clusterImages(images, 4, strategy, none); len(groups) != 0 {
+			t.Errorf("clusterImages(%s) with no matches = %v", strategy, paths(groups))
+		}
+	}
+}
+
+func TestCheckClusterStrategy(t *testing.T) {
+	for _, s := range []string{"greedy", "Components", "centroid"} {
+		if err := checkClusterStrategy(s); err != nil {
+			t.Errorf("checkClusterStrategy(%q) = %v", s, err)
+		}
+	}
+	if checkClusterStrategy("kmeans") == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
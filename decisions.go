@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Actions in a decision sheet. Rows marked review, or left empty, are left alone.
+const (
+	decisionKeep   = "keep"
+	decisionDelete = "delete"
+	decisionReview = "review"
+)
+
+// decisionColumns are the columns of the CSV export and decision sheet
+var decisionColumns = []string{"group", "hash", "size", "similarity", "action", "path", "modified", "tags", "algorithm"}
+
+// decisionRow is one file of the -export-csv or -export-decisions sheet.
+// Users edit Action in a spreadsheet and hand the sheet to -apply-decisions.
+type decisionRow struct {
+	Group      int     `json:"group"`
+	Hash       string  `json:"hash"`
+	Size       int64   `json:"size"`
+	Similarity float64 `json:"similarity"`
+	Action     string  `json:"action"`
+	Path       string  `json:"path"`
+	Modified   string  `json:"modified"`
+	Tags       string  `json:"tags,omitempty"`
+	Algorithm  string  `json:"algorithm,omitempty"` // what produced Hash, see Config.hashAlgorithmOf
+}
+
+// decisionRows lists every file of every group with the action a run would take
+func decisionRows(duplicates []DuplicateGroup, c Config) []decisionRow {
+	var rows []decisionRow
+	for i, group := range duplicates {
+		keepIdx := selectFileToKeep(group, c.KeepCriteria)
+		for j, fh := range group.Files {
+			action := decisionDelete
+			if group.Unverified {
+				action = decisionReview
+			} else if j == keepIdx {
+				action = decisionKeep
+			}
+			rows = append(rows, decisionRow{
+				Group:      i + 1,
+				Hash:       group.Hash,
+				Size:       fh.Size,
+				Similarity: group.Similarity,
+				Action:     action,
+				Path:       fh.Path,
+				Modified:   fh.ModTime.Format(time.RFC3339),
+				Tags:       strings.Join(group.Tags, tagSeparator),
+				Algorithm:  c.hashAlgorithmOf(group),
+			})
+		}
+	}
+	return rows
+}
+
+// record returns the row's CSV fields, in decisionColumns order
+func (r decisionRow) record() []string {
+	return []string{
+		strconv.Itoa(r.Group),
+		r.Hash,
+		strconv.FormatInt(r.Size, 10),
+		strconv.FormatFloat(r.Similarity, 'f', 1, 64),
+		r.Action,
+		r.Path,
+		r.Modified,
+		r.Tags,
+		r.Algorithm,
+	}
+}
+
+// writeDecisionsCSV writes rows as CSV with a header
+func writeDecisionsCSV(w io.Writer, rows []decisionRow) error {
+	cw := csv.NewWriter(w)
+	cw.Write(decisionColumns)
+	for _, row := range rows {
+		cw.Write(row.record())
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportDecisions writes the -export-decisions sheet, as CSV when the name
+// ends in .csv and as a JSON array of rows otherwise
+func exportDecisions(path string, duplicates []DuplicateGroup, c Config) error {
+	rows := decisionRows(duplicates, c)
+	f, err := createAtomic(path, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		err = writeDecisionsCSV(f, rows)
+	} else {
+		if rows == nil {
+			rows = []decisionRow{}
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rows)
+	}
+	if err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+// readDecisions reads a decision sheet written by -export-decisions or
+// -export-csv. Only the group, action and path columns are required, so
+// columns can be dropped or reordered in the spreadsheet.
+func readDecisions(path string) ([]decisionRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		var rows []decisionRow
+		if err := json.NewDecoder(f).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("not a JSON decision sheet: %w", err)
+		}
+		return rows, nil
+	}
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("not a CSV decision sheet: %w", err)
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"group", "action", "path"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("not a CSV decision sheet: no %q column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := col[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []decisionRow
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		group, err := strconv.Atoi(field(record, "group"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid group %q", line, field(record, "group"))
+		}
+		size, _ := strconv.ParseInt(field(record, "size"), 10, 64)
+		similarity, _ := strconv.ParseFloat(field(record, "similarity"), 64)
+		rows = append(rows, decisionRow{
+			Group:      group,
+			Hash:       field(record, "hash"),
+			Size:       size,
+			Similarity: similarity,
+			Action:     field(record, "action"),
+			Path:       field(record, "path"),
+			Modified:   field(record, "modified"),
+			Tags:       field(record, "tags"),
+			Algorithm:  field(record, "algorithm"),
+		})
+	}
+	return rows, nil
+}
+
+// checkSheetAlgorithm refuses a sheet whose exact groups were hashed with
+// another algorithm than algorithm, as their hashes cannot be compared.
+// Sheets from before the algorithm column was added are taken as they are.
+func checkSheetAlgorithm(rows []decisionRow, algorithm string) error {
+	for _, row := range rows {
+		if row.Similarity >= 100.0 && row.Algorithm != "" && !strings.EqualFold(row.Algorithm, algorithm) {
+			return fmt.Errorf("group %d was hashed with %s, not %s; use -hash %s", row.Group, row.Algorithm, algorithm, strings.ToLower(row.Algorithm))
+		}
+	}
+	return nil
+}
+
+// decisionGroups turns edited rows into groups of the file to keep followed
+// by the files to delete. A group needs a kept copy that still exists, and
+// for exact groups every file must still have the exported content hash;
+// groups and files failing that are reported and left alone.
+func decisionGroups(rows []decisionRow, algorithm string) ([]DuplicateGroup, error) {
+	if err := checkSheetAlgorithm(rows, algorithm); err != nil {
+		return nil, err
+	}
+	type sheetGroup struct {
+		id           int
+		keep, delete []decisionRow
+		hash         string
+		similarity   float64
+	}
+	var order []*sheetGroup
+	byID := make(map[int]*sheetGroup)
+	for _, row := range rows {
+		g := byID[row.Group]
+		if g == nil {
+			g = &sheetGroup{id: row.Group, hash: row.Hash, similarity: row.Similarity}
+			byID[row.Group] = g
+			order = append(order, g)
+		}
+		switch strings.ToLower(row.Action) {
+		case decisionKeep:
+			g.keep = append(g.keep, row)
+		case decisionDelete:
+			g.delete = append(g.delete, row)
+		case decisionReview, "":
+		default:
+			return nil, fmt.Errorf("group %d: unknown action %q for %s (expected keep, delete or review)", row.Group, row.Action, row.Path)
+		}
+	}
+
+	// check stats a file and, for exact groups, confirms its content
+	check := func(g *sheetGroup, row decisionRow) (FileHash, error) {
+		info, err := os.Stat(row.Path)
+		if err != nil {
+			return FileHash{}, err
+		}
+		fh := FileHash{Path: row.Path, Size: info.Size(), ModTime: info.ModTime()}
+		if g.similarity >= 100.0 && g.hash != "" {
+			fh.Hash = g.hash
+			if err := revalidate(fh, algorithm); errors.Is(err, errChangedSinceScan) {
+				return FileHash{}, errors.New("content changed since the export")
+			} else if err != nil {
+				return FileHash{}, err
+			}
+		}
+		return fh, nil
+	}
+
+	var groups []DuplicateGroup
+	for _, g := range order {
+		if len(g.delete) == 0 {
+			continue
+		}
+		if len(g.keep) == 0 {
+			log.Printf("%sLeaving group %d alone: no file is marked keep", emoji("⚠️"), g.id)
+			continue
+		}
+
+		var keeper FileHash
+		for _, row := range g.keep {
+			fh, err := check(g, row)
+			if err == nil {
+				keeper = fh
+				break
+			}
+			log.Printf("%sKept file %s: %v", emoji("⚠️"), row.Path, err)
+		}
+		if keeper.Path == "" {
+			log.Printf("%sLeaving group %d alone: none of its kept files can be confirmed", emoji("⚠️"), g.id)
+			continue
+		}
+
+		group := DuplicateGroup{Hash: g.hash, Size: keeper.Size, Similarity: g.similarity, Files: []FileHash{keeper}}
+		for _, row := range g.delete {
+			if sameFilePath(row.Path, keeper.Path) {
+				continue
+			}
+			fh, err := check(g, row)
+			if err != nil {
+				log.Printf("%sNot touching %s: %v", emoji("⚠️"), row.Path, err)
+				continue
+			}
+			group.Files = append(group.Files, fh)
+		}
+		if len(group.Files) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// sameFilePath reports whether two paths name the same location
+func sameFilePath(a, b string) bool {
+	return absPath(a) == absPath(b)
+}
+
+// applyDecisions carries out an edited decision sheet: each group's files
+// marked delete are deleted, moved (-move-to) or linked (-link) in favour of
+// its file marked keep, by carryOut.
+func (e *Engine) applyDecisions(ctx context.Context, path string) error {
+	rows, err := readDecisions(path)
+	if err != nil {
+		return fmt.Errorf("cannot read decisions from %s: %w", path, err)
+	}
+	groups, err := decisionGroups(rows, e.cfg.hashName())
+	if err != nil {
+		return err
+	}
+
+	return e.carryOut(ctx, groups, "marked delete", path)
+}
+
+// carryOut acts on groups that list their keeper first, as planned in a
+// sheet or report at source: the rest of each group is deleted, moved or
+// linked with no keep criteria or prompts. With -dry-run the plan is only
+// printed.
+func (e *Engine) carryOut(ctx context.Context, groups []DuplicateGroup, marked, source string) error {
+	removals, space := 0, int64(0)
+	for _, group := range groups {
+		removals += len(group.Files) - 1
+		space += group.Size * int64(len(group.Files)-1)
+	}
+	log.Printf("%s%d groups with %d files %s (%s) in %s", emoji("📋"), len(groups), removals, marked, formatBytes(space), source)
+
+	if e.cfg.DryRun {
+		for _, group := range groups {
+			log.Printf("\n    %sKEEP %s", emoji("✓"), group.Files[0].Path)
+			for _, fh := range group.Files[1:] {
+				log.Printf("    %sDELETE %s", emoji("✗"), fh.Path)
+			}
+		}
+		log.Printf("\n%sDry run: no files were changed", emoji("ℹ️ "))
+		return nil
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	// Each group lists its keeper first; no keep criteria or prompts apply
+	c := e.cfg
+	c.KeepCriteria = ""
+	c.Interactive = false
+	return NewEngine(c, e.events).processDuplicates(ctx, groups)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyEditedDecisions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) FileHash {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		hash, size, mod, err := hashFile(path, getHasher("sha256"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return FileHash{Path: path, Hash: hash, Size: size, ModTime: mod}
+	}
+	a1, a2 := write("a1.txt", "alpha"), write("a2.txt", "alpha")
+	b1, b2 := write("b1.txt", "bravo"), write("b2.txt", "bravo")
+	c1, c2 := write("c1.txt", "charlie"), write("c2.txt", "charlie")
+	groups := []DuplicateGroup{
+		{Hash: a1.Hash, Size: 5, Similarity: 100, Files: []FileHash{a1, a2}},
+		{Hash: b1.Hash, Size: 5, Similarity: 100, Files: []FileHash{b1, b2}},
+		{Hash: c1.Hash, Size: 7, Similarity: 100, Files: []FileHash{c1, c2}},
+	}
+
+	sheet := filepath.Join(dir, "decisions.csv")
+	if err := exportDecisions(sheet, groups, DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The spreadsheet user keeps the second copy of a, marks nothing kept in
+	// b, and c2 is edited after the export
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("exported %d lines, want 7:\n%s", len(lines), data)
+	}
+	lines[1] = strings.Replace(lines[1], ",keep,", ",delete,", 1)
+	lines[2] = strings.Replace(lines[2], ",delete,", ",keep,", 1)
+	lines[3] = strings.Replace(lines[3], ",keep,", ",review,", 1)
+	os.WriteFile(sheet, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	os.WriteFile(c2.Path, []byte("CHARLIE"), 0644)
+
+	c := DefaultConfig()
+	c.JSON = true
+	c.Checkpoint = ""
+	c.MoveTo = filepath.Join(dir, "moved")
+	if err := NewEngine(c, nil).applyDecisions(context.Background(), sheet); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]bool{a1.Path: false, a2.Path: true, b1.Path: true, b2.Path: true, c1.Path: true, c2.Path: true} {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(path), err == nil, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(c.MoveTo, "a1.txt")); err != nil {
+		t.Errorf("a1.txt was not moved: %v", err)
+	}
+
+	// A sheet with an action the tool does not know is refused outright
+	os.WriteFile(sheet, []byte("group,action,path\n1,remove,"+a2.Path+"\n"), 0644)
+	if err := NewEngine(c, nil).applyDecisions(context.Background(), sheet); err == nil {
+		t.Error("unknown action was accepted")
+	}
+}
+
+func TestDecisionsRecordHashAlgorithm(t *testing.T) {
+	c := DefaultConfig()
+	c.HashAlgorithm = "SHA1"
+	groups := []DuplicateGroup{
+		{Hash: "aaaa", Similarity: 100, Files: []FileHash{{Path: "a1"}, {Path: "a2"}}},
+		{Hash: "1010", Similarity: 90, Files: []FileHash{{Path: "b1"}, {Path: "b2"}}},
+		{Unverified: true, Files: []FileHash{{Path: "c1"}, {Path: "c2"}}},
+	}
+	rows := decisionRows(groups, c)
+	for i, want := range []string{"sha1", "sha1", "dhash", "dhash", "", ""} {
+		if rows[i].Algorithm != want {
+			t.Errorf("row %d algorithm = %q, want %q", i, rows[i].Algorithm, want)
+		}
+	}
+
+	if err := checkSheetAlgorithm(rows, "sha1"); err != nil {
+		t.Errorf("a sheet applied with its own algorithm was refused: %v", err)
+	}
+	if _, err := decisionGroups(rows, "sha256"); err == nil || !strings.Contains(err.Error(), "-hash sha1") {
+		t.Errorf("decisionGroups() error = %v, want the sheet refused for its sha1 hashes", err)
+	}
+}
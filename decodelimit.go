@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// countByExt maps extensions or extension groups to a count, as in
+// -decode-limit. On the command line it is a comma-separated list of ext=n
+// pairs and can be given multiple times; in config files it is a JSON
+// object such as {"videos": 2, "gif": 4}.
+type countByExt map[string]int
+
+func (c *countByExt) String() string {
+	if c == nil {
+		return ""
+	}
+	var pairs []string
+	for ext, n := range *c {
+		pairs = append(pairs, ext+"="+strconv.Itoa(n))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (c *countByExt) Set(value string) error {
+	if *c == nil {
+		*c = make(countByExt)
+	}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		ext, count, ok := strings.Cut(item, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if !ok || strings.TrimSpace(ext) == "" || err != nil || n < 1 {
+			return fmt.Errorf("invalid limit %q: want ext=n with n at least 1, e.g. videos=2", item)
+		}
+		(*c)[strings.TrimSpace(ext)] = n
+	}
+	return nil
+}
+
+// decodeLimiter caps how many files of each -decode-limit class are decoded
+// for perceptual hashing at once, so a few huge images or ffmpeg runs cannot
+// take every worker or exhaust memory while plain hashing waits. A nil
+// decodeLimiter limits nothing.
+type decodeLimiter struct {
+	classes map[string]string        // extension -> the -decode-limit key it counts against
+	slots   map[string]chan struct{} // key -> one token per decode allowed at once
+}
+
+// newDecodeLimiter builds the limiter for limits, or returns nil when there
+// are none. A group shares one limit among all of its extensions; a single
+// extension gets its own limit even when a group also lists it.
+func newDecodeLimiter(limits countByExt) *decodeLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	l := &decodeLimiter{classes: make(map[string]string), slots: make(map[string]chan struct{})}
+	var singles []string
+	for key, n := range limits {
+		l.slots[key] = make(chan struct{}, n)
+		if _, ok := extGroups[strings.ToLower(strings.TrimSpace(key))]; !ok {
+			singles = append(singles, key)
+			continue
+		}
+		for ext := range parseExtList(key) {
+			l.classes[ext] = key
+		}
+	}
+	for _, key := range singles {
+		for ext := range parseExtList(key) {
+			l.classes[ext] = key
+		}
+	}
+	return l
+}
+
+// acquire waits for a decode slot for path and returns the function that
+// gives it back. Files no limit covers go ahead at once. It returns ctx's
+// error if ctx is cancelled while waiting.
+func (l *decodeLimiter) acquire(ctx context.Context, path string) (func(), error) {
+	slots := l.slotsFor(path)
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// slotsFor returns the slots path counts against, or nil if no limit
+// applies. The longest matching extension wins, so ".tar.gz" can differ
+// from ".gz".
+func (l *decodeLimiter) slotsFor(path string) chan struct{} {
+	if l == nil {
+		return nil
+	}
+	name := strings.ToLower(filepath.Base(path))
+	matched := ""
+	for ext := range l.classes {
+		if strings.HasSuffix(name, ext) && len(ext) > len(matched) {
+			matched = ext
+		}
+	}
+	if matched == "" {
+		return nil
+	}
+	return l.slots[l.classes[matched]]
+}
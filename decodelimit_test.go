@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCountByExtSet(t *testing.T) {
+	var limits countByExt
+	if err := limits.Set("videos=2, gif=4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := limits.Set("png=1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := limits.String(); got != "gif=4,png=1,videos=2" {
+		t.Errorf("String() = %q", got)
+	}
+
+	for _, bad := range []string{"videos", "videos=0", "=2", "gif=many"} {
+		var c countByExt
+		if err := c.Set(bad); err == nil {
+			t.Errorf("Set(%q) should fail", bad)
+		}
+	}
+}
+
+func TestDecodeLimiter(t *testing.T) {
+	if newDecodeLimiter(nil) != nil {
+		t.Error("no limits should give a nil limiter")
+	}
+
+	l := newDecodeLimiter(countByExt{"videos": 1, "mkv": 2})
+	ctx := context.Background()
+
+	// Videos share one slot
+	release, err := l.acquire(ctx, "/clips/a.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiting, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(waiting, "/clips/B.MOV"); err == nil {
+		t.Error("a second video decode should wait for the first")
+	}
+	release()
+	if release, err = l.acquire(ctx, "/clips/B.MOV"); err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// A single extension gets its own limit, and unlisted files are not limited
+	waiting, cancel = context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	for _, path := range []string{"/clips/c.mkv", "/clips/d.mkv", "/photos/e.jpg", "/photos/f.jpg"} {
+		if _, err := l.acquire(waiting, path); err != nil {
+			t.Errorf("acquire(%s) = %v", path, err)
+		}
+	}
+
+	var unlimited *decodeLimiter
+	if _, err := unlimited.acquire(ctx, "/clips/a.mp4"); err != nil {
+		t.Errorf("nil limiter acquire = %v", err)
+	}
+}
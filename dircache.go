@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// dirFingerprints returns a fingerprint of each directory's scanned files,
+// from their names, sizes and modification times. Adding, removing,
+// renaming or rewriting any of them changes it.
+func dirFingerprints(infos map[string]os.FileInfo) map[string]string {
+	byDir := make(map[string][]string)
+	for path, info := range infos {
+		dir := filepath.Dir(path)
+		byDir[dir] = append(byDir[dir], fmt.Sprintf("%s\x00%d\x00%d", filepath.Base(path), info.Size(), info.ModTime().UnixNano()))
+	}
+	prints := make(map[string]string, len(byDir))
+	for dir, entries := range byDir {
+		sort.Strings(entries)
+		h := sha256.New()
+		for _, entry := range entries {
+			h.Write([]byte(entry))
+			h.Write([]byte{'\n'})
+		}
+		prints[dir] = hex.EncodeToString(h.Sum(nil))
+	}
+	return prints
+}
+
+// unchangedDirs returns the directories whose fingerprint is the one cached
+func (c *hashCache) unchangedDirs(prints map[string]string) map[string]bool {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	unchanged := make(map[string]bool)
+	for dir, print := range prints {
+		if c.Dirs[dir] == print {
+			unchanged[dir] = true
+		}
+	}
+	return unchanged
+}
+
+// storeDirs caches the fingerprints of a scan's directories
+func (c *hashCache) storeDirs(prints map[string]string) {
+	if c == nil || len(prints) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Dirs == nil {
+		c.Dirs = make(map[string]string)
+	}
+	for dir, print := range prints {
+		if c.Dirs[dir] != print {
+			c.Dirs[dir] = print
+			c.dirty = true
+		}
+	}
+}
+
+// contentHash returns the cached content hash of an unchanged file
+func (c *hashCache) contentHash(path string, size int64, modTime time.Time, algorithm string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.entry(path, size, modTime)
+	if entry == nil {
+		return "", false
+	}
+	hash, ok := entry.Hashes[algorithm]
+	return hash, ok
+}
+
+// fingerprintDirs opens the -cache and notes which of the scanned
+// directories are unchanged since the last run, so reuseUnchangedDirs can
+// take their files' hashes from it. infos is nil when that is off: without
+// a -cache, and with -check-integrity, which needs every file read again.
+func (e *Engine) fingerprintDirs(infos map[string]os.FileInfo) {
+	e.dirPrints, e.unchanged = nil, nil
+	if infos == nil {
+		return
+	}
+	e.openCache()
+	e.dirPrints = dirFingerprints(infos)
+	e.unchanged = e.cache.unchangedDirs(e.dirPrints)
+}
+
+// reuseUnchangedDirs takes the hashes of files in unchanged directories from
+// the -cache and returns them with the files still to hash. A file is only
+// reused when everything the workers would compute for it is cached.
+func (e *Engine) reuseUnchangedDirs(files []string, infos map[string]os.FileInfo) (reused []FileHash, toHash []string) {
+	if len(e.unchanged) == 0 {
+		return nil, files
+	}
+	algorithm := e.cfg.hashName()
+	for _, path := range files {
+		info := infos[path]
+		if !e.unchanged[filepath.Dir(path)] || info == nil {
+			toHash = append(toHash, path)
+			continue
+		}
+		if fh, ok := e.cachedFileHash(path, info.Size(), info.ModTime(), algorithm); ok {
+			reused = append(reused, fh)
+			continue
+		}
+		toHash = append(toHash, path)
+	}
+	if len(reused) > 0 && !e.cfg.JSON {
+		log.Printf("%s%d unchanged folders: %d hashes reused from %s", emoji("♻️"), len(e.unchanged), len(reused), e.cfg.Cache)
+	}
+	return reused, toHash
+}
+
+// cachedFileHash is the FileHash a worker would produce for an unchanged
+// file, from the -cache alone
+func (e *Engine) cachedFileHash(path string, size int64, modTime time.Time, algorithm string) (FileHash, bool) {
+	if e.cfg.NormalizeSVG && isSVGFile(path) {
+		return FileHash{}, false // the cache holds the bytes' hash, not the markup's
+	}
+	hash, ok := e.cache.contentHash(path, size, modTime, algorithm)
+	if !ok {
+		return FileHash{}, false
+	}
+	fh := FileHash{Path: path, Size: size, Hash: hash, ModTime: modTime}
+	if e.cfg.perceptualCandidate(path) {
+		if e.cfg.SameDimensions || e.cfg.PhotoLocation {
+			return FileHash{}, false // read from the image itself
+		}
+		if fh.PHash, ok = e.cache.perceptual(path, size, modTime, e.cfg.PHashAlgorithm); !ok {
+			return FileHash{}, false
+		}
+	}
+	return fh, true
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dirPair is how much duplicate content two directories share. A equals B
+// for duplicates within a single directory.
+type dirPair struct {
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Files int    `json:"files"` // duplicate groups with a copy in both
+	Size  int64  `json:"size"`  // bytes one side could give up
+}
+
+// directoryPairs aggregates duplicate groups by the directories their
+// copies live in, largest shared size first
+func directoryPairs(groups []DuplicateGroup) []dirPair {
+	type key struct{ a, b string }
+	pairs := make(map[key]*dirPair)
+	add := func(a, b string, size int64) {
+		k := key{a, b}
+		p := pairs[k]
+		if p == nil {
+			p = &dirPair{A: a, B: b}
+			pairs[k] = p
+		}
+		p.Files++
+		p.Size += size
+	}
+
+	for _, group := range groups {
+		if group.Unverified {
+			continue
+		}
+		copies := make(map[string]int)
+		for _, fh := range group.Files {
+			if fh.Host == "" && !fh.Reference {
+				copies[filepath.Dir(fh.Path)]++
+			}
+		}
+		dirs := make([]string, 0, len(copies))
+		for dir, n := range copies {
+			dirs = append(dirs, dir)
+			if n > 1 {
+				add(dir, dir, group.Size*int64(n-1))
+			}
+		}
+		sort.Strings(dirs)
+		for i := range dirs {
+			for j := i + 1; j < len(dirs); j++ {
+				add(dirs[i], dirs[j], group.Size)
+			}
+		}
+	}
+
+	result := make([]dirPair, 0, len(pairs))
+	for _, p := range pairs {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		if result[i].Files != result[j].Files {
+			return result[i].Files > result[j].Files
+		}
+		return result[i].A+"\x00"+result[i].B < result[j].A+"\x00"+result[j].B
+	})
+	return result
+}
+
+// topDirectoryPairs returns at most n of the directory pairs sharing the most
+func topDirectoryPairs(groups []DuplicateGroup, n int) []dirPair {
+	pairs := directoryPairs(groups)
+	if len(pairs) > n {
+		pairs = pairs[:n]
+	}
+	return pairs
+}
+
+// printDirectoryPairs logs the top directory pairs, so whole folders can be
+// handled instead of individual files
+func printDirectoryPairs(groups []DuplicateGroup, top int) {
+	pairs := directoryPairs(groups)
+	if top <= 0 || len(pairs) == 0 {
+		return
+	}
+	log.Println("\n" + strings.Repeat("=", 70))
+	log.Printf("%sDirectories sharing the most duplicates:", emoji("📁"))
+	for i, p := range pairs {
+		if i == top {
+			log.Printf("  ... and %d more pairs", len(pairs)-top)
+			break
+		}
+		if p.A == p.B {
+			log.Printf("  %s holds %d duplicate files within itself (%s)", p.A, p.Files, formatBytes(p.Size))
+		} else {
+			log.Printf("  %s and %s share %d duplicate files (%s)", p.A, p.B, p.Files, formatBytes(p.Size))
+		}
+	}
+}
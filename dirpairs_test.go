@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDirectoryPairs(t *testing.T) {
+	a, b, c := filepath.Join("x", "a"), filepath.Join("x", "b"), filepath.Join("x", "c")
+	file := func(dir, name string) FileHash { return FileHash{Path: filepath.Join(dir, name)} }
+	groups := []DuplicateGroup{
+		{Size: 100, Files: []FileHash{file(a, "1"), file(b, "1")}},
+		{Size: 50, Files: []FileHash{file(b, "2"), file(a, "2"), file(c, "2")}},
+		{Size: 10, Files: []FileHash{file(c, "3"), file(c, "3 (1)"), file(c, "3 (2)")}},
+		{Size: 999, Unverified: true, Files: []FileHash{file(a, "4"), file(b, "4")}},
+	}
+
+	pairs := directoryPairs(groups)
+	want := []dirPair{
+		{A: a, B: b, Files: 2, Size: 150},
+		{A: a, B: c, Files: 1, Size: 50},
+		{A: b, B: c, Files: 1, Size: 50},
+		{A: c, B: c, Files: 1, Size: 20},
+	}
+	if len(pairs) != len(want) {
+		t.Fatalf("got %d pairs, want %d: %+v", len(pairs), len(want), pairs)
+	}
+	for i := range want {
+		if pairs[i] != want[i] {
+			t.Errorf("pair %d = %+v, want %+v", i, pairs[i], want[i])
+		}
+	}
+
+	if top := topDirectoryPairs(groups, 1); len(top) != 1 || top[0] != want[0] {
+		t.Errorf("topDirectoryPairs(1) = %+v", top)
+	}
+	if top := topDirectoryPairs(groups, 0); len(top) != 0 {
+		t.Errorf("topDirectoryPairs(0) = %+v, want none", top)
+	}
+}
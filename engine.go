@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// Engine runs the scan, hash, group and process pipeline with its own
+// Config, so several scans with different settings can share one process.
+type Engine struct {
+	cfg        Config
+	events     *Events
+	known      *knownDB            // open -known-db after a scan, nil when disabled
+	cache      *hashCache          // open -cache during a scan, nil when disabled
+	decodes    *decodeLimiter      // -decode-limit slots shared by the workers, nil when unlimited
+	index      *hashIndex          // groups hashes during a scan that only needs candidates
+	spilled    *spillSorter        // sorts hashes on disk instead during a -spill-dir scan
+	checkpoint *checkpointLog      // logs each hash of a running scan to -checkpoint, nil when disabled
+	skipped    *skipLog            // files the scans could not read
+	integrity  *integrityLog       // -check-integrity findings
+	ignore     *ignoreList         // groups and image pairs to keep apart, loaded by collectDuplicates
+	onGroup    func([]FileHash)    // set by streamDuplicates to hear of each group the index confirms
+	scanned    int                 // files the last scan found, before filters
+	partial    string              // where -max-files or -max-bytes stopped the last scan, "" if they did not
+	links      map[string][]string // other hard links of the files the last scan kept, by kept path
+	dirPrints  map[string]string   // fingerprints of the last scan's directories, cached once it is hashed
+	unchanged  map[string]bool     // directories whose fingerprint matches the -cache, see reuseUnchangedDirs
+	failed     []SkippedFile       // duplicates processDuplicates could not act on
+	anomalies  []HashAnomaly       // hashes the last grouping found at more than one size
+}
+
+// NewEngine creates an engine for the given configuration. ev may be nil.
+// With -quarantine-deletes it starts the batch the engine's files go to.
+func NewEngine(c Config, ev *Events) *Engine {
+	if c.QuarantineDeletes && c.deletes == nil {
+		c.deletes = newQuarantine(c.quarantineDir(), absPath(c.roots()[0]))
+	}
+	return &Engine{cfg: c, events: ev, skipped: &skipLog{}, integrity: &integrityLog{}, decodes: newDecodeLimiter(c.DecodeLimit)}
+}
+
+// DefaultConfig returns a Config with every option at its command-line default
+func DefaultConfig() Config {
+	var c Config
+	registerFlags(flag.NewFlagSet("defaults", flag.ContinueOnError), &c)
+	return c
+}
+
+// roots returns the directories to scan, defaulting to the current directory
+func (c Config) roots() []string {
+	if len(c.Dir) == 0 {
+		return []string{"."}
+	}
+	return c.Dir
+}
+
+// configWithFlags returns a copy of base with CLI-style flags applied, e.g.
+// {"min-size": "1", "perceptual": "true"}. base itself is never modified.
+func configWithFlags(base Config, flags map[string]string) (Config, error) {
+	fs := flag.NewFlagSet("flags", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var c Config
+	registerFlags(fs, &c)
+	c = base
+
+	// Repeatable flags append, so give them their own backing arrays
+	c.Dir = append(stringList(nil), base.Dir...)
+	c.FilePattern = append(stringList(nil), base.FilePattern...)
+	c.IgnoreCasePattern = append(stringList(nil), base.IgnoreCasePattern...)
+	c.ReferenceReadOnly = append(stringList(nil), base.ReferenceReadOnly...)
+	c.MinSizeExt = make(sizeByExt, len(base.MinSizeExt))
+	for ext, size := range base.MinSizeExt {
+		c.MinSizeExt[ext] = size
+	}
+	c.DecodeLimit = make(countByExt, len(base.DecodeLimit))
+	for ext, n := range base.DecodeLimit {
+		c.DecodeLimit[ext] = n
+	}
+
+	for name, value := range flags {
+		if err := fs.Set(name, value); err != nil {
+			return Config{}, fmt.Errorf("flag -%s: %w", name, err)
+		}
+	}
+	return c, nil
+}
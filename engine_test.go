@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	c := DefaultConfig()
+	if len(c.Dir) != 0 || !c.Recursive || c.MinSize != 1024 || c.KeepCriteria != "oldest" || c.Workers != runtime.NumCPU() {
+		t.Errorf("DefaultConfig() = %+v, want command-line defaults", c)
+	}
+}
+
+func TestConfigWithFlags(t *testing.T) {
+	base := DefaultConfig()
+	base.FilePattern = make(stringList, 1, 4) // spare capacity must not be shared
+	base.FilePattern[0] = "*.jpg"
+	base.MinSizeExt = sizeByExt{"images": 51200}
+
+	c, err := configWithFlags(base, map[string]string{"min-size": "1", "pattern": "*.png", "perceptual": "true", "min-size-ext": "pdf=0"})
+	if err != nil {
+		t.Fatalf("configWithFlags() error = %v", err)
+	}
+	if c.MinSize != 1 || !c.PerceptualMode || len(c.FilePattern) != 2 || len(c.MinSizeExt) != 2 {
+		t.Errorf("configWithFlags() = %+v, want flags applied", c)
+	}
+	if base.MinSize != 1024 || base.PerceptualMode || len(base.FilePattern) != 1 || base.FilePattern[:2][1] != "" || len(base.MinSizeExt) != 1 {
+		t.Errorf("configWithFlags() modified base: %+v", base)
+	}
+
+	if _, err := configWithFlags(base, map[string]string{"no-such-flag": "1"}); err == nil {
+		t.Error("configWithFlags() should reject unknown flags")
+	}
+}
+
+// Two engines with different settings must not affect each other
+func TestEnginesAreIndependent(t *testing.T) {
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{"small1.txt": "tiny", "small2.txt": "tiny", "big1.txt": "a much larger file", "big2.txt": "a much larger file"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	all := DefaultConfig()
+	all.Dir = stringList{tmpDir}
+	all.MinSize = 1
+	all.JSON = true
+
+	bigOnly := all
+	bigOnly.MinSize = 10
+
+	results := make(chan int, 2)
+	for _, c := range []Config{all, bigOnly} {
+		go func(c Config) {
+			duplicates, err := NewEngine(c, nil).collectDuplicates(context.Background())
+			if err != nil {
+				t.Errorf("collectDuplicates() error = %v", err)
+			}
+			results <- len(duplicates)
+		}(c)
+	}
+
+	total := <-results + <-results
+	if total != 3 {
+		t.Errorf("engines found %d groups in total, want 2 + 1", total)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Estimate is the result of a quick duplicate estimate
+type Estimate struct {
+	TotalFiles          int     `json:"total_files"`
+	TotalBytes          int64   `json:"total_bytes"`
+	CandidateFiles      int     `json:"candidate_files"`      // files sharing a size with at least one other file
+	UpperBoundBytes     int64   `json:"upper_bound_bytes"`    // recoverable if every same-size file were a duplicate
+	SampledFiles        int     `json:"sampled_files"`        // candidate files actually hashed
+	DuplicateRatio      float64 `json:"duplicate_ratio"`      // estimated fraction of candidate bytes that are duplicates
+	EstimatedBytes      int64   `json:"estimated_bytes"`      // estimated recoverable space
+	EstimatedDuplicates int     `json:"estimated_duplicates"` // estimated number of removable files
+	Method              string  `json:"method"`               // "sample" or "size-name"
+}
+
+// runEstimate approximates the duplicate ratio without hashing every file.
+// Files are grouped by size (metadata only); then a random sample of
+// same-size groups is hashed and the result extrapolated. With a sample of 0
+// only the size+name heuristic is used and no file content is read.
+func (e *Engine) runEstimate(ctx context.Context) error {
+	startTime := time.Now()
+
+	filter, err := newFileFilter(e.cfg)
+	if err != nil {
+		return err
+	}
+
+	files, err := e.scanRoots(ctx, e.cfg.roots(), e.cfg.Recursive)
+	if err != nil {
+		return fmt.Errorf("failed to scan files: %w", err)
+	}
+
+	est := Estimate{Method: "sample"}
+	bySize := make(map[int64][]string)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || filter.rejectFile(file, info) != "" {
+			continue
+		}
+		est.TotalFiles++
+		est.TotalBytes += info.Size()
+		bySize[info.Size()] = append(bySize[info.Size()], file)
+	}
+
+	var candidates [][]string
+	var candidateSizes []int64
+	for size, group := range bySize {
+		if len(group) < 2 {
+			continue
+		}
+		candidates = append(candidates, group)
+		candidateSizes = append(candidateSizes, size)
+		est.CandidateFiles += len(group)
+		est.UpperBoundBytes += size * int64(len(group)-1)
+	}
+
+	if e.cfg.EstimateSample <= 0 {
+		estimateByName(&est, candidates, candidateSizes)
+	} else {
+		if err := e.estimateBySample(ctx, &est, candidates, candidateSizes, e.cfg.EstimateSample); err != nil {
+			return err
+		}
+	}
+
+	if e.cfg.JSON {
+		data, err := json.MarshalIndent(est, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	log.Printf("")
+	log.Printf("%sQuick Estimate (%s):", emoji("📊"), est.Method)
+	log.Println(strings.Repeat("=", 70))
+	log.Printf("  Files considered:     %d (%s)", est.TotalFiles, formatBytes(est.TotalBytes))
+	log.Printf("  Same-size candidates: %d files", est.CandidateFiles)
+	log.Printf("  Upper bound:          %s recoverable", formatBytes(est.UpperBoundBytes))
+	if est.Method == "sample" {
+		log.Printf("  Files hashed:         %d", est.SampledFiles)
+	}
+	log.Printf("  Duplicate ratio:      %.1f%% of candidate space", est.DuplicateRatio*100)
+	log.Printf("  Estimated duplicates: ~%d files", est.EstimatedDuplicates)
+	log.Printf("  Estimated savings:    ~%s", formatBytes(est.EstimatedBytes))
+	log.Println(strings.Repeat("=", 70))
+	log.Printf("%sEstimate complete in %v (run without -estimate for exact results)", emoji("✅"), time.Since(startTime).Round(time.Millisecond))
+	return nil
+}
+
+// estimateBySample hashes randomly chosen same-size groups until about
+// sampleFiles files have been read, then extrapolates to all candidates
+func (e *Engine) estimateBySample(ctx context.Context, est *Estimate, candidates [][]string, sizes []int64, sampleFiles int) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	order := rand.New(rand.NewSource(time.Now().UnixNano())).Perm(len(candidates))
+
+	var sampledUpper, sampledBytes int64
+	var sampledDups, sampledCandidates int
+	for _, idx := range order {
+		if est.SampledFiles >= sampleFiles {
+			break
+		}
+		group, size := candidates[idx], sizes[idx]
+
+		seen := make(map[string]int)
+		hashed := 0
+		for _, file := range group {
+			hash, _, _, err := hashFileContext(ctx, file, getHasher(e.cfg.HashAlgorithm))
+			if err != nil {
+				continue
+			}
+			seen[hash]++
+			hashed++
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		est.SampledFiles += hashed
+		if hashed < 2 {
+			continue
+		}
+
+		sampledCandidates += hashed
+		sampledUpper += size * int64(hashed-1)
+		for _, count := range seen {
+			if count > 1 {
+				sampledDups += count - 1
+				sampledBytes += size * int64(count-1)
+			}
+		}
+	}
+
+	if sampledUpper > 0 {
+		est.DuplicateRatio = float64(sampledBytes) / float64(sampledUpper)
+	}
+	est.EstimatedBytes = int64(float64(est.UpperBoundBytes) * est.DuplicateRatio)
+	if sampledCandidates > 0 {
+		est.EstimatedDuplicates = int(float64(est.CandidateFiles) * float64(sampledDups) / float64(sampledCandidates))
+	}
+	return nil
+}
+
+// estimateByName treats same-size files that also share a base name as
+// probable duplicates, without reading any file content
+func estimateByName(est *Estimate, candidates [][]string, sizes []int64) {
+	est.Method = "size-name"
+	for i, group := range candidates {
+		byName := make(map[string]int)
+		for _, file := range group {
+			byName[strings.ToLower(filepath.Base(file))]++
+		}
+		for _, count := range byName {
+			if count > 1 {
+				est.EstimatedDuplicates += count - 1
+				est.EstimatedBytes += sizes[i] * int64(count-1)
+			}
+		}
+	}
+	if est.UpperBoundBytes > 0 {
+		est.DuplicateRatio = float64(est.EstimatedBytes) / float64(est.UpperBoundBytes)
+	}
+}
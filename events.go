@@ -0,0 +1,39 @@
+package main
+
+// Events receives structured progress from the deduplication pipeline so
+// embedders do not have to scrape log output. Any hook may be nil, and a nil
+// *Events disables them all. Hooks are always called from the goroutine that
+// runs the pipeline, never concurrently.
+type Events struct {
+	OnFileHashed  func(fh FileHash)                     // after each file is hashed
+	OnGroupFound  func(index int, group DuplicateGroup) // once per duplicate group, in report order
+	OnActionTaken func(action ActionEvent)              // after each file is deleted or moved (or fails to be)
+}
+
+// ActionEvent describes what happened to one duplicate during processing
+type ActionEvent struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Action string `json:"action,omitempty"` // "deleted", "trashed", "moved" or "linked"; empty on failure
+	Target string `json:"target,omitempty"` // destination for "moved"
+	Error  string `json:"error,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"` // with -dry-run: the action was not taken
+}
+
+func (e *Events) fileHashed(fh FileHash) {
+	if e != nil && e.OnFileHashed != nil {
+		e.OnFileHashed(fh)
+	}
+}
+
+func (e *Events) groupFound(index int, group DuplicateGroup) {
+	if e != nil && e.OnGroupFound != nil {
+		e.OnGroupFound(index, group)
+	}
+}
+
+func (e *Events) actionTaken(action ActionEvent) {
+	if e != nil && e.OnActionTaken != nil {
+		e.OnActionTaken(action)
+	}
+}
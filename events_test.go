@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEventsHooks(t *testing.T) {
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "same content", "b.txt": "same content", "c.txt": "different"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{tmpDir}
+	c.MinSize = 1
+	c.Workers = 2
+	c.JSON = true
+	c.MoveTo = filepath.Join(t.TempDir(), "moved") // moving keeps the undo log untouched
+
+	var hashed, groups int
+	var actions []ActionEvent
+	ev := &Events{
+		OnFileHashed: func(fh FileHash) { hashed++ },
+		OnGroupFound: func(index int, group DuplicateGroup) {
+			if index != groups {
+				t.Errorf("OnGroupFound index = %d, want %d", index, groups)
+			}
+			groups++
+		},
+		OnActionTaken: func(action ActionEvent) { actions = append(actions, action) },
+	}
+
+	engine := NewEngine(c, ev)
+	duplicates, err := engine.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatalf("collectDuplicates() error = %v", err)
+	}
+	if hashed != 2 { // c.txt is ruled out by its size, unread
+		t.Errorf("OnFileHashed called %d times, want 2", hashed)
+	}
+	if groups != len(duplicates) || groups != 1 {
+		t.Errorf("OnGroupFound called %d times for %d groups, want 1", groups, len(duplicates))
+	}
+
+	if err := engine.processDuplicates(context.Background(), duplicates); err != nil {
+		t.Fatalf("processDuplicates() error = %v", err)
+	}
+	if len(actions) != 1 || actions[0].Action != "moved" || actions[0].Size != int64(len("same content")) {
+		t.Fatalf("OnActionTaken events = %+v, want one move", actions)
+	}
+	if _, err := os.Stat(actions[0].Target); err != nil {
+		t.Errorf("moved file %s is missing: %v", actions[0].Target, err)
+	}
+}
+
+func TestNilEvents(t *testing.T) {
+	var ev *Events
+	ev.fileHashed(FileHash{})
+	ev.groupFound(0, DuplicateGroup{})
+	ev.actionTaken(ActionEvent{})
+
+	(&Events{}).actionTaken(ActionEvent{})
+}
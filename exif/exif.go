@@ -0,0 +1,245 @@
+// Package exif reads the camera, capture time and GPS position recorded in
+// the EXIF data of JPEG and TIFF-based images (TIFF, DNG and most camera
+// raws). It is shared by the preview pane and -photo-location.
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Info is what an image's EXIF data says about it
+type Info struct {
+	Camera    string     // make and model
+	Taken     *time.Time // DateTimeOriginal, in the camera's clock without a zone
+	Latitude  float64
+	Longitude float64
+	GPS       bool // Latitude and Longitude are set
+}
+
+// TimeLayout is how EXIF writes dates
+const TimeLayout = "2006:01:02 15:04:05"
+
+// ErrNoExif is returned for files without readable EXIF data
+var ErrNoExif = errors.New("no EXIF data")
+
+// EXIF tags read by Parse
+const (
+	tagMake             = 0x010f
+	tagModel            = 0x0110
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+)
+
+// maxIFDEntries bounds a directory so a corrupt count cannot run away
+const maxIFDEntries = 1000
+
+// Read reads the EXIF data of the JPEG or TIFF-based image at path
+func Read(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var magic [4]byte
+	if _, err := io.ReadFull(f, magic[:]); err != nil {
+		return nil, ErrNoExif
+	}
+	var tiff io.ReaderAt
+	switch {
+	case magic[0] == 0xFF && magic[1] == 0xD8:
+		data, err := jpegExif(f)
+		if err != nil {
+			return nil, err
+		}
+		tiff = bytes.NewReader(data)
+	case string(magic[:]) == "II*\x00" || string(magic[:]) == "MM\x00*":
+		tiff = f
+	default:
+		return nil, ErrNoExif
+	}
+	return Parse(tiff)
+}
+
+// jpegExif returns the TIFF data of the Exif APP1 segment of the JPEG f
+func jpegExif(f *os.File) ([]byte, error) {
+	if _, err := f.Seek(2, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return nil, ErrNoExif
+		}
+		// Image data starts at SOS; metadata always comes before it
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return nil, ErrNoExif
+		}
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return nil, ErrNoExif
+		}
+		if marker[1] != 0xE1 {
+			if _, err := r.Discard(length); err != nil {
+				return nil, ErrNoExif
+			}
+			continue
+		}
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, ErrNoExif
+		}
+		if bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+	}
+}
+
+// ifdEntry is one field of a TIFF image file directory
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value [4]byte // the value itself if it fits, otherwise its offset
+}
+
+// tiffReader reads the directories of TIFF data
+type tiffReader struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+}
+
+// Parse reads the camera, capture time and GPS position from EXIF TIFF data.
+// Fields that are missing or malformed are left empty.
+func Parse(r io.ReaderAt) (*Info, error) {
+	var header [8]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return nil, ErrNoExif
+	}
+	t := tiffReader{r: r}
+	switch string(header[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, ErrNoExif
+	}
+	if t.order.Uint16(header[2:]) != 42 {
+		return nil, ErrNoExif
+	}
+
+	ifd0, err := t.ifd(t.order.Uint32(header[4:]))
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{}
+	maker, model := t.ascii(ifd0[tagMake]), t.ascii(ifd0[tagModel])
+	if maker != "" && !strings.HasPrefix(model, maker) {
+		info.Camera = strings.TrimSpace(maker + " " + model)
+	} else {
+		info.Camera = model
+	}
+
+	taken := t.ascii(ifd0[tagDateTime])
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if exif, err := t.ifd(t.order.Uint32(e.value[:])); err == nil {
+			if original := t.ascii(exif[tagDateTimeOriginal]); original != "" {
+				taken = original
+			}
+		}
+	}
+	if when, err := time.Parse(TimeLayout, taken); err == nil {
+		info.Taken = &when
+	}
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := t.ifd(t.order.Uint32(e.value[:])); err == nil {
+			lat, latOK := t.degrees(gps[tagGPSLatitude], t.ascii(gps[tagGPSLatitudeRef]), "S")
+			lon, lonOK := t.degrees(gps[tagGPSLongitude], t.ascii(gps[tagGPSLongitudeRef]), "W")
+			// 0,0 is what cameras without a fix often write
+			if latOK && lonOK && (lat != 0 || lon != 0) {
+				info.Latitude, info.Longitude, info.GPS = lat, lon, true
+			}
+		}
+	}
+	return info, nil
+}
+
+// ifd reads the directory at offset, by tag
+func (t tiffReader) ifd(offset uint32) (map[uint16]ifdEntry, error) {
+	var count [2]byte
+	if _, err := t.r.ReadAt(count[:], int64(offset)); err != nil {
+		return nil, ErrNoExif
+	}
+	n := int(t.order.Uint16(count[:]))
+	if n > maxIFDEntries {
+		return nil, ErrNoExif
+	}
+	data := make([]byte, 12*n)
+	if _, err := t.r.ReadAt(data, int64(offset)+2); err != nil {
+		return nil, ErrNoExif
+	}
+	entries := make(map[uint16]ifdEntry, n)
+	for i := 0; i < n; i++ {
+		raw := data[12*i:]
+		e := ifdEntry{typ: t.order.Uint16(raw[2:]), count: t.order.Uint32(raw[4:])}
+		copy(e.value[:], raw[8:12])
+		entries[t.order.Uint16(raw)] = e
+	}
+	return entries, nil
+}
+
+// ascii returns a string field, or "" if e is missing or not a string
+func (t tiffReader) ascii(e ifdEntry) string {
+	const typeASCII = 2
+	if e.typ != typeASCII || e.count == 0 || e.count > 256 {
+		return ""
+	}
+	data := e.value[:]
+	if e.count > 4 {
+		data = make([]byte, e.count)
+		if _, err := t.r.ReadAt(data, int64(t.order.Uint32(e.value[:]))); err != nil {
+			return ""
+		}
+	}
+	return strings.TrimSpace(strings.TrimRight(string(data[:min(int(e.count), len(data))]), "\x00"))
+}
+
+// degrees converts a GPS coordinate of three rationals (degrees, minutes,
+// seconds) to decimal degrees, negative when ref is negativeRef
+func (t tiffReader) degrees(e ifdEntry, ref, negativeRef string) (float64, bool) {
+	const typeRational = 5
+	if e.typ != typeRational || e.count != 3 {
+		return 0, false
+	}
+	data := make([]byte, 24)
+	if _, err := t.r.ReadAt(data, int64(t.order.Uint32(e.value[:]))); err != nil {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num, den := t.order.Uint32(data[8*i:]), t.order.Uint32(data[8*i+4:])
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	value := parts[0] + parts[1]/60 + parts[2]/3600
+	if strings.EqualFold(ref, negativeRef) {
+		value = -value
+	}
+	return value, true
+}
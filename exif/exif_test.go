@@ -0,0 +1,111 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// exifTIFF builds EXIF TIFF data with a camera, a capture time and a GPS
+// position given in degrees, minutes and seconds
+func exifTIFF(taken string, lat [3]uint32, latRef string, lon [3]uint32, lonRef string) []byte {
+	le := binary.LittleEndian
+	tiff := make([]byte, 221)
+	copy(tiff, "II*\x00")
+	le.PutUint32(tiff[4:], 8)
+	entry := func(at int, tag, typ uint16, count, value uint32) {
+		le.PutUint16(tiff[at:], tag)
+		le.PutUint16(tiff[at+2:], typ)
+		le.PutUint32(tiff[at+4:], count)
+		le.PutUint32(tiff[at+8:], value)
+	}
+
+	// IFD0 at 8 names the camera and points to the Exif IFD at 62 and the
+	// GPS IFD at 80; the values they point to start at 134
+	le.PutUint16(tiff[8:], 4)
+	entry(10, tagMake, 2, 6, 202)
+	entry(22, tagModel, 2, 13, 208)
+	entry(34, tagExifIFD, 4, 1, 62)
+	entry(46, tagGPSIFD, 4, 1, 80)
+	le.PutUint16(tiff[62:], 1)
+	entry(64, tagDateTimeOriginal, 2, 20, 134)
+	le.PutUint16(tiff[80:], 4)
+	entry(82, tagGPSLatitudeRef, 2, 2, uint32(latRef[0]))
+	entry(94, tagGPSLatitude, 5, 3, 154)
+	entry(106, tagGPSLongitudeRef, 2, 2, uint32(lonRef[0]))
+	entry(118, tagGPSLongitude, 5, 3, 178)
+
+	copy(tiff[134:], taken)
+	for i := 0; i < 3; i++ {
+		le.PutUint32(tiff[154+8*i:], lat[i])
+		le.PutUint32(tiff[158+8*i:], 1)
+		le.PutUint32(tiff[178+8*i:], lon[i])
+		le.PutUint32(tiff[182+8*i:], 1)
+	}
+	copy(tiff[202:], "Canon\x00")
+	copy(tiff[208:], "Canon EOS 5D\x00")
+	return tiff
+}
+
+// exifJPEG wraps EXIF TIFF data in a small JPEG
+func exifJPEG(tiff []byte) []byte {
+	var img bytes.Buffer
+	jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+	var out bytes.Buffer
+	out.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(2+6+len(tiff)))
+	out.WriteString("Exif\x00\x00")
+	out.Write(tiff)
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	tiff := exifTIFF("2024:07:14 18:30:05", [3]uint32{48, 51, 30}, "N", [3]uint32{2, 17, 40}, "W")
+	data := exifJPEG(tiff)
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("test JPEG does not decode: %v", err)
+	}
+
+	photo := filepath.Join(dir, "photo.jpg")
+	raw := filepath.Join(dir, "photo.dng")
+	os.WriteFile(photo, data, 0644)
+	os.WriteFile(raw, tiff, 0644)
+	for _, path := range []string{photo, raw} {
+		info, err := Read(path)
+		if err != nil {
+			t.Fatalf("Read(%s) error = %v", filepath.Base(path), err)
+		}
+		if info.Camera != "Canon EOS 5D" {
+			t.Errorf("Camera = %q, want Canon EOS 5D", info.Camera)
+		}
+		if info.Taken == nil || info.Taken.Format(TimeLayout) != "2024:07:14 18:30:05" {
+			t.Errorf("Taken = %v, want 2024-07-14 18:30:05", info.Taken)
+		}
+		if !info.GPS || math.Abs(info.Latitude-48.8583) > 0.001 || math.Abs(info.Longitude+2.2944) > 0.001 {
+			t.Errorf("position = %v %.4f,%.4f, want 48.8583,-2.2944", info.GPS, info.Latitude, info.Longitude)
+		}
+	}
+
+	// A JPEG without an Exif segment has nothing to tell
+	var plain bytes.Buffer
+	jpeg.Encode(&plain, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+	os.WriteFile(photo, plain.Bytes(), 0644)
+	if info, err := Read(photo); err != ErrNoExif {
+		t.Errorf("Read() of a plain JPEG = %+v, %v, want ErrNoExif", info, err)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	full := exifTIFF("2024:07:14 18:30:05", [3]uint32{48, 51, 30}, "N", [3]uint32{2, 17, 40}, "W")
+	// Truncated data must never panic
+	for n := 0; n < len(full); n++ {
+		Parse(bytes.NewReader(full[:n]))
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/luinbytes/file-deduplicator/tui"
+)
+
+// absPath returns path made absolute, or path itself if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// exploreData converts a scan into the explorer's files and groups. Waste
+// is what removing a copy would recover, as recoverableFiles decides it.
+func exploreData(fileHashes []FileHash, duplicates []DuplicateGroup, criteria string) ([]tui.ExploreFile, []tui.DuplicateGroup) {
+	waste := recoverableFiles(duplicates, criteria)
+	groupOf := make(map[string]int)
+	groups := make([]tui.DuplicateGroup, len(duplicates))
+	for i, group := range duplicates {
+		groups[i] = tui.DuplicateGroup{Hash: group.Hash, Size: group.Size, Similarity: group.Similarity}
+		for _, fh := range group.Files {
+			path := absPath(fh.Path)
+			groups[i].Files = append(groups[i].Files, tui.FileInfo{Path: path, Size: fh.Size, Hash: fh.Hash})
+			groupOf[path] = i + 1
+		}
+	}
+
+	files := make([]tui.ExploreFile, 0, len(fileHashes))
+	for _, fh := range fileHashes {
+		if fh.Host != "" || fh.Reference {
+			continue
+		}
+		path := absPath(fh.Path)
+		files = append(files, tui.ExploreFile{Path: path, Size: fh.Size, Waste: waste[path], Group: groupOf[path] - 1})
+	}
+	return files, groups
+}
+
+// runExplore scans the roots and opens the disk usage explorer
+func (e *Engine) runExplore(ctx context.Context) error {
+	fileHashes, err := e.collectFiles(ctx)
+	if err != nil {
+		return err
+	}
+	var duplicates []DuplicateGroup
+	if e.cfg.NoHash {
+		duplicates = findSizeGroups(fileHashes, e.cfg.SameName)
+	} else {
+		duplicates = e.findDuplicates(fileHashes)
+	}
+
+	files, groups := exploreData(fileHashes, duplicates, e.cfg.KeepCriteria)
+	if err := tui.RunExplore(files, groups); err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+	return nil
+}
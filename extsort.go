@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// spillRunSize is roughly how many bytes of records a spillSorter holds
+// before sorting them and writing them out as a run. Tests lower it.
+var spillRunSize = 64 << 20
+
+// spillBatch is how many same-size files -spill-dir narrows with partial
+// hashes at a time
+const spillBatch = 1 << 16
+
+// spillSorter sorts text records too many to hold in memory: they are
+// sorted in runs of spillRunSize bytes written to temporary files, which
+// each then merges. Records must not contain newlines. The first error
+// writing a run is kept and returned by each.
+type spillSorter struct {
+	dir   string
+	lines []string
+	size  int
+	runs  []string // temporary files, each sorted
+	err   error
+}
+
+// newSpillSorter creates a sorter writing its runs in dir
+func newSpillSorter(dir string) *spillSorter {
+	return &spillSorter{dir: dir}
+}
+
+// add adds a record, writing a run once enough are held
+func (s *spillSorter) add(line string) {
+	if s.err != nil {
+		return
+	}
+	s.lines = append(s.lines, line)
+	s.size += len(line) + 16 // and the string header
+	if s.size >= spillRunSize {
+		s.err = s.flush()
+	}
+}
+
+// flush sorts the records held and writes them out as a run
+func (s *spillSorter) flush() error {
+	if len(s.lines) == 0 {
+		return nil
+	}
+	sort.Strings(s.lines)
+	f, err := os.CreateTemp(s.dir, "dedup-spill-*.run")
+	if err != nil {
+		return fmt.Errorf("cannot spill to %s: %w", s.dir, err)
+	}
+	s.runs = append(s.runs, f.Name())
+	w := bufio.NewWriter(f)
+	for _, line := range s.lines {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("cannot spill to %s: %w", s.dir, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cannot spill to %s: %w", s.dir, err)
+	}
+	s.lines, s.size = nil, 0
+	return nil
+}
+
+// each calls fn with every record added, in order, and removes the runs.
+// Records that never filled a run are sorted in memory. An error from fn
+// stops it and is returned.
+func (s *spillSorter) each(fn func(line string) error) error {
+	defer s.remove()
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.runs) == 0 {
+		sort.Strings(s.lines)
+		for _, line := range s.lines {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	// Merge the runs, taking the smallest head each time
+	var heads runHeads
+	for _, run := range s.runs {
+		f, err := os.Open(run)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64<<10), 1<<20)
+		heads.next(scanner)
+	}
+	heap.Init(&heads)
+	for heads.Len() > 0 {
+		head := heads.runs[0]
+		if err := fn(head.line); err != nil {
+			return err
+		}
+		if head.scanner.Scan() {
+			head.line = head.scanner.Text()
+			heap.Fix(&heads, 0)
+			continue
+		}
+		if err := head.scanner.Err(); err != nil {
+			return err
+		}
+		heap.Pop(&heads)
+	}
+	return nil
+}
+
+// remove deletes the runs and forgets every record
+func (s *spillSorter) remove() {
+	for _, run := range s.runs {
+		os.Remove(run)
+	}
+	s.runs, s.lines, s.size = nil, nil, 0
+}
+
+// runHead is the next record of a run being merged
+type runHead struct {
+	line    string
+	scanner *bufio.Scanner
+}
+
+// runHeads is a min-heap of the runs being merged, by their next record
+type runHeads struct{ runs []*runHead }
+
+// next adds a run to the heads, unless it is empty
+func (h *runHeads) next(scanner *bufio.Scanner) {
+	if scanner.Scan() {
+		h.runs = append(h.runs, &runHead{line: scanner.Text(), scanner: scanner})
+	}
+}
+
+func (h runHeads) Len() int           { return len(h.runs) }
+func (h runHeads) Less(i, j int) bool { return h.runs[i].line < h.runs[j].line }
+func (h runHeads) Swap(i, j int)      { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *runHeads) Push(x any)        { h.runs = append(h.runs, x.(*runHead)) }
+func (h *runHeads) Pop() any {
+	last := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return last
+}
+
+// sizeRecord is how -spill-dir sorts a scanned file: by size, written in
+// fixed-width hex so the text order is the numeric one
+func sizeRecord(path string, size int64) string {
+	return fmt.Sprintf("%016x\t%s", size, strconv.Quote(path))
+}
+
+// parseSizeRecord reads a sizeRecord back
+func parseSizeRecord(line string) (string, int64, error) {
+	sizeHex, quoted, _ := strings.Cut(line, "\t")
+	size, err := strconv.ParseInt(sizeHex, 16, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("corrupt spill record %q", line)
+	}
+	path, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", 0, fmt.Errorf("corrupt spill record %q", line)
+	}
+	return path, size, nil
+}
+
+// hashRecord is how -spill-dir sorts a hashed file: by size and hash, so
+// the copies of a file end up next to each other, then the file itself
+func hashRecord(fh FileHash) string {
+	data, _ := json.Marshal(fh) // escapes newlines
+	return fmt.Sprintf("%016x\t%s\t%s", fh.Size, fh.Hash, data)
+}
+
+// spilledHashes is collectHashes for -spill-dir: it returns the files that
+// share a hash with another file without ever holding the whole scan in
+// memory. Scanned files are sorted by size on disk; the sizes more than one
+// file has are narrowed with partial hashes a batch at a time, then hashed,
+// and the hashes sorted on disk in turn so each group can be read off in
+// one pass. Memory holds one batch, one run and the duplicates found.
+// Interrupted runs are not checkpointed.
+func (e *Engine) spilledHashes(ctx context.Context) ([]FileHash, error) {
+	filter, err := newFileFilter(e.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scan, sorting what passes the filters by size
+	bySize := newSpillSorter(e.cfg.SpillDir)
+	defer bySize.remove()
+	scanner := e.scanner(e.cfg.Recursive, e.scanProgress())
+	e.scanned = 0
+	kept := 0
+	budget := e.cfg.scanBudget()
+	for _, root := range e.cfg.scanDirs() {
+		err := scanner.WalkFunc(ctx, root, func(file string) error {
+			e.scanned++
+			info, err := os.Stat(file)
+			if err != nil {
+				e.skipped.add(file, err)
+				if e.cfg.Verbose {
+					log.Printf("%sCould not stat %s: %v", emoji("⚠️"), file, err)
+				}
+				return nil
+			}
+			if reason := filter.rejectFile(file, info); reason != "" {
+				if e.cfg.Verbose {
+					log.Printf("%sSkipping %s: %s", emoji("🚫"), reason, file)
+				}
+				return nil
+			}
+			if !budget.admit(info.Size()) {
+				return errBudgetSpent
+			}
+			kept++
+			bySize.add(sizeRecord(file, info.Size()))
+			return nil
+		})
+		if errors.Is(err, errBudgetSpent) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan files: %w", err)
+		}
+	}
+	e.partial = budget.partial()
+	if !e.cfg.JSON {
+		if !e.cfg.Verbose {
+			fmt.Fprintf(os.Stderr, "\r📁 Scanning: %d files\n", e.scanned)
+		}
+		log.Printf("📊 Found %d files", e.scanned)
+		log.Printf("📏 After filters: %d files", kept)
+	}
+	e.logPartial()
+
+	// Keep the files sharing a size, and their ends when -partial-hash
+	candidates, err := os.CreateTemp(e.cfg.SpillDir, "dedup-spill-*.candidates")
+	if err != nil {
+		return nil, fmt.Errorf("cannot spill to %s: %w", e.cfg.SpillDir, err)
+	}
+	defer os.Remove(candidates.Name())
+	defer candidates.Close()
+	out := bufio.NewWriter(candidates)
+	total, sharedSize := 0, 0
+	var batch []string
+	var batchSizes []int64
+	writeBatch := func() error {
+		files, sizes := batch, batchSizes
+		if e.stagedHashing(true) {
+			var err error
+			if files, sizes, _, err = e.narrowCandidates(ctx, files, sizes); err != nil {
+				return fmt.Errorf("failed to compute partial hashes: %w", err)
+			}
+		}
+		for i, file := range files {
+			out.WriteString(sizeRecord(file, sizes[i]))
+			out.WriteByte('\n')
+		}
+		sharedSize += len(batch)
+		total += len(files)
+		batch, batchSizes = nil, nil
+		return nil
+	}
+	var run []string
+	var runSize int64
+	endRun := func() error {
+		// A file reached through nested or repeated roots is one file
+		seen := make(map[string]bool, len(run))
+		var distinct []string
+		for _, file := range run {
+			if abs := absPath(file); !seen[abs] {
+				seen[abs] = true
+				distinct = append(distinct, file)
+			}
+		}
+		run = run[:0]
+		if len(distinct) < 2 {
+			return nil
+		}
+		for _, file := range distinct {
+			batch = append(batch, file)
+			batchSizes = append(batchSizes, runSize)
+		}
+		if len(batch) >= spillBatch {
+			return writeBatch()
+		}
+		return nil
+	}
+	err = bySize.each(func(line string) error {
+		file, size, err := parseSizeRecord(line)
+		if err != nil {
+			return err
+		}
+		if len(run) > 0 && size != runSize {
+			if err := endRun(); err != nil {
+				return err
+			}
+		}
+		run, runSize = append(run, file), size
+		return nil
+	})
+	if err == nil {
+		err = endRun()
+	}
+	if err == nil && len(batch) > 0 {
+		err = writeBatch()
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.stagedHashing(true) {
+		e.logPartialHashes(sharedSize, total)
+	}
+
+	// Hash the candidates, sorting the results by size and hash
+	if _, err := candidates.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	e.spilled = newSpillSorter(e.cfg.SpillDir)
+	defer func() { e.spilled = nil }()
+	e.openCache()
+	_, err = e.hashFiles(ctx, total, func(send func(string) bool) error {
+		lines := bufio.NewScanner(candidates)
+		lines.Buffer(make([]byte, 64<<10), 1<<20)
+		for lines.Scan() {
+			file, _, err := parseSizeRecord(lines.Text())
+			if err != nil {
+				return err
+			}
+			if !send(file) {
+				return nil
+			}
+		}
+		return lines.Err()
+	})
+	if cacheErr := e.cache.save(); cacheErr != nil {
+		log.Printf("%sFailed to save cache: %v", emoji("⚠️"), cacheErr)
+	}
+	if err != nil {
+		e.spilled.remove()
+		return nil, fmt.Errorf("failed to compute hashes: %w", err)
+	}
+
+	// Read the groups off the sorted hashes
+	var fileHashes, group []FileHash
+	groupKey, computed := "", 0
+	endGroup := func() {
+		if len(group) > 1 {
+			fileHashes = append(fileHashes, group...)
+			if e.onGroup != nil {
+				e.onGroup(group)
+			}
+		}
+		group = nil
+	}
+	err = e.spilled.each(func(line string) error {
+		computed++
+		size, rest, _ := strings.Cut(line, "\t")
+		hash, data, _ := strings.Cut(rest, "\t")
+		var fh FileHash
+		if err := json.Unmarshal([]byte(data), &fh); err != nil {
+			return fmt.Errorf("corrupt spill record %q", line)
+		}
+		if key := size + "\t" + hash; key != groupKey {
+			endGroup()
+			groupKey = key
+		}
+		group = append(group, fh)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to group hashes: %w", err)
+	}
+	endGroup()
+
+	if !e.cfg.JSON {
+		if !e.cfg.Verbose {
+			fmt.Fprintln(os.Stderr) // Newline after progress bar
+		}
+		log.Printf("🔐 Computed %d hashes", computed)
+		log.Printf("🧮 %d files share a hash with another file", len(fileHashes))
+	}
+	return fileHashes, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestSpillSorterMergesRuns(t *testing.T) {
+	defer func(size int) { spillRunSize = size }(spillRunSize)
+	spillRunSize = 64 // a few records per run
+
+	dir := t.TempDir()
+	s := newSpillSorter(dir)
+	var want []string
+	for i := 0; i < 50; i++ {
+		line := fmt.Sprintf("%03d", (i*37)%50)
+		s.add(line)
+		want = append(want, line)
+	}
+	sort.Strings(want)
+	if len(s.runs) < 2 {
+		t.Fatalf("only %d runs written, want several", len(s.runs))
+	}
+
+	var got []string
+	if err := s.each(func(line string) error {
+		got = append(got, line)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("each() = %v, want %v", got, want)
+	}
+	if left, _ := os.ReadDir(dir); len(left) != 0 {
+		t.Errorf("runs left behind: %v", left)
+	}
+}
+
+func TestSpilledHashes(t *testing.T) {
+	defer func(size int) { spillRunSize = size }(spillRunSize)
+	spillRunSize = 128
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":       "same content",
+		"sub/b.txt":   "same content",
+		"c.txt":       "other content",
+		"d.txt":       "same-length!",
+		"e\nname.txt": "other content",
+		"unique.txt":  "a file of a size nothing else has",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spill := t.TempDir()
+	c := DefaultConfig()
+	c.Dir = stringList{dir, filepath.Join(dir, "sub")} // b.txt is reached twice
+	c.Recursive = true
+	c.MinSize = 1
+	c.Checkpoint = ""
+	c.JSON = true
+	c.SpillDir = spill
+	engine := NewEngine(c, nil)
+	var groups int
+	engine.onGroup = func([]FileHash) { groups++ }
+
+	candidates, err := engine.collectHashes(context.Background(), true)
+	if err != nil {
+		t.Fatalf("collectHashes() error = %v", err)
+	}
+	var got []string
+	for _, fh := range candidates {
+		rel, _ := filepath.Rel(dir, fh.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	want := []string{"a.txt", "c.txt", "e\nname.txt", "sub/b.txt"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("collectHashes() = %q, want %q", got, want)
+	}
+	if groups != 2 {
+		t.Errorf("onGroup called %d times, want 2", groups)
+	}
+	if left, _ := os.ReadDir(spill); len(left) != 0 {
+		t.Errorf("spill files left behind: %v", left)
+	}
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
+)
+
+// stringList is a flag value that can be given multiple times. In config
+// files it accepts either a single string or an array of strings.
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*l = nil
+		} else {
+			*l = stringList{single}
+		}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*l = many
+	return nil
+}
+
+// sizeByExt maps extensions or extension groups to a size in bytes, as in
+// -min-size-ext. On the command line it is a comma-separated list of
+// ext=bytes pairs and can be given multiple times; in config files it is a
+// JSON object such as {"images": 51200, "documents": 0}.
+type sizeByExt map[string]int64
+
+func (s *sizeByExt) String() string {
+	if s == nil {
+		return ""
+	}
+	var pairs []string
+	for ext, size := range *s {
+		pairs = append(pairs, ext+"="+strconv.FormatInt(size, 10))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (s *sizeByExt) Set(value string) error {
+	if *s == nil {
+		*s = make(sizeByExt)
+	}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		ext, size, ok := strings.Cut(item, "=")
+		n, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		if !ok || strings.TrimSpace(ext) == "" || err != nil || n < 0 {
+			return fmt.Errorf("invalid size %q: want ext=bytes, e.g. images=51200", item)
+		}
+		(*s)[strings.TrimSpace(ext)] = n
+	}
+	return nil
+}
+
+// byExt expands the groups and extensions of s into single lowercase
+// extensions with a leading dot. A single extension wins over a group
+// containing it, so "images=51200,gif=0" lets any GIF through.
+func (s sizeByExt) byExt() map[string]int64 {
+	sizes := make(map[string]int64)
+	var singles []string
+	for key := range s {
+		if _, ok := extGroups[strings.ToLower(strings.TrimSpace(key))]; !ok {
+			singles = append(singles, key)
+			continue
+		}
+		for ext := range parseExtList(key) {
+			sizes[ext] = s[key]
+		}
+	}
+	for _, key := range singles {
+		for ext := range parseExtList(key) {
+			sizes[ext] = s[key]
+		}
+	}
+	return sizes
+}
+
+// extGroups are shorthand names accepted by -ext and -exclude-ext
+var extGroups = map[string][]string{
+	"images":    {".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".tif", ".tiff", ".heic", ".raw", ".cr2", ".nef", ".arw", ".dng"},
+	"videos":    {".mp4", ".mov", ".mkv", ".avi", ".wmv", ".webm", ".m4v", ".mts"},
+	"audio":     {".mp3", ".flac", ".wav", ".aac", ".ogg", ".m4a", ".wma"},
+	"documents": {".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".odt", ".txt", ".md"},
+	"archives":  {".zip", ".tar", ".gz", ".tgz", ".7z", ".rar", ".bz2", ".xz"},
+}
+
+// parseExtList turns a comma-separated list such as "jpg, .PNG,images" into a
+// set of lowercase extensions with a leading dot. Group names are expanded.
+func parseExtList(list string) map[string]bool {
+	exts := make(map[string]bool)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if group, ok := extGroups[item]; ok {
+			for _, ext := range group {
+				exts[ext] = true
+			}
+			continue
+		}
+		if !strings.HasPrefix(item, ".") {
+			item = "." + item
+		}
+		exts[item] = true
+	}
+	return exts
+}
+
+// hasExt reports whether name ends with any extension in exts.
+// Suffix matching lets multi-part extensions like ".tar.gz" work.
+func hasExt(name string, exts map[string]bool) bool {
+	name = strings.ToLower(name)
+	for ext := range exts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileFilter holds the name and size filters derived from a Config
+type fileFilter struct {
+	minSize    int64
+	minSizeExt map[string]int64 // -min-size-ext, overriding minSize by extension
+	maxSize    int64
+	patterns   []string
+	ipatterns  []string
+	includeExt map[string]bool
+	excludeExt map[string]bool
+	exclude    []string
+	minAge     fileAge
+	maxAge     fileAge
+}
+
+// newFileFilter builds a fileFilter from the given configuration
+func newFileFilter(c Config) (*fileFilter, error) {
+	for _, pattern := range append(append([]string{}, c.FilePattern...), c.IgnoreCasePattern...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+	if err := checkExcludes(c); err != nil {
+		return nil, err
+	}
+
+	ipatterns := make([]string, len(c.IgnoreCasePattern))
+	for i, pattern := range c.IgnoreCasePattern {
+		ipatterns[i] = strings.ToLower(pattern)
+	}
+
+	return &fileFilter{
+		minSize:    c.MinSize,
+		minSizeExt: c.MinSizeExt.byExt(),
+		maxSize:    c.MaxSize,
+		patterns:   c.FilePattern,
+		ipatterns:  ipatterns,
+		includeExt: parseExtList(c.Extensions),
+		excludeExt: parseExtList(c.ExcludeExtensions),
+		exclude:    c.Exclude,
+		minAge:     c.MinAge,
+		maxAge:     c.MaxAge,
+	}, nil
+}
+
+// reject returns the reason a file should be skipped, or "" if it passes
+func (f *fileFilter) reject(path string, size int64) string {
+	if isOwnState(path) {
+		return "file-deduplicator's own file"
+	}
+	if minSize, ext := f.minSizeFor(path); size < minSize {
+		if ext != "" {
+			return fmt.Sprintf("small %s file (%d bytes < %d)", ext, size, minSize)
+		}
+		return fmt.Sprintf("small file (%d bytes < %d)", size, minSize)
+	}
+	if f.maxSize > 0 && size > f.maxSize {
+		return fmt.Sprintf("large file (%d bytes > %d)", size, f.maxSize)
+	}
+
+	name := filepath.Base(path)
+	if (len(f.patterns) > 0 || len(f.ipatterns) > 0) && !f.matchesPattern(name) {
+		return "non-matching file"
+	}
+	if len(f.includeExt) > 0 && !hasExt(name, f.includeExt) {
+		return "extension not in -ext list"
+	}
+	if len(f.excludeExt) > 0 && hasExt(name, f.excludeExt) {
+		return "excluded extension"
+	}
+	if matchesAny(f.exclude, name) {
+		return "excluded by -exclude"
+	}
+	return ""
+}
+
+// minSizeFor returns the minimum size for path and the -min-size-ext
+// extension it comes from, or "" for -min-size. The longest matching
+// extension wins, so ".tar.gz" can differ from ".gz".
+func (f *fileFilter) minSizeFor(path string) (int64, string) {
+	name := strings.ToLower(filepath.Base(path))
+	minSize, matched := f.minSize, ""
+	for ext, size := range f.minSizeExt {
+		if strings.HasSuffix(name, ext) && len(ext) > len(matched) {
+			minSize, matched = size, ext
+		}
+	}
+	return minSize, matched
+}
+
+// matchesPattern reports whether name matches any -pattern or -ipattern glob
+func (f *fileFilter) matchesPattern(name string) bool {
+	for _, pattern := range f.patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	lower := strings.ToLower(name)
+	for _, pattern := range f.ipatterns {
+		if matched, _ := filepath.Match(pattern, lower); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// partialExts are extensions browsers, download managers and copy tools give
+// files that are still being written and will be renamed when complete
+var partialExts = map[string]bool{
+	".part": true, ".partial": true, ".crdownload": true, ".download": true, ".opdownload": true,
+	".tmp": true, ".temp": true, ".!qb": true, ".!ut": true,
+}
+
+// isPartialFile reports whether path looks like an unfinished download or a
+// temporary file
+func isPartialFile(path string) bool {
+	base := filepath.Base(path)
+	if strings.HasSuffix(base, "~") || partialExts[strings.ToLower(filepath.Ext(base))] {
+		return true
+	}
+	// rsync writes to .name.XXXXXX and renames it into place when done
+	if strings.HasPrefix(base, ".") {
+		if i := strings.LastIndex(base, "."); i > 0 && len(base)-i-1 == 6 {
+			return true
+		}
+	}
+	return false
+}
+
+// beyondMaxDepth reports whether files inside dir would sit deeper than
+// maxDepth levels below root. Like find's -maxdepth, 1 means only the files
+// directly in root; 0 disables the limit.
+func beyondMaxDepth(root, dir string, maxDepth int) bool {
+	return dedup.Scanner{MaxDepth: maxDepth}.TooDeep(root, dir)
+}
+
+// otherFilesystem reports whether -one-filesystem leaves out dir for being
+// mounted from another filesystem than root
+func (c Config) otherFilesystem(root, dir string) bool {
+	return dedup.Scanner{OneFilesystem: c.OneFilesystem}.OtherFilesystem(root, dir)
+}
+
+// skipNetworkDir reports whether dir is a network or FUSE mount that should
+// be skipped because -skip-network-fs is set. Skipped mounts are always logged.
+func (e *Engine) skipNetworkDir(dir string) bool {
+	if !e.cfg.SkipNetworkFS {
+		return false
+	}
+	fsType, remote := networkFSType(dir)
+	if !remote {
+		return false
+	}
+	if !e.cfg.JSON {
+		log.Printf("%sSkipping network filesystem (%s): %s", emoji("🌐"), fsType, dir)
+	}
+	return true
+}
+
+// leftOut reports whether a scan leaves out the entry at path as hidden or as
+// a Windows system file, following -include-hidden and -include-system
+func (c Config) leftOut(path string, info os.FileInfo) bool {
+	return (!c.IncludeHidden && dedup.Hidden(path, info)) || (!c.IncludeSystem && dedup.System(info))
+}
+
+// ownStatePrefix starts the names of what the tool keeps in the folders it
+// scans: reports, the undo log, the journal, the checkpoint and the
+// quarantine
+const ownStatePrefix = ".deduplicator_"
+
+// isOwnState reports whether path is one of the tool's own files or
+// folders. They are hidden, but stay out of scans even with -include-hidden:
+// quarantined files would otherwise turn up as duplicates of the files they
+// were copies of.
+func isOwnState(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ownStatePrefix)
+}
+
+// unfollowed reports whether a scan leaves out the entry info describes as
+// a symbolic link, without -follow-symlinks
+func (c Config) unfollowed(info os.FileInfo) bool {
+	return !c.FollowSymlinks && info.Mode()&os.ModeSymlink != 0
+}
+
+// checkExcludes validates the -exclude and -exclude-dir globs
+func checkExcludes(c Config) error {
+	for _, pattern := range append(append([]string{}, c.Exclude...), c.ExcludeDir...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether name matches any of the globs
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedDir reports whether -exclude or -exclude-dir leaves out the folder
+// at path. Patterns match the folder's name, except that an -exclude-dir
+// holding a path separator matches the whole path, so -exclude-dir
+// /mnt/backup skips that mount wherever the scan meets it.
+func (c Config) excludedDir(path string) bool {
+	name := filepath.Base(path)
+	if matchesAny(c.Exclude, name) {
+		return true
+	}
+	for _, pattern := range c.ExcludeDir {
+		if !strings.ContainsAny(pattern, `/`+string(filepath.Separator)) {
+			if matched, _ := filepath.Match(pattern, name); matched {
+				return true
+			}
+			continue
+		}
+		absPattern, err1 := filepath.Abs(pattern)
+		absPath, err2 := filepath.Abs(path)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if matched, _ := filepath.Match(absPattern, absPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreFile returns the name of the gitignore-style files whose rules a
+// scan follows, or "" with -no-dedupignore
+func (c Config) ignoreFile() string {
+	if c.NoDedupIgnore {
+		return ""
+	}
+	return dedupIgnoreFile
+}
+
+// skipDir is the Scanner's SkipDir: it leaves out the tool's own folders
+// below a root, excluded folders, logging them with -verbose, and network
+// mounts with -skip-network-fs
+func (e *Engine) skipDir(dir string) bool {
+	if isOwnState(dir) && !e.cfg.isRoot(dir) {
+		if e.cfg.Verbose {
+			log.Printf("%sSkipping file-deduplicator's own folder: %s", emoji("🚫"), dir)
+		}
+		return true
+	}
+	if e.cfg.excludedDir(dir) {
+		if e.cfg.Verbose {
+			log.Printf("%sSkipping excluded folder: %s", emoji("🚫"), dir)
+		}
+		return true
+	}
+	return e.skipNetworkDir(dir)
+}
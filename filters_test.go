@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseExtList(t *testing.T) {
+	exts := parseExtList("jpg, .PNG,,tar.gz")
+	for _, want := range []string{".jpg", ".png", ".tar.gz"} {
+		if !exts[want] {
+			t.Errorf("parseExtList() missing %s", want)
+		}
+	}
+	if len(exts) != 3 {
+		t.Errorf("parseExtList() returned %d extensions, want 3", len(exts))
+	}
+
+	// Group names expand to their member extensions
+	images := parseExtList("images")
+	if !images[".jpeg"] || !images[".webp"] {
+		t.Error("parseExtList(images) did not expand image extensions")
+	}
+}
+
+func TestFileFilterExtensions(t *testing.T) {
+	filter, err := newFileFilter(Config{
+		Extensions:        "jpg,png,tar.gz",
+		ExcludeExtensions: "png",
+	})
+	if err != nil {
+		t.Fatalf("newFileFilter() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/photos/a.jpg", true},
+		{"/photos/A.JPG", true},
+		{"/photos/b.png", false},      // excluded wins over included
+		{"/backup/site.tar.gz", true}, // multi-part extension
+		{"/notes/readme.txt", false},
+		{"/notes/Makefile", false},
+	}
+
+	for _, tt := range tests {
+		got := filter.reject(tt.path, 2048) == ""
+		if got != tt.want {
+			t.Errorf("reject(%s) passed = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFileFilterInvalidPattern(t *testing.T) {
+	if _, err := newFileFilter(Config{FilePattern: stringList{"*.jpg", "[abc"}}); err == nil {
+		t.Error("newFileFilter() should reject malformed pattern")
+	}
+}
+
+func TestFileFilterPatterns(t *testing.T) {
+	filter, err := newFileFilter(Config{
+		FilePattern:       stringList{"*.pdf", "report_*"},
+		IgnoreCasePattern: stringList{"*.jpg"},
+	})
+	if err != nil {
+		t.Fatalf("newFileFilter() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/docs/a.pdf", true},
+		{"/docs/A.PDF", false}, // -pattern stays case-sensitive
+		{"/docs/report_2024.txt", true},
+		{"/photos/a.jpg", true},
+		{"/photos/B.JPG", true},
+		{"/photos/c.png", false},
+	}
+
+	for _, tt := range tests {
+		got := filter.reject(tt.path, 2048) == ""
+		if got != tt.want {
+			t.Errorf("reject(%s) passed = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExcludes(t *testing.T) {
+	c := Config{
+		Exclude:    stringList{"*.bak", "node_modules"},
+		ExcludeDir: stringList{".cache", "/mnt/backup", "/srv/*/tmp"},
+	}
+	filter, err := newFileFilter(c)
+	if err != nil {
+		t.Fatalf("newFileFilter() error = %v", err)
+	}
+	if filter.reject("/docs/a.bak", 2048) == "" || filter.reject("/docs/a.txt", 2048) != "" {
+		t.Error("-exclude should reject a.bak and only a.bak")
+	}
+
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{"/src/app/node_modules", true}, // -exclude applies to folders too
+		{"/home/me/.cache", true},
+		{"/mnt/backup", true},
+		{"/mnt/backup2", false},
+		{"/home/me/mnt/backup", false}, // a path matches only itself
+		{"/srv/www/tmp", true},
+		{"/srv/www/cache", false},
+	}
+	for _, tt := range tests {
+		if got := c.excludedDir(filepath.FromSlash(tt.dir)); got != tt.want {
+			t.Errorf("excludedDir(%s) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+
+	if _, err := newFileFilter(Config{ExcludeDir: stringList{"[abc"}}); err == nil {
+		t.Error("newFileFilter() should reject a malformed -exclude-dir")
+	}
+}
+
+func TestStringListUnmarshal(t *testing.T) {
+	var single, many stringList
+	if err := json.Unmarshal([]byte(`"*.jpg"`), &single); err != nil {
+		t.Fatalf("Unmarshal(string) error = %v", err)
+	}
+	if len(single) != 1 || single[0] != "*.jpg" {
+		t.Errorf("Unmarshal(string) = %v, want [*.jpg]", single)
+	}
+
+	if err := json.Unmarshal([]byte(`["*.jpg", "*.png"]`), &many); err != nil {
+		t.Fatalf("Unmarshal(array) error = %v", err)
+	}
+	if len(many) != 2 {
+		t.Errorf("Unmarshal(array) = %v, want 2 entries", many)
+	}
+}
+
+func TestIsPartialFile(t *testing.T) {
+	tests := map[string]bool{
+		"movie.mkv":                    false,
+		"movie.mkv.part":               true,
+		"setup.exe.crdownload":         true,
+		"report.docx.TMP":              true,
+		"notes.txt~":                   true,
+		"/photos/.IMG_0001.jpg.Xa3bQ9": true,
+		"/photos/.hidden":              false,
+		"archive.tar.gz":               false,
+	}
+	for path, want := range tests {
+		if got := isPartialFile(path); got != want {
+			t.Errorf("isPartialFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestFileFilterMinSizeByExt(t *testing.T) {
+	var sizes sizeByExt
+	if err := sizes.Set("images=51200, documents=0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sizes.Set("gif=0,tar.gz=100"); err != nil {
+		t.Fatal(err)
+	}
+	filter, err := newFileFilter(Config{MinSize: 1024, MinSizeExt: sizes})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		size int64
+		want bool
+	}{
+		{"/photos/thumb.jpg", 20000, false}, // images need 50KB
+		{"/photos/photo.JPG", 60000, true},
+		{"/photos/tiny.gif", 10, true}, // a single extension wins over its group
+		{"/notes/todo.txt", 3, true},   // documents of any size
+		{"/backup/a.tar.gz", 500, true},
+		{"/backup/a.gz", 500, false}, // plain -min-size
+		{"/misc/data.bin", 500, false},
+	}
+	for _, tt := range tests {
+		if got := filter.reject(tt.path, tt.size) == ""; got != tt.want {
+			t.Errorf("reject(%s, %d) passed = %v, want %v", tt.path, tt.size, got, tt.want)
+		}
+	}
+
+	var bad sizeByExt
+	for _, value := range []string{"images", "images=big", "=5", "jpg=-1"} {
+		if err := bad.Set(value); err == nil {
+			t.Errorf("Set(%q) accepted an invalid size", value)
+		}
+	}
+}
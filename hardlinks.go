@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileID identifies a file independently of its names: hard links to one
+// file share it
+type fileID struct {
+	dev, ino uint64
+}
+
+// collapseHardlinks keeps one name per file of files (with their sizes from
+// the scan), so hard links to one file are hashed once and never reported as
+// duplicates of each other: their space is shared already. The other names
+// are remembered, attached to the file's FileHash by attachLinks and listed
+// by AlreadyLinked. Only files whose size another file shares are checked.
+//
+// A path that is only another spelling of a name already kept, such as
+// Photo.JPG for photo.jpg on a case-insensitive filesystem, or a name seen
+// again through a symlinked folder, is dropped altogether: acting on it
+// would act on the kept file itself.
+func (e *Engine) collapseHardlinks(files []string, sizes []int64) ([]string, []int64) {
+	e.links = nil
+	bySize := make(map[int64]int)
+	for _, size := range sizes {
+		bySize[size]++
+	}
+
+	first := make(map[fileID]int) // file -> index of its first name in kept
+	var keptFiles []string
+	var keptSizes []int64
+	aliases := 0
+	for i, path := range files {
+		if bySize[sizes[i]] > 1 {
+			if id, links, ok := fileIdentity(path); ok {
+				if j, seen := first[id]; seen {
+					if links < 2 || e.knownName(keptFiles[j], path) {
+						aliases++
+						if e.cfg.Verbose {
+							log.Printf("%sSkipping %s: the same file as %s", emoji("🔗"), path, keptFiles[j])
+						}
+						continue
+					}
+					if e.links == nil {
+						e.links = make(map[string][]string)
+					}
+					e.links[keptFiles[j]] = append(e.links[keptFiles[j]], path)
+					continue
+				}
+				first[id] = len(keptFiles)
+			}
+		}
+		keptFiles = append(keptFiles, path)
+		keptSizes = append(keptSizes, sizes[i])
+	}
+
+	if aliases > 0 && !e.cfg.JSON {
+		log.Printf("%s%d paths lead to files already scanned under another spelling or through a linked folder; each file is counted once", emoji("🔗"), aliases)
+	}
+	if len(e.links) > 0 && !e.cfg.JSON {
+		names := 0
+		for _, others := range e.links {
+			names += len(others)
+		}
+		log.Printf("%s%d names are hard links to files already scanned; each file is counted once", emoji("🔗"), names)
+	}
+	return keptFiles, keptSizes
+}
+
+// knownName reports whether path is one of the names already recorded for
+// the file kept as kept, spelled differently or reached through another
+// folder
+func (e *Engine) knownName(kept, path string) bool {
+	for _, name := range append([]string{kept}, e.links[kept]...) {
+		if sameEntry(name, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameEntry reports whether a and b are one directory entry: names equal but
+// for case, in folders that are one folder. Two hard links to a file are two
+// entries.
+func sameEntry(a, b string) bool {
+	if !strings.EqualFold(filepath.Base(a), filepath.Base(b)) {
+		return false
+	}
+	return sameFile(filepath.Dir(a), filepath.Dir(b))
+}
+
+// sameFile reports whether paths a and b lead to one file, as two spellings
+// of a name on a case-insensitive filesystem or two hard links do
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// attachLinks gives each hashed file the other names collapseHardlinks found
+// for it
+func (e *Engine) attachLinks(fileHashes []FileHash) {
+	if len(e.links) == 0 {
+		return
+	}
+	for i := range fileHashes {
+		fileHashes[i].Links = e.links[fileHashes[i].Path]
+	}
+}
+
+// AlreadyLinked returns every set of hard links the engine's scans
+// collapsed, first name first: files that share their storage already
+func (e *Engine) AlreadyLinked() [][]string {
+	var sets [][]string
+	for path, others := range e.links {
+		sets = append(sets, append([]string{path}, others...))
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i][0] < sets[j][0] })
+	return sets
+}
+
+// printAlreadyLinked logs the hard link sets of a scan, which are left out
+// of the duplicates and the space they could free
+func printAlreadyLinked(sets [][]string) {
+	if len(sets) == 0 {
+		return
+	}
+	names := 0
+	for _, set := range sets {
+		names += len(set)
+	}
+	log.Printf("%sAlready linked: %d files have %d names between them and take no extra space:", emoji("🔗"), len(sets), names)
+	for i, set := range sets {
+		if i == maxSkippedListed {
+			log.Printf("    ... and %d more", len(sets)-maxSkippedListed)
+			break
+		}
+		log.Printf("    %s", set[0])
+		for _, name := range set[1:] {
+			log.Printf("      = %s", name)
+		}
+	}
+}
+
+// actOnLinks does to the other names of a duplicate what was just done to
+// its path, since its space is only freed once no name is left, and returns
+// a line for each name
+func (c Config) actOnLinks(fh FileHash, keeper string) []string {
+	var lines []string
+	for _, name := range fh.Links {
+		var err error
+		var line string
+		switch {
+		case c.Link != "":
+			err = c.linkFile(keeper, name)
+			line = fmt.Sprintf("✓ Linked %s -> %s", name, keeper)
+		case c.MoveTo != "":
+			target := uniqueTargetPath(c.MoveTo, name)
+			err = c.moveFile(name, target)
+			line = fmt.Sprintf("✓ Moved %s -> %s", name, target)
+			if err == nil {
+				if logErr := logMove(c.MoveTo, FileHash{Path: name, Size: fh.Size, ModTime: fh.ModTime, Hash: fh.Hash}, target); logErr != nil {
+					lines = append(lines, fmt.Sprintf("%s%v", emoji("⚠️"), logErr))
+				}
+			}
+		default:
+			err = c.removeFile(name)
+			line = fmt.Sprintf("✓ Deleted %s", name)
+		}
+		if err != nil {
+			line = fmt.Sprintf("❌ Failed to process %s, a hard link to %s: %v", name, fh.Path, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollapseHardlinks(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "a.txt")
+	os.WriteFile(original, []byte("shared content"), 0644)
+	for _, name := range []string{"b.txt", "c.txt"} {
+		if err := os.Link(original, filepath.Join(dir, name)); err != nil {
+			t.Skipf("hard links not supported here: %v", err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "copy.txt"), []byte("shared content"), 0644)
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.JSON = true
+	c.IgnoreList = "off"
+	e := NewEngine(c, nil)
+	files, err := e.collectHashes(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One name of the linked file and the real copy remain
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2: %+v", len(files), files)
+	}
+	linked := e.AlreadyLinked()
+	if len(linked) != 1 || len(linked[0]) != 3 {
+		t.Fatalf("AlreadyLinked() = %v, want one set of three names", linked)
+	}
+	for _, fh := range files {
+		if fh.Path == linked[0][0] && len(fh.Links) != 2 {
+			t.Errorf("%s has Links %v, want the two other names", fh.Path, fh.Links)
+		}
+		if filepath.Base(fh.Path) == "copy.txt" && len(fh.Links) != 0 {
+			t.Errorf("copy.txt has Links %v, want none", fh.Links)
+		}
+	}
+
+	// Removing the duplicate takes every name with it
+	var linkedFile FileHash
+	for _, fh := range files {
+		if len(fh.Links) > 0 {
+			linkedFile = fh
+		}
+	}
+	c.DryRun = false
+	if err := c.removeFile(linkedFile.Path); err != nil {
+		t.Fatal(err)
+	}
+	c.actOnLinks(linkedFile, filepath.Join(dir, "copy.txt"))
+	for _, name := range append([]string{linkedFile.Path}, linkedFile.Links...) {
+		if _, err := os.Lstat(name); !os.IsNotExist(err) {
+			t.Errorf("%s should be gone", name)
+		}
+	}
+}
+
+func TestSameFileUnderAnotherName(t *testing.T) {
+	// A symlinked folder stands in for a case-insensitive filesystem: both
+	// reach one directory entry by two paths
+	dir := t.TempDir()
+	real := filepath.Join(dir, "Photos")
+	os.Mkdir(real, 0755)
+	alias := filepath.Join(dir, "photos-link")
+	if err := os.Symlink(real, alias); err != nil {
+		t.Skipf("symlinks not supported here: %v", err)
+	}
+	os.WriteFile(filepath.Join(real, "a.jpg"), []byte("one photo"), 0644)
+	os.WriteFile(filepath.Join(real, "copy.jpg"), []byte("one photo"), 0644)
+
+	c := DefaultConfig()
+	c.JSON = true
+	e := NewEngine(c, nil)
+	files := []string{filepath.Join(real, "a.jpg"), filepath.Join(alias, "A.JPG"), filepath.Join(real, "copy.jpg")}
+	if _, err := os.Stat(files[1]); err != nil {
+		files[1] = filepath.Join(alias, "a.jpg") // a case-sensitive filesystem
+	}
+	kept, _ := e.collapseHardlinks(files, []int64{9, 9, 9})
+	if len(kept) != 2 || kept[0] != files[0] || kept[1] != files[2] {
+		t.Errorf("collapseHardlinks() kept %v, want a.jpg and copy.jpg", kept)
+	}
+	if len(e.AlreadyLinked()) != 0 {
+		t.Errorf("another path to one name is not a hard link: %v", e.AlreadyLinked())
+	}
+
+	// Even when both paths reach an action, the kept file is never removed
+	c.DryRun = false
+	var group DuplicateGroup
+	for _, path := range files[:2] {
+		hash, size, mod, err := hashFile(path, getHasher(c.HashAlgorithm))
+		if err != nil {
+			t.Fatal(err)
+		}
+		group.Hash, group.Size = hash, size
+		group.Files = append(group.Files, FileHash{Path: path, Hash: hash, Size: size, ModTime: mod})
+	}
+	c.KeepCriteria = "first"
+	if err := NewEngine(c, nil).processDuplicates(context.Background(), []DuplicateGroup{group}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(files[0]); err != nil {
+		t.Errorf("the kept file was removed through its other name: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"syscall"
+)
+
+// fileIdentity returns the volume serial number and file index of path and
+// how many names the file has. Unlike on Unix these are not part of a stat,
+// so the file is opened (without reading it) to ask for them.
+func fileIdentity(path string) (id fileID, links uint64, ok bool) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return fileID{}, 0, false
+	}
+	handle, err := syscall.CreateFile(name, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, 0, false
+	}
+	defer syscall.CloseHandle(handle)
+
+	var info syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(handle, &info); err != nil {
+		return fileID{}, 0, false
+	}
+	return fileID{
+		dev: uint64(info.VolumeSerialNumber),
+		ino: uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow),
+	}, uint64(info.NumberOfLinks), true
+}
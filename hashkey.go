@@ -0,0 +1,108 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+)
+
+// contentKey is what exact matching groups files by: the content hash and
+// the size. Copies always have both in common, so a read cut short or a
+// colliding hash can never pass for a copy. Normalized SVG hashes compare
+// markup rather than bytes and match across sizes.
+type contentKey struct {
+	hash string
+	size int64
+}
+
+// contentKeyOf returns the key fh is grouped under
+func contentKeyOf(fh FileHash) contentKey {
+	if strings.HasPrefix(fh.Hash, "svg:") {
+		return contentKey{hash: fh.Hash, size: -1}
+	}
+	return contentKey{hash: fh.Hash, size: fh.Size}
+}
+
+// sameContent reports whether a and b are copies by their hashes and sizes
+func sameContent(a, b FileHash) bool {
+	return a.Hash != "" && contentKeyOf(a) == contentKeyOf(b)
+}
+
+// HashAnomaly is a content hash shared by files of different sizes. Equal
+// content has equal size, so a read was cut short, a file changed while it
+// was hashed, or the hash collided; the files are not treated as copies of
+// each other.
+type HashAnomaly struct {
+	Hash  string   `json:"hash"`
+	Paths []string `json:"paths"`
+	Sizes []int64  `json:"sizes"`
+}
+
+// groupByContent groups files by contentKey, returning the groups of more
+// than one file in the order their first file came, and the hashes found at
+// more than one size
+func groupByContent(files []FileHash) ([][]FileHash, []HashAnomaly) {
+	byKey := make(map[contentKey][]FileHash)
+	var order []contentKey
+	sizes := make(map[string]map[int64]bool) // hash -> the sizes it was seen at
+	for _, fh := range files {
+		key := contentKeyOf(fh)
+		if _, ok := byKey[key]; !ok {
+			order = append(order, key)
+		}
+		byKey[key] = append(byKey[key], fh)
+		if sizes[fh.Hash] == nil {
+			sizes[fh.Hash] = make(map[int64]bool)
+		}
+		sizes[fh.Hash][key.size] = true
+	}
+
+	var groups [][]FileHash
+	anomalies := make(map[string]*HashAnomaly)
+	for _, key := range order {
+		same := byKey[key]
+		if len(same) > 1 {
+			groups = append(groups, same)
+		}
+		if len(sizes[key.hash]) < 2 {
+			continue
+		}
+		a := anomalies[key.hash]
+		if a == nil {
+			a = &HashAnomaly{Hash: key.hash}
+			anomalies[key.hash] = a
+		}
+		for _, fh := range same {
+			a.Paths = append(a.Paths, fh.Path)
+			a.Sizes = append(a.Sizes, fh.Size)
+		}
+	}
+
+	var list []HashAnomaly
+	for _, a := range anomalies {
+		list = append(list, *a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Paths[0] < list[j].Paths[0] })
+	return groups, list
+}
+
+// HashAnomalies returns the hashes the engine's scans found shared by files
+// of different sizes
+func (e *Engine) HashAnomalies() []HashAnomaly {
+	return e.anomalies
+}
+
+// printHashAnomalies warns about every hash shared by files of different
+// sizes
+func printHashAnomalies(anomalies []HashAnomaly) {
+	if len(anomalies) == 0 {
+		return
+	}
+	log.Printf("%s%d hashes are shared by files of different sizes; they were not treated as duplicates (a truncated read, a file changing while it was hashed, or a hash collision):", emoji("⚠️"), len(anomalies))
+	for _, a := range anomalies {
+		log.Printf("  %s", shortHash(a.Hash))
+		for i, path := range a.Paths {
+			log.Printf("    %s (%s)", path, formatBytes(a.Sizes[i]))
+		}
+	}
+}
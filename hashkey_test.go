@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestGroupByContent(t *testing.T) {
+	files := []FileHash{
+		{Path: "/a", Hash: "h1", Size: 10},
+		{Path: "/b", Hash: "h1", Size: 10},
+		{Path: "/c", Hash: "h1", Size: 4}, // cut short while it was read
+		{Path: "/d", Hash: "h2", Size: 7},
+		{Path: "/x.svg", Hash: "svg:h3", Size: 100},
+		{Path: "/y.svg", Hash: "svg:h3", Size: 120}, // the same drawing, exported again
+	}
+	groups, anomalies := groupByContent(files)
+	if len(groups) != 2 || len(groups[0]) != 2 || groups[0][0].Path != "/a" || groups[0][1].Path != "/b" || len(groups[1]) != 2 {
+		t.Errorf("groups = %+v, want a with b and the two SVGs", groups)
+	}
+	if len(anomalies) != 1 || anomalies[0].Hash != "h1" || len(anomalies[0].Paths) != 3 {
+		t.Fatalf("anomalies = %+v, want h1 at two sizes", anomalies)
+	}
+	if anomalies[0].Paths[2] != "/c" || anomalies[0].Sizes[2] != 4 {
+		t.Errorf("anomaly = %+v, want /c listed with its size", anomalies[0])
+	}
+
+	e := testEngine()
+	duplicates := e.findDuplicates(files)
+	for _, group := range duplicates {
+		for _, fh := range group.Files {
+			if fh.Path == "/c" {
+				t.Errorf("findDuplicates() grouped /c, of another size, with %+v", group.Files)
+			}
+		}
+	}
+	if len(e.HashAnomalies()) != 1 {
+		t.Errorf("HashAnomalies() = %+v", e.HashAnomalies())
+	}
+
+	if sameContent(files[0], files[2]) || !sameContent(files[0], files[1]) || sameContent(FileHash{}, FileHash{}) {
+		t.Error("sameContent() should need both the hash and the size")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Orders for -hash-order
+const (
+	hashOrderSavings = "savings" // the most space a file's duplicates could free first
+	hashOrderSize    = "size"    // the largest files first
+	hashOrderScan    = "scan"    // as scanned, interleaved across -dir roots
+)
+
+// checkHashOrder validates a -hash-order value
+func checkHashOrder(order string) error {
+	switch strings.ToLower(order) {
+	case hashOrderSavings, hashOrderSize, hashOrderScan:
+		return nil
+	}
+	return fmt.Errorf("invalid -hash-order %q: expected savings, size or scan", order)
+}
+
+// orderForHashing sorts files, with their sizes, into the -hash-order they
+// are hashed in, so the biggest wins are confirmed and streamed first. For
+// savings a file counts its size once for every other file of that size:
+// the space its group would free if all were copies. Files of one size stay
+// together, so each group completes as early as it can, and ties keep the
+// scan order.
+func (c Config) orderForHashing(files []string, sizes []int64) {
+	order := strings.ToLower(c.HashOrder)
+	if order == hashOrderScan || len(files) < 2 {
+		return
+	}
+	bySize := make(map[int64]int64)
+	for _, size := range sizes {
+		bySize[size]++
+	}
+	weight := func(size int64) int64 {
+		if order == hashOrderSize {
+			return size
+		}
+		return size * (bySize[size] - 1)
+	}
+	sort.Stable(hashQueue{files, sizes, weight})
+}
+
+// hashQueue sorts files and their sizes together, heaviest first
+type hashQueue struct {
+	files  []string
+	sizes  []int64
+	weight func(size int64) int64
+}
+
+func (q hashQueue) Len() int { return len(q.files) }
+
+func (q hashQueue) Less(i, j int) bool {
+	wi, wj := q.weight(q.sizes[i]), q.weight(q.sizes[j])
+	if wi != wj {
+		return wi > wj
+	}
+	return q.sizes[i] > q.sizes[j]
+}
+
+func (q hashQueue) Swap(i, j int) {
+	q.files[i], q.files[j] = q.files[j], q.files[i]
+	q.sizes[i], q.sizes[j] = q.sizes[j], q.sizes[i]
+}
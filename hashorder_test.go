@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderForHashing(t *testing.T) {
+	scan := func() ([]string, []int64) {
+		return []string{"small-a", "huge", "mid-a", "small-b", "mid-b", "small-c"},
+			[]int64{10, 1000, 300, 10, 300, 10}
+	}
+
+	files, sizes := scan()
+	Config{HashOrder: hashOrderSavings}.orderForHashing(files, sizes)
+	// The two 300-byte files could free 300; the lone huge file nothing
+	want := []string{"mid-a", "mid-b", "small-a", "small-b", "small-c", "huge"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("savings order = %v, want %v", files, want)
+	}
+	if !reflect.DeepEqual(sizes, []int64{300, 300, 10, 10, 10, 1000}) {
+		t.Errorf("sizes did not move with their files: %v", sizes)
+	}
+
+	files, sizes = scan()
+	Config{HashOrder: hashOrderSize}.orderForHashing(files, sizes)
+	want = []string{"huge", "mid-a", "mid-b", "small-a", "small-b", "small-c"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("size order = %v, want %v", files, want)
+	}
+
+	files, sizes = scan()
+	Config{HashOrder: hashOrderScan}.orderForHashing(files, sizes)
+	if original, _ := scan(); !reflect.DeepEqual(files, original) {
+		t.Errorf("scan order = %v, want it unchanged", files)
+	}
+
+	if checkHashOrder("biggest") == nil {
+		t.Error("checkHashOrder(biggest) should fail")
+	}
+}
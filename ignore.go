@@ -0,0 +1,303 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ignoreOff disables the ignore list for a run (-ignore-list off)
+const ignoreOff = "off"
+
+// minIgnorePrefix is the shortest signature prefix -ignore accepts, so a
+// typo cannot suppress unrelated groups
+const minIgnorePrefix = 8
+
+// ignoredGroup is a group the user marked "not a duplicate"
+type ignoredGroup struct {
+	Files []string  `json:"files,omitempty"` // members when it was marked, for -list-ignored
+	Added time.Time `json:"added"`
+}
+
+// differentPair is two images recorded as genuinely distinct, so perceptual
+// mode never groups them again. They are identified by content hash, so the
+// record survives renames and moves.
+type differentPair struct {
+	A     string    `json:"a"`
+	B     string    `json:"b"`
+	Paths [2]string `json:"paths"` // where the images were when recorded, for -list-ignored
+	Added time.Time `json:"added"`
+}
+
+// ignoreList is the persistent list of groups suppressed from every scan,
+// keyed by group signature (or a prefix of one given to -ignore), and of
+// image pairs known to be different
+type ignoreList struct {
+	path      string
+	Groups    map[string]*ignoredGroup `json:"groups"`
+	Different []differentPair          `json:"different,omitempty"`
+	pairs     map[[2]string]bool       // Different by ordered key pair
+}
+
+// ignoreListPath returns the -ignore-list file, the default location when
+// none is given, or "" when the list is turned off
+func (c Config) ignoreListPath() string {
+	switch c.IgnoreList {
+	case ignoreOff:
+		return ""
+	case "":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(home, ".config", "file-deduplicator", "ignored.json")
+	}
+	return c.IgnoreList
+}
+
+// groupSignature identifies a group across scans: its content hash for
+// exact duplicates, and a digest of its member paths for perceptual and
+// size-only groups, whose hashes do not describe the whole group
+func groupSignature(group DuplicateGroup) string {
+	if !group.Unverified && group.Similarity >= 100.0 && group.Hash != "" {
+		return group.Hash
+	}
+	paths := make([]string, len(group.Files))
+	for i, fh := range group.Files {
+		paths[i] = absPath(fh.Path)
+	}
+	sort.Strings(paths)
+	return fmt.Sprintf("files:%x", sha256.Sum256([]byte(strings.Join(paths, "\x00"))))
+}
+
+// loadIgnoreList opens the list at path, starting an empty one if the file
+// does not exist yet. An empty path gives an empty list that is never saved.
+func loadIgnoreList(path string) (*ignoreList, error) {
+	list := &ignoreList{path: path, Groups: make(map[string]*ignoredGroup), pairs: make(map[[2]string]bool)}
+	if path == "" {
+		return list, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read ignore list: %w", err)
+	}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("invalid ignore list %s: %w", path, err)
+	}
+	if list.Groups == nil {
+		list.Groups = make(map[string]*ignoredGroup)
+	}
+	for _, pair := range list.Different {
+		list.pairs[pairKey(pair.A, pair.B)] = true
+	}
+	return list, nil
+}
+
+// save writes the list atomically
+func (l *ignoreList) save() error {
+	if l.path == "" {
+		return fmt.Errorf("the ignore list is turned off (-ignore-list %s)", ignoreOff)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(l.path, data, 0600)
+}
+
+// add marks a group as not a duplicate
+func (l *ignoreList) add(group DuplicateGroup) {
+	entry := &ignoredGroup{Added: time.Now()}
+	for _, fh := range group.Files {
+		entry.Files = append(entry.Files, absPath(fh.Path))
+	}
+	l.Groups[groupSignature(group)] = entry
+}
+
+// imageKey identifies an image for a known-different pair: its content
+// hash, or its path when the content was not hashed
+func imageKey(fh FileHash) string {
+	if fh.Hash != "" {
+		return fh.Hash
+	}
+	return absPath(fh.Path)
+}
+
+// pairKey orders two image keys so a pair is found either way round
+func pairKey(a, b string) [2]string {
+	if b < a {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// markDifferent records two images as genuinely distinct
+func (l *ignoreList) markDifferent(a, b FileHash) {
+	key := pairKey(imageKey(a), imageKey(b))
+	if key[0] == key[1] || l.pairs[key] {
+		return
+	}
+	l.pairs[key] = true
+	l.Different = append(l.Different, differentPair{
+		A:     key[0],
+		B:     key[1],
+		Paths: [2]string{absPath(a.Path), absPath(b.Path)},
+		Added: time.Now(),
+	})
+}
+
+// different reports whether two images were recorded as distinct
+func (l *ignoreList) different(a, b FileHash) bool {
+	if l == nil || len(l.pairs) == 0 {
+		return false
+	}
+	return l.pairs[pairKey(imageKey(a), imageKey(b))]
+}
+
+// ignored reports whether a group is on the list, by its signature or a
+// stored prefix of it
+func (l *ignoreList) ignored(group DuplicateGroup) bool {
+	if l == nil || len(l.Groups) == 0 {
+		return false
+	}
+	sig := groupSignature(group)
+	if l.Groups[sig] != nil {
+		return true
+	}
+	for prefix := range l.Groups {
+		if strings.HasPrefix(sig, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter drops the groups on the list and returns how many it dropped
+func (l *ignoreList) filter(duplicates []DuplicateGroup) ([]DuplicateGroup, int) {
+	if l == nil {
+		return duplicates, 0
+	}
+	kept := duplicates[:0]
+	for _, group := range duplicates {
+		if !l.ignored(group) {
+			kept = append(kept, group)
+		}
+	}
+	return kept, len(duplicates) - len(kept)
+}
+
+// dropIgnored removes the groups marked not a duplicate from a scan's results
+func (e *Engine) dropIgnored(duplicates []DuplicateGroup) []DuplicateGroup {
+	duplicates, dropped := e.ignore.filter(duplicates)
+	if dropped > 0 && !e.cfg.JSON {
+		log.Printf("%s%d groups marked not a duplicate were left out (-list-ignored)", emoji("🙈"), dropped)
+	}
+	return duplicates
+}
+
+// notSimilar records the images of a -not-similar "a,b" value as different
+func (l *ignoreList) notSimilar(value, algorithm string) error {
+	paths := strings.Split(value, ",")
+	if len(paths) != 2 {
+		return fmt.Errorf("-not-similar %q: expected two images separated by a comma", value)
+	}
+	var images [2]FileHash
+	for i, path := range paths {
+		path = strings.TrimSpace(path)
+		hash, _, _, err := hashFile(path, getHasher(algorithm))
+		if err != nil {
+			return fmt.Errorf("-not-similar: %w", err)
+		}
+		images[i] = FileHash{Path: path, Hash: hash}
+	}
+	l.markDifferent(images[0], images[1])
+	log.Printf("%s%s and %s will no longer be grouped as similar", emoji("🙈"), paths[0], strings.TrimSpace(paths[1]))
+	return nil
+}
+
+// updateIgnoreList applies -ignore, -unignore and -not-similar, then lists
+// the ignored groups and image pairs when -list-ignored is set
+func updateIgnoreList(c Config) error {
+	list, err := loadIgnoreList(c.ignoreListPath())
+	if err != nil {
+		return err
+	}
+
+	for _, sig := range c.Ignore {
+		sig = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(sig), "..."))
+		if len(sig) < minIgnorePrefix {
+			return fmt.Errorf("-ignore %q: give at least %d characters of the group hash", sig, minIgnorePrefix)
+		}
+		list.Groups[sig] = &ignoredGroup{Added: time.Now()}
+		log.Printf("%sIgnoring groups with hash %s", emoji("🙈"), sig)
+	}
+	for _, sig := range c.Unignore {
+		sig = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(sig), "..."))
+		removed := 0
+		for stored := range list.Groups {
+			if stored == sig || (len(sig) >= minIgnorePrefix && strings.HasPrefix(stored, sig)) {
+				delete(list.Groups, stored)
+				removed++
+			}
+		}
+		if removed == 0 {
+			return fmt.Errorf("-unignore %q: no ignored group has that signature", sig)
+		}
+		log.Printf("%sNo longer ignoring %s", emoji("👀"), sig)
+	}
+	for _, value := range c.NotSimilar {
+		if err := list.notSimilar(value, c.HashAlgorithm); err != nil {
+			return err
+		}
+	}
+	if len(c.Ignore) > 0 || len(c.Unignore) > 0 || len(c.NotSimilar) > 0 {
+		if err := list.save(); err != nil {
+			return err
+		}
+	}
+
+	if !c.ListIgnored {
+		return nil
+	}
+	if c.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+	if len(list.Groups) == 0 && len(list.Different) == 0 {
+		log.Printf("%sNo groups are marked not a duplicate", emoji("ℹ️ "))
+		return nil
+	}
+	sigs := make([]string, 0, len(list.Groups))
+	for sig := range list.Groups {
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+	log.Printf("%sGroups marked not a duplicate (%s):", emoji("🙈"), list.path)
+	for _, sig := range sigs {
+		entry := list.Groups[sig]
+		log.Printf("\n  %s (since %s)", sig, entry.Added.Format("2006-01-02"))
+		for _, path := range entry.Files {
+			log.Printf("    %s", path)
+		}
+	}
+	if len(list.Different) > 0 {
+		log.Printf("\n%sImages known to be different:", emoji("🖼️"))
+		for _, pair := range list.Different {
+			log.Printf("  %s\n    %s (since %s)", pair.Paths[0], pair.Paths[1], pair.Added.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
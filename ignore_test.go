@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreList(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"backup/a.txt": "deliberate backup",
+		"work/a.txt":   "deliberate backup",
+		"x.txt":        "accidental copy",
+		"y.txt":        "accidental copy",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.Checkpoint = ""
+	c.JSON = true
+	c.IgnoreList = filepath.Join(t.TempDir(), "ignored.json")
+	scan := func() []DuplicateGroup {
+		t.Helper()
+		groups, err := NewEngine(c, nil).collectDuplicates(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return groups
+	}
+
+	groups := scan()
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	var backup DuplicateGroup
+	for _, group := range groups {
+		if filepath.Base(filepath.Dir(group.Files[0].Path)) != filepath.Base(dir) {
+			backup = group
+		}
+	}
+
+	// Marked in the TUI
+	list, err := loadIgnoreList(c.IgnoreList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list.add(backup)
+	if err := list.save(); err != nil {
+		t.Fatal(err)
+	}
+	if groups := scan(); len(groups) != 1 || groups[0].Hash == backup.Hash {
+		t.Fatalf("the ignored group should be left out, got %v", groups)
+	}
+
+	// Un-ignored and ignored again by a hash prefix on the command line
+	c.Unignore = stringList{backup.Hash}
+	if err := updateIgnoreList(c); err != nil {
+		t.Fatal(err)
+	}
+	if groups := scan(); len(groups) != 2 {
+		t.Fatalf("after -unignore got %d groups, want 2", len(groups))
+	}
+	c.Unignore = nil
+	c.Ignore = stringList{"1234"}
+	if err := updateIgnoreList(c); err == nil {
+		t.Error("a short -ignore prefix should be refused")
+	}
+	c.Ignore = stringList{backup.Hash[:16] + "..."}
+	if err := updateIgnoreList(c); err != nil {
+		t.Fatal(err)
+	}
+	if groups := scan(); len(groups) != 1 {
+		t.Fatalf("after -ignore with a prefix got %d groups, want 1", len(groups))
+	}
+
+	// Perceptual and size-only groups are identified by their members
+	unverified := DuplicateGroup{Hash: "10", Unverified: true, Files: backup.Files}
+	if sig := groupSignature(unverified); sig == "10" || sig != groupSignature(DuplicateGroup{Hash: "20", Unverified: true, Files: []FileHash{backup.Files[1], backup.Files[0]}}) {
+		t.Errorf("size-only signature %q should depend on the member paths only", sig)
+	}
+}
+
+func TestKnownDifferentImages(t *testing.T) {
+	dir := t.TempDir()
+	twin1, twin2 := filepath.Join(dir, "twin1.jpg"), filepath.Join(dir, "twin2.jpg")
+	os.WriteFile(twin1, []byte("alice"), 0644)
+	os.WriteFile(twin2, []byte("bob"), 0644)
+
+	c := DefaultConfig()
+	c.PerceptualMode = true
+	c.IgnoreList = filepath.Join(dir, "ignored.json")
+	images := []FileHash{
+		{Path: twin1, Size: 5, Hash: scanOne(t, twin1).Hash, PHash: "1010101010101010"},
+		{Path: twin2, Size: 3, Hash: scanOne(t, twin2).Hash, PHash: "1010101010101011"},
+		{Path: filepath.Join(dir, "copy.jpg"), Size: 5, Hash: "other", PHash: "1010101010101010"},
+	}
+	group := func(e *Engine) int {
+		for _, group := range e.findDuplicates(images) {
+			return len(group.Files)
+		}
+		return 0
+	}
+
+	e := NewEngine(c, nil)
+	if n := group(e); n != 3 {
+		t.Fatalf("look-alike images should start out in one group of 3, got %d", n)
+	}
+
+	c.NotSimilar = stringList{twin1 + "," + twin2}
+	if err := updateIgnoreList(c); err != nil {
+		t.Fatal(err)
+	}
+	if e.ignore, _ = loadIgnoreList(c.IgnoreList); !e.ignore.different(images[1], images[0]) {
+		t.Fatal("the pair should be recorded either way round")
+	}
+	if n := group(e); n != 2 {
+		t.Errorf("twin2 should no longer be grouped with twin1, got a group of %d", n)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// exportIndex scans and hashes the engine's roots and writes them to path as
+// a hash index, in the same format an -agent streams
+func (e *Engine) exportIndex(ctx context.Context, path string) error {
+	f, err := createAtomic(path, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	defer f.Abort() // never leave a partial index behind
+
+	if err := e.runAgent(ctx, f); err != nil {
+		return err
+	}
+	if err := f.Commit(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	if !e.cfg.JSON {
+		log.Printf("%sExported hash index of %v to %s", emoji("📇"), e.cfg.roots(), path)
+	}
+	return nil
+}
+
+// loadIndexes reads every -import-index file as a reference set. Records are
+// labelled with the index's file name and are never modified.
+func (e *Engine) loadIndexes() ([]FileHash, error) {
+	var all []FileHash
+	for _, path := range e.cfg.ImportIndex {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open index: %w", err)
+		}
+		fileHashes, err := e.readAgent(f, filepath.Base(path))
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for i := range fileHashes {
+			fileHashes[i].Reference = true
+		}
+		if !e.cfg.JSON {
+			log.Printf("%sLoaded %d reference files from %s", emoji("📇"), len(fileHashes), path)
+		}
+		all = append(all, fileHashes...)
+	}
+	return all, nil
+}
+
+// hasLocalFile reports whether any file in group lives on this machine
+func hasLocalFile(group DuplicateGroup) bool {
+	for _, fh := range group.Files {
+		if !fh.Reference {
+			return true
+		}
+	}
+	return false
+}
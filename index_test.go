@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportImportIndex(t *testing.T) {
+	archive, current := t.TempDir(), t.TempDir()
+	writeFiles := func(dir string, files map[string]string) {
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+	}
+	writeFiles(archive, map[string]string{"old.txt": "archived content", "a.txt": "archive dup", "b.txt": "archive dup"})
+	writeFiles(current, map[string]string{"copy.txt": "archived content", "new.txt": "brand new"})
+
+	indexPath := filepath.Join(t.TempDir(), "last-month.idx")
+	c := DefaultConfig()
+	c.Dir = stringList{archive}
+	c.MinSize = 1
+	c.JSON = true
+	if err := NewEngine(c, nil).exportIndex(context.Background(), indexPath); err != nil {
+		t.Fatalf("exportIndex() error = %v", err)
+	}
+
+	c.Dir = stringList{current}
+	c.ImportIndex = stringList{indexPath}
+	c.KeepCriteria = "newest" // the indexed copy must win regardless
+	c.MoveTo = filepath.Join(t.TempDir(), "moved")
+	engine := NewEngine(c, nil)
+	duplicates, err := engine.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatalf("collectDuplicates() error = %v", err)
+	}
+
+	// a.txt/b.txt only duplicate each other inside the index, so are not reported
+	if len(duplicates) != 1 || len(duplicates[0].Files) != 2 {
+		t.Fatalf("collectDuplicates() = %+v, want one group with the local copy", duplicates)
+	}
+	keeper := duplicates[0].Files[selectFileToKeep(duplicates[0], c.KeepCriteria)]
+	if !keeper.Reference || keeper.Path != "last-month.idx:"+filepath.Join(archive, "old.txt") {
+		t.Errorf("keeper = %+v, want the indexed copy", keeper)
+	}
+
+	if err := engine.processDuplicates(context.Background(), duplicates); err != nil {
+		t.Fatalf("processDuplicates() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(current, "copy.txt")); !os.IsNotExist(err) {
+		t.Error("copy.txt already exists in the index and should have been moved")
+	}
+	if _, err := os.Stat(filepath.Join(archive, "old.txt")); err != nil {
+		t.Error("indexed files must never be touched")
+	}
+
+	c.HashAlgorithm = "md5"
+	if _, err := NewEngine(c, nil).collectDuplicates(context.Background()); err == nil {
+		t.Error("importing an index hashed with another algorithm should fail")
+	}
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Machine keep policies for -machine-keep
+const (
+	machineKeepAlways = "always" // copies on this machine are never redundant
+	machineKeepNever  = "never"  // copies here are redundant whenever another machine has the content
+)
+
+// parseMachinePolicies parses repeated -machine-keep name=policy values
+func parseMachinePolicies(specs stringList) (map[string]string, error) {
+	policies := make(map[string]string)
+	for _, spec := range specs {
+		machine, policy, ok := strings.Cut(spec, "=")
+		policy = strings.ToLower(policy)
+		if !ok || machine == "" || (policy != machineKeepAlways && policy != machineKeepNever) {
+			return nil, fmt.Errorf("invalid -machine-keep %q: expected machine=always or machine=never", spec)
+		}
+		policies[machine] = policy
+	}
+	return policies, nil
+}
+
+// machineManifest is the latest set of records pushed by one machine
+type machineManifest struct {
+	Files   []FileHash
+	Updated time.Time
+}
+
+// indexServer aggregates hash manifests from several machines
+type indexServer struct {
+	mu        sync.Mutex
+	engine    *Engine // checks the hash algorithm and supplies -keep
+	policies  map[string]string
+	manifests map[string]*machineManifest
+}
+
+// newIndexServer creates a server that accepts manifests hashed like base
+func newIndexServer(base Config) (*indexServer, error) {
+	policies, err := parseMachinePolicies(base.MachineKeep)
+	if err != nil {
+		return nil, err
+	}
+	base.JSON = true
+	return &indexServer{
+		engine:    NewEngine(base, nil),
+		policies:  policies,
+		manifests: make(map[string]*machineManifest),
+	}, nil
+}
+
+// machineSummary describes one machine's manifest in GET /manifests
+type machineSummary struct {
+	Machine string    `json:"machine"`
+	Files   int       `json:"files"`
+	Bytes   int64     `json:"bytes"`
+	Policy  string    `json:"policy,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// crossMachineGroup is content held by more than one machine
+type crossMachineGroup struct {
+	Hash      string   `json:"hash"`
+	Size      int64    `json:"size"`
+	Machines  []string `json:"machines"`
+	Keep      []string `json:"keep"`
+	Redundant []string `json:"redundant"`
+}
+
+// machinePair is the content two machines both hold
+type machinePair struct {
+	Machines [2]string `json:"machines"`
+	Files    int       `json:"files"`
+	Bytes    int64     `json:"bytes"`
+}
+
+// indexReport is the body of GET /report
+type indexReport struct {
+	Machines       []machineSummary    `json:"machines"`
+	Shared         []machinePair       `json:"shared"`
+	Groups         []crossMachineGroup `json:"groups"`
+	RedundantBytes int64               `json:"redundant_bytes"`
+}
+
+// handler returns the HTTP API:
+//
+//	PUT    /manifests/{machine}  replace a machine's manifest (-agent output)
+//	DELETE /manifests/{machine}  forget a machine
+//	GET    /manifests            list machines
+//	GET    /report               cross-machine duplicates
+func (s *indexServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /manifests/{machine}", s.handlePut)
+	mux.HandleFunc("DELETE /manifests/{machine}", s.handleDelete)
+	mux.HandleFunc("GET /manifests", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.summaries())
+	})
+	mux.HandleFunc("GET /report", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.report())
+	})
+	return mux
+}
+
+func (s *indexServer) handlePut(w http.ResponseWriter, r *http.Request) {
+	machine := r.PathValue("machine")
+	if strings.ContainsAny(machine, ":/") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "machine names cannot contain ':' or '/'"})
+		return
+	}
+
+	fileHashes, err := s.engine.readAgent(r.Body, machine)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.manifests[machine] = &machineManifest{Files: fileHashes, Updated: time.Now()}
+	s.mu.Unlock()
+
+	log.Printf("%s%s: received manifest with %d files", emoji("📥"), machine, len(fileHashes))
+	writeJSON(w, http.StatusOK, map[string]int{"files": len(fileHashes)})
+}
+
+func (s *indexServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	machine := r.PathValue("machine")
+
+	s.mu.Lock()
+	_, ok := s.manifests[machine]
+	delete(s.manifests, machine)
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown machine " + machine})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"deleted": machine})
+}
+
+// summaries lists the known machines by name
+func (s *indexServer) summaries() []machineSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summaries := make([]machineSummary, 0, len(s.manifests))
+	for machine, m := range s.manifests {
+		summary := machineSummary{Machine: machine, Files: len(m.Files), Policy: s.policies[machine], Updated: m.Updated}
+		for _, fh := range m.Files {
+			summary.Bytes += fh.Size
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Machine < summaries[j].Machine })
+	return summaries
+}
+
+// report groups every machine's files by hash and size and keeps the groups that
+// span more than one machine, largest savings first
+func (s *indexServer) report() indexReport {
+	report := indexReport{Machines: s.summaries()}
+
+	s.mu.Lock()
+	byContent := make(map[contentKey][]FileHash)
+	for _, m := range s.manifests {
+		for _, fh := range m.Files {
+			if fh.Hash != "" { // -no-hash records cannot be matched across machines
+				byContent[contentKeyOf(fh)] = append(byContent[contentKeyOf(fh)], fh)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	pairs := make(map[[2]string]*machinePair)
+	for key, files := range byContent {
+		machines := machinesOf(files)
+		if len(machines) < 2 {
+			continue
+		}
+
+		group := s.resolve(DuplicateGroup{Hash: key.hash, Size: files[0].Size, Files: files})
+		group.Machines = machines
+		report.Groups = append(report.Groups, group)
+		report.RedundantBytes += group.Size * int64(len(group.Redundant))
+
+		for i := range machines {
+			for j := i + 1; j < len(machines); j++ {
+				key := [2]string{machines[i], machines[j]}
+				if pairs[key] == nil {
+					pairs[key] = &machinePair{Machines: key}
+				}
+				pairs[key].Files++
+				pairs[key].Bytes += group.Size
+			}
+		}
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if wa, wb := a.Size*int64(len(a.Redundant)), b.Size*int64(len(b.Redundant)); wa != wb {
+			return wa > wb
+		}
+		return a.Hash < b.Hash
+	})
+	for _, pair := range pairs {
+		report.Shared = append(report.Shared, *pair)
+	}
+	sort.Slice(report.Shared, func(i, j int) bool {
+		if report.Shared[i].Bytes != report.Shared[j].Bytes {
+			return report.Shared[i].Bytes > report.Shared[j].Bytes
+		}
+		return report.Shared[i].Machines[0]+report.Shared[i].Machines[1] < report.Shared[j].Machines[0]+report.Shared[j].Machines[1]
+	})
+	return report
+}
+
+// resolve applies the machine keep policies to a group. Copies on "always"
+// machines are all kept; otherwise one copy is kept by -keep, preferring
+// machines that are not "never".
+func (s *indexServer) resolve(group DuplicateGroup) crossMachineGroup {
+	result := crossMachineGroup{Hash: group.Hash, Size: group.Size, Keep: []string{}, Redundant: []string{}}
+
+	keep := make(map[int]bool)
+	for i, fh := range group.Files {
+		if s.policies[fh.Host] == machineKeepAlways {
+			keep[i] = true
+		}
+	}
+	if len(keep) == 0 {
+		var candidates []int
+		for i, fh := range group.Files {
+			if s.policies[fh.Host] != machineKeepNever {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) == 0 {
+			keep[selectFileToKeep(group, s.engine.cfg.KeepCriteria)] = true
+		} else {
+			preferred := DuplicateGroup{Hash: group.Hash, Size: group.Size}
+			for _, i := range candidates {
+				preferred.Files = append(preferred.Files, group.Files[i])
+			}
+			keep[candidates[selectFileToKeep(preferred, s.engine.cfg.KeepCriteria)]] = true
+		}
+	}
+
+	for i, fh := range group.Files {
+		if keep[i] {
+			result.Keep = append(result.Keep, fh.Path)
+		} else {
+			result.Redundant = append(result.Redundant, fh.Path)
+		}
+	}
+	sort.Strings(result.Keep)
+	sort.Strings(result.Redundant)
+	return result
+}
+
+// machinesOf returns the sorted distinct hosts of files
+func machinesOf(files []FileHash) []string {
+	seen := make(map[string]bool)
+	var machines []string
+	for _, fh := range files {
+		if !seen[fh.Host] {
+			seen[fh.Host] = true
+			machines = append(machines, fh.Host)
+		}
+	}
+	sort.Strings(machines)
+	return machines
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// runIndexServer serves the index API on addr until ctx is cancelled
+func runIndexServer(ctx context.Context, base Config, addr string) error {
+	s, err := newIndexServer(base)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: s.handler()}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("%sIndex server listening on %s", emoji("🗄️ "), listener.Addr())
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// pushIndex scans like -agent and uploads the manifest to an index server
+// as machine (the host name when empty)
+func (e *Engine) pushIndex(ctx context.Context, serverURL, machine string) error {
+	if machine == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("cannot determine machine name, set -machine: %w", err)
+		}
+		machine = hostname
+	}
+
+	body, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(e.runAgent(ctx, writer))
+	}()
+
+	endpoint := strings.TrimRight(serverURL, "/") + "/manifests/" + url.PathEscape(machine)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Files int    `json:"files"`
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("index server rejected manifest: %s (%s)", result.Error, resp.Status)
+	}
+
+	if !e.cfg.JSON {
+		log.Printf("%sPushed %d files to %s as %s", emoji("📤"), result.Files, serverURL, machine)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMachinePolicies(t *testing.T) {
+	policies, err := parseMachinePolicies(stringList{"nas=always", "laptop=NEVER"})
+	if err != nil || policies["nas"] != machineKeepAlways || policies["laptop"] != machineKeepNever {
+		t.Errorf("parseMachinePolicies() = %v, %v", policies, err)
+	}
+	for _, bad := range []string{"nas", "=always", "nas=sometimes"} {
+		if _, err := parseMachinePolicies(stringList{bad}); err == nil {
+			t.Errorf("parseMachinePolicies(%q) should fail", bad)
+		}
+	}
+}
+
+func TestIndexServer(t *testing.T) {
+	base := DefaultConfig()
+	base.MachineKeep = stringList{"nas=always", "laptop=never"}
+	s, err := newIndexServer(base)
+	if err != nil {
+		t.Fatalf("newIndexServer() error = %v", err)
+	}
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	// Every machine holds the shared file; only the laptop has its own copy twice
+	for _, machine := range []string{"nas", "laptop", "desktop"} {
+		dir := t.TempDir()
+		files := map[string]string{"shared.txt": "shared content", machine + ".txt": "only on " + machine}
+		if machine == "laptop" {
+			files["shared (1).txt"] = "shared content"
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+
+		c := DefaultConfig()
+		c.Dir = stringList{dir}
+		c.MinSize = 1
+		c.JSON = true
+		if err := NewEngine(c, nil).pushIndex(context.Background(), server.URL, machine); err != nil {
+			t.Fatalf("pushIndex(%s) error = %v", machine, err)
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/report")
+	if err != nil {
+		t.Fatalf("GET /report error = %v", err)
+	}
+	defer resp.Body.Close()
+	var report indexReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("report is not JSON: %v", err)
+	}
+
+	if len(report.Machines) != 3 {
+		t.Errorf("report has %d machines, want 3", len(report.Machines))
+	}
+	if len(report.Groups) != 1 {
+		t.Fatalf("report has %d groups, want 1 cross-machine group", len(report.Groups))
+	}
+	group := report.Groups[0]
+	if strings.Join(group.Machines, ",") != "desktop,laptop,nas" {
+		t.Errorf("group machines = %v", group.Machines)
+	}
+	// nas=always keeps its copy; every other copy is redundant
+	if len(group.Keep) != 1 || !strings.HasPrefix(group.Keep[0], "nas:") || len(group.Redundant) != 3 {
+		t.Errorf("group keep = %v, redundant = %v", group.Keep, group.Redundant)
+	}
+	if report.RedundantBytes != 3*int64(len("shared content")) {
+		t.Errorf("redundant bytes = %d", report.RedundantBytes)
+	}
+	if len(report.Shared) != 3 || report.Shared[0].Files != 1 {
+		t.Errorf("shared pairs = %+v, want 3 pairs of one file", report.Shared)
+	}
+
+	// Without an "always" machine the keeper never comes from a "never" machine
+	s.policies = map[string]string{"laptop": machineKeepNever}
+	group = s.report().Groups[0]
+	if len(group.Keep) != 1 || strings.HasPrefix(group.Keep[0], "laptop:") {
+		t.Errorf("group keep = %v, want one copy outside the laptop", group.Keep)
+	}
+
+	// Manifests hashed with another algorithm are refused
+	c := DefaultConfig()
+	c.Dir = stringList{t.TempDir()}
+	c.HashAlgorithm = "md5"
+	c.JSON = true
+	if err := NewEngine(c, nil).pushIndex(context.Background(), server.URL, "old"); err == nil {
+		t.Error("pushIndex() with a different hash algorithm should fail")
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/manifests/desktop", nil)
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("DELETE /manifests/desktop failed: %v", err)
+	}
+	if summaries := s.summaries(); len(summaries) != 2 {
+		t.Errorf("%d machines after delete, want 2", len(summaries))
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IntegrityIssue is a file whose content hash no longer matches the -cache
+// although its size and modification time do, which usually means silent
+// corruption
+type IntegrityIssue struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	Expected string    `json:"expected_hash"`
+	Actual   string    `json:"actual_hash"`
+}
+
+// integrityLog collects -check-integrity findings from the hashing workers.
+// Its methods do nothing on a nil log.
+type integrityLog struct {
+	mu     sync.Mutex
+	issues []IntegrityIssue
+}
+
+func (l *integrityLog) add(issue IntegrityIssue) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.issues = append(l.issues, issue)
+}
+
+// list returns the findings ordered by path
+func (l *integrityLog) list() []IntegrityIssue {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	issues := append([]IntegrityIssue(nil), l.issues...)
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+// recordContentHash stores a freshly computed content hash in the -cache and,
+// with -check-integrity, flags it when the cache expected another hash for
+// the same size and modification time
+func (e *Engine) recordContentHash(path string, size int64, modTime time.Time, hash string) {
+	expected, changed := e.cache.checkContent(path, size, modTime, e.cfg.hashName(), hash)
+	if changed && e.cfg.CheckIntegrity {
+		e.integrity.add(IntegrityIssue{Path: path, Size: size, ModTime: modTime, Expected: expected, Actual: hash})
+	}
+}
+
+// IntegrityIssues returns the files -check-integrity flagged during the
+// engine's scans
+func (e *Engine) IntegrityIssues() []IntegrityIssue {
+	return e.integrity.list()
+}
+
+// printIntegrityIssues logs every file whose content changed while its size
+// and modification time did not
+func printIntegrityIssues(issues []IntegrityIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	log.Printf("%s%d files changed content without changing size or modification time (possible bit rot):", emoji("🧬"), len(issues))
+	for _, issue := range issues {
+		log.Printf("  %s (%s, modified %s)", issue.Path, formatBytes(issue.Size), issue.ModTime.Format("2006-01-02 15:04"))
+		log.Printf("    expected %s, now %s", shortHash(issue.Expected), shortHash(issue.Actual))
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// interactiveSession drives the legacy -interactive prompt across groups
+type interactiveSession struct {
+	in      *bufio.Reader
+	out     io.Writer
+	all     bool        // "a" was answered: delete remaining duplicates without asking
+	answers *answerBook // pre-recorded decisions from -answers (may be nil)
+	keep    string      // -keep criteria, shown next to the keeper
+}
+
+// newInteractiveSession creates a session reading answers from stdin
+func newInteractiveSession(keepCriteria string) *interactiveSession {
+	return &interactiveSession{
+		in:   bufio.NewReader(os.Stdin),
+		out:  os.Stdout,
+		keep: keepCriteria,
+	}
+}
+
+// ask prints a prompt and returns the lowercased, trimmed answer
+func (s *interactiveSession) ask(prompt string) string {
+	fmt.Fprint(s.out, prompt)
+	line, err := s.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "q" // stdin closed: behave like quit
+	}
+	return strings.ToLower(strings.TrimSpace(line))
+}
+
+// decideGroup prompts for each non-keeper file in a group and returns the
+// indices of files to remove. quit is true when the user asked to stop.
+func (s *interactiveSession) decideGroup(num, total int, group DuplicateGroup, keepIdx int) (remove []int, quit bool) {
+	if s.all {
+		return othersThan(len(group.Files), keepIdx), false
+	}
+	if recorded, ok := s.answers.forGroup(group, keepIdx); ok {
+		fmt.Fprintf(s.out, "\nGroup %d/%d: applying recorded answer (%d to remove)\n", num, total, len(recorded))
+		return recorded, false
+	}
+	if recorded, ok := s.recordedFiles(group, keepIdx); ok {
+		fmt.Fprintf(s.out, "\nGroup %d/%d: applying recorded file answers (%d to remove)\n", num, total, len(recorded))
+		return recorded, false
+	}
+
+	fmt.Fprintf(s.out, "\nGroup %d/%d (%s each, %d files)\n", num, total, formatBytes(group.Size), len(group.Files))
+	for i, fh := range group.Files {
+		marker := " "
+		if i == keepIdx {
+			marker = "*"
+		}
+		fmt.Fprintf(s.out, "  %s [%d] %s (modified: %s)\n", marker, i+1, fh.Path, fh.ModTime.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(s.out, "  * = keeper (%s)\n", s.keep)
+
+	for i := 0; i < len(group.Files); i++ {
+		if i == keepIdx {
+			continue
+		}
+		fh := group.Files[i]
+		if del, ok := s.answers.forFile(fh.Path); ok {
+			if del {
+				remove = append(remove, i)
+			}
+			continue
+		}
+		for {
+			answer := s.ask(fmt.Sprintf("Delete %s? (%s) [y]es [n]o [k]eep this, delete rest [s]kip group [a]ll remaining [o]pen [q]uit: ",
+				fh.Path, formatBytes(fh.Size)))
+			switch answer {
+			case "y", "yes":
+				remove = append(remove, i)
+			case "n", "no", "":
+			case "k":
+				return othersThan(len(group.Files), i), false
+			case "s":
+				return nil, false
+			case "a":
+				s.all = true
+				return othersThan(len(group.Files), keepIdx), false
+			case "o":
+				if err := openFile(fh.Path); err != nil {
+					fmt.Fprintf(s.out, "Could not open %s: %v\n", fh.Path, err)
+				}
+				continue
+			case "q":
+				return remove, true
+			default:
+				fmt.Fprintln(s.out, "Please answer y, n, k, s, a, o or q.")
+				continue
+			}
+			break
+		}
+	}
+	return remove, false
+}
+
+// recordedFiles returns the files to remove when every non-keeper file in
+// the group has a recorded per-file answer
+func (s *interactiveSession) recordedFiles(group DuplicateGroup, keepIdx int) (remove []int, ok bool) {
+	if s.answers == nil {
+		return nil, false
+	}
+	for i, fh := range group.Files {
+		if i == keepIdx {
+			continue
+		}
+		del, found := s.answers.forFile(fh.Path)
+		if !found {
+			return nil, false
+		}
+		if del {
+			remove = append(remove, i)
+		}
+	}
+	return remove, true
+}
+
+// othersThan returns all indices in [0, n) except keep
+func othersThan(n, keep int) []int {
+	indices := make([]int, 0, n-1)
+	for i := 0; i < n; i++ {
+		if i != keep {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// openFile opens path with the platform's default application
+func openFile(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestSession(answers string) *interactiveSession {
+	return &interactiveSession{
+		in:  bufio.NewReader(strings.NewReader(answers)),
+		out: io.Discard,
+	}
+}
+
+func TestDecideGroup(t *testing.T) {
+	group := DuplicateGroup{
+		Hash: "test",
+		Size: 100,
+		Files: []FileHash{
+			{Path: "/keep.txt"},
+			{Path: "/b.txt"},
+			{Path: "/c.txt"},
+			{Path: "/d.txt"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		answers  string
+		want     []int
+		wantQuit bool
+	}{
+		{"yes no yes", "y\nn\ny\n", []int{1, 3}, false},
+		{"keep this deletes rest", "n\nk\n", []int{0, 1, 3}, false},
+		{"skip group", "y\ns\n", nil, false},
+		{"all remaining", "a\n", []int{1, 2, 3}, false},
+		{"quit keeps earlier answers", "y\nq\n", []int{1}, true},
+		{"invalid answer re-prompts", "x\ny\nn\nn\n", []int{1}, false},
+		{"closed stdin quits", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := newTestSession(tt.answers)
+			got, quit := session.decideGroup(1, 1, group, 0)
+			if !reflect.DeepEqual(got, tt.want) || quit != tt.wantQuit {
+				t.Errorf("decideGroup() = %v, quit=%v; want %v, quit=%v", got, quit, tt.want, tt.wantQuit)
+			}
+		})
+	}
+}
+
+func TestDecideGroupAllAppliesToLaterGroups(t *testing.T) {
+	session := newTestSession("a\n")
+	group := DuplicateGroup{Files: []FileHash{{Path: "/a"}, {Path: "/b"}}}
+
+	session.decideGroup(1, 2, group, 0)
+	got, _ := session.decideGroup(2, 2, group, 1)
+	if !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("decideGroup() after 'a' = %v, want [0]", got)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// journalFile is where -journal writes by default, next to the undo log
+const journalFile = ".deduplicator_journal.jsonl"
+
+// journalEntry is one line of the operation journal. Every delete, move and
+// link writes an "intent" line before touching the file and a "done" or
+// "failed" line after; an intent without either is an action a run was
+// interrupted in, which -reconcile settles with a "reconciled" line.
+type journalEntry struct {
+	Run    string    `json:"run"`           // the process that wrote the line
+	Seq    int       `json:"seq,omitempty"` // ties an action's lines together within a run
+	Time   time.Time `json:"time"`
+	Phase  string    `json:"phase"`            // "start", "intent", "done", "failed" or "reconciled"
+	Action string    `json:"action,omitempty"` // "delete", "trash", "move", "link", "reflink" or "purge"
+	Path   string    `json:"path,omitempty"`
+	Target string    `json:"target,omitempty"` // move destination or link target
+	Size   int64     `json:"size,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	State  string    `json:"state,omitempty"` // what -reconcile found: "done", "not done" or "unknown"
+	Args   []string  `json:"args,omitempty"`  // command line, on "start"
+}
+
+// journals holds the open journal of each path. A run's first action adds a
+// "start" line with its command line.
+var journals = struct {
+	sync.Mutex
+	run   string
+	seq   int
+	files map[string]*os.File
+}{
+	run:   time.Now().Format("20060102-150405") + "-" + strconv.Itoa(os.Getpid()),
+	files: make(map[string]*os.File),
+}
+
+// journaled runs do, the action on path, between its intent and result
+// lines in the -journal. The action is not attempted when its intent
+// cannot be written, so the journal never misses a change.
+func (c Config) journaled(action, path, target string, do func() error) error {
+	if c.Journal == "" {
+		return do()
+	}
+	journals.Lock()
+	journals.seq++
+	entry := journalEntry{Run: journals.run, Seq: journals.seq, Time: time.Now(), Phase: "intent", Action: action, Path: absPath(path)}
+	journals.Unlock()
+	if target != "" {
+		entry.Target = absPath(target)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		entry.Size = info.Size()
+	}
+	if err := writeJournal(c.Journal, entry); err != nil {
+		return fmt.Errorf("not touched, the journal cannot be written: %w", err)
+	}
+
+	err := do()
+	entry.Time, entry.Phase = time.Now(), "done"
+	if err != nil {
+		entry.Phase, entry.Error = "failed", err.Error()
+	}
+	if jErr := writeJournal(c.Journal, entry); jErr != nil {
+		log.Printf("%sFailed to journal %s of %s: %v", emoji("⚠️"), action, path, jErr)
+	}
+	return err
+}
+
+// writeJournal appends entry to the journal at path and syncs it, so an
+// intent is on disk before its action starts
+func writeJournal(path string, entry journalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	journals.Lock()
+	defer journals.Unlock()
+	f := journals.files[path]
+	if f == nil {
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err != nil {
+			return err
+		}
+		journals.files[path] = f
+		start, _ := json.Marshal(journalEntry{Run: journals.run, Time: time.Now(), Phase: "start", Args: os.Args[1:]})
+		if _, err := f.Write(append(start, '\n')); err != nil {
+			return err
+		}
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// readJournal returns every entry in the journal at path, oldest first. A
+// last line cut short by a crash is ignored.
+func readJournal(path string) ([]journalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []journalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			if scanner.Scan() {
+				return nil, fmt.Errorf("invalid journal line %d: %w", line, err)
+			}
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// unfinished returns the intents in entries that have no result line
+func unfinished(entries []journalEntry) []journalEntry {
+	type key struct {
+		run string
+		seq int
+	}
+	open := make(map[key]int)
+	var intents []journalEntry
+	for _, entry := range entries {
+		k := key{entry.Run, entry.Seq}
+		switch entry.Phase {
+		case "intent":
+			open[k] = len(intents)
+			intents = append(intents, entry)
+		case "done", "failed", "reconciled":
+			if i, ok := open[k]; ok {
+				intents[i].Phase = ""
+				delete(open, k)
+			}
+		}
+	}
+	var pending []journalEntry
+	for _, intent := range intents {
+		if intent.Phase == "intent" {
+			pending = append(pending, intent)
+		}
+	}
+	return pending
+}
+
+// reconcileState tells from the file system whether an interrupted action
+// happened: "done", "not done" or "unknown"
+func reconcileState(entry journalEntry) string {
+	_, srcErr := os.Lstat(entry.Path)
+	source := srcErr == nil
+	switch entry.Action {
+	case "delete", "trash", "purge":
+		if source {
+			return "not done"
+		}
+		return "done"
+	case "move":
+		_, dstErr := os.Lstat(entry.Target)
+		switch {
+		case dstErr == nil && !source:
+			return "done"
+		case source && dstErr != nil:
+			return "not done"
+		}
+	case "link":
+		pathInfo, err1 := os.Stat(entry.Path)
+		targetInfo, err2 := os.Stat(entry.Target)
+		if err1 == nil && err2 == nil {
+			if os.SameFile(pathInfo, targetInfo) {
+				return "done"
+			}
+			return "not done"
+		}
+	}
+	return "unknown"
+}
+
+// runReconcile settles the actions interrupted runs left unfinished in the
+// -journal: each is checked against the file system, reported, and closed
+// with a "reconciled" line
+func runReconcile(c Config) error {
+	if c.Journal == "" {
+		return errors.New("-reconcile needs a -journal")
+	}
+	entries, err := readJournal(c.Journal)
+	if os.IsNotExist(err) {
+		log.Printf("%sNo journal at %s: nothing to reconcile", emoji("ℹ️ "), c.Journal)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pending := unfinished(entries)
+	if len(pending) == 0 {
+		log.Printf("%sEvery action in %s finished", emoji("✅"), c.Journal)
+		return nil
+	}
+	log.Printf("%s%d actions in %s were interrupted:", emoji("⚠️"), len(pending), c.Journal)
+	for _, intent := range pending {
+		state := reconcileState(intent)
+		target := ""
+		if intent.Target != "" {
+			target = " -> " + intent.Target
+		}
+		log.Printf("  %s %s%s (%s, run %s): %s", intent.Action, intent.Path, target, intent.Time.Format("2006-01-02 15:04:05"), intent.Run, state)
+
+		settled := intent
+		settled.Time, settled.Phase, settled.State = time.Now(), "reconciled", state
+		if err := writeJournal(c.Journal, settled); err != nil {
+			return fmt.Errorf("cannot record the reconciliation: %w", err)
+		}
+	}
+	return nil
+}
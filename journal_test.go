@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain runs the tests in a folder of their own, so that the journal and
+// the undo log of the files they delete, move and link stay out of the
+// source tree
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "dedup-journal")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	c := DefaultConfig()
+	c.Journal = filepath.Join(dir, "journal.jsonl")
+
+	keep := filepath.Join(dir, "keep")
+	moved := filepath.Join(dir, "moved")
+	deleted := filepath.Join(dir, "deleted")
+	for _, path := range []string{keep, moved, deleted} {
+		os.WriteFile(path, []byte("same"), 0644)
+	}
+	target := filepath.Join(dir, "target")
+	if err := c.moveFile(moved, target); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.removeFile(deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.removeFile(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("removing a missing file succeeded")
+	}
+
+	entries, err := readJournal(c.Journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var phases []string
+	for _, entry := range entries {
+		phases = append(phases, entry.Phase)
+	}
+	want := []string{"start", "intent", "done", "intent", "done", "intent", "failed"}
+	if len(phases) != len(want) {
+		t.Fatalf("phases = %v, want %v", phases, want)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Fatalf("phases = %v, want %v", phases, want)
+		}
+	}
+	if move := entries[1]; move.Action != "move" || move.Path != moved || move.Target != target || move.Size != 4 {
+		t.Errorf("move intent = %+v", move)
+	}
+	if failed := entries[6]; failed.Error == "" || failed.Seq != entries[5].Seq {
+		t.Errorf("failed result = %+v", failed)
+	}
+	if pending := unfinished(entries); len(pending) != 0 {
+		t.Errorf("unfinished = %+v, want none", pending)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	dir := t.TempDir()
+	c := DefaultConfig()
+	c.Journal = filepath.Join(dir, "journal.jsonl")
+
+	gone := filepath.Join(dir, "gone")
+	still := filepath.Join(dir, "still")
+	os.WriteFile(still, []byte("x"), 0644)
+	for seq, path := range map[int]string{1: gone, 2: still} {
+		if err := writeJournal(c.Journal, journalEntry{Run: "interrupted", Seq: seq, Phase: "intent", Action: "delete", Path: path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := runReconcile(c); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := readJournal(c.Journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Phase == "reconciled" {
+			states[entry.Path] = entry.State
+		}
+	}
+	if states[gone] != "done" || states[still] != "not done" {
+		t.Errorf("reconciled states = %v", states)
+	}
+	if pending := unfinished(entries); len(pending) != 0 {
+		t.Errorf("still unfinished after reconciling: %+v", pending)
+	}
+}
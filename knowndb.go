@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// knownHash is what the known-hash database remembers about one content hash
+type knownHash struct {
+	Path      string    `json:"path"` // where the content was first seen
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Removed   int       `json:"removed,omitempty"` // copies deleted or moved by earlier runs
+	Kept      string    `json:"kept,omitempty"`    // copy kept the last time others were removed
+}
+
+// knownDB is the long-lived -known-db file of every hash ever scanned
+type knownDB struct {
+	path      string
+	Algorithm string                `json:"algorithm"`
+	Hashes    map[string]*knownHash `json:"hashes"`
+}
+
+// defaultKnownDB is used by -reintroduced when no -known-db is given
+func defaultKnownDB() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "file-deduplicator", "known.json")
+}
+
+// loadKnownDB opens the database at path, starting an empty one if the
+// file does not exist yet
+func loadKnownDB(path, algorithm string) (*knownDB, error) {
+	algorithm = strings.ToLower(algorithm)
+	db := &knownDB{path: path, Algorithm: algorithm, Hashes: make(map[string]*knownHash)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read known-hash database: %w", err)
+	}
+	if err := json.Unmarshal(data, db); err != nil {
+		return nil, fmt.Errorf("invalid known-hash database %s: %w", path, err)
+	}
+	if db.Algorithm != algorithm {
+		return nil, fmt.Errorf("known-hash database %s uses %s, not %s", path, db.Algorithm, algorithm)
+	}
+	if db.Hashes == nil {
+		db.Hashes = make(map[string]*knownHash)
+	}
+	return db, nil
+}
+
+// save writes the database atomically so an interrupted run cannot corrupt it
+func (db *knownDB) save() error {
+	if err := os.MkdirAll(filepath.Dir(db.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(db)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(db.path, data, 0600)
+}
+
+// record notes that every hashed file was seen at now
+func (db *knownDB) record(files []FileHash, now time.Time) {
+	for _, fh := range files {
+		if fh.Hash == "" {
+			continue
+		}
+		entry := db.Hashes[fh.Hash]
+		if entry == nil {
+			entry = &knownHash{Path: fh.Path, FirstSeen: now}
+			db.Hashes[fh.Hash] = entry
+		}
+		entry.LastSeen = now
+	}
+}
+
+// removed notes that a copy of hash was deleted or moved while kept survived
+func (db *knownDB) removed(hash, kept string) {
+	entry := db.Hashes[hash]
+	if entry == nil {
+		return
+	}
+	entry.Removed++
+	entry.Kept = kept
+}
+
+// reintroducedFile is a scanned file whose content was deduplicated before
+type reintroducedFile struct {
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	Hash      string    `json:"hash"`
+	FirstPath string    `json:"first_path"`
+	FirstSeen time.Time `json:"first_seen"`
+	Kept      string    `json:"kept"`
+	Removed   int       `json:"removed"`
+}
+
+// reintroduced returns the files whose content earlier runs already removed
+// copies of, other than the copy those runs kept
+func (db *knownDB) reintroduced(files []FileHash) []reintroducedFile {
+	var found []reintroducedFile
+	for _, fh := range files {
+		entry := db.Hashes[fh.Hash]
+		if fh.Hash == "" || entry == nil || entry.Removed == 0 || fh.Path == entry.Kept {
+			continue
+		}
+		found = append(found, reintroducedFile{
+			Path:      fh.Path,
+			Size:      fh.Size,
+			Hash:      fh.Hash,
+			FirstPath: entry.Path,
+			FirstSeen: entry.FirstSeen,
+			Kept:      entry.Kept,
+			Removed:   entry.Removed,
+		})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	return found
+}
+
+// updateKnownDB records this scan's files in the -known-db, if configured,
+// and returns the files that re-introduce previously deduplicated content.
+// The database stays open on the engine so removals can be recorded.
+func (e *Engine) updateKnownDB(files []FileHash) ([]reintroducedFile, error) {
+	if e.cfg.KnownDB == "" || e.cfg.NoHash {
+		return nil, nil
+	}
+
+	db, err := loadKnownDB(e.cfg.KnownDB, e.cfg.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	found := db.reintroduced(files)
+	db.record(files, time.Now())
+	if err := db.save(); err != nil {
+		return nil, fmt.Errorf("failed to save known-hash database: %w", err)
+	}
+	e.known = db
+
+	if len(found) > 0 && !e.cfg.JSON {
+		log.Printf("%s%d files re-introduce content that was deduplicated before (see -reintroduced)", emoji("♻️"), len(found))
+	}
+	return found, nil
+}
+
+// runReintroduced scans and lists files whose content was deduplicated by
+// an earlier run, without changing anything
+func (e *Engine) runReintroduced(ctx context.Context) error {
+	fileHashes, err := e.collectFiles(ctx)
+	if err != nil {
+		return err
+	}
+	found, err := e.updateKnownDB(fileHashes)
+	if err != nil {
+		return err
+	}
+
+	if e.cfg.JSON {
+		if found == nil {
+			found = []reintroducedFile{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(found)
+	}
+
+	if len(found) == 0 {
+		log.Printf("%sNo re-introduced duplicates", emoji("✅"))
+		return nil
+	}
+	log.Println("\n" + strings.Repeat("=", 70))
+	log.Printf("%sRE-INTRODUCED DUPLICATES", emoji("♻️"))
+	log.Println(strings.Repeat("=", 70))
+	for _, f := range found {
+		log.Printf("\n%s (%s)", f.Path, formatBytes(f.Size))
+		log.Printf("    First seen: %s on %s", f.FirstPath, f.FirstSeen.Format("2006-01-02"))
+		log.Printf("    Kept copy:  %s (%d copies removed before)", f.Kept, f.Removed)
+	}
+	return nil
+}
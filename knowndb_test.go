@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKnownDBReintroduced(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "unique.txt"} {
+		content := "same content"
+		if name == "unique.txt" {
+			content = "different"
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{tmpDir}
+	c.MinSize = 1
+	c.JSON = true
+	c.KnownDB = filepath.Join(t.TempDir(), "known.json")
+	c.MoveTo = filepath.Join(t.TempDir(), "moved")
+
+	// First run: both hashes are recorded and one copy is removed
+	engine := NewEngine(c, nil)
+	duplicates, err := engine.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatalf("collectDuplicates() error = %v", err)
+	}
+	if err := engine.processDuplicates(context.Background(), duplicates); err != nil {
+		t.Fatalf("processDuplicates() error = %v", err)
+	}
+	kept := duplicates[0].Files[selectFileToKeep(duplicates[0], c.KeepCriteria)].Path
+
+	db, err := loadKnownDB(c.KnownDB, c.HashAlgorithm)
+	if err != nil {
+		t.Fatalf("loadKnownDB() error = %v", err)
+	}
+	if len(db.Hashes) != 2 {
+		t.Errorf("database has %d hashes, want 2", len(db.Hashes))
+	}
+	entry := db.Hashes[duplicates[0].Hash]
+	if entry == nil || entry.Removed != 1 || entry.Kept != kept || entry.FirstSeen.IsZero() {
+		t.Fatalf("database entry = %+v, want one removal keeping %s", entry, kept)
+	}
+
+	// The content comes back under a new name
+	reintroduced := filepath.Join(tmpDir, "again.txt")
+	if err := os.WriteFile(reintroduced, []byte("same content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	engine = NewEngine(c, nil)
+	fileHashes, err := engine.collectFiles(context.Background())
+	if err != nil {
+		t.Fatalf("collectFiles() error = %v", err)
+	}
+	found, err := engine.updateKnownDB(fileHashes)
+	if err != nil {
+		t.Fatalf("updateKnownDB() error = %v", err)
+	}
+	if len(found) != 1 || found[0].Path != reintroduced || found[0].Kept != kept || found[0].FirstPath != entry.Path {
+		t.Errorf("reintroduced = %+v, want only %s", found, reintroduced)
+	}
+
+	if _, err := loadKnownDB(c.KnownDB, "md5"); err == nil {
+		t.Error("loadKnownDB() should refuse a database built with another algorithm")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Actions for -link
+const (
+	linkHardlink = "hardlink" // replace duplicates with hard links to the kept file
+	linkReflink  = "reflink"  // replace duplicates with copy-on-write clones of the kept file
+)
+
+// errCannotLink marks a duplicate that cannot be linked to its kept copy,
+// such as one on another volume or on a filesystem without hard links
+var errCannotLink = errors.New("cannot link")
+
+// checkLinkMode validates a -link value
+func checkLinkMode(mode string) error {
+	switch strings.ToLower(mode) {
+	case "", linkHardlink, linkReflink:
+		return nil
+	}
+	return fmt.Errorf("invalid -link %q: expected hardlink or reflink", mode)
+}
+
+// actionVerbs names what processing does to duplicates, as in "Deleting"
+// and "Deleted"
+func (c Config) actionVerbs() (string, string) {
+	switch {
+	case c.Link != "":
+		return "Linking", "Linked"
+	case c.MoveTo != "":
+		return "Moving", "Moved"
+	case c.Trash:
+		return "Trashing", "Trashed"
+	case c.QuarantineDeletes:
+		return "Quarantining", "Quarantined"
+	}
+	return "Deleting", "Deleted"
+}
+
+// linkFile replaces dup with a link to keep, as -link asks
+func (c Config) linkFile(keep, dup string) error {
+	if strings.ToLower(c.Link) == linkReflink {
+		return c.journaled("reflink", dup, keep, func() error {
+			if err := c.checkWritable(dup); err != nil {
+				return err
+			}
+			return reflinkFile(keep, dup)
+		})
+	}
+	return c.journaled("link", dup, keep, func() error {
+		if err := c.checkWritable(dup); err != nil {
+			return err
+		}
+		return hardlinkFile(keep, dup)
+	})
+}
+
+// hardlinkFile replaces dup with a hard link to keep. The link is created
+// next to dup under a temporary name and renamed over it, so dup's path
+// never goes missing even if the run is interrupted.
+func hardlinkFile(keep, dup string) error {
+	keepInfo, err := os.Stat(keep)
+	if err != nil {
+		return err
+	}
+	dupInfo, err := os.Stat(dup)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(keepInfo, dupInfo) {
+		return fmt.Errorf("%w: already a hard link to %s", errCannotLink, keep)
+	}
+	if err := checkLinkTarget(keep, dup); err != nil {
+		return err
+	}
+
+	tmp := uniqueTargetPath(filepath.Dir(dup), filepath.Base(dup)+".dedup-link")
+	if err := os.Link(keep, tmp); err != nil {
+		return fmt.Errorf("%w: %v", errCannotLink, err)
+	}
+	if err := os.Rename(tmp, dup); err != nil {
+		os.Remove(tmp)
+		return lockedError(err)
+	}
+	return nil
+}
+
+// reflinkFile replaces dup with a copy-on-write clone of keep: dup stays a
+// file of its own, with its own permissions and modification time, but
+// shares keep's blocks until either is changed. Like hardlinkFile it clones
+// under a temporary name next to dup and renames the clone over it.
+func reflinkFile(keep, dup string) error {
+	keepInfo, err := os.Stat(keep)
+	if err != nil {
+		return err
+	}
+	dupInfo, err := os.Stat(dup)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(keepInfo, dupInfo) {
+		return fmt.Errorf("%w: %s is a hard link to %s", errCannotLink, dup, keep)
+	}
+
+	tmp := uniqueTargetPath(filepath.Dir(dup), filepath.Base(dup)+".dedup-clone")
+	if err := cloneFile(keep, tmp); err != nil {
+		return fmt.Errorf("%w: %v", errCannotLink, err)
+	}
+	os.Chmod(tmp, dupInfo.Mode().Perm())
+	os.Chtimes(tmp, dupInfo.ModTime(), dupInfo.ModTime())
+	if err := os.Rename(tmp, dup); err != nil {
+		os.Remove(tmp)
+		return lockedError(err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHardlinkFile(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.txt")
+	dup := filepath.Join(dir, "dup.txt")
+	os.WriteFile(keep, []byte("same content"), 0644)
+	os.WriteFile(dup, []byte("same content"), 0644)
+
+	if err := hardlinkFile(keep, dup); err != nil {
+		t.Fatalf("hardlinkFile() error = %v", err)
+	}
+	keepInfo, _ := os.Stat(keep)
+	dupInfo, err := os.Stat(dup)
+	if err != nil || !os.SameFile(keepInfo, dupInfo) {
+		t.Fatalf("%s is not a hard link to %s (%v)", dup, keep, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("temporary link left behind: %v", entries)
+	}
+
+	if err := hardlinkFile(keep, dup); !errors.Is(err, errCannotLink) {
+		t.Errorf("linking an existing link = %v, want errCannotLink", err)
+	}
+}
+
+func TestProcessDuplicatesLink(t *testing.T) {
+	dir := t.TempDir()
+	var files []FileHash
+	for i, name := range []string{"keep.txt", "dup1.txt", "dup2.txt"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("same content"), 0644)
+		modTime := time.Now().Add(time.Duration(i-10) * time.Hour) // keep.txt is oldest
+		os.Chtimes(path, modTime, modTime)
+		files = append(files, scanOne(t, path))
+	}
+	group := DuplicateGroup{Hash: files[0].Hash, Size: files[0].Size, Files: files, Similarity: 100}
+
+	c := DefaultConfig()
+	c.JSON = true
+	c.KeepCriteria = "oldest"
+	c.Link = linkHardlink // links never write the undo log
+
+	var actions []ActionEvent
+	engine := NewEngine(c, &Events{OnActionTaken: func(a ActionEvent) { actions = append(actions, a) }})
+	if err := engine.processDuplicates(context.Background(), []DuplicateGroup{group}); err != nil {
+		t.Fatal(err)
+	}
+	keepInfo, _ := os.Stat(files[0].Path)
+	for _, fh := range files[1:] {
+		info, err := os.Stat(fh.Path)
+		if err != nil || !os.SameFile(keepInfo, info) {
+			t.Errorf("%s should be a hard link to the kept file (%v)", fh.Path, err)
+		}
+	}
+	if len(actions) != 2 || actions[0].Action != "linked" || actions[0].Target != files[0].Path {
+		t.Errorf("actions = %+v, want two links to the kept file", actions)
+	}
+}
+
+func TestProcessDuplicatesLinkIndexed(t *testing.T) {
+	dir := t.TempDir()
+	var files []FileHash
+	for _, name := range []string{"a.txt", "b.txt"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("same content"), 0644)
+		files = append(files, scanOne(t, path))
+	}
+	indexed := files[0]
+	indexed.Path = "idx.json:/archive/a.txt"
+	indexed.Reference = true
+	group := DuplicateGroup{Hash: files[0].Hash, Size: files[0].Size, Files: append([]FileHash{indexed}, files...), Similarity: 100}
+	alone := DuplicateGroup{Hash: "other", Size: 1, Files: []FileHash{indexed, {Path: "host:/srv/a.txt", Host: "host"}}, Similarity: 100}
+
+	c := DefaultConfig()
+	c.JSON = true
+	c.Link = linkHardlink
+
+	var actions []ActionEvent
+	engine := NewEngine(c, &Events{OnActionTaken: func(a ActionEvent) { actions = append(actions, a) }})
+	if err := engine.processDuplicates(context.Background(), []DuplicateGroup{group, alone}); err != nil {
+		t.Fatal(err)
+	}
+	aInfo, _ := os.Stat(files[0].Path)
+	if bInfo, err := os.Stat(files[1].Path); err != nil || !os.SameFile(aInfo, bInfo) {
+		t.Errorf("%s should be a hard link to the local copy %s (%v)", files[1].Path, files[0].Path, err)
+	}
+	if len(actions) != 1 || actions[0].Error != "" || actions[0].Target != files[0].Path {
+		t.Errorf("actions = %+v, want one link to the local copy", actions)
+	}
+	if len(engine.failed) != 0 {
+		t.Errorf("failures = %+v, want none", engine.failed)
+	}
+}
+
+func TestCheckLinkMode(t *testing.T) {
+	for _, mode := range []string{"", "hardlink", "HardLink", "reflink"} {
+		if err := checkLinkMode(mode); err != nil {
+			t.Errorf("checkLinkMode(%q) = %v", mode, err)
+		}
+	}
+	if err := checkLinkMode("symlink"); err == nil {
+		t.Error("checkLinkMode(\"symlink\") should fail")
+	}
+}
+
+func TestReflinkFile(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.txt")
+	dup := filepath.Join(dir, "dup.txt")
+	os.WriteFile(keep, []byte("same content"), 0644)
+	os.WriteFile(dup, []byte("same content"), 0600)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(dup, modTime, modTime)
+
+	err := reflinkFile(keep, dup)
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("temporary clone left behind: %v", entries)
+	}
+	if err != nil {
+		// Most test machines have no copy-on-write filesystem; the file
+		// must then be left alone with a clear error
+		if !errors.Is(err, errCannotLink) {
+			t.Errorf("reflinkFile() on an unsupported filesystem = %v, want errCannotLink", err)
+		}
+		if data, _ := os.ReadFile(dup); string(data) != "same content" {
+			t.Errorf("failed clone changed %s", dup)
+		}
+		t.Skipf("filesystem has no reflinks: %v", err)
+	}
+
+	keepInfo, _ := os.Stat(keep)
+	dupInfo, err := os.Stat(dup)
+	if err != nil || os.SameFile(keepInfo, dupInfo) {
+		t.Fatalf("%s should be a separate file (%v)", dup, err)
+	}
+	if dupInfo.Mode().Perm() != 0600 || !dupInfo.ModTime().Equal(modTime) {
+		t.Errorf("clone has mode %v and mtime %v, want the duplicate's", dupInfo.Mode(), dupInfo.ModTime())
+	}
+	if data, _ := os.ReadFile(dup); string(data) != "same content" {
+		t.Errorf("clone content = %q", data)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// checkLinkTarget refuses to link across filesystems, which hard links
+// cannot span
+func checkLinkTarget(keep, dup string) error {
+	keepInfo, err := os.Stat(keep)
+	if err != nil {
+		return err
+	}
+	dirInfo, err := os.Stat(filepath.Dir(dup))
+	if err != nil {
+		return err
+	}
+	keepStat, ok1 := keepInfo.Sys().(*syscall.Stat_t)
+	dirStat, ok2 := dirInfo.Sys().(*syscall.Stat_t)
+	if ok1 && ok2 && keepStat.Dev != dirStat.Dev {
+		return fmt.Errorf("%w: %s and %s are on different filesystems", errCannotLink, keep, dup)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procGetVolumePathName    = kernel32.NewProc("GetVolumePathNameW")
+	procGetVolumeInformation = kernel32.NewProc("GetVolumeInformationW")
+)
+
+// volumeInfo returns the root, serial number and filesystem name of the
+// volume holding path
+func volumeInfo(path string) (string, uint32, string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", 0, "", err
+	}
+	name, err := syscall.UTF16PtrFromString(abs)
+	if err != nil {
+		return "", 0, "", err
+	}
+	root := make([]uint16, syscall.MAX_PATH+1)
+	if ret, _, err := procGetVolumePathName.Call(uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(&root[0])), uintptr(len(root))); ret == 0 {
+		return "", 0, "", fmt.Errorf("cannot find the volume of %s: %v", path, err)
+	}
+
+	var serial uint32
+	fsName := make([]uint16, syscall.MAX_PATH+1)
+	ret, _, err := procGetVolumeInformation.Call(
+		uintptr(unsafe.Pointer(&root[0])),
+		0, 0,
+		uintptr(unsafe.Pointer(&serial)),
+		0, 0,
+		uintptr(unsafe.Pointer(&fsName[0])),
+		uintptr(len(fsName)),
+	)
+	if ret == 0 {
+		return "", 0, "", fmt.Errorf("cannot read the volume of %s: %v", path, err)
+	}
+	return syscall.UTF16ToString(root), serial, syscall.UTF16ToString(fsName), nil
+}
+
+// checkLinkTarget refuses to link across volumes, or on filesystems such as
+// FAT32, exFAT and ReFS that have no hard links. CreateHardLink (behind
+// os.Link) needs both files on the same NTFS volume.
+func checkLinkTarget(keep, dup string) error {
+	keepRoot, keepSerial, _, err := volumeInfo(keep)
+	if err != nil {
+		return err
+	}
+	dupRoot, dupSerial, fsName, err := volumeInfo(dup)
+	if err != nil {
+		return err
+	}
+	if keepSerial != dupSerial {
+		return fmt.Errorf("%w: %s is on %s but %s is on %s", errCannotLink, keep, keepRoot, dup, dupRoot)
+	}
+	if !strings.EqualFold(fsName, "NTFS") {
+		return fmt.Errorf("%w: %s is %s, which has no hard links (NTFS is required)", errCannotLink, dupRoot, fsName)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
+)
+
+// lintConfig looks for option combinations that can make a run that changes
+// files go wrong, before anything is scanned. Each problem is one sentence.
+func (c Config) lintConfig() []string {
+	var problems []string
+	targets := [][2]string{{"-move-to", c.MoveTo}}
+	if c.WatchAutoClean || c.QuarantineDeletes {
+		targets = append(targets, [2]string{"-quarantine", c.quarantineDir()})
+	}
+	for _, target := range targets {
+		if target[1] == "" {
+			continue
+		}
+		if root := c.scannedRoot(target[1]); root != "" {
+			problems = append(problems, fmt.Sprintf("%s %s is inside the scanned directory %s: files moved there would be scanned again and could be kept in place of the originals", target[0], target[1], root))
+		}
+		for _, root := range c.roots() {
+			if rel, err := filepath.Rel(resolvedPath(target[1]), resolvedPath(root)); err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				problems = append(problems, fmt.Sprintf("-dir %s is inside %s %s: the files staged there would be scanned, and removing them would leave -restore and -purge-staged nothing to find", root, target[0], target[1]))
+			}
+		}
+	}
+	for _, root := range c.roots() {
+		if c.underReadOnly(root) {
+			problems = append(problems, fmt.Sprintf("-dir %s is inside a -reference-readonly directory: none of its files could be changed", root))
+		}
+	}
+	if target, ok := strings.CutPrefix(c.KeepCriteria, dedup.KeepPath); ok && target == "" {
+		problems = append(problems, "-keep path: names no path, so every group would keep an arbitrary copy")
+	}
+	return problems
+}
+
+// lintKeep checks a -keep path: criterion against the groups about to be
+// acted on: one matching no copy in any group is almost certainly a typo
+func lintKeep(duplicates []DuplicateGroup, c Config) []string {
+	target, ok := strings.CutPrefix(c.KeepCriteria, dedup.KeepPath)
+	if !ok || target == "" || len(duplicates) == 0 {
+		return nil
+	}
+	unmatched := 0
+	for _, group := range duplicates {
+		matched := false
+		for _, fh := range group.Files {
+			if strings.Contains(fh.Path, target) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unmatched++
+		}
+	}
+	switch {
+	case unmatched == len(duplicates):
+		return []string{fmt.Sprintf("-keep %s matches no file in any of the %d groups, so each would keep its first copy instead", c.KeepCriteria, len(duplicates))}
+	case unmatched > 0 && !c.JSON:
+		log.Printf("%s-keep %s matches no file in %d of %d groups; those keep their first copy", emoji("⚠️"), c.KeepCriteria, unmatched, len(duplicates))
+	}
+	return nil
+}
+
+// checkLint logs the problems found and refuses to go on unless -force
+func (c Config) checkLint(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	for _, problem := range problems {
+		log.Printf("%s%s", emoji("⚠️"), problem)
+	}
+	if !c.Force {
+		return fmt.Errorf("refusing to change files: %d problems with the options (fix them, try -dry-run, or add -force)", len(problems))
+	}
+	log.Printf("%s-force given: going ahead anyway", emoji("⚠️"))
+	return nil
+}
+
+// scannedRoot returns the -dir a scan would find path in, or "" if none.
+// Hidden folders are not walked without -include-hidden, folders below
+// -max-depth are not walked at all, and without -recursive only the roots
+// themselves are.
+func (c Config) scannedRoot(path string) string {
+	p := resolvedPath(path)
+	for _, root := range c.roots() {
+		rel, err := filepath.Rel(resolvedPath(root), p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel != "." && !c.Recursive {
+			continue
+		}
+		if beyondMaxDepth(resolvedPath(root), p, c.MaxDepth) {
+			continue
+		}
+		if !c.underHidden(resolvedPath(root), rel) {
+			return root
+		}
+	}
+	return ""
+}
+
+// underHidden reports whether a scan of root leaves out the folder rel below
+// it, or one on the way there, as hidden or as a system folder. Folders that
+// do not exist yet are judged by name alone.
+func (c Config) underHidden(root, rel string) bool {
+	if rel == "." {
+		return false
+	}
+	dir := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		dir = filepath.Join(dir, part)
+		if info, err := os.Lstat(dir); err == nil {
+			if c.leftOut(dir, info) {
+				return true
+			}
+		} else if !c.IncludeHidden && strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintConfig(t *testing.T) {
+	root := t.TempDir()
+	reference := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "photos"), 0755)
+
+	tests := []struct {
+		name string
+		edit func(c *Config)
+		want string // substring of the only problem, "" for none
+	}{
+		{"move-to outside", func(c *Config) { c.MoveTo = t.TempDir() }, ""},
+		{"move-to inside", func(c *Config) { c.MoveTo = filepath.Join(root, "dupes") }, "inside the scanned directory"},
+		{"move-to is the root", func(c *Config) { c.MoveTo = root }, "inside the scanned directory"},
+		{"move-to hidden", func(c *Config) { c.MoveTo = filepath.Join(root, ".dupes") }, ""},
+		{"move-to below, not recursive", func(c *Config) { c.MoveTo = filepath.Join(root, "dupes"); c.Recursive = false }, ""},
+		{"move-to below max-depth", func(c *Config) { c.MoveTo = filepath.Join(root, "photos", "dupes"); c.MaxDepth = 2 }, ""},
+		{"move-to within max-depth", func(c *Config) { c.MoveTo = filepath.Join(root, "photos"); c.MaxDepth = 2 }, "inside the scanned directory"},
+		{"quarantine inside without auto-clean", func(c *Config) { c.Quarantine = filepath.Join(root, "q") }, ""},
+		{"quarantine inside with auto-clean", func(c *Config) { c.Quarantine = filepath.Join(root, "q"); c.WatchAutoClean = true }, "-quarantine"},
+		{"quarantine inside with quarantine-deletes", func(c *Config) { c.Quarantine = filepath.Join(root, "q"); c.QuarantineDeletes = true }, "-quarantine"},
+		{"default quarantine with quarantine-deletes", func(c *Config) { c.QuarantineDeletes = true }, ""},
+		{"default quarantine scanned", func(c *Config) { c.QuarantineDeletes = true; c.IncludeHidden = true }, "-quarantine"},
+		{"dir inside move-to", func(c *Config) { c.MoveTo = filepath.Dir(root) }, "staged there"},
+		{"dir inside quarantine", func(c *Config) { c.Quarantine = filepath.Dir(root); c.WatchAutoClean = true }, "staged there"},
+		{"dir on reference media", func(c *Config) {
+			c.Dir = stringList{filepath.Join(reference, "sub")}
+			c.ReferenceReadOnly = stringList{reference}
+		}, "-reference-readonly"},
+		{"empty keep path", func(c *Config) { c.KeepCriteria = "path:" }, "-keep path:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			c.Dir = stringList{root}
+			tt.edit(&c)
+			problems := c.lintConfig()
+			if tt.want == "" {
+				if len(problems) != 0 {
+					t.Errorf("problems = %q, want none", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.want) {
+				t.Errorf("problems = %q, want one about %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintKeep(t *testing.T) {
+	groups := []DuplicateGroup{
+		{Files: []FileHash{{Path: "/a/photos/1.jpg"}, {Path: "/b/1.jpg"}}},
+		{Files: []FileHash{{Path: "/a/docs/2.txt"}, {Path: "/b/2.txt"}}},
+	}
+	c := DefaultConfig()
+	c.JSON = true
+
+	c.KeepCriteria = "path:/a/"
+	if problems := lintKeep(groups, c); len(problems) != 0 {
+		t.Errorf("matching criterion: problems = %q", problems)
+	}
+	c.KeepCriteria = "path:/photos"
+	if problems := lintKeep(groups, c); len(problems) != 0 {
+		t.Errorf("partly matching criterion is refused: %q", problems)
+	}
+	c.KeepCriteria = "path:/Photos"
+	if problems := lintKeep(groups, c); len(problems) != 1 {
+		t.Errorf("criterion matching nothing: problems = %q", problems)
+	}
+
+	if err := c.checkLint([]string{"problem"}); err == nil {
+		t.Error("checkLint accepted a problem without -force")
+	}
+	c.Force = true
+	if err := c.checkLint([]string{"problem"}); err != nil {
+		t.Errorf("checkLint with -force: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/luinbytes/file-deduplicator/exif"
+)
+
+// photoMeta is where and when a photo was taken, from its EXIF data
+type photoMeta struct {
+	Taken     *time.Time `json:",omitempty"` // DateTimeOriginal, in the camera's clock without a zone
+	Latitude  float64    `json:",omitempty"`
+	Longitude float64    `json:",omitempty"`
+	GPS       bool       `json:",omitempty"` // Latitude and Longitude are set
+}
+
+// readPhotoMeta reads the capture time and GPS position of a JPEG or a
+// TIFF-based image. It returns nil when the file has neither.
+func readPhotoMeta(path string) (*photoMeta, error) {
+	info, err := exif.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Taken == nil && !info.GPS {
+		return nil, nil
+	}
+	return &photoMeta{Taken: info.Taken, Latitude: info.Latitude, Longitude: info.Longitude, GPS: info.GPS}, nil
+}
+
+// Limits behind -photo-location
+const (
+	samePlaceKm     = 1.0       // photos this close were taken at the same place
+	sameMomentGap   = time.Hour // and this close in time, at the same moment
+	farApartKm      = 2000.0    // photos this far apart are from another region or continent
+	farApartPenalty = 25.0      // percentage points a far-apart group loses
+)
+
+// earthRadiusKm is the mean radius used for distances between photos
+const earthRadiusKm = 6371.0
+
+// distanceKm is the great-circle distance between two GPS positions
+func distanceKm(a, b *photoMeta) float64 {
+	rad := math.Pi / 180
+	dLat := (b.Latitude - a.Latitude) * rad
+	dLon := (b.Longitude - a.Longitude) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(a.Latitude*rad)*math.Cos(b.Latitude*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// applyLocationHint adjusts a scored perceptual group by where and when its
+// photos were taken. A group whose photos all come from the same place and
+// moment is more likely a burst or re-export, so its similarity moves
+// halfway to the maximum; a group with two photos taken more than
+// farApartKm apart is more likely two similar scenes, so it loses
+// farApartPenalty points. Photos without EXIF data leave the group as it is.
+func applyLocationHint(group *DuplicateGroup) {
+	files := group.Files
+	farthest, longestGap := 0.0, time.Duration(0)
+	pairs, located, timed := 0, 0, 0
+	for i := range files {
+		for j := i + 1; j < len(files); j++ {
+			pairs++
+			a, b := files[i].Photo, files[j].Photo
+			if a == nil || b == nil {
+				continue
+			}
+			if a.GPS && b.GPS {
+				located++
+				farthest = math.Max(farthest, distanceKm(a, b))
+			}
+			if a.Taken != nil && b.Taken != nil {
+				timed++
+				gap := a.Taken.Sub(*b.Taken)
+				if gap < 0 {
+					gap = -gap
+				}
+				if gap > longestGap {
+					longestGap = gap
+				}
+			}
+		}
+	}
+
+	switch {
+	case located > 0 && farthest > farApartKm:
+		group.Similarity = math.Max(group.Similarity-farApartPenalty, 0)
+		group.Location = fmt.Sprintf("taken %.0f km apart, similarity lowered", farthest)
+	case pairs > 0 && located == pairs && timed == pairs && farthest <= samePlaceKm && longestGap <= sameMomentGap:
+		group.Similarity += (maxPerceptualSimilarity - group.Similarity) / 2
+		group.Location = "taken at the same place and time, similarity raised"
+	}
+}
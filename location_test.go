@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyLocationHint(t *testing.T) {
+	at := func(lat, lon float64, taken time.Time) FileHash {
+		return FileHash{Photo: &photoMeta{Latitude: lat, Longitude: lon, GPS: true, Taken: &taken}}
+	}
+	noon := time.Date(2024, 7, 14, 12, 0, 0, 0, time.UTC)
+
+	// A burst in Paris: same place, minutes apart
+	burst := DuplicateGroup{Similarity: 90, Files: []FileHash{at(48.8583, 2.2944, noon), at(48.8590, 2.2950, noon.Add(3*time.Minute))}}
+	applyLocationHint(&burst)
+	if burst.Similarity <= 90 || burst.Similarity > maxPerceptualSimilarity || !strings.Contains(burst.Location, "same place") {
+		t.Errorf("burst = %.1f%% %q, want raised", burst.Similarity, burst.Location)
+	}
+
+	// Two sunsets, Paris and New York
+	sunsets := DuplicateGroup{Similarity: 90, Files: []FileHash{at(48.8583, 2.2944, noon), at(40.7128, -74.0060, noon)}}
+	applyLocationHint(&sunsets)
+	if sunsets.Similarity != 90-farApartPenalty || !strings.Contains(sunsets.Location, "km apart") {
+		t.Errorf("sunsets = %.1f%% %q, want lowered by %.0f", sunsets.Similarity, sunsets.Location, farApartPenalty)
+	}
+
+	// One photo without EXIF data: no hint either way
+	partial := DuplicateGroup{Similarity: 90, Files: []FileHash{at(48.8583, 2.2944, noon), {}}}
+	applyLocationHint(&partial)
+	if partial.Similarity != 90 || partial.Location != "" {
+		t.Errorf("partial = %.1f%% %q, want unchanged", partial.Similarity, partial.Location)
+	}
+
+	if d := distanceKm(at(48.8583, 2.2944, noon).Photo, at(40.7128, -74.0060, noon).Photo); d < 5800 || d > 5900 {
+		t.Errorf("Paris to New York = %.0f km, want about 5840", d)
+	}
+}
@@ -1,20 +1,20 @@
 package main
 
 import (
-	"crypto/md5"
-	"crypto/sha1"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"hash"
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -22,24 +22,36 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/fsnotify/fsnotify"
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
 	"github.com/luinbytes/file-deduplicator/tui"
 )
 
 const (
 	version                = "3.1.0"
-	reportFile             = ".deduplicator_report.json"
 	undoFile               = ".deduplicator_undo.json"
+	reportFile             = ".deduplicator_report.json"
+	csvReportFile          = ".deduplicator_report.csv"
+	checkpointFile         = ".deduplicator_checkpoint.jsonl"
+	partialReportFile      = ".deduplicator_partial_report.json"
+	dedupIgnoreFile        = ".dedupignore"
 	maxHistory             = 100
-	progressUpdateInterval  = 1 * time.Second
+	progressUpdateInterval = 1 * time.Second
 )
 
 // FileHash represents a file and its hash
 type FileHash struct {
-	Path     string
-	Size     int64
-	Hash     string
-	ModTime  time.Time
-	PHash    string  // Perceptual hash for images
+	Path       string
+	Size       int64
+	Hash       string
+	ModTime    time.Time
+	PHash      string     // Perceptual hash for images
+	Width      int        `json:",omitempty"` // Image dimensions, set with -same-dimensions
+	Height     int        `json:",omitempty"`
+	Host       string     `json:",omitempty"` // Set for files reported by a -remote agent; never modified locally
+	Reference  bool       `json:",omitempty"` // From an -import-index or -reference-readonly; kept in preference to other copies
+	Similarity float64    `json:",omitempty"` // Average similarity to the rest of a perceptual group
+	Photo      *photoMeta `json:",omitempty"` // EXIF capture time and position, read with -photo-location
+	Links      []string   `json:",omitempty"` // Other hard links to this file, acted on along with Path
 }
 
 // Statistics tracks detailed operation metrics
@@ -67,49 +79,156 @@ func NewStatistics() *Statistics {
 
 // DuplicateGroup represents a group of duplicate files
 type DuplicateGroup struct {
-	Hash  string
-	Size  int64
-	Files []FileHash
-	Similarity float64 // For perceptual matches
+	Hash          string
+	Size          int64
+	Files         []FileHash
+	Similarity    float64  // For perceptual matches, from the average distance between its images
+	MaxDistance   int      `json:",omitempty"` // Hamming distance of a perceptual group's least similar pair
+	Location      string   `json:",omitempty"` // How -photo-location changed the similarity, if it did
+	Unverified    bool     // Grouped by metadata only (-no-hash), content not compared
+	SuggestedName string   `json:",omitempty"` // Canonical name when the copies are name variants ("report (1).docx")
+	Tags          []string `json:",omitempty"` // Review tags ("review later", "safe") from the tag file
 }
 
 // Config holds application configuration
 type Config struct {
-	Dir            string
-	Recursive      bool
-	DryRun         bool
-	Verbose        bool
-	Workers        int
-	MinSize        int64  // Minimum file size to check (bytes)
-	MaxSize        int64  // Maximum file size to check (bytes, 0 = unlimited)
-	Interactive    bool
-	TUI            bool   // Enable TUI mode (new interactive interface)
-	MoveTo         string // Move duplicates to this folder instead of deleting
-	KeepCriteria   string // "oldest", "newest", "largest", "smallest", "first", "path"
-	HashAlgorithm  string // "sha256", "sha1", "md5"
-	FilePattern    string // Only include files matching this pattern
-	ExportReport   bool
-	ExportCSV      bool   // Export as CSV format
-	UndoLast       bool
-	NoEmoji        bool   // Disable emoji output for cleaner logs
+	Dir               stringList // Directories to scan (empty = current directory)
+	Recursive         bool
+	MaxDepth          int  // Maximum directory depth to descend (0 = unlimited)
+	SkipNetworkFS     bool // Skip NFS/SMB/FUSE mounts encountered during the walk
+	OneFilesystem     bool // Stay on each root's filesystem, skipping folders mounted from others
+	FollowSymlinks    bool // Scan what symbolic links point to, under the link's path; links are skipped otherwise
+	IncludeHidden     bool // Scan hidden files and folders (dot names; the hidden attribute on Windows)
+	IncludeSystem     bool // Scan files and folders with the Windows system attribute
+	DryRun            bool
+	Force             bool // Change files despite problems the safety lint finds in the options
+	Verbose           bool
+	Workers           int
+	ReadBuffer        int           // Bytes read per syscall while hashing (pooled per worker)
+	OnError           string        // What an unreadable file does to the run: "stop", "skip", "retry"
+	HashOrder         string        // Which files are hashed first: "savings", "size", "scan"
+	Retries           int           // How often a failing read is retried, with doubling backoff
+	Timeout           time.Duration // Give up scanning/processing after this long (0 = no limit)
+	Checkpoint        string        // Where an interrupted run saves its hashes for the next run ("" = off)
+	Resume            bool          // Fail rather than start over when there is no checkpoint to resume from
+	Journal           string        // Append-only NDJSON record of every delete, move and link ("" = off)
+	Reconcile         bool          // Settle the actions interrupted runs left unfinished in the journal, and exit
+	MinSize           int64         // Minimum file size to check (bytes)
+	MinSizeExt        sizeByExt     // Minimum sizes by extension or group, overriding MinSize
+	MaxSize           int64         // Maximum file size to check (bytes, 0 = unlimited)
+	MinAge            fileAge       // Skip files modified more recently than this (0 = no limit)
+	MaxAge            fileAge       // Skip files modified longer ago than this (0 = no limit)
+	MaxFiles          int           // Stop taking in files after this many, marking the report partial (0 = unlimited)
+	MaxBytes          int64         // Stop taking in files once they total this many bytes (0 = unlimited)
+	Interactive       bool
+	AnswersFile       string     // Pre-recorded interactive decisions (implies Interactive)
+	TUI               bool       // Enable TUI mode (new interactive interface)
+	TUIStream         bool       // Open the TUI review while hashing continues (implies TUI)
+	MoveTo            string     // Move duplicates to this folder instead of deleting
+	Link              string     // Replace duplicates with links to the kept file instead: "hardlink", "reflink" ("" = off)
+	KeepCriteria      string     // "oldest", "newest", "canonical", "largest", "smallest", "first", "path"
+	HashAlgorithm     string     // "sha256", "sha1", "md5", "xxh3", "blake3"
+	PartialHash       bool       // Compare the first and last 4KB of same-size files before hashing them in full
+	SpillDir          string     // Group files by sorting them in temporary files here instead of in memory ("" = off)
+	FilePattern       stringList // Only include files matching any of these patterns
+	IgnoreCasePattern stringList // Case-insensitive variant of FilePattern
+	Extensions        string     // Comma-separated extensions (or groups) to include
+	ExcludeExtensions string     // Comma-separated extensions (or groups) to skip
+	Exclude           stringList // Skip files and folders whose name matches any of these globs
+	ExcludeDir        stringList // Skip folders whose name matches any of these globs, or at these paths
+	NoDedupIgnore     bool       // Disregard .dedupignore files in the scanned folders
+	ExportReport      bool
+	ExportCSV         bool   // Export as CSV format
+	Audit             string // Write the JSON report and its SHA-256 checksum here, and nothing else anywhere ("" = off)
+	UndoLast          bool
+	UndoRestore       string // Quarantine batch to move back, e.g. 20240301-120000
+	UndoLog           string // Where the last run's undo log is kept ("" = off)
+	QuarantineDeletes bool   // Move deleted duplicates into the quarantine so -undo can restore them
+	Restore           bool   // Browse quarantined files and the undo log, and restore files
+	PurgeStaged       int    // Delete files staged in the quarantine or -move-to more than this many days ago, and exit
+	Estimate          bool   // Print a quick sampled estimate instead of a full scan
+	Probe             bool   // Stop at the first duplicate and exit with probeExitDuplicate
+	NoHash            bool   // Group same-size files as potential duplicates without reading content
+	SameName          bool   // With NoHash, also require matching file names
+	EstimateSample    int    // Files to hash for -estimate (0 = size+name heuristic only)
+	Bench             bool   // Measure walk, hash and perceptual hashing speed on -dir
+	NoEmoji           bool   // Disable emoji output for cleaner logs
 	// Perceptual hashing options
-	PerceptualMode bool   // Enable perceptual hashing for images
-	PHashAlgorithm string // "dhash", "ahash", "phash"
-	SimilarityThreshold int // Hamming distance threshold (0-64, default 10)
+	PerceptualMode      bool       // Enable perceptual hashing for images
+	PHashAlgorithm      string     // "dhash", "ahash", "phash"
+	PerceptualVideo     bool       // Also hash short videos by a few frames (needs ffmpeg)
+	DecodeLimit         countByExt // Most files of an extension or group decoded for perceptual hashing at once
+	NormalizeSVG        bool       // Hash SVG markup without comments, whitespace and editor metadata
+	SameDimensions      bool       // Only group perceptual matches with identical width and height
+	PhotoLocation       bool       // Raise or lower perceptual similarity by EXIF GPS position and capture time
+	Screenshots         bool       // Preset for screenshot folders, see applyScreenshotPreset
+	SimilarityMatrix    string     // Export pairwise perceptual distances to this CSV or JSON file
+	Treemap             string     // Export recoverable space per directory to this ncdu JSON or du file
+	MatrixDistance      int        // Largest distance exported for images in different groups (0 = -similarity)
+	Cluster             string     // How similar images are grouped: "greedy", "components", "centroid"
+	SimilarityThreshold int        // Hamming distance threshold (0-64, default 10)
+	SimilarityPercent   float64    `json:",omitempty"` // Threshold as a percentage of the hash length instead, see maxDistance
 	// Output options
-	JSON           bool   // Output results as JSON to stdout (for integrations)
+	JSON              bool       // Output results as JSON to stdout (for integrations)
+	SummaryJSON       bool       // Print one JSON object summing up the run to stdout when it ends
+	Robot             bool       // Line-delimited JSON command/event protocol over stdio
+	RPC               string     // JSON-RPC 2.0 endpoint: "stdio" or a unix socket path
+	Agent             bool       // Scan/hash locally and stream FileHash records to stdout (run over SSH by -remote)
+	Remotes           stringList // [user@]host:/path targets scanned by agents over SSH
+	RemoteBin         string     // Command that starts file-deduplicator on remote hosts
+	IndexServer       string     // Listen address for the multi-machine index server
+	PushIndex         string     // Index server URL to upload this machine's manifest to
+	Machine           string     // Name used with -push-index (default: host name)
+	MachineKeep       stringList // machine=always|never keep policies for the index server
+	ExportIndex       string     // Write a hash index of the scanned files to this file and exit
+	ImportIndex       stringList // Hash indexes used as the reference set: matching local files are duplicates
+	ReferenceReadOnly stringList // Directories hashed as reference copies; nothing in them is ever modified
+	KnownDB           string     // Long-lived database of every hash ever seen (empty = disabled)
+	Cache             string     // Persistent cache of content and perceptual hashes by path, size and mtime (empty = disabled)
+	CheckIntegrity    bool       // Flag files whose content hash changed while size and mtime did not (needs Cache)
+	Revalidate        bool       // Re-stat and re-hash files just before acting and leave changed ones alone
+	ClearReadOnly     bool       // Clear the Windows read-only attribute of duplicates so they can be deleted
+	Trash             bool       // Move duplicates to the system trash instead of deleting them
+	Redact            bool       // Replace private paths and names in -export, -export-csv and -json reports with pseudonyms
+	Reintroduced      bool       // List files re-introducing content deduplicated by an earlier run
+	NameVariants      bool       // List files whose names are variants of each other and exit
+	IgnoreList        string     // Groups marked "not a duplicate" (empty = ~/.config/file-deduplicator/ignored.json, "off" = none)
+	Ignore            stringList // Group hashes to add to the ignore list before exiting
+	Unignore          stringList // Group hashes to take off the ignore list before exiting
+	NotSimilar        stringList // "a.jpg,b.jpg" image pairs to record as genuinely different before exiting
+	ListIgnored       bool       // Print the ignore list and exit
+	TagFile           string     // Tags given to groups in review (empty = ~/.config/file-deduplicator/tags.json, "off" = none)
+	ImportTags        string     // Merge the tags of an earlier JSON or CSV export into the tag file
+	Tagged            string     // Only report groups carrying this tag
+	ExportDecisions   string     // Write every file with its keep/delete action to this CSV or JSON sheet
+	ApplyDecisions    string     // Carry out the keep/delete actions of an edited sheet and exit
+	ApplyReport       string     // Carry out the plan of an -export report after re-verifying its files, and exit
+	Explore           bool       // Browse disk usage with duplicate share per directory and exit
+	DirPairs          int        // Directory pairs sharing the most duplicates to report (0 = none)
 	// Theme options
-	Theme          string // "dark", "light", "auto" (default: "auto")
+	Theme string // "dark", "light", "auto" (default: "auto")
+	// Image comparison options
+	CompareImg1 string // First image (or "img1,img2") for -compare
+	CompareImg2 string // Second image for -compare-with
+	// Watch mode options
+	WatchMode      bool          // Monitor directory for new duplicates
+	WatchDebounce  time.Duration // How long each new file must be quiet before it is processed
+	WatchAutoClean bool          // Automatically clean duplicates in watch mode
+	WatchGrace     time.Duration // Wait this long before auto-cleaning, and only if the file is unchanged
+	Quarantine     string        // Where auto-clean puts files (default: <dir>/.deduplicator_quarantine)
+	WatchStatus    bool          // Print the status of the watcher running for -dir and exit
+	Control        string        // Send pause, resume or status to the run in progress for -dir
+	IntegrateShell string        // "install" or "remove" the file manager's "Find duplicates here" entry
+
+	deletes *quarantine // the batch -quarantine-deletes moves this run's files into, see NewEngine
 }
 
-var (
-	cfg        Config
-	configPath string // Path to config file
-)
+// noEmoji is -no-emoji, set once the config is loaded like the log output
+var noEmoji bool
 
-// emoji returns the emoji if NoEmoji is false, otherwise returns empty string
+// emoji returns the emoji if -no-emoji is off, otherwise returns empty string
 func emoji(e string) string {
-	if cfg.NoEmoji {
+	if noEmoji {
 		return ""
 	}
 	return e + " "
@@ -118,42 +237,139 @@ func emoji(e string) string {
 func init() {
 	// Override default usage to show categorized help
 	flag.Usage = customUsage
+}
 
-	// Config file flag (must be parsed first)
-	flag.StringVar(&configPath, "config", "", "Config file path (JSON format)")
+// registerFlags binds every Config option to fs, resetting c to the defaults
+func registerFlags(fs *flag.FlagSet, c *Config) {
+	fs.Var(&c.Dir, "dir", "Directory to scan for duplicates (default \".\"). Repeatable; roots are scanned concurrently")
+	fs.BoolVar(&c.Recursive, "recursive", true, "Scan directories recursively")
+	fs.IntVar(&c.MaxDepth, "max-depth", 0, "Maximum directory depth to descend (0 = unlimited, 1 = top level only)")
+	fs.BoolVar(&c.SkipNetworkFS, "skip-network-fs", false, "Skip network filesystems (NFS, SMB, FUSE) encountered during the scan")
+	fs.BoolVar(&c.OneFilesystem, "one-filesystem", false, "Do not cross mount points: skip folders on another filesystem than their scan root")
+	fs.BoolVar(&c.FollowSymlinks, "follow-symlinks", false, "Scan the files and folders symbolic links point to, skipping folders already scanned; links are skipped otherwise")
+	fs.BoolVar(&c.IncludeHidden, "include-hidden", false, "Scan hidden files and folders: names starting with a dot, or on Windows those with the hidden attribute")
+	fs.BoolVar(&c.IncludeSystem, "include-system", false, "Scan files and folders with the Windows system attribute, such as desktop.ini")
+	fs.BoolVar(&c.DryRun, "dry-run", false, "Show what would be deleted without actually deleting")
+	fs.BoolVar(&c.Force, "force", false, "Delete, move or link even when the options look dangerous (e.g. -move-to inside -dir)")
+	fs.BoolVar(&c.Verbose, "verbose", false, "Show detailed output")
+	fs.IntVar(&c.Workers, "workers", runtime.NumCPU(), "Number of worker goroutines")
+	fs.IntVar(&c.ReadBuffer, "read-buffer", defaultReadBuffer, "Bytes read at a time while hashing (larger = fewer syscalls for big files)")
+	fs.StringVar(&c.HashOrder, "hash-order", hashOrderSavings, "Hash first: savings (files whose duplicates could free the most space), size (the largest files) or scan (scan order, reading all -dir roots at once)")
+	fs.StringVar(&c.OnError, "on-error", onErrorSkip, "On an unreadable file: stop (abort the run), skip (report and continue), retry (read again, then skip)")
+	fs.IntVar(&c.Retries, "retries", defaultRetries, "Times a read failing with a transient error (EAGAIN, network timeout) is retried, with doubling backoff")
+	fs.DurationVar(&c.Timeout, "timeout", 0, "Stop scanning, hashing and processing after this long (e.g. 30m; 0 = no limit)")
+	fs.StringVar(&c.Checkpoint, "checkpoint", checkpointFile, "Where a run saves finished hashes as it goes, so the next run resumes if it is interrupted (empty to disable)")
+	fs.BoolVar(&c.Resume, "resume", false, "Pick up from the -checkpoint of an interrupted run, and fail rather than start over if there is none")
+	fs.StringVar(&c.Journal, "journal", journalFile, "Append every delete, move and link, before and after it happens, to this NDJSON journal (empty to disable)")
+	fs.BoolVar(&c.Reconcile, "reconcile", false, "Check the actions interrupted runs left unfinished in -journal against the disk, record what happened, and exit")
+	fs.Int64Var(&c.MinSize, "min-size", 1024, "Minimum file size in bytes (default: 1KB)")
+	fs.Var(&c.MinSizeExt, "min-size-ext", "Minimum sizes by extension or group overriding -min-size, e.g. images=51200,documents=0")
+	fs.Int64Var(&c.MaxSize, "max-size", 0, "Maximum file size in bytes (0 = unlimited)")
+	fs.Var(&c.MinAge, "min-age", "Skip files modified more recently than this, e.g. 30d, 2w or 12h")
+	fs.Var(&c.MaxAge, "max-age", "Skip files modified longer ago than this, e.g. 365d")
+	fs.IntVar(&c.MaxFiles, "max-files", 0, "Stop the scan after this many files pass the filters and report on those (0 = unlimited)")
+	fs.Int64Var(&c.MaxBytes, "max-bytes", 0, "Stop the scan once the files passing the filters total this many bytes and report on those (0 = unlimited)")
+	fs.BoolVar(&c.Interactive, "interactive", false, "Ask before deleting each duplicate (legacy mode)")
+	fs.StringVar(&c.AnswersFile, "answers", "", "File of pre-recorded interactive decisions; only uncovered groups are prompted")
+	fs.BoolVar(&c.TUI, "tui", false, "Use TUI interface for interactive deletion (recommended)")
+	fs.BoolVar(&c.TUIStream, "tui-stream", false, "Open the TUI review as soon as the first groups are confirmed, while hashing continues")
+	fs.StringVar(&c.MoveTo, "move-to", "", "Move duplicates to this folder instead of deleting")
+	fs.StringVar(&c.Link, "link", "", "Replace duplicates with links to the kept file instead of deleting: hardlink, or reflink (copy-on-write clones on btrfs, XFS, APFS)")
+	fs.StringVar(&c.KeepCriteria, "keep", "oldest", "File to keep criteria: oldest, newest, canonical, largest, smallest, first, or path:<path>")
+	fs.StringVar(&c.HashAlgorithm, "hash", "sha256", "Hash algorithm: sha256, sha1, md5, xxh3 (fastest, not cryptographic), or blake3 (fast and cryptographic)")
+	fs.BoolVar(&c.PartialHash, "partial-hash", true, "Compare the first and last 4KB of same-size files and hash in full only those still matching")
+	fs.StringVar(&c.SpillDir, "spill-dir", "", "Group files by sorting them in temporary files in this directory, keeping memory flat for tens of millions of files")
+	fs.Var(&c.FilePattern, "pattern", "File pattern to match (e.g., *.jpg, *.pdf). Repeatable")
+	fs.Var(&c.IgnoreCasePattern, "ipattern", "Case-insensitive file pattern (e.g., *.jpg also matches *.JPG). Repeatable")
+	fs.StringVar(&c.Extensions, "ext", "", "Only include these extensions (e.g., jpg,png,mp4 or images,videos)")
+	fs.StringVar(&c.ExcludeExtensions, "exclude-ext", "", "Skip these extensions (e.g., tmp,log)")
+	fs.Var(&c.Exclude, "exclude", "Skip files and folders whose name matches this glob (e.g., *.bak, node_modules). Repeatable")
+	fs.BoolVar(&c.NoDedupIgnore, "no-dedupignore", false, "Disregard the .dedupignore files in scanned folders")
+	fs.Var(&c.ExcludeDir, "exclude-dir", "Skip folders whose name matches this glob (e.g., .cache), or the folder at this path (e.g., /mnt/backup). Repeatable")
+	fs.BoolVar(&c.ExportReport, "export", false, "Export duplicate report to JSON file")
+	fs.BoolVar(&c.ExportCSV, "export-csv", false, "Export duplicate report to CSV file")
+	fs.StringVar(&c.Audit, "audit", "", "Read-only audit: write nothing but the JSON report to this file outside the scanned folders, with its SHA-256 checksum next to it")
+	fs.BoolVar(&c.Redact, "redact", false, "Replace the home directory and file and folder names in -export, -export-csv and -json reports with stable pseudonyms, for sharing")
+	fs.BoolVar(&c.UndoLast, "undo", false, "Undo last operation: restore its quarantined files, or view the log of a permanent deletion")
+	fs.StringVar(&c.UndoRestore, "undo-restore", "", "Move the files of one quarantine batch back, by its id (e.g. 20240301-120000)")
+	fs.StringVar(&c.UndoLog, "undo-log", undoFile, "Where to keep the undo log of the last run, which -undo and -restore read (empty to disable)")
+	fs.BoolVar(&c.QuarantineDeletes, "quarantine-deletes", false, "Move duplicates into a timestamped batch in the quarantine instead of deleting them, so -undo can restore them")
+	fs.BoolVar(&c.Restore, "restore", false, "Browse quarantined files and the undo log, and restore files")
+	fs.IntVar(&c.PurgeStaged, "purge-staged", 0, "Delete files moved to the quarantine or -move-to more than this many days ago, and exit (0 = off)")
+	fs.BoolVar(&c.NoHash, "no-hash", false, "Report same-size files as potential duplicates without reading content (report only)")
+	fs.BoolVar(&c.SameName, "same-name", false, "With -no-hash, also require identical file names")
+	fs.Var(matchMode{c}, "match", "What makes files duplicates: content (hash), size (like -no-hash) or name-size (like -no-hash -same-name)")
+	fs.BoolVar(&c.Probe, "probe", false, fmt.Sprintf("Stop at the first duplicate found and exit with status %d (0 when there is none); changes nothing", probeExitDuplicate))
+	fs.BoolVar(&c.Estimate, "estimate", false, "Quickly estimate duplicate ratio and recoverable space by sampling")
+	fs.BoolVar(&c.Bench, "bench", false, "Measure walk, hash and perceptual hashing speed on -dir and suggest -hash/-workers")
+	fs.IntVar(&c.EstimateSample, "estimate-sample", 1000, "Number of files to hash for -estimate (0 = size+name heuristic only)")
+	fs.BoolVar(&c.NoEmoji, "no-emoji", false, "Disable emoji output for cleaner logs")
+	fs.BoolVar(&c.JSON, "json", false, "Output results as JSON to stdout (for integrations)")
+	fs.BoolVar(&c.SummaryJSON, "summary-json", false, "Print one line of JSON summing up the run (files, groups, space, actions, errors) to stdout when it ends")
+	fs.BoolVar(&c.Robot, "robot", false, "Read commands and write events as line-delimited JSON over stdin/stdout (for GUI front-ends)")
+	fs.StringVar(&c.RPC, "rpc", "", "Serve JSON-RPC 2.0 on \"stdio\" or a unix socket path (for editor plugins and automation)")
+	fs.BoolVar(&c.Agent, "agent", false, "Scan and hash locally, streaming records as JSON lines to stdout (used by -remote)")
+	fs.Var(&c.Remotes, "remote", "Also scan [user@]host:/path over SSH with a remote agent. Repeatable")
+	fs.StringVar(&c.RemoteBin, "remote-bin", "file-deduplicator", "Command that runs file-deduplicator on -remote hosts")
+	fs.StringVar(&c.IndexServer, "index-server", "", "Collect manifests from several machines and report cross-machine duplicates, listening on this address (e.g. :7070)")
+	fs.StringVar(&c.PushIndex, "push-index", "", "Scan and upload this machine's manifest to an index server URL")
+	fs.StringVar(&c.Machine, "machine", "", "Machine name used with -push-index (default: host name)")
+	fs.Var(&c.MachineKeep, "machine-keep", "Index server keep policy as machine=always or machine=never. Repeatable")
+	fs.StringVar(&c.ExportIndex, "export-index", "", "Scan, write a hash index of every file to this file, and exit")
+	fs.StringVar(&c.KnownDB, "known-db", "", "Remember every hash ever seen, with first-seen path and date, in this database file")
+	fs.StringVar(&c.Cache, "cache", "", "Cache hashes in this file so unchanged images are not decoded again and -check-integrity can spot bit rot")
+	fs.BoolVar(&c.Revalidate, "revalidate", true, "Re-stat and re-hash each file just before deleting or moving it, and skip files that changed since the scan")
+	fs.BoolVar(&c.ClearReadOnly, "clear-readonly", false, "Clear the read-only attribute of duplicates before deleting them (Windows)")
+	fs.BoolVar(&c.Trash, "trash", false, "Move duplicates to the system trash (Trash, Recycle Bin) instead of deleting them permanently")
+	fs.BoolVar(&c.CheckIntegrity, "check-integrity", false, "Flag files whose content changed while size and mtime did not (bit rot; needs -cache)")
+	fs.BoolVar(&c.Reintroduced, "reintroduced", false, "List files whose content was deduplicated by an earlier run (uses -known-db)")
+	fs.BoolVar(&c.NameVariants, "name-variants", false, "List files whose names are variants of each other (\"report (1).docx\", \"report_final.docx\") and exit")
+	fs.StringVar(&c.IgnoreList, "ignore-list", "", "File of groups marked not a duplicate, left out of every scan (default ~/.config/file-deduplicator/ignored.json, \"off\" to disable)")
+	fs.Var(&c.Ignore, "ignore", "Mark the group with this hash (at least 8 characters) as not a duplicate and exit. Repeatable")
+	fs.Var(&c.Unignore, "unignore", "Take the group with this hash off the ignore list and exit. Repeatable")
+	fs.Var(&c.NotSimilar, "not-similar", "Record two images (\"a.jpg,b.jpg\") as genuinely different so -perceptual never groups them, and exit. Repeatable")
+	fs.BoolVar(&c.ListIgnored, "list-ignored", false, "List the groups marked not a duplicate and exit")
+	fs.StringVar(&c.TagFile, "tag-file", "", "File of group tags kept between review sessions (default ~/.config/file-deduplicator/tags.json, \"off\" to disable)")
+	fs.StringVar(&c.ImportTags, "import-tags", "", "Merge the group tags of an earlier JSON report or CSV export into the tag file")
+	fs.StringVar(&c.Tagged, "tagged", "", "Only report groups carrying this tag, e.g. \"review later\"")
+	fs.StringVar(&c.ExportDecisions, "export-decisions", "", "Write every file with its keep/delete action to this sheet (.csv or JSON) for editing")
+	fs.StringVar(&c.ApplyDecisions, "apply-decisions", "", "Carry out the keep/delete actions of an edited -export-decisions or -export-csv sheet and exit")
+	fs.StringVar(&c.ApplyReport, "apply", "", "Carry out the plan of an -export JSON report without rescanning, once its files are confirmed unchanged, and exit")
+	fs.BoolVar(&c.Explore, "explore", false, "Browse disk usage per directory with the share that is duplicates (ncdu-style) and exit")
+	fs.IntVar(&c.DirPairs, "dir-pairs", 0, "Report this many directory pairs sharing the most duplicate files (0 = off)")
+	fs.Var(&c.ReferenceReadOnly, "reference-readonly", "Also hash this directory as reference copies (e.g. an optical archive or snapshot); nothing in it is ever deleted, moved or linked. Repeatable")
+	fs.Var(&c.ImportIndex, "import-index", "Treat files listed in this hash index as originals; local copies of them are duplicates. Repeatable")
+	fs.StringVar(&c.IntegrateShell, "integrate-shell", "", "Add (install) or remove (remove) a \"Find duplicates here\" folder context-menu entry that opens the TUI, and exit")
+	fs.StringVar(&c.Theme, "theme", "auto", "Color theme: dark, light, auto (detects terminal background)")
 
-	flag.StringVar(&cfg.Dir, "dir", ".", "Directory to scan for duplicates")
-	flag.BoolVar(&cfg.Recursive, "recursive", true, "Scan directories recursively")
-	flag.BoolVar(&cfg.DryRun, "dry-run", false, "Show what would be deleted without actually deleting")
-	flag.BoolVar(&cfg.Verbose, "verbose", false, "Show detailed output")
-	flag.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "Number of worker goroutines")
-	flag.Int64Var(&cfg.MinSize, "min-size", 1024, "Minimum file size in bytes (default: 1KB)")
-	flag.Int64Var(&cfg.MaxSize, "max-size", 0, "Maximum file size in bytes (0 = unlimited)")
-	flag.BoolVar(&cfg.Interactive, "interactive", false, "Ask before deleting each duplicate (legacy mode)")
-	flag.BoolVar(&cfg.TUI, "tui", false, "Use TUI interface for interactive deletion (recommended)")
-	flag.StringVar(&cfg.MoveTo, "move-to", "", "Move duplicates to this folder instead of deleting")
-	flag.StringVar(&cfg.KeepCriteria, "keep", "oldest", "File to keep criteria: oldest, newest, largest, smallest, first, or path:<path>")
-	flag.StringVar(&cfg.HashAlgorithm, "hash", "sha256", "Hash algorithm: sha256, sha1, or md5")
-	flag.StringVar(&cfg.FilePattern, "pattern", "", "File pattern to match (e.g., *.jpg, *.pdf)")
-	flag.BoolVar(&cfg.ExportReport, "export", false, "Export duplicate report to JSON file")
-	flag.BoolVar(&cfg.ExportCSV, "export-csv", false, "Export duplicate report to CSV file")
-	flag.BoolVar(&cfg.UndoLast, "undo", false, "Undo last operation")
-	flag.BoolVar(&cfg.JSON, "json", false, "Output results as JSON to stdout (for integrations)")
-	flag.StringVar(&cfg.Theme, "theme", "auto", "Color theme: dark, light, auto (detects terminal background)")
-	
 	// Perceptual hashing flags
-	flag.BoolVar(&cfg.PerceptualMode, "perceptual", false, "Enable perceptual hashing for images (finds similar images, not just exact duplicates)")
-	flag.StringVar(&cfg.PHashAlgorithm, "phash-algo", "dhash", "Perceptual hash algorithm: dhash (fast), ahash, phash (robust)")
-	flag.IntVar(&cfg.SimilarityThreshold, "similarity", 10, "Similarity threshold (0-64). Lower = stricter. Default 10.")
+	fs.BoolVar(&c.PerceptualMode, "perceptual", false, "Enable perceptual hashing for images (finds similar images, not just exact duplicates)")
+	fs.StringVar(&c.PHashAlgorithm, "phash-algo", "dhash", "Perceptual hash algorithm: dhash (fast), ahash, phash (robust)")
+	fs.StringVar(&c.Cluster, "cluster", clusterGreedy, "How similar images are grouped: greedy, components (chains join), centroid (re-link to closest group)")
+	fs.StringVar(&c.Treemap, "treemap", "", "Export recoverable space per directory for treemap viewers (ncdu JSON if .json, du -ab otherwise)")
+	fs.StringVar(&c.SimilarityMatrix, "similarity-matrix", "", "With -perceptual, export pairwise image distances to this file (.csv or JSON)")
+	fs.IntVar(&c.MatrixDistance, "matrix-distance", 0, "Largest distance in -similarity-matrix for images not grouped together (0 = -similarity)")
+	fs.BoolVar(&c.SameDimensions, "same-dimensions", false, "With -perceptual, only group similar images of the same width and height (keeps thumbnails apart from originals)")
+	fs.BoolVar(&c.Screenshots, "screenshots", false, "Preset for screenshot folders: PNG-heavy, -perceptual with -similarity 4 and -same-dimensions, -keep newest (explicit flags win)")
+	fs.BoolVar(&c.PhotoLocation, "photo-location", false, "With -perceptual, trust photos taken at the same place and time more and photos taken far apart less (EXIF GPS)")
+	fs.BoolVar(&c.NormalizeSVG, "normalize-svg", false, "Match SVGs that differ only in whitespace, comments, attribute order or editor metadata")
+	fs.BoolVar(&c.PerceptualVideo, "perceptual-video", false, "With -perceptual, also match short videos by a few frames (needs ffmpeg)")
+	fs.Var(&c.DecodeLimit, "decode-limit", "With -perceptual, most files of an extension or group decoded at once, e.g. videos=2,gif=4")
+	c.SimilarityThreshold = 10
+	fs.Var(similarityFlag{&c.SimilarityThreshold, &c.SimilarityPercent}, "similarity", "Similarity threshold: a distance 0-64 (lower = stricter) or a percentage such as 92% (higher = stricter). Default 10.")
 
 	// Image comparison flags
-	flag.StringVar(&cfg.CompareImg1, "compare", "", "Compare two images (format: img1,img2 or use with -compare-with)")
-	flag.StringVar(&cfg.CompareImg2, "compare-with", "", "Second image for comparison (use with -compare)")
+	fs.StringVar(&c.CompareImg1, "compare", "", "Compare two images (format: img1,img2 or use with -compare-with)")
+	fs.StringVar(&c.CompareImg2, "compare-with", "", "Second image for comparison (use with -compare)")
 
 	// Watch mode flags
-	flag.BoolVar(&cfg.WatchMode, "watch", false, "Enable real-time watch mode (monitor for new duplicates)")
-	flag.DurationVar(&cfg.WatchDebounce, "watch-debounce", 2*time.Second, "Debounce interval for file events in watch mode")
-	flag.BoolVar(&cfg.WatchAutoClean, "watch-auto-clean", false, "Automatically clean duplicates in watch mode (use with caution)")
+	fs.BoolVar(&c.WatchMode, "watch", false, "Enable real-time watch mode (monitor for new duplicates)")
+	fs.DurationVar(&c.WatchDebounce, "watch-debounce", 2*time.Second, "How long each new file must go without changes before watch mode processes it")
+	fs.BoolVar(&c.WatchAutoClean, "watch-auto-clean", false, "Automatically clean duplicates in watch mode (use with caution)")
+	fs.DurationVar(&c.WatchGrace, "watch-grace", 30*time.Second, "Wait this long before auto-cleaning a duplicate; skipped if the file changes meanwhile")
+	fs.StringVar(&c.Control, "control", "", "Send pause, resume or status to the run in progress for -dir")
+	fs.BoolVar(&c.WatchStatus, "watch-status", false, "Show the status of the watcher running for -dir")
+	fs.StringVar(&c.Quarantine, "quarantine", "", "Directory auto-clean moves duplicates into (default: <dir>/"+quarantineDirName+")")
 }
 
 // customUsage prints categorized help text
@@ -165,43 +381,140 @@ func customUsage() {
 	fmt.Fprintf(os.Stderr, "  -config string\n\tConfig file path (JSON). Also checks ./.deduprc.json and ~/.config/file-deduplicator/config.json\n")
 
 	fmt.Fprintf(os.Stderr, "\nSCAN OPTIONS:\n")
-	fmt.Fprintf(os.Stderr, "  -dir string\n\tDirectory to scan (default: current directory)\n")
+	fmt.Fprintf(os.Stderr, "  -dir string\n\tDirectory to scan (default: current directory). Repeat to scan several roots concurrently\n")
 	fmt.Fprintf(os.Stderr, "  -recursive\n\tScan subdirectories (default: true)\n")
+	fmt.Fprintf(os.Stderr, "  -max-depth int\n\tLimit how many directory levels to descend (0 = unlimited, 1 = top level only)\n")
+	fmt.Fprintf(os.Stderr, "  -skip-network-fs\n\tSkip NFS/SMB/FUSE mounts instead of hashing over the network\n")
+	fmt.Fprintf(os.Stderr, "  -one-filesystem\n\tDo not cross mount points: skip folders on another filesystem than their scan root, like find -xdev\n")
+	fmt.Fprintf(os.Stderr, "  -follow-symlinks\n\tScan the files and folders symbolic links point to under the link's path; a folder reached twice, as through a loop, is scanned once (default: links are skipped)\n")
+	fmt.Fprintf(os.Stderr, "  -include-hidden\n\tAlso scan hidden files and folders (dot names; on Windows, the hidden attribute)\n")
+	fmt.Fprintf(os.Stderr, "  -include-system\n\tAlso scan Windows system files and folders\n")
 	fmt.Fprintf(os.Stderr, "  -workers int\n\tNumber of parallel workers (default: %d)\n", runtime.NumCPU())
+	fmt.Fprintf(os.Stderr, "  -read-buffer int\n\tBytes read at a time while hashing (default: %d)\n", defaultReadBuffer)
+	fmt.Fprintf(os.Stderr, "  -hash-order order\n\tHash first: savings (most space to free), size (largest files) or scan (default: savings)\n")
+	fmt.Fprintf(os.Stderr, "  -on-error policy\n\tOn an unreadable file: stop, skip or retry (default: skip)\n")
+	fmt.Fprintf(os.Stderr, "  -retries int\n\tTimes a read failing with a transient error is retried, with doubling backoff (default: %d)\n", defaultRetries)
+	fmt.Fprintf(os.Stderr, "  -timeout duration\n\tStop cleanly after this long, e.g. 30m (Ctrl-C also stops cleanly)\n")
+	fmt.Fprintf(os.Stderr, "  -checkpoint file\n\tWhere a run saves finished hashes as it goes, for the next run if it is interrupted (default: %s, empty to disable)\n", checkpointFile)
+	fmt.Fprintf(os.Stderr, "  -resume\n\tPick up from the checkpoint of an interrupted run; fail if there is none instead of starting over\n")
+	fmt.Fprintf(os.Stderr, "  -journal file\n\tNDJSON journal of every delete, move and link with its outcome (default: %s, empty to disable)\n", journalFile)
+	fmt.Fprintf(os.Stderr, "  -reconcile\n\tReport what became of actions an interrupted run left unfinished in -journal, and record it\n")
+	fmt.Fprintf(os.Stderr, "  -control command\n\tpause, resume or status of the run in progress for -dir (SIGUSR1 also toggles pausing)\n")
 	fmt.Fprintf(os.Stderr, "  -min-size int\n\tSkip files smaller than this (bytes, default: 1024)\n")
+	fmt.Fprintf(os.Stderr, "  -min-size-ext list\n\tMinimum sizes by extension or group overriding -min-size, e.g. images=51200,documents=0\n")
 	fmt.Fprintf(os.Stderr, "  -max-size int\n\tSkip files larger than this (bytes, 0 = unlimited)\n")
-	fmt.Fprintf(os.Stderr, "  -pattern string\n\tOnly match files matching this pattern (e.g., *.jpg)\n")
+	fmt.Fprintf(os.Stderr, "  -min-age duration\n\tSkip files modified more recently than this, by modification time: 30d, 2w, 12h\n")
+	fmt.Fprintf(os.Stderr, "  -max-age duration\n\tSkip files modified longer ago than this\n")
+	fmt.Fprintf(os.Stderr, "  -max-files int\n\tStop the scan after this many files pass the filters; the report is marked partial (0 = unlimited)\n")
+	fmt.Fprintf(os.Stderr, "  -max-bytes int\n\tStop the scan once the files passing the filters total this many bytes; the report is marked partial (0 = unlimited)\n")
+	fmt.Fprintf(os.Stderr, "  -pattern string\n\tOnly match files matching this pattern (e.g., *.jpg). Repeatable, any match counts\n")
+	fmt.Fprintf(os.Stderr, "  -ipattern string\n\tLike -pattern but case-insensitive. Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -ext list\n\tOnly include these extensions (e.g., jpg,png,mp4). Groups: images, videos, audio, documents, archives\n")
+	fmt.Fprintf(os.Stderr, "  -exclude-ext list\n\tSkip these extensions (e.g., tmp,log)\n")
+	fmt.Fprintf(os.Stderr, "  -exclude glob\n\tSkip files and folders whose name matches (e.g., *.bak, node_modules). Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -exclude-dir glob|path\n\tSkip folders by name (e.g., build) or path (e.g., /mnt/backup). Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -no-dedupignore\n\tDisregard .dedupignore files (gitignore syntax) in the scanned folders\n")
+	fmt.Fprintf(os.Stderr, "  -remote string\n\tAlso scan [user@]host:/path through an agent over SSH; remote copies are never modified. Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -remote-bin string\n\tCommand that runs file-deduplicator on remote hosts (default: file-deduplicator)\n")
+	fmt.Fprintf(os.Stderr, "  -agent\n\tScan and stream hashes as JSON lines on stdout (started by -remote)\n")
+	fmt.Fprintf(os.Stderr, "  -known-db file\n\tRemember every hash ever seen, with first-seen path and date\n")
+	fmt.Fprintf(os.Stderr, "  -cache file\n\tCache perceptual hashes so re-scans only decode new or changed images\n")
+	fmt.Fprintf(os.Stderr, "  -check-integrity\n\tFlag files whose content changed while size and mtime did not, using -cache\n")
+	fmt.Fprintf(os.Stderr, "  -revalidate\n\tRe-hash files just before acting and skip any that changed since the scan (default: true)\n")
+	fmt.Fprintf(os.Stderr, "  -clear-readonly\n\tClear the read-only attribute of duplicates before deleting them (Windows)\n")
+	fmt.Fprintf(os.Stderr, "  -trash\n\tMove duplicates to the system trash instead of deleting them, so they can be restored\n")
+	fmt.Fprintf(os.Stderr, "  -reintroduced\n\tList files re-introducing previously deduplicated content (default db: ~/.config/file-deduplicator/known.json)\n")
+	fmt.Fprintf(os.Stderr, "  -name-variants\n\tList files whose names are variants of each other, marking identical content, and exit\n")
+	fmt.Fprintf(os.Stderr, "  -ignore hash\n\tMark a group as not a duplicate (deliberate backups); it is left out of future scans. Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -unignore hash\n\tTake a group off the ignore list. Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -not-similar a,b\n\tRecord two look-alike images as different so perceptual mode stops grouping them. Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -list-ignored\n\tList the groups marked not a duplicate and exit\n")
+	fmt.Fprintf(os.Stderr, "  -ignore-list file\n\tWhere ignored groups are kept (default ~/.config/file-deduplicator/ignored.json, off to disable)\n")
+	fmt.Fprintf(os.Stderr, "  -tagged tag\n\tOnly report groups carrying this tag (tag groups with t in the TUI)\n")
+	fmt.Fprintf(os.Stderr, "  -import-tags file\n\tMerge the group tags of an earlier JSON report or CSV export\n")
+	fmt.Fprintf(os.Stderr, "  -tag-file file\n\tWhere group tags are kept (default ~/.config/file-deduplicator/tags.json, off to disable)\n")
+	fmt.Fprintf(os.Stderr, "  -export-decisions file\n\tWrite a keep/delete sheet (.csv or JSON) to edit in a spreadsheet\n")
+	fmt.Fprintf(os.Stderr, "  -apply-decisions file\n\tCarry out an edited keep/delete sheet (also accepts -export-csv output) and exit\n")
+	fmt.Fprintf(os.Stderr, "  -apply report.json\n\tCarry out an -export report later without rescanning; files changed since are left alone\n")
+	fmt.Fprintf(os.Stderr, "  -explore\n\tBrowse directory sizes and their duplicate share, drilling down to the groups responsible, and exit\n")
+	fmt.Fprintf(os.Stderr, "  -dir-pairs n\n\tReport the n directory pairs sharing the most duplicate files, e.g. -dir-pairs 10 (default: off)\n")
+	fmt.Fprintf(os.Stderr, "  -treemap file\n\tExport recoverable space per directory: ncdu JSON export (.json) for ncdu/gdu, or du -ab lines\n")
+	fmt.Fprintf(os.Stderr, "  -export-index file\n\tWrite a hash index of every scanned file and exit\n")
+	fmt.Fprintf(os.Stderr, "  -reference-readonly dir\n\tHash this directory as reference copies that are kept and never deleted, moved or linked. Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -import-index file\n\tUse a hash index as the reference set: local copies of its files are duplicates. Repeatable\n")
+	fmt.Fprintf(os.Stderr, "  -index-server addr\n\tCollect manifests from several machines and report cross-machine duplicates\n")
+	fmt.Fprintf(os.Stderr, "  -push-index url\n\tScan and upload this machine's manifest to an index server\n")
+	fmt.Fprintf(os.Stderr, "  -machine string\n\tName to push the manifest as (default: host name)\n")
+	fmt.Fprintf(os.Stderr, "  -machine-keep machine=policy\n\tIndex server policy: always (keep every copy there) or never (its copies are redundant). Repeatable\n")
 
 	fmt.Fprintf(os.Stderr, "\nHASH OPTIONS:\n")
-	fmt.Fprintf(os.Stderr, "  -hash string\n\tAlgorithm: sha256, sha1, md5 (default: sha256)\n")
+	fmt.Fprintf(os.Stderr, "  -hash string\n\tAlgorithm: sha256, sha1, md5, xxh3, blake3 (default: sha256)\n")
+	fmt.Fprintf(os.Stderr, "  -partial-hash\n\tCompare the first and last 4KB of same-size files and hash only those still matching (default: true)\n")
+	fmt.Fprintf(os.Stderr, "  -spill-dir dir\n\tSort sizes and hashes in temporary files here instead of memory, for tens of millions of files\n")
+
+	fmt.Fprintf(os.Stderr, "  -no-hash\n\tSize-only triage: report same-size files as potential duplicates, never deletes\n")
+	fmt.Fprintf(os.Stderr, "  -same-name\n\tWith -no-hash, also require identical file names\n")
+	fmt.Fprintf(os.Stderr, "  -match mode\n\tcontent (default), size, or name-size: same basename and size without reading any file\n")
 
 	fmt.Fprintf(os.Stderr, "\nPERCEPTUAL IMAGE MATCHING:\n")
 	fmt.Fprintf(os.Stderr, "  -perceptual\n\tFind similar images, not just exact duplicates\n")
 	fmt.Fprintf(os.Stderr, "  -phash-algo string\n\tAlgorithm: dhash, ahash, phash (default: dhash)\n")
-	fmt.Fprintf(os.Stderr, "  -similarity int\n\tThreshold 0-64, lower = stricter (default: 10)\n")
+	fmt.Fprintf(os.Stderr, "  -cluster string\n\tGrouping of similar images: greedy, components, centroid (default: greedy)\n")
+	fmt.Fprintf(os.Stderr, "  -similarity-matrix file\n\tExport pairwise image distances within groups or below -matrix-distance (.csv or JSON)\n")
+	fmt.Fprintf(os.Stderr, "  -matrix-distance int\n\tLargest distance exported for images in different groups (default: -similarity)\n")
+	fmt.Fprintf(os.Stderr, "  -same-dimensions\n\tOnly group similar images of the same width and height\n")
+	fmt.Fprintf(os.Stderr, "  -screenshots\n\tPreset for screenshot folders: %s files, -perceptual -similarity %d -same-dimensions -keep %s (explicit flags win)\n", screenshotExtensions, screenshotSimilarity, screenshotKeep)
+	fmt.Fprintf(os.Stderr, "  -photo-location\n\tRaise the similarity of photos taken at the same place and time, lower it for photos taken far apart (EXIF GPS)\n")
+	fmt.Fprintf(os.Stderr, "  -normalize-svg\n\tMatch SVGs that differ only in whitespace, comments, attribute order or editor metadata\n")
+	fmt.Fprintf(os.Stderr, "  -perceptual-video\n\tAlso match short videos by a few frames (needs ffmpeg on PATH)\n")
+	fmt.Fprintf(os.Stderr, "  -decode-limit list\n\tMost files of an extension or group decoded at once, e.g. videos=2,gif=4, so they leave workers for hashing\n")
+	fmt.Fprintf(os.Stderr, "  -similarity value\n\tThreshold as a distance 0-64, lower = stricter, or a percentage such as 92%%, higher = stricter (default: 10)\n")
 	fmt.Fprintf(os.Stderr, "  -compare img1,img2\n\tCompare two specific images\n")
 	fmt.Fprintf(os.Stderr, "  -compare-with string\n\tSecond image (alternative to comma syntax)\n")
 
 	fmt.Fprintf(os.Stderr, "\nACTION OPTIONS:\n")
 	fmt.Fprintf(os.Stderr, "  -dry-run\n\tPreview what would be deleted (no changes made)\n")
+	fmt.Fprintf(os.Stderr, "  -force\n\tGo ahead despite the safety checks run before changing files (-move-to inside -dir, -keep path: matching nothing, ...)\n")
 	fmt.Fprintf(os.Stderr, "  -tui\n\tUse TUI interface for interactive deletion (recommended)\n")
+	fmt.Fprintf(os.Stderr, "  -tui-stream\n\tOpen the TUI review as soon as the first groups are confirmed; new groups are added while hashing continues\n")
 	fmt.Fprintf(os.Stderr, "  -interactive\n\tAsk before deleting each file (legacy mode)\n")
+	fmt.Fprintf(os.Stderr, "  -answers file\n\tApply pre-recorded decisions (by group hash or path), prompting only for the rest\n")
 	fmt.Fprintf(os.Stderr, "  -move-to string\n\tMove duplicates to folder instead of deleting\n")
-	fmt.Fprintf(os.Stderr, "  -keep string\n\tWhich file to keep: oldest, newest, largest, smallest, path:<pattern> (default: oldest)\n")
+	fmt.Fprintf(os.Stderr, "  -link mode\n\thardlink: replace duplicates with hard links to the kept file (same volume; NTFS on Windows)\n\treflink: replace them with copy-on-write clones that stay separate files (btrfs, XFS, APFS)\n")
+	fmt.Fprintf(os.Stderr, "  -keep string\n\tWhich file to keep: oldest, newest, canonical, largest, smallest, path:<pattern> (default: oldest)\n\tcanonical keeps the oldest file whose name has no copy marker such as \"(1)\" or \"_copy\"\n")
 
 	fmt.Fprintf(os.Stderr, "\nOUTPUT OPTIONS:\n")
 	fmt.Fprintf(os.Stderr, "  -verbose\n\tShow detailed progress\n")
 	fmt.Fprintf(os.Stderr, "  -export\n\tExport JSON report of duplicates found\n")
 	fmt.Fprintf(os.Stderr, "  -export-csv\n\tExport CSV report of duplicates found\n")
+	fmt.Fprintf(os.Stderr, "  -audit file\n\tRead-only audit: change, cache and log nothing, and write only the JSON report to file (outside the scanned folders) and its checksum to file.sha256\n")
+	fmt.Fprintf(os.Stderr, "  -redact\n\tReplace the home directory and file and folder names in exported reports with stable pseudonyms, keeping sizes, hashes and structure\n")
 	fmt.Fprintf(os.Stderr, "  -no-emoji\n\tPlain text output (no emoji)\n")
+	fmt.Fprintf(os.Stderr, "  -json\n\tPrint the duplicate report as JSON to stdout\n")
+	fmt.Fprintf(os.Stderr, "  -summary-json\n\tPrint one line of JSON summing up the run to stdout when it ends\n")
+	fmt.Fprintf(os.Stderr, "  -robot\n\tLine-delimited JSON commands on stdin, events on stdout (for embedding)\n")
+	fmt.Fprintf(os.Stderr, "  -rpc string\n\tServe JSON-RPC 2.0 on \"stdio\" or a unix socket path\n")
 
 	fmt.Fprintf(os.Stderr, "\nUTILITY:\n")
-	fmt.Fprintf(os.Stderr, "  -undo\n\tView log of last deletion operation\n")
+	fmt.Fprintf(os.Stderr, "  -undo\n\tRestore the files the last run quarantined, or view its log if it deleted them permanently\n")
+	fmt.Fprintf(os.Stderr, "  -undo-restore id\n\tMove the files of one quarantine batch back (the id is its folder name, e.g. 20240301-120000)\n")
+	fmt.Fprintf(os.Stderr, "  -undo-log file\n\tWhere the undo log of the last run is kept (default: %s, empty to disable)\n", undoFile)
+	fmt.Fprintf(os.Stderr, "  -quarantine-deletes\n\tMove duplicates into a timestamped batch in -quarantine instead of deleting them, keeping their paths, so -undo can put them back\n")
+	fmt.Fprintf(os.Stderr, "  -restore\n\tBrowse quarantined files and the undo log in a TUI, and restore files or whole operations\n")
+	fmt.Fprintf(os.Stderr, "  -purge-staged days\n\tDelete files moved to the quarantine or -move-to more than this many days ago, freeing their space\n")
+	fmt.Fprintf(os.Stderr, "  -probe\n\tStop at the first duplicate (of -import-index or -reference-readonly copies, if given) and exit with status %d, or 0 if there is none\n", probeExitDuplicate)
+	fmt.Fprintf(os.Stderr, "  -estimate\n\tFast approximation of duplicate ratio and recoverable space\n")
+	fmt.Fprintf(os.Stderr, "  -estimate-sample int\n\tFiles to hash for -estimate, 0 = size+name only (default: 1000)\n")
+	fmt.Fprintf(os.Stderr, "  -integrate-shell install|remove\n\tAdd or remove a \"%s\" folder entry in Explorer or Nautilus (and other XDG file managers) that opens the TUI\n", shellMenuLabel)
+	fmt.Fprintf(os.Stderr, "  -bench\n\tMeasure walk, hash and perceptual hashing speed on -dir and suggest -hash/-workers\n")
 
 	fmt.Fprintf(os.Stderr, "\nWATCH MODE:\n")
 	fmt.Fprintf(os.Stderr, "  -watch\n\tMonitor directory for new files and detect duplicates in real-time\n")
-	fmt.Fprintf(os.Stderr, "  -watch-debounce duration\n\tDebounce interval for file events (default: 2s)\n")
-	fmt.Fprintf(os.Stderr, "  -watch-auto-clean\n\tAutomatically clean duplicates in watch mode (dangerous!)\n")
+	fmt.Fprintf(os.Stderr, "  -watch-debounce duration\n\tHow long each new file must go without changes before it is processed (default: 2s)\n")
+	fmt.Fprintf(os.Stderr, "  -watch-auto-clean\n\tAutomatically clean duplicates in watch mode (dangerous!); with -dry-run only log them\n")
+	fmt.Fprintf(os.Stderr, "  -watch-grace duration\n\tWait before auto-cleaning; files that change meanwhile are kept (default: 30s)\n")
+	fmt.Fprintf(os.Stderr, "  -watch-status\n\tShow tracked files, duplicates and last event of the watcher running for -dir\n")
+	fmt.Fprintf(os.Stderr, "  -quarantine dir\n\tWhere auto-clean moves duplicates (default: <dir>/%s)\n", quarantineDirName)
 
 	fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
 	fmt.Fprintf(os.Stderr, "  file-deduplicator -dir ~/Photos -dry-run\n")
@@ -209,11 +522,12 @@ func customUsage() {
 	fmt.Fprintf(os.Stderr, "  file-deduplicator -dir ~/Photos -perceptual -similarity 8\n")
 	fmt.Fprintf(os.Stderr, "  file-deduplicator -compare photo1.jpg,photo2.jpg\n")
 	fmt.Fprintf(os.Stderr, "  file-deduplicator -dir ~/Downloads -watch\n")
+	fmt.Fprintf(os.Stderr, "  file-deduplicator -dir /mnt/archive -estimate\n")
 }
 
-// loadConfig loads configuration from a JSON file.
+// loadConfig merges a JSON config file into cfg.
 // Precedence: explicit --config > ./.deduprc.json > ~/.config/file-deduplicator/config.json
-func loadConfig() error {
+func loadConfig(c *Config, configPath string) error {
 	// Determine which config file to load
 	var configFile string
 	if configPath != "" {
@@ -255,49 +569,109 @@ func loadConfig() error {
 	// Merge config: only override defaults, respect explicitly set flags
 	// Since we can't detect which flags were explicitly set with standard flag pkg,
 	// we apply config values only if they differ from zero values and flags are at defaults
-	if fileCfg.Dir != "" && cfg.Dir == "." {
-		cfg.Dir = fileCfg.Dir
+	if len(fileCfg.Dir) > 0 && len(c.Dir) == 0 {
+		c.Dir = fileCfg.Dir
+	}
+	if fileCfg.Workers != 0 && c.Workers == runtime.NumCPU() {
+		c.Workers = fileCfg.Workers
+	}
+	if fileCfg.MinSize != 0 && c.MinSize == 1024 {
+		c.MinSize = fileCfg.MinSize
+	}
+	if len(fileCfg.MinSizeExt) > 0 && len(c.MinSizeExt) == 0 {
+		c.MinSizeExt = fileCfg.MinSizeExt
+	}
+	if len(fileCfg.DecodeLimit) > 0 && len(c.DecodeLimit) == 0 {
+		c.DecodeLimit = fileCfg.DecodeLimit
 	}
-	if fileCfg.Workers != 0 && cfg.Workers == runtime.NumCPU() {
-		cfg.Workers = fileCfg.Workers
+	if fileCfg.MaxSize != 0 && c.MaxSize == 0 {
+		c.MaxSize = fileCfg.MaxSize
 	}
-	if fileCfg.MinSize != 0 && cfg.MinSize == 1024 {
-		cfg.MinSize = fileCfg.MinSize
+	if fileCfg.MinAge != 0 && c.MinAge == 0 {
+		c.MinAge = fileCfg.MinAge
 	}
-	if fileCfg.MaxSize != 0 && cfg.MaxSize == 0 {
-		cfg.MaxSize = fileCfg.MaxSize
+	if fileCfg.MaxAge != 0 && c.MaxAge == 0 {
+		c.MaxAge = fileCfg.MaxAge
 	}
-	if fileCfg.HashAlgorithm != "" && cfg.HashAlgorithm == "sha256" {
-		cfg.HashAlgorithm = fileCfg.HashAlgorithm
+	if fileCfg.MaxDepth != 0 && c.MaxDepth == 0 {
+		c.MaxDepth = fileCfg.MaxDepth
 	}
-	if fileCfg.KeepCriteria != "" && cfg.KeepCriteria == "oldest" {
-		cfg.KeepCriteria = fileCfg.KeepCriteria
+	if fileCfg.MaxFiles != 0 && c.MaxFiles == 0 {
+		c.MaxFiles = fileCfg.MaxFiles
 	}
-	if fileCfg.PHashAlgorithm != "" && cfg.PHashAlgorithm == "dhash" {
-		cfg.PHashAlgorithm = fileCfg.PHashAlgorithm
+	if fileCfg.MaxBytes != 0 && c.MaxBytes == 0 {
+		c.MaxBytes = fileCfg.MaxBytes
 	}
-	if fileCfg.SimilarityThreshold != 0 && cfg.SimilarityThreshold == 10 {
-		cfg.SimilarityThreshold = fileCfg.SimilarityThreshold
+	if fileCfg.HashAlgorithm != "" && c.HashAlgorithm == "sha256" {
+		c.HashAlgorithm = fileCfg.HashAlgorithm
+	}
+	if fileCfg.HashOrder != "" && c.HashOrder == hashOrderSavings {
+		c.HashOrder = fileCfg.HashOrder
+	}
+	if fileCfg.SpillDir != "" && c.SpillDir == "" {
+		c.SpillDir = fileCfg.SpillDir
+	}
+	if fileCfg.KeepCriteria != "" && c.KeepCriteria == "oldest" {
+		c.KeepCriteria = fileCfg.KeepCriteria
+	}
+	if fileCfg.PHashAlgorithm != "" && c.PHashAlgorithm == "dhash" {
+		c.PHashAlgorithm = fileCfg.PHashAlgorithm
+	}
+	if fileCfg.SimilarityThreshold != 0 && c.SimilarityThreshold == 10 && c.SimilarityPercent == 0 {
+		c.SimilarityThreshold = fileCfg.SimilarityThreshold
+	}
+	if fileCfg.SimilarityPercent != 0 && c.SimilarityThreshold == 10 && c.SimilarityPercent == 0 {
+		c.SimilarityPercent = fileCfg.SimilarityPercent
 	}
 	if fileCfg.MoveTo != "" {
-		cfg.MoveTo = fileCfg.MoveTo
+		c.MoveTo = fileCfg.MoveTo
+	}
+	if fileCfg.AnswersFile != "" && c.AnswersFile == "" {
+		c.AnswersFile = fileCfg.AnswersFile
+	}
+	if fileCfg.Audit != "" && c.Audit == "" {
+		c.Audit = fileCfg.Audit
+	}
+	if len(fileCfg.FilePattern) > 0 && len(c.FilePattern) == 0 {
+		c.FilePattern = fileCfg.FilePattern
+	}
+	if len(fileCfg.IgnoreCasePattern) > 0 && len(c.IgnoreCasePattern) == 0 {
+		c.IgnoreCasePattern = fileCfg.IgnoreCasePattern
+	}
+	if fileCfg.Extensions != "" && c.Extensions == "" {
+		c.Extensions = fileCfg.Extensions
 	}
-	if fileCfg.FilePattern != "" {
-		cfg.FilePattern = fileCfg.FilePattern
+	if fileCfg.ExcludeExtensions != "" && c.ExcludeExtensions == "" {
+		c.ExcludeExtensions = fileCfg.ExcludeExtensions
+	}
+	if len(fileCfg.Exclude) > 0 && len(c.Exclude) == 0 {
+		c.Exclude = fileCfg.Exclude
+	}
+	if len(fileCfg.ExcludeDir) > 0 && len(c.ExcludeDir) == 0 {
+		c.ExcludeDir = fileCfg.ExcludeDir
 	}
 
 	// Boolean flags - use file values if not explicitly set (we assume explicit if different from default)
 	// This is a simplification; for full control, flags should override config
-	// cfg.Recursive = fileCfg.Recursive || cfg.Recursive
-	cfg.DryRun = fileCfg.DryRun || cfg.DryRun
-	cfg.Verbose = fileCfg.Verbose || cfg.Verbose
-	cfg.Interactive = fileCfg.Interactive || cfg.Interactive
-	cfg.ExportReport = fileCfg.ExportReport || cfg.ExportReport
-	cfg.NoEmoji = fileCfg.NoEmoji || cfg.NoEmoji
-	cfg.PerceptualMode = fileCfg.PerceptualMode || cfg.PerceptualMode
-	cfg.UndoLast = fileCfg.UndoLast || cfg.UndoLast
-
-	if cfg.Verbose {
+	// c.Recursive = fileCfg.Recursive || c.Recursive
+	c.DryRun = fileCfg.DryRun || c.DryRun
+	c.Verbose = fileCfg.Verbose || c.Verbose
+	c.Interactive = fileCfg.Interactive || c.Interactive
+	c.ExportReport = fileCfg.ExportReport || c.ExportReport
+	c.SummaryJSON = fileCfg.SummaryJSON || c.SummaryJSON
+	c.NoEmoji = fileCfg.NoEmoji || c.NoEmoji
+	c.PerceptualMode = fileCfg.PerceptualMode || c.PerceptualMode
+	c.Screenshots = fileCfg.Screenshots || c.Screenshots
+	c.SkipNetworkFS = fileCfg.SkipNetworkFS || c.SkipNetworkFS
+	c.OneFilesystem = fileCfg.OneFilesystem || c.OneFilesystem
+	c.FollowSymlinks = fileCfg.FollowSymlinks || c.FollowSymlinks
+	c.IncludeHidden = fileCfg.IncludeHidden || c.IncludeHidden
+	c.IncludeSystem = fileCfg.IncludeSystem || c.IncludeSystem
+	c.NoDedupIgnore = fileCfg.NoDedupIgnore || c.NoDedupIgnore
+	c.UndoLast = fileCfg.UndoLast || c.UndoLast
+	c.QuarantineDeletes = fileCfg.QuarantineDeletes || c.QuarantineDeletes
+
+	if c.Verbose {
 		log.Printf("📄 Loaded config from: %s", configFile)
 	}
 
@@ -305,11 +679,17 @@ func loadConfig() error {
 }
 
 func main() {
+	var cfg Config
+	var configPath string
+	// Config file flag (must be parsed first)
+	flag.StringVar(&configPath, "config", "", "Config file path (JSON format)")
+	registerFlags(flag.CommandLine, &cfg)
+
 	// Load persisted config (theme preference)
-	loadConfig()
+	loadPersistedConfig(&cfg)
 
-	// Detect if double-clicked vs run from CLI
-	if isDoubleClick() && os.Getenv("_DEDUP_SPAWNED") != "1" {
+	// Detect if double-clicked vs run from CLI (double-clicks never pass arguments)
+	if len(os.Args) == 1 && isDoubleClick() && os.Getenv("_DEDUP_SPAWNED") != "1" {
 		// Double-clicked: spawn terminal with TUI and exit
 		if err := spawnTerminal(); err != nil {
 			fmt.Fprintf(os.Stderr, "⚠️  Failed to spawn terminal: %v\n", err)
@@ -327,6 +707,38 @@ func main() {
 
 	flag.Parse()
 
+	// Load config file (explicit -config, ./.deduprc.json or global config)
+	if err := loadConfig(&cfg, configPath); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	noEmoji = cfg.NoEmoji
+
+	// An answers file only makes sense with the interactive prompt
+	if cfg.AnswersFile != "" {
+		cfg.Interactive = true
+	}
+	if cfg.TUIStream {
+		cfg.TUI = true
+	}
+	if cfg.Screenshots {
+		applyScreenshotPreset(&cfg, isFlagSet)
+	}
+
+	// An audit writes its report and nothing else
+	if err := setupAudit(&cfg); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// -reintroduced needs a database; fall back to the per-user one
+	if cfg.Reintroduced && cfg.KnownDB == "" {
+		cfg.KnownDB = defaultKnownDB()
+	}
+
+	// Agents write records to stdout, so keep human output off
+	if cfg.Agent {
+		cfg.JSON = true
+	}
+
 	// Handle JSON output mode
 	if cfg.JSON {
 		// Suppress all logging for clean JSON output
@@ -334,9 +746,95 @@ func main() {
 		cfg.Verbose = false
 	}
 
+	if err := checkClusterStrategy(cfg.Cluster); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := checkOnError(cfg.OnError); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := checkHashOrder(cfg.HashOrder); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if cfg.MaxDepth < 0 {
+		log.Fatalf("❌ -max-depth must be 0 (no limit) or more, not %d", cfg.MaxDepth)
+	}
+	if cfg.MaxFiles < 0 || cfg.MaxBytes < 0 {
+		log.Fatalf("❌ -max-files and -max-bytes must be 0 (no limit) or more")
+	}
+	if cfg.MaxAge > 0 && cfg.MinAge > cfg.MaxAge {
+		log.Fatalf("❌ -min-age %s is more than -max-age %s, so no file could match", cfg.MinAge.String(), cfg.MaxAge.String())
+	}
+	if cfg.MinAge > 0 && cfg.WatchMode {
+		log.Fatalf("❌ -min-age leaves out every file as it arrives, so -watch would never see a new one")
+	}
+	if err := checkLinkMode(cfg.Link); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := checkExcludes(cfg); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := checkHashAlgorithm(cfg.HashAlgorithm); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := checkShellAction(cfg.IntegrateShell); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if err := checkReferenceReadOnly(cfg); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if cfg.Link != "" && cfg.MoveTo != "" {
+		log.Fatalf("❌ -link and -move-to are different actions; choose one")
+	}
+	if cfg.Trash && (cfg.Link != "" || cfg.MoveTo != "") {
+		log.Fatalf("❌ -trash replaces deletion and cannot be combined with -link or -move-to")
+	}
+	if cfg.QuarantineDeletes && (cfg.Trash || cfg.Link != "" || cfg.MoveTo != "") {
+		log.Fatalf("❌ -quarantine-deletes replaces deletion and cannot be combined with -trash, -link or -move-to")
+	}
+	if cfg.CheckIntegrity && cfg.Cache == "" {
+		log.Fatalf("❌ -check-integrity compares against hashes from earlier runs and needs -cache")
+	}
+	if cfg.PurgeStaged < 0 {
+		log.Fatalf("❌ -purge-staged must be a number of days")
+	}
+	if cfg.Resume && cfg.Checkpoint == "" {
+		log.Fatalf("❌ -resume needs a -checkpoint to resume from")
+	}
+	if cfg.Resume && cfg.SpillDir != "" {
+		log.Fatalf("❌ -resume cannot be used with -spill-dir, whose scans keep no checkpoint")
+	}
+	if cfg.SpillDir != "" {
+		if info, err := os.Stat(cfg.SpillDir); err != nil || !info.IsDir() {
+			log.Fatalf("❌ -spill-dir %s is not a directory", cfg.SpillDir)
+		}
+	}
+
+	// Handle file manager integration
+	if cfg.IntegrateShell != "" {
+		if err := runIntegrateShell(cfg.IntegrateShell); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// A -tui run started from a file manager's context menu has no
+	// terminal yet: open one with the same options
+	if launchedWithoutTerminal(cfg) {
+		if err := spawnTerminal(os.Args[1:]...); err != nil {
+			log.Fatalf("❌ Failed to open a terminal for the TUI: %v", err)
+		}
+		return
+	}
+
 	// Handle undo
+	if cfg.UndoRestore != "" {
+		if err := undoRestore(cfg.quarantineDir(), cfg.UndoRestore); err != nil {
+			log.Fatalf("❌ Error undoing: %v", err)
+		}
+		return
+	}
 	if cfg.UndoLast {
-		if err := undoLast(); err != nil {
+		if err := undoLast(cfg.UndoLog); err != nil {
 			if !cfg.JSON {
 				log.Fatalf("❌ Error undoing: %v", err)
 			} else {
@@ -347,18 +845,139 @@ func main() {
 		return
 	}
 
+	// Handle ignore list changes
+	if len(cfg.Ignore) > 0 || len(cfg.Unignore) > 0 || len(cfg.NotSimilar) > 0 || cfg.ListIgnored {
+		if err := updateIgnoreList(cfg); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Handle restore browser
+	if cfg.Restore {
+		if err := runRestore(cfg); err != nil {
+			log.Fatalf("❌ Error restoring: %v", err)
+		}
+		return
+	}
+
+	// Handle reconciliation of interrupted actions
+	if cfg.Reconcile {
+		if err := runReconcile(cfg); err != nil {
+			log.Fatalf("❌ Error reconciling: %v", err)
+		}
+		return
+	}
+
+	// Handle purging of staged files
+	if cfg.PurgeStaged > 0 {
+		if err := runPurge(cfg); err != nil {
+			log.Fatalf("❌ Error purging: %v", err)
+		}
+		return
+	}
+
+	// Handle robot (embedded front-end) mode
+	if cfg.Robot {
+		if err := runRobot(cfg, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "{\"error\": \"%v\"}\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle JSON-RPC mode
+	if cfg.RPC != "" {
+		if err := runRPC(cfg, cfg.RPC); err != nil {
+			fmt.Fprintf(os.Stderr, "{\"error\": \"%v\"}\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle image comparison
+	if cfg.CompareImg1 != "" {
+		if err := compareImagesCLI(cfg); err != nil {
+			log.Fatalf("❌ Error comparing images: %v", err)
+		}
+		return
+	}
+
+	// Handle pause/resume of a run in progress
+	if cfg.Control != "" {
+		dir, err := filepath.Abs(cfg.roots()[0])
+		if err != nil {
+			log.Fatalf("❌ Cannot resolve directory: %v", err)
+		}
+		reply, err := sendControl(dir, cfg.Control)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Printf("Run %d on %s is %s\n", reply.PID, dir, map[bool]string{true: "paused", false: "running"}[reply.Paused])
+		return
+	}
+
+	// Handle status query of a running watcher
+	if cfg.WatchStatus {
+		if err := runWatchStatus(cfg); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Handle watch mode
+	if cfg.WatchMode {
+		if err := runWatchMode(cfg); err != nil {
+			log.Fatalf("❌ Watch mode error: %v", err)
+		}
+		return
+	}
+
 	// Skip logging setup in JSON mode
 	if !cfg.JSON {
 		log.SetFlags(log.Ltime)
 		log.Printf("🔍 File Deduplicator v%s - Starting...", version)
 		if cfg.Verbose {
-			log.Printf("📁 Scanning directory: %s", cfg.Dir)
+			log.Printf("📁 Scanning directory: %s", strings.Join(cfg.roots(), ", "))
 			log.Printf("🔄 Recursive: %v", cfg.Recursive)
+			if cfg.MaxDepth > 0 {
+				log.Printf("📐 Max depth: %d", cfg.MaxDepth)
+			}
+			if cfg.MaxFiles > 0 {
+				log.Printf("🧮 Max files: %d", cfg.MaxFiles)
+			}
+			if cfg.MaxBytes > 0 {
+				log.Printf("🧮 Max bytes: %s", formatBytes(cfg.MaxBytes))
+			}
 			log.Printf("👷 Workers: %d", cfg.Workers)
 			log.Printf("📏 Min size: %d bytes", cfg.MinSize)
+			if len(cfg.MinSizeExt) > 0 {
+				log.Printf("📏 Min size by type: %s", cfg.MinSizeExt.String())
+			}
+			if cfg.MinAge > 0 {
+				log.Printf("🕰️  Min age: %s", cfg.MinAge.String())
+			}
+			if cfg.MaxAge > 0 {
+				log.Printf("🕰️  Max age: %s", cfg.MaxAge.String())
+			}
 			log.Printf("🔐 Hash algorithm: %s", cfg.HashAlgorithm)
-			if cfg.FilePattern != "" {
-				log.Printf("🎯 File pattern: %s", cfg.FilePattern)
+			if len(cfg.FilePattern) > 0 {
+				log.Printf("🎯 File pattern: %s", cfg.FilePattern.String())
+			}
+			if len(cfg.IgnoreCasePattern) > 0 {
+				log.Printf("🎯 File pattern (ignore case): %s", cfg.IgnoreCasePattern.String())
+			}
+			if cfg.Extensions != "" {
+				log.Printf("🎯 Extensions: %s", cfg.Extensions)
+			}
+			if cfg.ExcludeExtensions != "" {
+				log.Printf("🚫 Excluded extensions: %s", cfg.ExcludeExtensions)
+			}
+			if len(cfg.Exclude) > 0 {
+				log.Printf("🚫 Excluded names: %s", cfg.Exclude.String())
+			}
+			if len(cfg.ExcludeDir) > 0 {
+				log.Printf("🚫 Excluded folders: %s", cfg.ExcludeDir.String())
 			}
 			if cfg.MoveTo != "" {
 				log.Printf("📦 Move duplicates to: %s", cfg.MoveTo)
@@ -371,118 +990,250 @@ func main() {
 				log.Printf("🖥️  TUI mode enabled")
 			}
 			if cfg.PerceptualMode {
-				log.Printf("🖼️  Perceptual mode enabled (%s, threshold: %d)", cfg.PHashAlgorithm, cfg.SimilarityThreshold)
+				log.Printf("🖼️  Perceptual mode enabled (%s, threshold: %s)", cfg.PHashAlgorithm, cfg.thresholdLabel())
+				if len(cfg.DecodeLimit) > 0 {
+					log.Printf("🖼️  Decode limits: %s", cfg.DecodeLimit.String())
+				}
+			}
+			if cfg.NoHash {
+				log.Printf("📏 Size-only mode (same name: %v, low confidence: nothing is read)", cfg.SameName)
 			}
 		}
 	}
 
-	startTime := time.Now()
-
-	// Scan files
-	files, err := scanFiles(cfg.Dir, cfg.Recursive)
-	if err != nil {
+	// Ctrl-C or -timeout cancels scanning, hashing and processing. A second
+	// Ctrl-C falls back to the default handler and exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	go func() {
+		<-ctx.Done()
+		stop()
 		if !cfg.JSON {
-			log.Fatalf("❌ Error scanning files: %v", err)
+			log.Printf("%sStopping: %v", emoji("⚠️"), ctx.Err())
+		}
+	}()
+
+	// SIGUSR1 or -control pause/resume hold hashing without losing progress
+	gate := newPauseGate()
+	ctx = withPauseGate(ctx, gate)
+	pauseSignals := make(chan os.Signal, 1)
+	notifyPauseSignal(pauseSignals)
+	go func() {
+		for range pauseSignals {
+			logPauseState(gate.toggle())
+		}
+	}()
+	if controlDir, err := filepath.Abs(cfg.roots()[0]); err == nil && cfg.Audit == "" {
+		if stopControl, err := serveControl(gate, controlDir); err != nil {
+			if cfg.Verbose {
+				log.Printf("%sControl socket unavailable: %v", emoji("⚠️"), err)
+			}
 		} else {
-			fmt.Fprintf(os.Stderr, "{\"error\": \"failed to scan files: %v\"}\n", err)
+			defer stopControl()
+		}
+	}
+
+	summary := newRunSummary(cfg)
+	engine := NewEngine(cfg, summary.events())
+
+	// Handle remote agent mode (started over SSH by -remote)
+	if cfg.Agent {
+		if err := engine.runAgent(ctx, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "{\"error\": \"%v\"}\n", err)
 			os.Exit(1)
 		}
+		return
 	}
 
-	if !cfg.JSON {
-		log.Printf("📊 Found %d files", len(files))
+	// Handle re-introduced duplicate check
+	if cfg.Reintroduced {
+		if err := engine.runReintroduced(ctx); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
 	}
 
-	// Filter by minimum size
-	var filteredFiles []string
-	for _, file := range files {
-		info, err := os.Stat(file)
+	// Handle name variant report
+	if cfg.NameVariants {
+		if err := engine.runNameVariants(ctx); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Handle edited decision sheets
+	if cfg.ApplyDecisions != "" {
+		if err := engine.applyDecisions(ctx, cfg.ApplyDecisions); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Handle reports reviewed before acting
+	if cfg.ApplyReport != "" {
+		if err := engine.applyReport(ctx, cfg.ApplyReport, isFlagSet("keep")); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Handle disk usage explorer
+	if cfg.Explore {
+		if err := engine.runExplore(ctx); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Handle hash index export
+	if cfg.ExportIndex != "" {
+		if err := engine.exportIndex(ctx, cfg.ExportIndex); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Handle multi-machine index server
+	if cfg.IndexServer != "" {
+		if err := runIndexServer(ctx, cfg, cfg.IndexServer); err != nil {
+			log.Fatalf("❌ Index server error: %v", err)
+		}
+		return
+	}
+
+	// Handle manifest upload to an index server
+	if cfg.PushIndex != "" {
+		if err := engine.pushIndex(ctx, cfg.PushIndex, cfg.Machine); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// Handle benchmark
+	if cfg.Bench {
+		if err := engine.runBench(ctx); err != nil {
+			log.Fatalf("❌ Error benchmarking: %v", err)
+		}
+		return
+	}
+
+	// Handle duplicate probe
+	if cfg.Probe {
+		match, err := engine.runProbe(ctx)
 		if err != nil {
-			if cfg.Verbose {
-				log.Printf("%sCould not stat %s: %v", emoji("⚠️"), file, err)
-			}
-			continue
+			log.Fatalf("❌ %v", err)
 		}
-		size := info.Size()
-		if size >= cfg.MinSize && (cfg.MaxSize == 0 || size <= cfg.MaxSize) {
-			// Filter by file pattern if specified
-			if cfg.FilePattern != "" {
-				matched, err := filepath.Match(cfg.FilePattern, filepath.Base(file))
-				if err != nil {
-					if !cfg.JSON {
-						log.Printf("⚠️  Invalid pattern %s: %v", cfg.FilePattern, err)
-					}
-					continue
-				}
-				if !matched {
-					if cfg.Verbose {
-						log.Printf("%sSkipping non-matching file: %s", emoji("🚫"), file)
-					}
-					continue
-				}
-			}
-			filteredFiles = append(filteredFiles, file)
-		} else {
-			if cfg.Verbose {
-				if size < cfg.MinSize {
-					log.Printf("%sSkipping small file: %s (%d bytes < %d)", emoji("🚫"), file, size, cfg.MinSize)
-				} else if cfg.MaxSize > 0 && size > cfg.MaxSize {
-					log.Printf("%sSkipping large file: %s (%d bytes > %d)", emoji("🚫"), file, size, cfg.MaxSize)
-				}
+		if err := printProbe(match, cfg.JSON); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if match != nil {
+			os.Exit(probeExitDuplicate)
+		}
+		return
+	}
+
+	// Handle quick estimate
+	if cfg.Estimate {
+		if err := engine.runEstimate(ctx); err != nil {
+			log.Fatalf("❌ Error estimating: %v", err)
+		}
+		return
+	}
+
+	startTime := time.Now()
+
+	// Catch dangerous option combinations before files are changed
+	if !cfg.DryRun && !cfg.JSON && !cfg.NoHash {
+		if err := cfg.checkLint(cfg.lintConfig()); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+
+	// Handle a TUI review that opens while hashing continues
+	if cfg.TUIStream && !cfg.DryRun && !cfg.JSON {
+		if engine.streamable() {
+			if err := engine.runStreamingReview(ctx); err != nil {
+				summary.print(engine, err)
+				log.Fatalf("❌ %v", err)
 			}
+			summary.print(engine, nil)
+			log.Printf("%sComplete in %v", emoji("✅"), time.Since(startTime))
+			return
 		}
+		log.Printf("%s-tui-stream needs exact matching without -known-db, -import-index or -remote; the review opens after the scan", emoji("⚠️"))
 	}
 
-	if !cfg.JSON {
-		log.Printf("📏 After filters: %d files", len(filteredFiles))
+	if cfg.SpillDir != "" && !engine.streamable() {
+		log.Printf("%s-spill-dir needs exact matching without -known-db, -import-index or -remote; grouping in memory", emoji("⚠️"))
 	}
 
-	// Compute hashes in parallel
-	fileHashes, err := computeHashes(filteredFiles)
+	// Scan, filter, hash and group
+	duplicates, err := engine.collectDuplicates(ctx)
 	if err != nil {
+		summary.print(engine, err)
 		if !cfg.JSON {
-			log.Fatalf("❌ Error computing hashes: %v", err)
+			log.Fatalf("❌ %v", err)
 		} else {
-			fmt.Fprintf(os.Stderr, "{\"error\": \"failed to compute hashes: %v\"}\n", err)
+			fmt.Fprintf(os.Stderr, "{\"error\": \"%v\"}\n", err)
 			os.Exit(1)
 		}
 	}
 
-	if !cfg.JSON {
-		if !cfg.Verbose {
-			fmt.Fprintln(os.Stderr) // Newline after progress bar
+	summary.addGroups(duplicates, cfg)
+
+	// Handle read-only audit: the report and its checksum are all it writes
+	if cfg.Audit != "" {
+		sum, err := engine.exportAudit(duplicates, startTime)
+		if err != nil {
+			summary.print(engine, err)
+			if !cfg.JSON {
+				log.Fatalf("❌ Failed to write audit report: %v", err)
+			}
+			fmt.Fprintf(os.Stderr, "{\"error\": \"failed to write audit report: %v\"}\n", err)
+			os.Exit(1)
 		}
-		log.Printf("🔐 Computed %d hashes", len(fileHashes))
+		log.Printf("%sAudit report written to %s (sha256 %s)", emoji("🔏"), cfg.Audit, sum)
 	}
 
-	// Find duplicates
-	duplicates := findDuplicates(fileHashes)
-
 	// Handle JSON output mode
 	if cfg.JSON {
-		if err := outputJSON(duplicates); err != nil {
+		if err := engine.outputJSON(duplicates); err != nil {
 			fmt.Fprintf(os.Stderr, "{\"error\": \"failed to output JSON: %v\"}\n", err)
 			os.Exit(1)
 		}
+		summary.print(engine, nil)
 		return
 	}
 
 	// Normal mode: report and process
 	log.Printf("👯 Found %d duplicate groups", len(duplicates))
+	if engine.Partial() != "" {
+		log.Printf("%sPartial report: the scan %s, so files past that point were not compared", emoji("⚠️"), engine.Partial())
+	}
 
 	// Report duplicates
-	reportDuplicates(duplicates)
+	engine.reportDuplicates(duplicates)
+	printDirectoryPairs(duplicates, cfg.DirPairs)
+	printSkippedSummary(engine.Skipped())
+	printAlreadyLinked(engine.AlreadyLinked())
+	printIntegrityIssues(engine.IntegrityIssues())
+	printHashAnomalies(engine.HashAnomalies())
 
 	// Save config if theme was explicitly set
-	if isFlagSet("theme") {
-		if err := saveConfig(); err != nil && !cfg.JSON {
+	if isFlagSet("theme") && cfg.Audit == "" {
+		if err := saveConfig(cfg); err != nil && !cfg.JSON {
 			log.Printf("⚠️  Failed to save config: %v", err)
 		}
 	}
 
 	// Export report if requested
 	if cfg.ExportReport {
-		if err := exportReport(duplicates); err != nil {
+		if err := engine.exportReport(duplicates); err != nil {
 			log.Printf("%sFailed to export report: %v", emoji("⚠️"), err)
 		} else {
 			log.Printf("%sReport exported to %s", emoji("📄"), reportFile)
@@ -491,146 +1242,467 @@ func main() {
 
 	// Export CSV if requested
 	if cfg.ExportCSV {
-		if err := exportCSV(duplicates); err != nil {
+		if err := engine.exportCSV(duplicates); err != nil {
 			log.Printf("%sFailed to export CSV: %v", emoji("⚠️"), err)
 		} else {
-			log.Printf("%sCSV exported to %s", emoji("📄"), ".deduplicator_report.csv")
+			log.Printf("%sCSV exported to %s", emoji("📄"), csvReportFile)
 		}
 	}
 
+	// Export the decision sheet if requested
+	if cfg.ExportDecisions != "" {
+		if err := exportDecisions(cfg.ExportDecisions, duplicates, cfg); err != nil {
+			log.Printf("%sFailed to export decisions: %v", emoji("⚠️"), err)
+		} else {
+			log.Printf("%sDecisions exported to %s; edit the action column and run -apply-decisions", emoji("📄"), cfg.ExportDecisions)
+		}
+	}
+
+	// Size-only groups are never acted upon
+	if cfg.NoHash {
+		if len(duplicates) > 0 {
+			log.Printf("%sSize-only mode: content was not compared, no files were changed", emoji("ℹ️"))
+		}
+		summary.print(engine, nil)
+		log.Printf("%sComplete in %v", emoji("✅"), time.Since(startTime))
+		return
+	}
+
 	// Process duplicates if not dry run
 	if !cfg.DryRun && len(duplicates) > 0 {
+		if err := cfg.checkLint(lintKeep(duplicates, cfg)); err != nil {
+			summary.print(engine, err)
+			log.Fatalf("❌ %v", err)
+		}
 		if cfg.TUI {
-			if err := processDuplicatesTUI(duplicates); err != nil {
+			if err := engine.processDuplicatesTUI(duplicates); err != nil {
+				summary.print(engine, err)
 				log.Fatalf("❌ Error processing duplicates: %v", err)
 			}
 		} else if cfg.Interactive {
-			if err := processDuplicates(duplicates); err != nil {
+			if err := engine.processDuplicates(ctx, duplicates); err != nil {
+				summary.print(engine, err)
 				log.Fatalf("❌ Error processing duplicates: %v", err)
 			}
 		} else {
-			if err := processDuplicates(duplicates); err != nil {
+			if err := engine.processDuplicates(ctx, duplicates); err != nil {
+				summary.print(engine, err)
 				log.Fatalf("❌ Error processing duplicates: %v", err)
 			}
 		}
 	}
+	summary.print(engine, nil)
 
 	elapsed := time.Since(startTime)
 	log.Printf("%sComplete in %v", emoji("✅"), elapsed)
 }
 
-func scanFiles(dir string, recursive bool) ([]string, error) {
-	var files []string
-	var scanned int
-	var scannedMutex sync.Mutex
+// collectDuplicates runs the scan, filter, hash and grouping phases for the
+// engine's roots and any -remote agents. It stops early with ctx's error when
+// ctx is cancelled, and reports hashed files and groups through the engine's
+// Events.
+func (e *Engine) collectDuplicates(ctx context.Context) ([]DuplicateGroup, error) {
+	// Remote agents scan and hash while the local files are processed
+	waitRemotes := e.startRemotes(ctx)
 
-	// Simple progress tracker
-	lastProgressUpdate := time.Now()
+	fileHashes, err := e.collectHashes(ctx, e.streamable())
+	remoteHashes, remoteErr := waitRemotes()
+	if err != nil {
+		return nil, err
+	}
+	if remoteErr != nil {
+		return nil, remoteErr
+	}
+	fileHashes = append(fileHashes, remoteHashes...)
+	e.markReadOnly(fileHashes)
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	if e.ignore, err = loadIgnoreList(e.cfg.ignoreListPath()); err != nil {
+		return nil, err
+	}
+
+	if _, err := e.updateKnownDB(fileHashes); err != nil {
+		return nil, err
+	}
+
+	references, err := e.loadIndexes()
+	if err != nil {
+		return nil, err
+	}
+	fileHashes = append(fileHashes, references...)
+
+	// Size-only triage: group by metadata, never read content
+	var duplicates []DuplicateGroup
+	if e.cfg.NoHash {
+		duplicates = findSizeGroups(fileHashes, e.cfg.SameName)
+	} else {
+		duplicates = e.findDuplicates(fileHashes)
+	}
+
+	// Duplicates within an imported index or read-only media are not this
+	// scan's business
+	if len(references) > 0 || len(e.cfg.ReferenceReadOnly) > 0 {
+		kept := duplicates[:0]
+		for _, group := range duplicates {
+			if hasLocalFile(group) {
+				kept = append(kept, group)
+			}
+		}
+		duplicates = kept
+	}
+
+	duplicates = e.dropIgnored(duplicates)
+	if err := e.applyTags(duplicates); err != nil {
+		return nil, err
+	}
+	if e.cfg.Tagged != "" {
+		duplicates = withTag(duplicates, e.cfg.Tagged)
+	}
+
+	if e.cfg.SimilarityMatrix != "" && e.cfg.PerceptualMode {
+		if err := e.exportSimilarityMatrix(fileHashes, duplicates); err != nil {
+			return nil, fmt.Errorf("failed to export similarity matrix: %w", err)
+		}
+	}
+	if e.cfg.Treemap != "" {
+		if err := e.exportTreemap(duplicates); err != nil {
+			return nil, fmt.Errorf("failed to export treemap: %w", err)
+		}
+	}
+	annotateNames(duplicates)
+	return reportGroups(duplicates, e.events), nil
+}
+
+// collectFiles scans, filters and hashes the local roots. With -no-hash the
+// files are only stat'ed and their Hash is left empty.
+func (e *Engine) collectFiles(ctx context.Context) ([]FileHash, error) {
+	return e.collectHashes(ctx, false)
+}
+
+// collectHashes is collectFiles, optionally keeping only the files that share
+// a hash with another file. Hashes are then grouped as the workers produce
+// them and unique files are released instead of accumulating.
+func (e *Engine) collectHashes(ctx context.Context, candidatesOnly bool) ([]FileHash, error) {
+	if candidatesOnly && e.cfg.SpillDir != "" {
+		return e.spilledHashes(ctx)
+	}
+
+	files, err := e.scanRoots(ctx, e.cfg.scanDirs(), e.cfg.Recursive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan files: %w", err)
+	}
+
+	e.scanned = len(files)
+	if !e.cfg.JSON {
+		log.Printf("📊 Found %d files", len(files))
+	}
+	budget := e.cfg.scanBudget()
+
+	// Apply size, pattern and extension filters
+	filter, err := newFileFilter(e.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredFiles []string
+	var fileSizes []int64
+	var infos map[string]os.FileInfo // kept to fingerprint directories for the -cache
+	if e.cfg.Cache != "" && !e.cfg.CheckIntegrity {
+		infos = make(map[string]os.FileInfo)
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
 		if err != nil {
-			return err
+			e.skipped.add(file, err)
+			if e.cfg.Verbose {
+				log.Printf("%sCould not stat %s: %v", emoji("⚠️"), file, err)
+			}
+			continue
 		}
+		if reason := filter.rejectFile(file, info); reason != "" {
+			if e.cfg.Verbose {
+				log.Printf("%sSkipping %s: %s", emoji("🚫"), reason, file)
+			}
+			continue
+		}
+		if !budget.admit(info.Size()) {
+			break
+		}
+		filteredFiles = append(filteredFiles, file)
+		fileSizes = append(fileSizes, info.Size())
+		if infos != nil {
+			infos[file] = info
+		}
+	}
+	e.fingerprintDirs(infos)
+	e.partial = budget.partial()
+
+	if !e.cfg.JSON {
+		log.Printf("📏 After filters: %d files", len(filteredFiles))
+	}
+	e.logPartial()
+
+	// Hard links to one file are the same file, not duplicates of it
+	filteredFiles, fileSizes = e.collapseHardlinks(filteredFiles, fileSizes)
+
+	if e.cfg.NoHash {
+		stated := e.statFiles(filteredFiles)
+		e.attachLinks(stated)
+		return stated, nil
+	}
+
+	// Rule out files whose size or ends no other file shares
+	if e.stagedHashing(candidatesOnly) {
+		if filteredFiles, fileSizes, err = e.stagedCandidates(ctx, filteredFiles, fileSizes); err != nil {
+			return nil, fmt.Errorf("failed to compute partial hashes: %w", err)
+		}
+	}
+
+	// Compute hashes in parallel, biggest potential wins first, skipping
+	// files an interrupted run finished
+	e.cfg.orderForHashing(filteredFiles, fileSizes)
+	reused, toHash, err := e.reuseCheckpoint(filteredFiles)
+	if err != nil {
+		return nil, err
+	}
+	// and files in folders unchanged since the -cache saw them
+	cached, toHash := e.reuseUnchangedDirs(toHash, infos)
+	reused = append(reused, cached...)
+	if err := e.openCheckpointLog(reused); err != nil {
+		log.Printf("%s%v", emoji("⚠️"), err)
+	}
+	defer func() {
+		e.checkpoint.close()
+		e.checkpoint = nil
+	}()
+	if candidatesOnly {
+		sizes := make(map[int64]int)
+		for _, size := range fileSizes {
+			sizes[size]++
+		}
+		e.index = newHashIndex(sizes)
+		e.index.onGroup = e.onGroup
+		defer func() { e.index = nil }()
+	}
+	for _, fh := range reused {
+		e.events.fileHashed(fh)
+		if e.index != nil {
+			e.index.add(fh)
+		}
+	}
+	if e.cfg.PerceptualMode && e.cfg.PerceptualVideo && !e.cfg.JSON {
+		if _, err := exec.LookPath(ffmpegCommand); err != nil {
+			log.Printf("%s-perceptual-video needs ffmpeg on PATH; videos will only be matched exactly", emoji("⚠️"))
+		}
+	}
+	e.openCache()
+	hashed, err := e.computeHashes(ctx, toHash)
+	if err == nil {
+		e.cache.storeDirs(e.dirPrints)
+	}
+	if cacheErr := e.cache.save(); cacheErr != nil {
+		log.Printf("%sFailed to save cache: %v", emoji("⚠️"), cacheErr)
+	}
+	fileHashes := append(reused, hashed...)
+	computed := len(fileHashes)
+	if e.index != nil {
+		computed = e.index.added
+		fileHashes = e.index.candidates()
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			if cpErr := e.saveCheckpoint(fileHashes, len(filteredFiles), err); cpErr != nil {
+				log.Printf("%s%v", emoji("⚠️"), cpErr)
+			}
+		}
+		return nil, fmt.Errorf("failed to compute hashes: %w", err)
+	}
+	e.clearCheckpoint()
+
+	if !e.cfg.JSON {
+		if !e.cfg.Verbose {
+			fmt.Fprintln(os.Stderr) // Newline after progress bar
+		}
+		log.Printf("🔐 Computed %d hashes", computed)
+		if e.index != nil {
+			log.Printf("🧮 %d files share a hash with another file", len(fileHashes))
+		}
+	}
+
+	e.attachLinks(fileHashes)
+	return fileHashes, nil
+}
+
+// reportGroups passes each group to ev and returns the groups unchanged
+func reportGroups(groups []DuplicateGroup, ev *Events) []DuplicateGroup {
+	for i, group := range groups {
+		ev.groupFound(i, group)
+	}
+	return groups
+}
+
+// scanFiles walks a single directory and returns the files to consider
+func (e *Engine) scanFiles(ctx context.Context, dir string, recursive bool) ([]string, error) {
+	return e.scanRoots(ctx, []string{dir}, recursive)
+}
+
+// scanRoots walks every root concurrently so scans of separate drives overlap,
+// with one aggregated progress counter. Files are interleaved across roots so
+// the hashing workers read from all drives at once; a file reachable from
+// more than one root (nested or repeated roots) is listed only once.
+func (e *Engine) scanRoots(ctx context.Context, roots []string, recursive bool) ([]string, error) {
+	files, err := e.scanner(recursive, e.scanProgress()).Scan(ctx, roots...)
+
+	// Final progress update
+	if !e.cfg.Verbose && !e.cfg.JSON {
+		fmt.Fprintf(os.Stderr, "\r📁 Scanning: %d files\n", len(files))
+	}
+	return files, err
+}
+
+// scanProgress returns a Scanner.Progress that counts the entries visited
+// and shows the count every progressUpdateInterval
+func (e *Engine) scanProgress() func() {
+	var scanned int
+	var scannedMutex sync.Mutex
 
+	// Simple progress tracker
+	lastProgressUpdate := time.Now()
+	return func() {
 		scannedMutex.Lock()
+		defer scannedMutex.Unlock()
 		scanned++
-		currentScanned := scanned
-		scannedMutex.Unlock()
 
 		// Update progress periodically
 		if time.Since(lastProgressUpdate) > progressUpdateInterval {
 			lastProgressUpdate = time.Now()
-			if cfg.Verbose {
-				log.Printf("📁 Scanned %d files...", currentScanned)
-			} else if !cfg.JSON {
-				fmt.Fprintf(os.Stderr, "\r📁 Scanning: %d files", currentScanned)
+			if e.cfg.Verbose {
+				log.Printf("📁 Scanned %d files...", scanned)
+			} else if !e.cfg.JSON {
+				fmt.Fprintf(os.Stderr, "\r📁 Scanning: %d files", scanned)
 			}
 		}
+	}
+}
 
-		if info.IsDir() {
-			// Skip hidden directories
-			if strings.HasPrefix(filepath.Base(path), ".") {
-				if cfg.Verbose {
-					log.Printf("%sSkipping hidden directory: %s", emoji("🚫"), path)
-				}
-				return filepath.SkipDir
+// scanner is the dedup.Scanner behind scanRoots, logging what it leaves out
+// and applying -max-depth, -exclude, -exclude-dir, .dedupignore files,
+// -skip-network-fs, -one-filesystem, -follow-symlinks, -include-hidden,
+// -include-system and -on-error. Mounts left out are always logged.
+func (e *Engine) scanner(recursive bool, progress func()) dedup.Scanner {
+	return dedup.Scanner{
+		Recursive:      recursive,
+		MaxDepth:       e.cfg.MaxDepth,
+		IncludeHidden:  e.cfg.IncludeHidden,
+		IncludeSystem:  e.cfg.IncludeSystem,
+		OneFilesystem:  e.cfg.OneFilesystem,
+		FollowSymlinks: e.cfg.FollowSymlinks,
+		IgnoreFile:     e.cfg.ignoreFile(),
+		SkipDir:        e.skipDir,
+		OnError: func(path string, err error) error {
+			// Unreadable entries below the root are skipped unless -on-error stop
+			if e.cfg.stopOnError() {
+				return err
 			}
-			// Skip non-recursive
-			if !recursive && path != dir {
-				return filepath.SkipDir
+			e.skipped.add(path, err)
+			if e.cfg.Verbose {
+				log.Printf("%s%s", emoji("⚠️"), formatFileError(path, err))
 			}
 			return nil
-		}
+		},
+		Skipped: func(path, reason string) {
+			if reason == dedup.SkippedOtherFS && !e.cfg.JSON {
+				log.Printf("%sSkipping mount on another filesystem: %s", emoji("💽"), path)
+			} else if e.cfg.Verbose {
+				log.Printf("%sSkipping %s: %s", emoji("🚫"), reason, path)
+			}
+		},
+		Progress: progress,
+	}
+}
 
-		// Skip hidden files
-		if strings.HasPrefix(filepath.Base(path), ".") {
-			if cfg.Verbose {
-				log.Printf("%sSkipping hidden file: %s", emoji("🚫"), path)
+func (e *Engine) computeHashes(ctx context.Context, files []string) ([]FileHash, error) {
+	return e.hashFiles(ctx, len(files), func(send func(string) bool) error {
+		for _, file := range files {
+			if !send(file) {
+				break
 			}
-			return nil
 		}
-
-		files = append(files, path)
 		return nil
 	})
-
-	// Final progress update
-	if !cfg.Verbose && !cfg.JSON {
-		fmt.Fprintf(os.Stderr, "\r📁 Scanning: %d files\n", len(files))
-	}
-
-	return files, err
 }
 
-func computeHashes(files []string) ([]FileHash, error) {
+// hashFiles hashes the total files feed passes to send, which returns false
+// once hashing stops. An error from feed ends the hashing with it.
+func (e *Engine) hashFiles(ctx context.Context, totalFiles int, feed func(send func(string) bool) error) ([]FileHash, error) {
+	// With -on-error stop the first failing worker cancels the rest
+	hashCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
 	var wg sync.WaitGroup
-	fileChan := make(chan string, cfg.Workers)
-	resultChan := make(chan FileHash, len(files))
-	errorChan := make(chan error, len(files))
+	fileChan := make(chan string, e.cfg.Workers)
+	resultChan := make(chan FileHash, e.cfg.Workers)
 
 	// Progress tracking
 	var hashedCount int
 	var hashedMutex sync.Mutex
-	totalFiles := len(files)
 	lastProgressUpdate := time.Now()
 	startTime := time.Now()
 
 	// Start worker goroutines
-	for i := 0; i < cfg.Workers; i++ {
+	for i := 0; i < e.cfg.Workers; i++ {
 		wg.Add(1)
-		go worker(&wg, fileChan, resultChan, errorChan, &hashedCount, &hashedMutex, &lastProgressUpdate, totalFiles, startTime)
+		go e.worker(hashCtx, stop, &wg, fileChan, resultChan, &hashedCount, &hashedMutex, &lastProgressUpdate, totalFiles, startTime)
 	}
 
 	// Send files to workers
+	var feedErr error
 	go func() {
-		for _, file := range files {
-			fileChan <- file
-		}
-		close(fileChan)
+		defer close(fileChan)
+		feedErr = feed(func(file string) bool {
+			select {
+			case fileChan <- file:
+				return true
+			case <-hashCtx.Done():
+				return false
+			}
+		})
 	}()
 
 	// Wait for workers to finish
 	go func() {
 		wg.Wait()
 		close(resultChan)
-		close(errorChan)
 	}()
 
 	// Collect results
 	var fileHashes []FileHash
 	for fh := range resultChan {
+		e.events.fileHashed(fh)
+		e.checkpoint.add(fh)
+		if e.index != nil {
+			e.index.add(fh) // grouped now, unique files released as sizes finish
+			continue
+		}
+		if e.spilled != nil {
+			e.spilled.add(hashRecord(fh)) // grouped once sorted on disk
+			continue
+		}
 		fileHashes = append(fileHashes, fh)
 	}
+	if feedErr != nil {
+		return nil, feedErr
+	}
 
-	// Check for errors
-	for err := range errorChan {
-		if err != nil {
-			log.Printf("%s%s", emoji("⚠️"), formatFileError("", err))
-		}
+	// Hand back what was finished so it can be checkpointed
+	if err := ctx.Err(); err != nil {
+		return fileHashes, err
+	}
+	if err := context.Cause(hashCtx); err != nil {
+		return nil, fmt.Errorf("stopped at the first unreadable file (-on-error stop): %w", err)
 	}
 
 	// Final progress update
-	if !cfg.Verbose && totalFiles > 0 {
+	if !e.cfg.Verbose && !e.cfg.JSON && totalFiles > 0 {
 		elapsed := time.Since(startTime).Seconds()
 		// Create styled progress bar (100% full)
 		barWidth := 30
@@ -650,30 +1722,64 @@ func computeHashes(files []string) ([]FileHash, error) {
 	return fileHashes, nil
 }
 
-func worker(wg *sync.WaitGroup, fileChan <-chan string, resultChan chan<- FileHash, errorChan chan<- error, hashedCount *int, hashedMutex *sync.Mutex, lastProgressUpdate *time.Time, totalFiles int, startTime time.Time) {
+func (e *Engine) worker(ctx context.Context, stop context.CancelCauseFunc, wg *sync.WaitGroup, fileChan <-chan string, resultChan chan<- FileHash, hashedCount *int, hashedMutex *sync.Mutex, lastProgressUpdate *time.Time, totalFiles int, startTime time.Time) {
 	defer wg.Done()
 
 	for file := range fileChan {
-		hasher := getHasher()
-		hash, size, modTime, err := hashFile(file, hasher)
+		hasher := getHasher(e.cfg.HashAlgorithm)
+		hash, size, modTime, err := e.hashWithPolicy(ctx, file, hasher)
+		if ctx.Err() != nil {
+			continue // cancelled: drain remaining files without reporting them
+		}
 		if err != nil {
-			errorChan <- fmt.Errorf("%s", formatFileError(file, err))
+			if e.cfg.stopOnError() {
+				stop(fmt.Errorf("%s", formatFileError(file, err)))
+				continue
+			}
+			// Summarized at the end instead of scrolling past the progress bar
+			e.skipped.add(file, err)
+			if e.cfg.Verbose {
+				log.Printf("%s%s", emoji("⚠️"), formatFileError(file, err))
+			}
 			continue
 		}
 
+		e.recordContentHash(file, size, modTime, hash)
+
+		// Hash SVG markup instead of bytes, so re-exports match
+		if e.cfg.NormalizeSVG && isSVGFile(file) {
+			if svgHash, err := normalizedSVGHash(file, getHasher(e.cfg.HashAlgorithm)); err == nil {
+				hash = svgHash
+			} else if e.cfg.Verbose {
+				log.Printf("%sHashing %s byte for byte: %v", emoji("⚠️"), file, err)
+			}
+		}
+
 		// Compute perceptual hash for images if enabled
 		var pHash string
-		if cfg.PerceptualMode && isImageFile(file) {
-			pHash, err = computePerceptualHash(file, cfg.PHashAlgorithm)
+		if e.cfg.perceptualCandidate(file) {
+			pHash, err = e.perceptualHash(ctx, file, size, modTime)
 			if err != nil {
 				// Log error but continue with regular hash
-				if cfg.Verbose {
+				if e.cfg.Verbose {
 					log.Printf("%sCould not compute perceptual hash for %s: %v", emoji("⚠️"), file, err)
 				}
 			}
 		}
 
-		if cfg.Verbose {
+		// Image size, so resized copies can be kept apart
+		var width, height int
+		if pHash != "" && e.cfg.SameDimensions {
+			width, height, _ = imageDimensions(file)
+		}
+
+		// Where and when a photo was taken, to weigh its matches
+		var photo *photoMeta
+		if pHash != "" && e.cfg.PhotoLocation {
+			photo, _ = readPhotoMeta(file)
+		}
+
+		if e.cfg.Verbose {
 			if pHash != "" {
 				log.Printf("📄 %s: %s [phash: %s...] (%d bytes)", file, hash[:8]+"...", pHash[:8], size)
 			} else {
@@ -687,6 +1793,9 @@ func worker(wg *sync.WaitGroup, fileChan <-chan string, resultChan chan<- FileHa
 			Hash:    hash,
 			ModTime: modTime,
 			PHash:   pHash,
+			Width:   width,
+			Height:  height,
+			Photo:   photo,
 		}
 
 		// Update progress
@@ -698,9 +1807,9 @@ func worker(wg *sync.WaitGroup, fileChan <-chan string, resultChan chan<- FileHa
 		// Update progress periodically
 		if time.Since(*lastProgressUpdate) > progressUpdateInterval {
 			*lastProgressUpdate = time.Now()
-			if cfg.Verbose {
+			if e.cfg.Verbose {
 				log.Printf("🔐 Hashed %d/%d files (%.1f%%)", currentHashed, totalFiles, float64(currentHashed)*100/float64(totalFiles))
-			} else if !cfg.JSON {
+			} else if !e.cfg.JSON {
 				percentage := float64(currentHashed) * 100 / float64(totalFiles)
 				fmt.Fprintf(os.Stderr, "\r🔐 Hashing: %d/%d files (%.1f%%)", currentHashed, totalFiles, percentage)
 			}
@@ -709,7 +1818,7 @@ func worker(wg *sync.WaitGroup, fileChan <-chan string, resultChan chan<- FileHa
 }
 
 // printProgress displays a progress bar with ETA
-func printProgress(current, total int, startTime time.Time) {
+func (e *Engine) printProgress(current, total int, startTime time.Time) {
 	percentage := float64(current) / float64(total)
 	barWidth := 30
 	filled := int(percentage * float64(barWidth))
@@ -726,8 +1835,8 @@ func printProgress(current, total int, startTime time.Time) {
 
 	// Add perceptual mode indicator
 	var modeIcon string
-	if cfg.PerceptualMode {
-		if cfg.NoEmoji {
+	if e.cfg.PerceptualMode {
+		if e.cfg.NoEmoji {
 			modeIcon = "[IMG]"
 		} else {
 			modeIcon = emoji("🖼️")
@@ -772,59 +1881,111 @@ func formatDuration(seconds float64) string {
 	return fmt.Sprintf("%dh %dm", hours, minutes)
 }
 
-func getHasher() hash.Hash {
-	switch strings.ToLower(cfg.HashAlgorithm) {
-	case "md5":
-		return md5.New()
-	case "sha1":
-		return sha1.New()
-	case "sha256":
-		return sha256.New()
-	default:
-		return sha256.New()
+// checkHashAlgorithm validates a -hash value. getHasher falls back to
+// sha256 for names it does not know, which would record hashes under a
+// name that did not produce them.
+func checkHashAlgorithm(algorithm string) error {
+	if dedup.CheckAlgorithm(algorithm) != nil {
+		return fmt.Errorf("invalid -hash %q: expected %s", algorithm, strings.Join(dedup.Algorithms, ", "))
 	}
+	return nil
 }
 
-func hashFile(path string, hasher hash.Hash) (string, int64, time.Time, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return "", 0, time.Time{}, err
-	}
-	defer file.Close()
+// hashName is the -hash algorithm as it is recorded next to stored hashes
+func (c Config) hashName() string {
+	return strings.ToLower(c.HashAlgorithm)
+}
 
-	info, err := file.Stat()
-	if err != nil {
-		return "", 0, time.Time{}, err
+// hashAlgorithmOf names the algorithm behind a group's Hash: the -hash for
+// exact duplicates, the -phash-algo for similar images, and none for
+// size-only groups
+func (c Config) hashAlgorithmOf(group DuplicateGroup) string {
+	switch {
+	case group.Unverified || group.Hash == "":
+		return ""
+	case group.Similarity < 100.0:
+		return strings.ToLower(c.PHashAlgorithm)
 	}
+	return c.hashName()
+}
 
-	if _, err := io.Copy(hasher, file); err != nil {
-		return "", 0, time.Time{}, err
-	}
+func getHasher(algorithm string) hash.Hash {
+	return dedup.Hasher{Algorithm: algorithm}.New()
+}
+
+func hashFile(path string, hasher hash.Hash) (string, int64, time.Time, error) {
+	return hashFileContext(context.Background(), path, hasher)
+}
 
-	return hex.EncodeToString(hasher.Sum(nil)), info.Size(), info.ModTime(), nil
+// hashFileContext is like hashFile but gives up between reads once ctx is
+// cancelled, so large files do not delay shutdown
+func hashFileContext(ctx context.Context, path string, hasher hash.Hash) (string, int64, time.Time, error) {
+	return hashFileBuffer(ctx, path, hasher, defaultReadBuffer)
 }
 
-func findDuplicates(fileHashes []FileHash) []DuplicateGroup {
+func (e *Engine) findDuplicates(fileHashes []FileHash) []DuplicateGroup {
 	// If perceptual mode is enabled, handle images differently
-	if cfg.PerceptualMode {
-		return findPerceptualDuplicates(fileHashes)
+	if e.cfg.PerceptualMode {
+		return e.findPerceptualDuplicates(fileHashes)
+	}
+
+	// Standard exact-match deduplication, by hash and size
+	groups, anomalies := groupByContent(fileHashes)
+	e.anomalies = anomalies
+
+	var duplicates []DuplicateGroup
+	for _, files := range groups {
+		duplicates = append(duplicates, DuplicateGroup{
+			Hash:       files[0].Hash,
+			Size:       files[0].Size,
+			Files:      files,
+			Similarity: 100.0, // Exact match
+		})
 	}
 
-	// Standard exact-match deduplication
-	hashMap := make(map[string][]FileHash)
+	return duplicates
+}
+
+// statFiles builds FileHash entries from metadata only, leaving Hash empty
+func (e *Engine) statFiles(files []string) []FileHash {
+	fileHashes := make([]FileHash, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			if e.cfg.Verbose {
+				log.Printf("%sCould not stat %s: %v", emoji("⚠️"), file, err)
+			}
+			continue
+		}
+		fileHashes = append(fileHashes, FileHash{
+			Path:    file,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return fileHashes
+}
 
+// findSizeGroups groups files by size (and optionally base name) without
+// looking at their content. The resulting groups are marked Unverified.
+func findSizeGroups(fileHashes []FileHash, sameName bool) []DuplicateGroup {
+	groupMap := make(map[string][]FileHash)
 	for _, fh := range fileHashes {
-		hashMap[fh.Hash] = append(hashMap[fh.Hash], fh)
+		key := fmt.Sprintf("size:%d", fh.Size)
+		if sameName {
+			key += ":" + strings.ToLower(filepath.Base(fh.Path))
+		}
+		groupMap[key] = append(groupMap[key], fh)
 	}
 
 	var duplicates []DuplicateGroup
-	for hash, files := range hashMap {
+	for key, files := range groupMap {
 		if len(files) > 1 {
 			duplicates = append(duplicates, DuplicateGroup{
-				Hash:  hash,
-				Size:  files[0].Size,
-				Files: files,
-				Similarity: 100.0, // Exact match
+				Hash:       key,
+				Size:       files[0].Size,
+				Files:      files,
+				Unverified: true,
 			})
 		}
 	}
@@ -833,7 +1994,7 @@ func findDuplicates(fileHashes []FileHash) []DuplicateGroup {
 }
 
 // findPerceptualDuplicates groups similar images together
-func findPerceptualDuplicates(fileHashes []FileHash) []DuplicateGroup {
+func (e *Engine) findPerceptualDuplicates(fileHashes []FileHash) []DuplicateGroup {
 	var imageFiles []FileHash
 	var regularFiles []FileHash
 
@@ -846,65 +2007,40 @@ func findPerceptualDuplicates(fileHashes []FileHash) []DuplicateGroup {
 		}
 	}
 
-	// Group regular files by exact hash (standard dedup)
+	// Group regular files by exact hash and size (standard dedup)
 	var duplicates []DuplicateGroup
-	hashMap := make(map[string][]FileHash)
-	for _, fh := range regularFiles {
-		hashMap[fh.Hash] = append(hashMap[fh.Hash], fh)
-	}
-	for hash, files := range hashMap {
-		if len(files) > 1 {
-			duplicates = append(duplicates, DuplicateGroup{
-				Hash:  hash,
-				Size:  files[0].Size,
-				Files: files,
-				Similarity: 100.0,
-			})
-		}
+	groups, anomalies := groupByContent(regularFiles)
+	e.anomalies = anomalies
+	for _, files := range groups {
+		duplicates = append(duplicates, DuplicateGroup{
+			Hash:       files[0].Hash,
+			Size:       files[0].Size,
+			Files:      files,
+			Similarity: 100.0,
+		})
 	}
 
 	// Group images by perceptual similarity
-	visited := make(map[int]bool)
-	for i := 0; i < len(imageFiles); i++ {
-		if visited[i] {
-			continue
-		}
-
-		group := []FileHash{imageFiles[i]}
-		visited[i] = true
-
-		for j := i + 1; j < len(imageFiles); j++ {
-			if visited[j] {
-				continue
-			}
-
-			dist := hammingDistance(imageFiles[i].PHash, imageFiles[j].PHash)
-			if dist >= 0 && dist <= cfg.SimilarityThreshold {
-				group = append(group, imageFiles[j])
-				visited[j] = true
-			}
+	match := func(a, b FileHash) bool {
+		return (!e.cfg.SameDimensions || sameDimensions(a, b)) && !e.ignore.different(a, b)
+	}
+	for _, files := range clusterImages(imageFiles, e.cfg.maxDistance(), e.cfg.Cluster, match) {
+		group := DuplicateGroup{
+			Hash:  files[0].PHash, // Use perceptual hash as group ID
+			Size:  files[0].Size,
+			Files: files,
 		}
-
-		if len(group) > 1 {
-			// Calculate average similarity
-			avgSimilarity := 100.0 - (float64(cfg.SimilarityThreshold) / 64.0 * 100.0)
-			if avgSimilarity < 50 {
-				avgSimilarity = 50 + float64(cfg.SimilarityThreshold)
-			}
-
-			duplicates = append(duplicates, DuplicateGroup{
-				Hash:  imageFiles[i].PHash, // Use perceptual hash as group ID
-				Size:  imageFiles[i].Size,
-				Files: group,
-				Similarity: avgSimilarity,
-			})
+		scoreGroup(&group)
+		if e.cfg.PhotoLocation {
+			applyLocationHint(&group)
 		}
+		duplicates = append(duplicates, group)
 	}
 
 	return duplicates
 }
 
-func reportDuplicates(duplicates []DuplicateGroup) {
+func (e *Engine) reportDuplicates(duplicates []DuplicateGroup) {
 	if len(duplicates) == 0 {
 		log.Println("✅ No duplicates found!")
 		return
@@ -916,12 +2052,14 @@ func reportDuplicates(duplicates []DuplicateGroup) {
 	// Count perceptual vs exact matches
 	perceptualGroups := 0
 	for _, group := range duplicates {
-		if group.Similarity < 100.0 {
+		if group.Similarity < 100.0 && !group.Unverified {
 			perceptualGroups++
 		}
 	}
 
-	if cfg.PerceptualMode && perceptualGroups > 0 {
+	if e.cfg.NoHash {
+		log.Printf("\n%sPotential Duplicates (same size, content not verified):", emoji("📏"))
+	} else if e.cfg.PerceptualMode && perceptualGroups > 0 {
 		log.Printf("\n%sSimilar Images Found:", emoji("🖼️"))
 	} else {
 		log.Printf("\n%sDuplicate Files:", emoji("👯"))
@@ -929,33 +2067,74 @@ func reportDuplicates(duplicates []DuplicateGroup) {
 	log.Println(strings.Repeat("=", 70))
 
 	for i, group := range duplicates {
-		numDuplicates := len(group.Files) - 1
+		keepIdx := selectFileToKeep(group, e.cfg.KeepCriteria)
+
+		// Remote copies are reported but never removed
+		numDuplicates := 0
+		for j, fh := range group.Files {
+			if j != keepIdx && fh.Host == "" {
+				numDuplicates++
+			}
+		}
 		space := group.Size * int64(numDuplicates)
 		totalDuplicates += numDuplicates
 		totalSpace += space
 
-		keepIdx := selectFileToKeep(group)
+		if group.Unverified {
+			log.Printf("\n[%d] Size: %s", i+1, formatBytes(group.Size))
+			log.Printf("    Files: %d (potential duplicates)", len(group.Files))
+			for _, fh := range group.Files {
+				log.Printf("    ? %s (modified: %s)", fh.Path, fh.ModTime.Format("2006-01-02 15:04:05"))
+			}
+			continue
+		}
 
-		log.Printf("\n[%d] Hash: %s", i+1, group.Hash[:16]+"...")
+		log.Printf("\n[%d] Hash: %s", i+1, shortHash(group.Hash))
 		log.Printf("    Size: %s", formatBytes(group.Size))
 		log.Printf("    Files: %d (keeping 1, removing %d)", len(group.Files), numDuplicates)
 
 		// Show similarity for perceptual matches
-		if group.Similarity < 100.0 {
-			log.Printf("    Similarity: %.0f%% (perceptual match)", group.Similarity)
+		perceptual := group.Similarity < 100.0
+		if perceptual {
+			log.Printf("    Similarity: %.0f%% (perceptual match, least similar pair %d bits apart)", group.Similarity, group.MaxDistance)
+		}
+		if group.Location != "" {
+			log.Printf("    Location: %s", group.Location)
+		}
+		if group.SuggestedName != "" {
+			log.Printf("    Names: variants of one name, %q looks canonical", group.SuggestedName)
+		}
+		if len(group.Tags) > 0 {
+			log.Printf("    Tags: %s", strings.Join(group.Tags, ", "))
 		}
 
 		for j, fh := range group.Files {
 			prefix := fmt.Sprintf("    %sKEEP", emoji("✓"))
-			if j != keepIdx {
+			if j != keepIdx && fh.Reference && e.cfg.underReadOnly(fh.Path) {
+				prefix = fmt.Sprintf("    %sREAD-ONLY", emoji("🔒"))
+			} else if j != keepIdx && fh.Reference {
+				prefix = fmt.Sprintf("    %sINDEXED", emoji("📇"))
+			} else if j != keepIdx && fh.Host != "" {
+				prefix = fmt.Sprintf("    %sREMOTE", emoji("🌐"))
+			} else if j != keepIdx {
 				prefix = fmt.Sprintf("    %sDELETE", emoji("✗"))
 			}
-			log.Printf("%s %s (modified: %s)", prefix, fh.Path, fh.ModTime.Format("2006-01-02 15:04:05"))
+			if perceptual && fh.Similarity > 0 {
+				log.Printf("%s %s (modified: %s, %.0f%% similar)", prefix, fh.Path, fh.ModTime.Format("2006-01-02 15:04:05"), fh.Similarity)
+			} else {
+				log.Printf("%s %s (modified: %s)", prefix, fh.Path, fh.ModTime.Format("2006-01-02 15:04:05"))
+			}
+			for _, name := range fh.Links {
+				log.Printf("        = %s (hard link)", name)
+			}
 		}
 	}
 
 	log.Println("\n" + strings.Repeat("=", 70))
-	if cfg.PerceptualMode && perceptualGroups > 0 {
+	if e.cfg.NoHash {
+		log.Printf("%sSummary: %d potential duplicate files in %d same-size groups, up to %s if all are confirmed",
+			emoji("📊"), totalDuplicates, len(duplicates), formatBytes(totalSpace))
+	} else if e.cfg.PerceptualMode && perceptualGroups > 0 {
 		log.Printf("%sSummary: %d duplicates/similar files, %s of space can be freed (%d perceptual groups)",
 			emoji("📊"), totalDuplicates, formatBytes(totalSpace), perceptualGroups)
 	} else {
@@ -964,68 +2143,56 @@ func reportDuplicates(duplicates []DuplicateGroup) {
 	}
 }
 
-func selectFileToKeep(group DuplicateGroup) int {
+func selectFileToKeep(group DuplicateGroup, criteria string) int {
 	files := group.Files
 
-	if strings.HasPrefix(cfg.KeepCriteria, "path:") {
-		// Keep file matching specific path
-		targetPath := strings.TrimPrefix(cfg.KeepCriteria, "path:")
-		for i, fh := range files {
-			if strings.Contains(fh.Path, targetPath) {
-				return i
-			}
+	// Files from an imported index are the originals; choose among them
+	var references []int
+	for i, fh := range files {
+		if fh.Reference {
+			references = append(references, i)
 		}
-		return 0 // Default to first if not found
 	}
-
-	switch strings.ToLower(cfg.KeepCriteria) {
-	case "oldest":
-		oldestIdx := 0
-		for i, fh := range files {
-			if fh.ModTime.Before(files[oldestIdx].ModTime) {
-				oldestIdx = i
-			}
-		}
-		return oldestIdx
-
-	case "newest":
-		newestIdx := 0
-		for i, fh := range files {
-			if fh.ModTime.After(files[newestIdx].ModTime) {
-				newestIdx = i
-			}
+	if len(references) > 0 && len(references) < len(files) {
+		indexed := DuplicateGroup{Hash: group.Hash, Size: group.Size}
+		for _, i := range references {
+			indexed.Files = append(indexed.Files, files[i])
 		}
-		return newestIdx
+		return references[selectFileToKeep(indexed, criteria)]
+	}
 
-	case "largest":
-		largestIdx := 0
-		for i, fh := range files {
-			if fh.Size > files[largestIdx].Size {
-				largestIdx = i
-			}
-		}
-		return largestIdx
+	candidates := make([]dedup.File, len(files))
+	for i, fh := range files {
+		candidates[i] = dedup.File{Path: fh.Path, Size: fh.Size, ModTime: fh.ModTime, Hash: fh.Hash}
+	}
+	return dedup.SelectKeep(candidates, criteria)
+}
 
-	case "smallest":
-		smallestIdx := 0
-		for i, fh := range files {
-			if fh.Size < files[smallestIdx].Size {
-				smallestIdx = i
-			}
+// localKeeper returns the file that -link links the rest of group to: the
+// kept file at keepIdx if it is on this machine, otherwise the first local
+// copy, or -1 if every copy is indexed or remote
+func localKeeper(group DuplicateGroup, keepIdx int) int {
+	if fh := group.Files[keepIdx]; fh.Host == "" && !fh.Reference {
+		return keepIdx
+	}
+	for i, fh := range group.Files {
+		if fh.Host == "" && !fh.Reference {
+			return i
 		}
-		return smallestIdx
-
-	default:
-		return 0
 	}
+	return -1
 }
 
-func processDuplicates(duplicates []DuplicateGroup) error {
+// processDuplicates deletes or moves every duplicate except each group's keeper.
+// When ctx is cancelled it stops before the next file, still reports what was
+// done and saves the undo log, then returns ctx's error. Each file's outcome is
+// reported through the engine's Events.
+func (e *Engine) processDuplicates(ctx context.Context, duplicates []DuplicateGroup) error {
 	var undoLog []UndoEntry
 
 	// Create move directory if specified
-	if cfg.MoveTo != "" {
-		if err := os.MkdirAll(cfg.MoveTo, 0755); err != nil {
+	if e.cfg.MoveTo != "" {
+		if err := os.MkdirAll(e.cfg.MoveTo, 0755); err != nil {
 			return fmt.Errorf("failed to create move directory: %w", err)
 		}
 	}
@@ -1033,149 +2200,413 @@ func processDuplicates(duplicates []DuplicateGroup) error {
 	totalDeleted := 0
 	totalSpace := int64(0)
 
-	log.Printf("\n🗑️  %s duplicates...", map[bool]string{true: "Moving", false: "Deleting"}[cfg.MoveTo != ""])
+	doing, _ := e.cfg.actionVerbs()
+	log.Printf("\n🗑️  %s duplicates...", doing)
 
 	// Warn users about permanent deletion
-	if cfg.Interactive && cfg.MoveTo == "" {
+	var session *interactiveSession
+	if e.cfg.Interactive {
+		session = newInteractiveSession(e.cfg.KeepCriteria)
+		if e.cfg.AnswersFile != "" {
+			answers, err := loadAnswers(e.cfg.AnswersFile)
+			if err != nil {
+				return err
+			}
+			session.answers = answers
+			log.Printf("%sLoaded %d group and %d file answers from %s", emoji("📋"), len(answers.groups), len(answers.files), e.cfg.AnswersFile)
+		}
+	}
+	if e.cfg.Interactive && e.cfg.MoveTo == "" && e.cfg.Link == "" && !e.cfg.Trash && !e.cfg.QuarantineDeletes {
 		log.Println("\n" + strings.Repeat("⚠️", 30))
 		log.Println("⚠️  WARNING: Files will be PERMANENTLY deleted!")
 		log.Println("⚠️  The -undo option only shows what was deleted.")
-		log.Println("⚠️  Use -move-to <folder> to move files instead of deleting.")
+		log.Println("⚠️  Use -move-to <folder>, -trash or -quarantine-deletes to keep them recoverable.")
 		log.Println("⚠️" + strings.Repeat("=", 55))
-		fmt.Print("Continue with permanent deletion? [y/N]: ")
-		var confirm string
-		fmt.Scanln(&confirm)
-		if strings.ToLower(confirm) != "y" {
+		if session.ask("Continue with permanent deletion? [y/N]: ") != "y" {
 			log.Println("❓ Operation cancelled. No files were deleted.")
 			return nil
 		}
 	}
 
+	// act removes one duplicate. Files another program has open are left
+	// for a retry pass at the end unless this is already the last try.
+	type lockedFile struct {
+		fh           FileHash
+		hash, keeper string
+	}
+	var locked []lockedFile
+	// Count the files to act on, for the progress line
+	planned := 0
 	for _, group := range duplicates {
-		keepIdx := selectFileToKeep(group)
-
+		keepIdx := selectFileToKeep(group, e.cfg.KeepCriteria)
+		if e.cfg.Link != "" {
+			keepIdx = localKeeper(group, keepIdx)
+		}
 		for i, fh := range group.Files {
-			if i != keepIdx {
-				// Interactive mode
-				if cfg.Interactive {
-					fmt.Printf("\nDelete %s? (%s) [y/n/q]: ", fh.Path, formatBytes(fh.Size))
-					var response string
-					fmt.Scanln(&response)
-					if strings.ToLower(response) != "y" {
-						if strings.ToLower(response) == "q" {
-							log.Println("❓ Quitting...")
-							return nil
-						}
-						continue
-					}
+			if i != keepIdx && !fh.Reference && fh.Host == "" {
+				planned++
+			}
+		}
+	}
+	progress := newActionProgress(e.cfg, planned)
+
+	act := func(fh FileHash, hash, keeper string, lastTry bool) error {
+		action := ActionEvent{Path: fh.Path, Size: fh.Size}
+		var err error
+		var line string
+		if e.cfg.Link != "" {
+			// Replace with a link to the kept copy
+			err = e.cfg.linkFile(keeper, fh.Path)
+			if err == nil {
+				line = fmt.Sprintf("✓ Linked %s -> %s", fh.Path, keeper)
+				action.Action, action.Target = "linked", keeper
+			}
+		} else if e.cfg.MoveTo != "" {
+			// Move to directory
+			targetPath := uniqueTargetPath(e.cfg.MoveTo, fh.Path)
+			err = e.cfg.moveFile(fh.Path, targetPath)
+			if err == nil {
+				line = fmt.Sprintf("✓ Moved %s -> %s", fh.Path, targetPath)
+				action.Action, action.Target = "moved", targetPath
+				if logErr := logMove(e.cfg.MoveTo, fh, targetPath); logErr != nil {
+					progress.logf("%s%v", emoji("⚠️"), logErr)
 				}
+			}
+		} else {
+			// Delete file
+			err = e.cfg.removeFile(fh.Path)
+			if err == nil {
+				_, done := e.cfg.actionVerbs()
+				line = fmt.Sprintf("✓ %s %s", done, fh.Path)
+				action.Action = strings.ToLower(done)
+			}
+		}
 
-				var err error
-				if cfg.MoveTo != "" {
-					// Move to directory
-					targetPath := filepath.Join(cfg.MoveTo, filepath.Base(fh.Path))
-					// Handle name conflicts
-					counter := 1
-					for {
-						if _, err := os.Stat(targetPath); os.IsNotExist(err) {
-							break
-						}
-						base := filepath.Base(fh.Path)
-						ext := filepath.Ext(base)
-						name := strings.TrimSuffix(base, ext)
-						targetPath = filepath.Join(cfg.MoveTo, fmt.Sprintf("%s_%d%s", name, counter, ext))
-						counter++
-					}
-					err = os.Rename(fh.Path, targetPath)
-					if err == nil {
-						log.Printf("✓ Moved %s -> %s", fh.Path, targetPath)
-					}
-				} else {
-					// Delete file
-					err = os.Remove(fh.Path)
-					if err == nil {
-						log.Printf("✓ Deleted %s", fh.Path)
+		if errors.Is(err, errFileLocked) && !lastTry {
+			return err
+		}
+		if err != nil {
+			action.Error = err.Error()
+			if !errors.Is(err, errFileLocked) {
+				line = fmt.Sprintf("❌ Failed to process %s: %v", fh.Path, err)
+			}
+			progress.failedOn(fh, err, line)
+		} else {
+			progress.succeeded(fh, line)
+			for _, line := range e.cfg.actOnLinks(fh, keeper) {
+				progress.logf("%s", line)
+			}
+			totalDeleted++
+			totalSpace += fh.Size
+			if e.known != nil {
+				e.known.removed(hash, keeper)
+			}
+			undoLog = append(undoLog, e.cfg.undoEntry(fh))
+		}
+		e.events.actionTaken(action)
+		return err
+	}
+
+	for gi, group := range duplicates {
+		if ctx.Err() != nil {
+			break
+		}
+		keepIdx := selectFileToKeep(group, e.cfg.KeepCriteria)
+
+		// Decide which files to remove
+		remove := othersThan(len(group.Files), keepIdx)
+		quit := false
+		if e.cfg.Interactive {
+			remove, quit = session.decideGroup(gi+1, len(duplicates), group, keepIdx)
+		}
+
+		// Links can only point at a copy on this machine
+		if e.cfg.Link != "" && len(remove) > 0 {
+			switch local := localKeeper(group, keepIdx); {
+			case local < 0:
+				progress.logf("%sLeaving group %d alone: -link needs a copy on this machine to link to", emoji("⚠️"), gi+1)
+				remove = nil
+			case local != keepIdx:
+				progress.logf("%sLinking group %d to %s: the kept file %s is indexed or remote", emoji("🔗"), gi+1, group.Files[local].Path, group.Files[keepIdx].Path)
+				keepIdx = local
+				others := remove[:0]
+				for _, i := range remove {
+					if i != local {
+						others = append(others, i)
 					}
 				}
+				remove = others
+			}
+		}
 
-				if err != nil {
-					log.Printf("❌ Failed to process %s: %v", fh.Path, err)
-				} else {
-					totalDeleted++
-					totalSpace += fh.Size
-					undoLog = append(undoLog, UndoEntry{
-						Path:        fh.Path,
-						Size:        fh.Size,
-						ModTime:     fh.ModTime,
-						Action:      "deleted",
-						Timestamp:   time.Now(),
-						TargetPath:  "",
-					})
-				}
+		// The copy that stays must still be what the scan found
+		if len(remove) > 0 {
+			if err := e.cfg.checkUnchanged(group.Files[keepIdx]); err != nil {
+				progress.logf("%sLeaving group %d alone: kept file %s: %v", emoji("⚠️"), gi+1, group.Files[keepIdx].Path, err)
+				remove = nil
+			}
+		}
+
+		for _, i := range remove {
+			if ctx.Err() != nil {
+				break
+			}
+			fh := group.Files[i]
+			if fh.Reference {
+				progress.logf("%sLeaving reference copy %s (reference files are never modified)", emoji("📇"), fh.Path)
+				continue
+			}
+			if fh.Host != "" {
+				progress.logf("%sLeaving remote copy %s (remote files are never modified)", emoji("🌐"), fh.Path)
+				continue
+			}
+			action := ActionEvent{Path: fh.Path, Size: fh.Size}
+			if err := e.cfg.checkUnchanged(fh); err != nil {
+				action.Error = err.Error()
+				progress.failedOn(fh, err, fmt.Sprintf("%sNot touching %s: %v", emoji("⚠️"), fh.Path, err))
+				e.events.actionTaken(action)
+				continue
+			}
+			// On a case-insensitive filesystem another spelling of the kept file's name is the kept file
+			if sameFile(fh.Path, group.Files[keepIdx].Path) {
+				progress.logf("%sNot touching %s: it is the kept file %s under another name", emoji("⚠️"), fh.Path, group.Files[keepIdx].Path)
+				continue
+			}
+			if err := act(fh, group.Hash, group.Files[keepIdx].Path, false); errors.Is(err, errFileLocked) {
+				locked = append(locked, lockedFile{fh, group.Hash, group.Files[keepIdx].Path})
 			}
 		}
+
+		if quit {
+			log.Println("❓ Quitting...")
+			break
+		}
+	}
+
+	lockedFiles := make([]FileHash, len(locked))
+	for i, l := range locked {
+		lockedFiles[i] = l.fh
 	}
+	if len(locked) > 0 {
+		progress.breakLine()
+	}
+	retryLocked(ctx, lockedFiles, func(i int) error {
+		return act(locked[i].fh, locked[i].hash, locked[i].keeper, true)
+	})
+	progress.finish()
+	e.failed = append(e.failed, progress.failures...)
 
-	log.Printf("\n✅ %s %d files, freed %s of space", map[bool]string{true: "Moved", false: "Deleted"}[cfg.MoveTo != ""], totalDeleted, formatBytes(totalSpace))
+	if err := ctx.Err(); err != nil {
+		log.Printf("%sStopped early: %v", emoji("⚠️"), err)
+	}
 
-	// Save undo log
-	if len(undoLog) > 0 && cfg.MoveTo == "" {
-		if err := saveUndoLog(undoLog); err != nil {
-			log.Printf("%sFailed to save undo log: %v", emoji("⚠️"), err)
-		} else {
-			log.Printf("%sUndo log saved (use -undo to view - files are NOT recoverable)", emoji("💾"))
+	e.cfg.logTotal(totalDeleted, totalSpace)
+
+	if e.known != nil && totalDeleted > 0 {
+		if err := e.known.save(); err != nil {
+			log.Printf("%sFailed to save known-hash database: %v", emoji("⚠️"), err)
 		}
 	}
 
-	return nil
+	e.cfg.saveUndo(undoLog)
+
+	return ctx.Err()
 }
 
 // processDuplicatesTUI handles duplicate processing with the new TUI interface
-func processDuplicatesTUI(duplicates []DuplicateGroup) error {
+func (e *Engine) processDuplicatesTUI(duplicates []DuplicateGroup) error {
 	// Convert DuplicateGroup to TUI format
 	tuiGroups := make([]tui.DuplicateGroup, len(duplicates))
 	for i, group := range duplicates {
-		files := make([]struct {
-			Path    string
-			Size    int64
-			ModTime string
-		}, len(group.Files))
-		for j, f := range group.Files {
-			files[j] = struct {
-				Path    string
-				Size    int64
-				ModTime string
-			}{
-				Path:    f.Path,
-				Size:    f.Size,
-				ModTime: f.ModTime.Format("2006-01-02"),
-			}
-		}
-		tuiGroups[i] = tui.ConvertDuplicateGroup(group.Hash, group.Size, files, group.Similarity)
+		tuiGroups[i] = tuiGroup(group)
 	}
 
 	// Run TUI
-	filesToDelete, err := tui.Run(tuiGroups)
+	review, err := tui.RunReview(tuiGroups)
 	if err != nil {
 		return fmt.Errorf("TUI error: %w", err)
 	}
+	return e.applyReview(duplicates, review)
+}
+
+// tuiGroup converts a group to the TUI format
+func tuiGroup(group DuplicateGroup) tui.DuplicateGroup {
+	files := make([]struct {
+		Path     string
+		Size     int64
+		ModTime  string
+		Hash     string
+		PHash    string
+		Distance int
+	}, len(group.Files))
+	perceptual := group.Similarity < 100.0 && !group.Unverified
+	for j, f := range group.Files {
+		files[j] = struct {
+			Path     string
+			Size     int64
+			ModTime  string
+			Hash     string
+			PHash    string
+			Distance int
+		}{
+			Path:     f.Path,
+			Size:     f.Size,
+			ModTime:  f.ModTime.Format("2006-01-02"),
+			Hash:     f.Hash,
+			PHash:    f.PHash,
+			Distance: -1,
+		}
+		if perceptual {
+			files[j].Distance = hammingDistance(group.Files[0].PHash, f.PHash)
+		}
+	}
+	converted := tui.ConvertDuplicateGroup(group.Hash, group.Size, files, group.Similarity)
+	converted.Tags = append([]string(nil), group.Tags...)
+	converted.MaxDistance = group.MaxDistance
+	converted.Location = group.Location
+	for j, f := range group.Files {
+		converted.Files[j].Similarity = f.Similarity
+	}
+	return converted
+}
+
+// applyReview keeps the tags and marks of a TUI review of duplicates and
+// processes the files selected in it
+func (e *Engine) applyReview(duplicates []DuplicateGroup, review tui.Review) error {
+	filesToDelete := review.Delete
+
+	// Keep the group tags for the next review session
+	if store, err := loadTagStore(e.cfg.tagFilePath()); err == nil && store.path != "" {
+		changed := false
+		for i, tags := range review.Tags {
+			if strings.Join(tags, "\x00") != strings.Join(duplicates[i].Tags, "\x00") {
+				store.set(duplicates[i], tags)
+				changed = true
+			}
+		}
+		if changed {
+			err = store.save()
+		}
+		if err != nil {
+			log.Printf("%sCould not save group tags: %v", emoji("⚠️"), err)
+		}
+	} else if err != nil {
+		log.Printf("%sCould not save group tags: %v", emoji("⚠️"), err)
+	}
+
+	// Remember the groups marked not a duplicate and the images marked different
+	if len(review.Ignored) > 0 || len(review.Distinct) > 0 {
+		list, err := loadIgnoreList(e.cfg.ignoreListPath())
+		if err == nil {
+			for _, i := range review.Ignored {
+				list.add(duplicates[i])
+			}
+			for i, marked := range review.Distinct {
+				for _, j := range marked {
+					for k, other := range duplicates[i].Files {
+						if k != j {
+							list.markDifferent(duplicates[i].Files[j], other)
+						}
+					}
+				}
+			}
+			err = list.save()
+		}
+		if err != nil {
+			log.Printf("%sCould not save the groups marked not a duplicate: %v", emoji("⚠️"), err)
+		} else {
+			log.Printf("%sGroups marked not a duplicate and images marked different will stay apart in future scans", emoji("🙈"))
+		}
+	}
+
+	// Process the selected files
+	var undoLog []UndoEntry
+	totalDeleted := 0
+	totalSpace := int64(0)
+
+	doing, _ := e.cfg.actionVerbs()
+	log.Printf("\n🗑️  %s %d selected files...", doing, len(filesToDelete))
+
+	selected := make(map[string]bool)
+	for _, path := range filesToDelete {
+		selected[path] = true
+	}
+	survivors := make(map[int]string) // per group: an unselected copy that still matches the scan
+
+	// act removes one selected file; files open elsewhere are retried at the end
+	var locked []FileHash
+	var keepers []string
+	act := func(fileInfo FileHash, keeper string) error {
+		path := fileInfo.Path
+		if e.cfg.Link != "" {
+			// Replace with a link to the kept copy
+			if err := e.cfg.linkFile(keeper, path); err != nil {
+				if !errors.Is(err, errFileLocked) {
+					log.Printf("❌ Failed to link %s: %v", path, err)
+				}
+				return err
+			}
+			log.Printf("✓ Linked %s -> %s", path, keeper)
+			totalDeleted++
+			totalSpace += fileInfo.Size
+			return nil
+		}
+		if e.cfg.MoveTo != "" {
+			// Move to directory
+			targetPath := uniqueTargetPath(e.cfg.MoveTo, path)
+			err := e.cfg.moveFile(path, targetPath)
+			if err != nil {
+				if !errors.Is(err, errFileLocked) {
+					log.Printf("❌ Failed to move %s: %v", path, err)
+				}
+				return err
+			}
+			log.Printf("✓ Moved %s -> %s", path, targetPath)
+			if err := logMove(e.cfg.MoveTo, fileInfo, targetPath); err != nil {
+				log.Printf("%s%v", emoji("⚠️"), err)
+			}
+			totalDeleted++
+			totalSpace += fileInfo.Size
+			return nil
+		}
+
+		// Delete file
+		err := e.cfg.removeFile(path)
+		if err != nil {
+			if !errors.Is(err, errFileLocked) {
+				log.Printf("❌ Failed to delete %s: %v", path, err)
+			}
+			return err
+		}
+		_, done := e.cfg.actionVerbs()
+		log.Printf("✓ %s %s", done, path)
+		totalDeleted++
+		totalSpace += fileInfo.Size
+		undoLog = append(undoLog, e.cfg.undoEntry(fileInfo))
+		return nil
+	}
+	// Every other name of a hard-linked file goes the same way, or no space is freed
+	actWithLinks := func(fileInfo FileHash, keeper string) error {
+		err := act(fileInfo, keeper)
+		if err == nil {
+			for _, line := range e.cfg.actOnLinks(fileInfo, keeper) {
+				log.Print(line)
+			}
+		}
+		return err
+	}
 
-	// Process the selected files
-	var undoLog []UndoEntry
-	totalDeleted := 0
-	totalSpace := int64(0)
-
-	log.Printf("\n🗑️  Deleting %d selected files...", len(filesToDelete))
-
 	for _, path := range filesToDelete {
 		// Find the file info from duplicates
 		var fileInfo FileHash
 		found := false
-		for _, group := range duplicates {
+		groupIdx := -1
+		for gi, group := range duplicates {
 			for _, f := range group.Files {
 				if f.Path == path {
 					fileInfo = f
 					found = true
+					groupIdx = gi
 					break
 				}
 			}
@@ -1188,61 +2619,67 @@ func processDuplicatesTUI(duplicates []DuplicateGroup) error {
 			log.Printf("⚠️  File not found in duplicates: %s", path)
 			continue
 		}
-
-		if cfg.MoveTo != "" {
-			// Move to directory
-			targetPath := filepath.Join(cfg.MoveTo, filepath.Base(path))
-			counter := 1
-			for {
-				if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+		if fileInfo.Reference {
+			log.Printf("%sLeaving reference copy %s (reference files are never modified)", emoji("📇"), path)
+			continue
+		}
+		if fileInfo.Host != "" {
+			log.Printf("%sLeaving remote copy %s (remote files are never modified)", emoji("🌐"), path)
+			continue
+		}
+		if err := e.cfg.checkUnchanged(fileInfo); err != nil {
+			log.Printf("%sNot touching %s: %v", emoji("⚠️"), path, err)
+			continue
+		}
+		keeper, checked := survivors[groupIdx]
+		if !checked {
+			for _, f := range duplicates[groupIdx].Files {
+				local := f.Host == "" && !f.Reference
+				if !selected[f.Path] && (local || e.cfg.Link == "") && e.cfg.checkUnchanged(f) == nil {
+					keeper = f.Path
 					break
 				}
-				base := filepath.Base(path)
-				ext := filepath.Ext(base)
-				name := strings.TrimSuffix(base, ext)
-				targetPath = filepath.Join(cfg.MoveTo, fmt.Sprintf("%s_%d%s", name, counter, ext))
-				counter++
-			}
-			if err := os.Rename(path, targetPath); err != nil {
-				log.Printf("❌ Failed to move %s: %v", path, err)
-			} else {
-				log.Printf("✓ Moved %s -> %s", path, targetPath)
-				totalDeleted++
-				totalSpace += fileInfo.Size
-			}
-		} else {
-			// Delete file
-			if err := os.Remove(path); err != nil {
-				log.Printf("❌ Failed to delete %s: %v", path, err)
-			} else {
-				log.Printf("✓ Deleted %s", path)
-				totalDeleted++
-				totalSpace += fileInfo.Size
-				undoLog = append(undoLog, UndoEntry{
-					Path:      path,
-					Size:      fileInfo.Size,
-					ModTime:   fileInfo.ModTime,
-					Action:    "deleted",
-					Timestamp: time.Now(),
-				})
 			}
+			survivors[groupIdx] = keeper
+		}
+		if keeper == "" {
+			log.Printf("%sNot touching %s: no unselected copy is unchanged since the scan", emoji("⚠️"), path)
+			continue
+		}
+		if sameFile(path, keeper) {
+			log.Printf("%sNot touching %s: it is the kept file %s under another name", emoji("⚠️"), path, keeper)
+			continue
 		}
-	}
-
-	log.Printf("\n✅ %s %d files, freed %s of space", map[bool]string{true: "Moved", false: "Deleted"}[cfg.MoveTo != ""], totalDeleted, formatBytes(totalSpace))
 
-	// Save undo log
-	if len(undoLog) > 0 && cfg.MoveTo == "" {
-		if err := saveUndoLog(undoLog); err != nil {
-			log.Printf("⚠️  Failed to save undo log: %v", err)
-		} else {
-			log.Printf("💾 Undo log saved (use -undo to restore)")
+		if errors.Is(actWithLinks(fileInfo, keeper), errFileLocked) {
+			locked = append(locked, fileInfo)
+			keepers = append(keepers, keeper)
 		}
 	}
+	retryLocked(context.Background(), locked, func(i int) error { return actWithLinks(locked[i], keepers[i]) })
+
+	e.cfg.logTotal(totalDeleted, totalSpace)
+
+	e.cfg.saveUndo(undoLog)
 
 	return nil
 }
 
+// uniqueTargetPath returns a path in dir for the base name of path, adding
+// a _N suffix when a file with that name already exists
+func uniqueTargetPath(dir, path string) string {
+	base := filepath.Base(path)
+	targetPath := filepath.Join(dir, base)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	for counter := 1; ; counter++ {
+		if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+			return targetPath
+		}
+		targetPath = filepath.Join(dir, fmt.Sprintf("%s_%d%s", name, counter, ext))
+	}
+}
+
 type UndoEntry struct {
 	Path       string    `json:"path"`
 	Size       int64     `json:"size"`
@@ -1250,10 +2687,30 @@ type UndoEntry struct {
 	Action     string    `json:"action"`
 	Timestamp  time.Time `json:"timestamp"`
 	TargetPath string    `json:"target_path,omitempty"`
+	Quarantine string    `json:"quarantine,omitempty"` // With -quarantine-deletes, the quarantine and batch the file went to
+	Batch      string    `json:"batch,omitempty"`
+}
+
+// saveUndo saves the undo log of a run that deleted files and says what
+// -undo can do with it. Moves and links leave their files in place and
+// keep no undo log.
+func (c Config) saveUndo(entries []UndoEntry) {
+	if len(entries) == 0 || c.UndoLog == "" || c.MoveTo != "" || c.Link != "" {
+		return
+	}
+	if err := saveUndoLog(c.UndoLog, entries); err != nil {
+		log.Printf("%sFailed to save undo log: %v", emoji("⚠️"), err)
+	} else if c.Trash {
+		log.Printf("%sUndo log saved (use -undo to view - the files can be restored from the trash)", emoji("💾"))
+	} else if c.QuarantineDeletes {
+		log.Printf("%sUndo log saved (use -undo to restore the quarantined files)", emoji("💾"))
+	} else {
+		log.Printf("%sUndo log saved (use -undo to view - files are NOT recoverable)", emoji("💾"))
+	}
 }
 
-func saveUndoLog(entries []UndoEntry) error {
-	return os.WriteFile(undoFile, []byte(fmt.Sprintf(`{"entries":%d,"files":%s}`,
+func saveUndoLog(path string, entries []UndoEntry) error {
+	return writeFileAtomic(path, []byte(fmt.Sprintf(`{"entries":%d,"files":%s}`,
 		len(entries),
 		toString(entries))), 0600)
 }
@@ -1263,19 +2720,41 @@ func toString(v interface{}) string {
 	return string(data)
 }
 
-func undoLast() error {
-	data, err := os.ReadFile(undoFile)
+func undoLast(path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("no undo log found: %w", err)
 	}
 
+	// A -quarantine-deletes run can really be undone
+	var undo struct {
+		Files []UndoEntry `json:"files"`
+	}
+	if err := json.Unmarshal(data, &undo); err != nil {
+		return fmt.Errorf("invalid undo log: %w", err)
+	}
+	if len(undo.Files) > 0 && undo.Files[0].Batch != "" {
+		entry := undo.Files[0]
+		fmt.Printf("Restore the %d files quarantined in batch %s? [y/N]: ", len(undo.Files), entry.Batch)
+		var response string
+		fmt.Scanln(&response)
+		if strings.ToLower(response) != "y" {
+			return nil
+		}
+		if err := undoRestore(entry.Quarantine, entry.Batch); err != nil {
+			return err
+		}
+		return os.Remove(path)
+	}
+
 	log.Println("\n" + strings.Repeat("⚠️", 30))
 	log.Println("⚠️  IMPORTANT: This undo log is INFORMATIONAL ONLY")
 	log.Println("⚠️  Files that were deleted CANNOT be restored.")
 	log.Println("⚠️  Only the metadata (what was deleted) is logged.")
 	log.Println("⚠️" + strings.Repeat("=", 55))
 	log.Println("")
-	log.Println("💡 TIP: Next time, use -move-to <folder> to safely move duplicates")
+	log.Println("💡 TIP: Next time, use -move-to <folder> to safely move duplicates,")
+	log.Println("💡       or -trash to send them to the system trash,")
 	log.Println("💡       instead of permanently deleting them.")
 	log.Println("")
 
@@ -1287,7 +2766,7 @@ func undoLast() error {
 	}
 
 	log.Println("")
-	log.Printf("💾 Undo log contents (%s):\n", undoFile)
+	log.Printf("💾 Undo log contents (%s):\n", path)
 	log.Println(strings.Repeat("=", 70))
 
 	var undoData map[string]interface{}
@@ -1322,14 +2801,45 @@ func undoLast() error {
 	return nil
 }
 
-func exportReport(duplicates []DuplicateGroup) error {
+func (e *Engine) exportReport(duplicates []DuplicateGroup) error {
+	data, err := e.marshalReport(duplicates, nil)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(reportFile, data, 0644)
+}
+
+// marshalReport encodes the report written by -export, -json and -audit,
+// with what the engine's last scan skipped and noticed; audit is only set
+// for -audit
+func (e *Engine) marshalReport(duplicates []DuplicateGroup, audit *auditInfo) ([]byte, error) {
 	type Report struct {
-		Version      string          `json:"version"`
-		Timestamp    time.Time       `json:"timestamp"`
-		Config       Config          `json:"config"`
-		DuplicateCount int           `json:"duplicate_count"`
-		TotalSpace   int64          `json:"total_space"`
-		Duplicates   []DuplicateGroup `json:"duplicates"`
+		Version        string           `json:"version"`
+		Timestamp      time.Time        `json:"timestamp"`
+		Config         Config           `json:"config"`
+		Redacted       bool             `json:"redacted,omitempty"`
+		Partial        string           `json:"partial,omitempty"` // where -max-files or -max-bytes stopped the scan
+		DuplicateCount int              `json:"duplicate_count"`
+		TotalSpace     int64            `json:"total_space"`
+		Duplicates     []DuplicateGroup `json:"duplicates"`
+		Skipped        []SkippedFile    `json:"skipped,omitempty"`
+		Integrity      []IntegrityIssue `json:"integrity,omitempty"`
+		HashAnomalies  []HashAnomaly    `json:"hash_anomalies,omitempty"`
+		AlreadyLinked  [][]string       `json:"already_linked,omitempty"`
+		DirPairs       []dirPair        `json:"dir_pairs,omitempty"`
+		HashAlgorithm  string           `json:"hash_algorithm"`
+		PHashAlgorithm string           `json:"phash_algorithm,omitempty"`
+		Audit          *auditInfo       `json:"audit,omitempty"`
+	}
+
+	skipped, integrity, linked, anomalies := e.Skipped(), e.IntegrityIssues(), e.AlreadyLinked(), e.HashAnomalies()
+	config := e.cfg
+	if e.cfg.Redact {
+		r := newRedactor()
+		duplicates, skipped, integrity, linked = r.findings(duplicates, skipped, integrity, linked)
+		anomalies = r.anomalies(anomalies)
+		config = r.config(e.cfg)
 	}
 
 	totalSpace := int64(0)
@@ -1340,46 +2850,51 @@ func exportReport(duplicates []DuplicateGroup) error {
 	report := Report{
 		Version:        version,
 		Timestamp:      time.Now(),
-		Config:         cfg,
+		Config:         config,
+		Redacted:       e.cfg.Redact,
+		Partial:        e.Partial(),
 		DuplicateCount: len(duplicates),
 		TotalSpace:     totalSpace,
 		Duplicates:     duplicates,
+		Skipped:        skipped,
+		Integrity:      integrity,
+		HashAnomalies:  anomalies,
+		AlreadyLinked:  linked,
+		DirPairs:       topDirectoryPairs(duplicates, e.cfg.DirPairs),
+		HashAlgorithm:  e.cfg.hashName(),
+		Audit:          audit,
 	}
-
-	data, err := json.MarshalIndent(report, "", "  ")
-	if err != nil {
-		return err
+	if e.cfg.PerceptualMode {
+		report.PHashAlgorithm = strings.ToLower(e.cfg.PHashAlgorithm)
 	}
 
-	return os.WriteFile(reportFile, data, 0644)
+	return json.MarshalIndent(report, "", "  ")
 }
 
-// outputJSON outputs the duplicate report as JSON to stdout
-func outputJSON(duplicates []DuplicateGroup) error {
-	type Report struct {
-		Version        string            `json:"version"`
-		Timestamp      time.Time         `json:"timestamp"`
-		Config         Config            `json:"config"`
-		DuplicateCount int               `json:"duplicate_count"`
-		TotalSpace     int64             `json:"total_space"`
-		Duplicates     []DuplicateGroup  `json:"duplicates"`
+// exportCSV writes one row per file in each duplicate group
+func (e *Engine) exportCSV(duplicates []DuplicateGroup) error {
+	f, err := createAtomic(csvReportFile, 0644)
+	if err != nil {
+		return err
 	}
+	defer f.Abort()
 
-	totalSpace := int64(0)
-	for _, group := range duplicates {
-		totalSpace += group.Size * int64(len(group.Files)-1)
+	rows := decisionRows(duplicates, e.cfg)
+	if e.cfg.Redact {
+		r := newRedactor()
+		for i := range rows {
+			rows[i].Path = r.file(rows[i].Path)
+		}
 	}
-
-	report := Report{
-		Version:        version,
-		Timestamp:      time.Now(),
-		Config:         cfg,
-		DuplicateCount: len(duplicates),
-		TotalSpace:     totalSpace,
-		Duplicates:     duplicates,
+	if err := writeDecisionsCSV(f, rows); err != nil {
+		return err
 	}
+	return f.Commit()
+}
 
-	data, err := json.MarshalIndent(report, "", "  ")
+// outputJSON outputs the duplicate report as JSON to stdout
+func (e *Engine) outputJSON(duplicates []DuplicateGroup) error {
+	data, err := e.marshalReport(duplicates, nil)
 	if err != nil {
 		return err
 	}
@@ -1397,8 +2912,8 @@ func configFile() string {
 	return filepath.Join(home, ".config", "file-deduplicator", "config.json")
 }
 
-// loadConfig loads the persisted configuration
-func loadConfig() {
+// loadPersistedConfig loads the persisted configuration into cfg
+func loadPersistedConfig(c *Config) {
 	configPath := configFile()
 	if configPath == "" {
 		return
@@ -1421,12 +2936,12 @@ func loadConfig() {
 
 	// Override theme if set in config and not overridden by flag
 	if pc.Theme != "" && !isFlagSet("theme") {
-		cfg.Theme = pc.Theme
+		c.Theme = pc.Theme
 	}
 }
 
 // saveConfig persists the configuration
-func saveConfig() error {
+func saveConfig(c Config) error {
 	configPath := configFile()
 	if configPath == "" {
 		return fmt.Errorf("cannot determine config path")
@@ -1443,7 +2958,7 @@ func saveConfig() error {
 	}
 
 	pc := persistedConfig{
-		Theme: cfg.Theme,
+		Theme: c.Theme,
 	}
 
 	data, err := json.MarshalIndent(pc, "", "  ")
@@ -1451,7 +2966,7 @@ func saveConfig() error {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	return writeFileAtomic(configPath, data, 0644)
 }
 
 // isFlagSet checks if a flag was explicitly set on the command line
@@ -1465,6 +2980,14 @@ func isFlagSet(name string) bool {
 	return found
 }
 
+// shortHash abbreviates a hash for display
+func shortHash(h string) string {
+	if len(h) <= 16 {
+		return h
+	}
+	return h[:16] + "..."
+}
+
 func formatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
@@ -1480,23 +3003,27 @@ func formatBytes(bytes int64) string {
 
 // formatFileError provides user-friendly error messages for common file issues
 func formatFileError(path string, err error) string {
-	// Check for common error types
-	errStr := err.Error()
-	
+	err = dedup.Classify(err)
 	switch {
-	case os.IsPermission(err):
+	case errors.Is(err, errFileLocked):
+		return fmt.Sprintf("%s: In use by another program. Close it and try again.", path)
+	case errors.Is(err, errReadOnly):
+		return fmt.Sprintf("%s: File is read-only. Use -clear-readonly to delete it anyway.", path)
+	case errors.Is(err, dedup.ErrPermission):
 		return fmt.Sprintf("%s: Permission denied. Try running with elevated privileges or check file ownership.", path)
-	case os.IsNotExist(err):
+	case errors.Is(err, os.ErrNotExist):
 		return fmt.Sprintf("%s: File not found. It may have been deleted or moved.", path)
-	case strings.Contains(errStr, "too many open files"):
+	case errors.Is(err, dedup.ErrTooManyOpenFiles):
 		return fmt.Sprintf("%s: System limit reached. Try reducing -workers count or increase ulimit.", path)
-	case strings.Contains(errStr, "input/output error") || strings.Contains(errStr, "I/O error"):
+	case errors.Is(err, dedup.ErrTransientIO):
+		return fmt.Sprintf("%s: Timed out or busy. Try again, or use -on-error retry.", path)
+	case errors.Is(err, dedup.ErrUnsupportedImage):
+		return fmt.Sprintf("%s: Not an image format perceptual hashing can decode.", path)
+	case errors.Is(err, syscall.EIO):
 		return fmt.Sprintf("%s: I/O error. The disk may be failing or the file is corrupted.", path)
-	case strings.Contains(errStr, "is a directory"):
+	case errors.Is(err, syscall.EISDIR):
 		return fmt.Sprintf("%s: Expected a file but found a directory.", path)
-	case strings.Contains(errStr, "no such file or directory"):
-		return fmt.Sprintf("%s: File not found. It may have been deleted during scanning.", path)
-	case strings.Contains(errStr, "invalid argument"):
+	case errors.Is(err, os.ErrInvalid), errors.Is(err, syscall.EINVAL):
 		return fmt.Sprintf("%s: Invalid file or path. Check for special characters in filename.", path)
 	default:
 		return fmt.Sprintf("%s: %v", path, err)
@@ -1504,13 +3031,13 @@ func formatFileError(path string, err error) string {
 }
 
 // printStatistics displays detailed operation statistics
-func printStatistics(stats *Statistics) {
+func printStatistics(c Config, stats *Statistics) {
 	scanDuration := stats.ScanEnd.Sub(stats.ScanStart).Seconds()
 	hashDuration := stats.HashEnd.Sub(stats.HashStart).Seconds()
 	processDuration := stats.ProcessEnd.Sub(stats.ProcessStart).Seconds()
 	totalDuration := stats.ProcessEnd.Sub(stats.ScanStart).Seconds()
 
-	if cfg.Verbose || !cfg.NoEmoji {
+	if c.Verbose || !c.NoEmoji {
 		log.Println("")
 		log.Println("📊 Detailed Statistics:")
 		log.Println("─────────────────────────────────────────────────────")
@@ -1566,25 +3093,40 @@ func printStatistics(stats *Statistics) {
 
 // WatchModeState tracks the state of the watch mode
 type WatchModeState struct {
-	mu          sync.RWMutex
-	hashMap     map[string][]FileHash // hash -> files
-	pHashMap    map[string][]FileHash // perceptual hash -> files (for images)
-	watchedDir  string
-	stats       WatchStats
+	mu         sync.RWMutex
+	hashMap    map[string][]FileHash // hash -> files
+	pHashMap   map[string][]FileHash // perceptual hash -> files (for images)
+	watchedDir string
+	stats      WatchStats
+	quarantine *quarantine            // where auto-clean puts files unless -move-to is set
+	pending    map[string]*time.Timer // auto-cleans waiting out -watch-grace
+	started    time.Time
+	recent     []string          // latest duplicates, newest last
+	dryRun     dryRunTally       // what auto-clean would have done with -dry-run
+	health     watchHealth       // overflows, errors and rescans so far
+	rescans    map[string]string // folder -> why it needs a rescan
+	unwatched  map[string]bool   // folders the watcher could not add
+	cfg        Config            // the options watch mode runs with
 }
 
 // WatchStats tracks statistics for watch mode
 type WatchStats struct {
-	FilesWatched    int
-	DuplicatesFound int
+	FilesWatched     int
+	DuplicatesFound  int
 	SpaceRecoverable int64
-	LastScan        time.Time
+	LastScan         time.Time
+	LastEvent        time.Time
+	Settling         int // files waiting out -watch-debounce
 }
 
 // runWatchMode starts the real-time duplicate detection mode
-func runWatchMode() error {
+func runWatchMode(c Config) error {
 	// Validate directory
-	absDir, err := filepath.Abs(cfg.Dir)
+	roots := c.roots()
+	if len(roots) > 1 {
+		log.Printf("%sWatch mode watches one directory; ignoring %s", emoji("⚠️"), strings.Join(roots[1:], ", "))
+	}
+	absDir, err := filepath.Abs(roots[0])
 	if err != nil {
 		return fmt.Errorf("cannot resolve directory: %w", err)
 	}
@@ -1593,11 +3135,25 @@ func runWatchMode() error {
 		return fmt.Errorf("%s is not a valid directory", absDir)
 	}
 
+	filter, err := newFileFilter(c)
+	if err != nil {
+		return err
+	}
+	if c.WatchAutoClean && !c.DryRun {
+		if err := c.checkLint(c.lintConfig()); err != nil {
+			return err
+		}
+	}
+
 	// Initialize state
 	state := &WatchModeState{
 		hashMap:    make(map[string][]FileHash),
 		pHashMap:   make(map[string][]FileHash),
 		watchedDir: absDir,
+		quarantine: newQuarantine(c.Quarantine, absDir),
+		pending:    make(map[string]*time.Timer),
+		started:    time.Now(),
+		cfg:        c,
 	}
 
 	log.Printf("%s═══════════════════════════════════════════════════════════", emoji("🔍"))
@@ -1605,20 +3161,35 @@ func runWatchMode() error {
 	log.Printf("%s═══════════════════════════════════════════════════════════", emoji("🔍"))
 	log.Printf("")
 	log.Printf("%sWatching: %s", emoji("📁"), absDir)
-	log.Printf("%sRecursive: %v", emoji("🔄"), cfg.Recursive)
-	log.Printf("%sMin size: %s", emoji("📏"), formatBytes(cfg.MinSize))
-	if cfg.MaxSize > 0 {
-		log.Printf("%sMax size: %s", emoji("📏"), formatBytes(cfg.MaxSize))
-	}
-	log.Printf("%sDebounce: %v", emoji("⏱️"), cfg.WatchDebounce)
-	if cfg.PerceptualMode {
-		log.Printf("%sPerceptual: %s (threshold: %d)", emoji("🖼️"), cfg.PHashAlgorithm, cfg.SimilarityThreshold)
-	}
-	if cfg.WatchAutoClean {
-		log.Printf("%sAUTO-CLEAN ENABLED - Duplicates will be %s automatically!", emoji("⚠️"), map[bool]string{true: "moved", false: "deleted"}[cfg.MoveTo != ""])
-		if cfg.MoveTo != "" {
-			log.Printf("%sMove target: %s", emoji("📦"), cfg.MoveTo)
+	log.Printf("%sRecursive: %v", emoji("🔄"), c.Recursive)
+	if c.MaxDepth > 0 {
+		log.Printf("%sMax depth: %d", emoji("📐"), c.MaxDepth)
+	}
+	log.Printf("%sMin size: %s", emoji("📏"), formatBytes(c.MinSize))
+	if len(c.MinSizeExt) > 0 {
+		log.Printf("%sMin size by type: %s", emoji("📏"), c.MinSizeExt.String())
+	}
+	if c.MaxSize > 0 {
+		log.Printf("%sMax size: %s", emoji("📏"), formatBytes(c.MaxSize))
+	}
+	if c.MaxAge > 0 {
+		log.Printf("%sMax age: %s", emoji("🕰️"), c.MaxAge.String())
+	}
+	log.Printf("%sDebounce: %v", emoji("⏱️"), c.WatchDebounce)
+	if c.PerceptualMode {
+		log.Printf("%sPerceptual: %s (threshold: %s)", emoji("🖼️"), c.PHashAlgorithm, c.thresholdLabel())
+	}
+	if c.WatchAutoClean && c.DryRun {
+		log.Printf("%sAuto-clean dry run - duplicates are only logged, with a summary each day", emoji("🧪"))
+		log.Printf("%sGrace period: %v", emoji("⏳"), c.WatchGrace)
+	} else if c.WatchAutoClean {
+		log.Printf("%sAUTO-CLEAN ENABLED - Duplicates will be %s automatically!", emoji("⚠️"), map[bool]string{true: "moved", false: "quarantined"}[c.MoveTo != ""])
+		if c.MoveTo != "" {
+			log.Printf("%sMove target: %s", emoji("📦"), c.MoveTo)
+		} else {
+			log.Printf("%sQuarantine: %s", emoji("📦"), state.quarantine.dir)
 		}
+		log.Printf("%sGrace period: %v", emoji("⏳"), c.WatchGrace)
 	}
 	log.Printf("")
 	log.Printf("%sPress Ctrl+C to stop watching...", emoji("💡"))
@@ -1632,26 +3203,37 @@ func runWatchMode() error {
 	defer watcher.Close()
 
 	// Add directory to watcher
-	if err := addWatchDir(watcher, absDir); err != nil {
+	if err := state.addWatchDir(watcher, absDir, absDir); err != nil {
 		return fmt.Errorf("failed to watch directory: %w", err)
 	}
 
 	// Initial scan - hash all existing files
 	log.Printf("%sPerforming initial scan...", emoji("🔄"))
-	if err := initialScan(state, absDir); err != nil {
+	if err := state.initialScan(absDir, filter); err != nil {
 		return fmt.Errorf("initial scan failed: %w", err)
 	}
 	log.Printf("%sInitial scan complete. Tracking %d file hashes.", emoji("✅"), state.countHashes())
+
+	// Let -watch-status query this watcher from another terminal
+	stopStatus, err := serveWatchStatus(state)
+	if err != nil {
+		log.Printf("%sStatus socket unavailable: %v", emoji("⚠️"), err)
+	} else {
+		defer stopStatus()
+	}
 	log.Printf("")
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Debounce timer for batch processing
-	var pendingFiles []string
-	var debounceTimer *time.Timer
-	debounceChan := make(chan struct{})
+	// Each file is processed on its own once it has settled
+	pending := newSettleQueue(c.WatchDebounce)
+	pending.verbose = c.Verbose
+	ticker := time.NewTicker(settleCheckInterval(c.WatchDebounce))
+	defer ticker.Stop()
+	retry := time.NewTicker(watchRetryInterval)
+	defer retry.Stop()
 
 	// Process events
 	for {
@@ -1659,6 +3241,9 @@ func runWatchMode() error {
 		case <-sigChan:
 			log.Printf("")
 			log.Printf("%sWatch mode stopped.", emoji("👋"))
+			if n := state.cancelPending(); n > 0 {
+				log.Printf("%sCancelled %d pending auto-cleans; those files were kept", emoji("⏳"), n)
+			}
 			state.printSummary()
 			return nil
 
@@ -1670,10 +3255,17 @@ func runWatchMode() error {
 			// Handle new directories (if recursive)
 			if event.Op&fsnotify.Create == fsnotify.Create {
 				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
-					if cfg.Recursive {
-						if err := addWatchDir(watcher, event.Name); err == nil && cfg.Verbose {
+					if link, err := os.Lstat(event.Name); err == nil && c.unfollowed(link) {
+						continue // a new link to a folder
+					}
+					if c.Recursive {
+						if err := state.addWatchDir(watcher, absDir, event.Name); err != nil {
+							state.unwatchable(event.Name, err)
+						} else if c.Verbose {
 							log.Printf("%sNow watching: %s", emoji("📁"), event.Name)
 						}
+						// Files created before the watch was added raised no events
+						state.needRescan(event.Name, "a new folder")
 					}
 					continue
 				}
@@ -1681,70 +3273,83 @@ func runWatchMode() error {
 
 			// Handle new/modified files
 			if event.Op&fsnotify.Create == fsnotify.Create || event.Op&fsnotify.Write == fsnotify.Write {
-				// Skip hidden files and directories
-				if strings.HasPrefix(filepath.Base(event.Name), ".") {
+				// Unfinished downloads are picked up under their final name
+				if isPartialFile(event.Name) {
+					if c.Verbose {
+						log.Printf("%sIgnoring partial file: %s", emoji("⏳"), event.Name)
+					}
 					continue
 				}
 
-				// Check file size
-				info, err := os.Stat(event.Name)
-				if err != nil || info.IsDir() || info.Size() < cfg.MinSize {
+				// Skip hidden and system files, then check size, pattern and extension filters
+				info, err := os.Lstat(event.Name)
+				if err != nil || c.leftOut(event.Name, info) || c.unfollowed(info) {
 					continue
 				}
-				if cfg.MaxSize > 0 && info.Size() > cfg.MaxSize {
+				info, err = os.Stat(event.Name)
+				if err != nil || info.IsDir() || filter.rejectFile(event.Name, info) != "" {
 					continue
 				}
 
-				// Check file pattern
-				if cfg.FilePattern != "" {
-					matched, _ := filepath.Match(cfg.FilePattern, filepath.Base(event.Name))
-					if !matched {
-						continue
-					}
-				}
-
-				// Add to pending files for debouncing
-				pendingFiles = append(pendingFiles, event.Name)
+				// Restart this file's debounce period
+				pending.touch(event.Name, time.Now())
+				state.setSettling(len(pending.files), time.Now())
+			}
 
-				// Reset debounce timer
-				if debounceTimer != nil {
-					debounceTimer.Stop()
-				}
-				debounceTimer = time.AfterFunc(cfg.WatchDebounce, func() {
-					debounceChan <- struct{}{}
-				})
+		case now := <-ticker.C:
+			if c.WatchAutoClean && c.DryRun {
+				state.mu.Lock()
+				state.dryRun.rollOver(now)
+				state.mu.Unlock()
 			}
 
-		case <-debounceChan:
-			// Process pending files
-			if len(pendingFiles) > 0 {
-				processNewFiles(state, pendingFiles)
-				pendingFiles = nil
+			// Catch up on whatever the watcher missed
+			state.runRescans(filter, pending, now)
+
+			// Process files that have been quiet and kept their size
+			if ready := pending.ready(now); len(ready) > 0 {
+				state.setSettling(len(pending.files), time.Time{})
+				state.processNewFiles(ready)
 			}
 
+		case <-retry.C:
+			state.retryUnwatchable(watcher)
+
 		case err, ok := <-watcher.Errors:
 			if !ok {
 				return nil
 			}
-			log.Printf("%sWatcher error: %v", emoji("⚠️"), err)
+			state.watchError(err)
 		}
 	}
 }
 
-// addWatchDir adds a directory and its subdirectories to the watcher
-func addWatchDir(watcher *fsnotify.Watcher, dir string) error {
+// addWatchDir adds a directory and its subdirectories to the watcher.
+// root is the watched directory, used to enforce -max-depth. Subdirectories
+// that cannot be watched are recorded in state to be tried again.
+func (s *WatchModeState) addWatchDir(watcher *fsnotify.Watcher, root, dir string) error {
+	if beyondMaxDepth(root, dir, s.cfg.MaxDepth) || (dir != root && (s.cfg.excludedDir(dir) || s.cfg.otherFilesystem(root, dir) || isOwnState(dir))) {
+		return nil
+	}
+	// A new hidden folder is only watched with -include-hidden
+	if info, err := os.Lstat(dir); err == nil && dir != root && s.cfg.leftOut(dir, info) {
+		return nil
+	}
 	if err := watcher.Add(dir); err != nil {
 		return err
 	}
 
-	if cfg.Recursive {
+	if s.cfg.Recursive {
 		return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return nil // Skip errors
 			}
-			if info.IsDir() && path != dir && !strings.HasPrefix(filepath.Base(path), ".") {
+			if info.IsDir() && path != dir {
+				if s.cfg.leftOut(path, info) || s.cfg.excludedDir(path) || beyondMaxDepth(root, path, s.cfg.MaxDepth) || s.cfg.otherFilesystem(root, path) || isOwnState(path) {
+					return filepath.SkipDir
+				}
 				if err := watcher.Add(path); err != nil {
-					return nil // Skip directories we can't watch
+					s.unwatchable(path, err)
 				}
 			}
 			return nil
@@ -1754,45 +3359,10 @@ func addWatchDir(watcher *fsnotify.Watcher, dir string) error {
 }
 
 // initialScan performs an initial scan of the directory
-func initialScan(state *WatchModeState, dir string) error {
-	var files []string
-
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip errors
-		}
-		if info.IsDir() {
-			if !cfg.Recursive && path != dir {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if strings.HasPrefix(filepath.Base(path), ".") {
-			return nil
-		}
-		if info.Size() < cfg.MinSize {
-			return nil
-		}
-		if cfg.MaxSize > 0 && info.Size() > cfg.MaxSize {
-			return nil
-		}
-		if cfg.FilePattern != "" {
-			matched, _ := filepath.Match(cfg.FilePattern, filepath.Base(path))
-			if !matched {
-				return nil
-			}
-		}
-		files = append(files, path)
-		return nil
-	})
-
-	if err != nil {
-		return err
-	}
-
+func (s *WatchModeState) initialScan(dir string, filter *fileFilter) error {
 	// Hash all files
-	for _, file := range files {
-		hasher := getHasher()
+	for _, file := range s.watchedFiles(dir, dir, filter) {
+		hasher := getHasher(s.cfg.HashAlgorithm)
 		hash, size, modTime, err := hashFile(file, hasher)
 		if err != nil {
 			continue
@@ -1806,35 +3376,70 @@ func initialScan(state *WatchModeState, dir string) error {
 		}
 
 		// Compute perceptual hash for images if enabled
-		if cfg.PerceptualMode && isImageFile(file) {
-			pHash, err := computePerceptualHash(file, cfg.PHashAlgorithm)
+		if s.cfg.perceptualCandidate(file) {
+			pHash, err := computePerceptualHash(file, s.cfg.PHashAlgorithm)
 			if err == nil {
 				fh.PHash = pHash
-				state.mu.Lock()
-				state.pHashMap[pHash] = append(state.pHashMap[pHash], fh)
-				state.mu.Unlock()
+				if s.cfg.SameDimensions {
+					fh.Width, fh.Height, _ = imageDimensions(file)
+				}
+				s.mu.Lock()
+				s.pHashMap[pHash] = append(s.pHashMap[pHash], fh)
+				s.mu.Unlock()
 			}
 		}
 
-		state.mu.Lock()
-		state.hashMap[hash] = append(state.hashMap[hash], fh)
-		state.stats.FilesWatched++
-		state.mu.Unlock()
+		s.mu.Lock()
+		s.hashMap[hash] = append(s.hashMap[hash], fh)
+		s.stats.FilesWatched++
+		s.mu.Unlock()
 	}
 
 	return nil
 }
 
-// processNewFiles hashes new files and checks for duplicates
-func processNewFiles(state *WatchModeState, files []string) {
+// watchedFiles lists the files below dir that a watcher of root tracks
+func (s *WatchModeState) watchedFiles(root, dir string, filter *fileFilter) []string {
+	engine := NewEngine(s.cfg, nil)
+
+	var files []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Skip errors
+		}
+		if info.IsDir() {
+			if !s.cfg.Recursive && path != root {
+				return filepath.SkipDir
+			}
+			if path != root && s.cfg.leftOut(path, info) {
+				return filepath.SkipDir
+			}
+			if beyondMaxDepth(root, path, s.cfg.MaxDepth) || s.cfg.otherFilesystem(root, path) || engine.skipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if s.cfg.leftOut(path, info) || s.cfg.unfollowed(info) || isPartialFile(path) {
+			return nil
+		}
+		if filter.rejectFile(path, info) != "" {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files
+}
+
+// processNewFiles hashes new files and checks for duplicates. Files are
+// expected to have settled already (see settleQueue).
+func (s *WatchModeState) processNewFiles(files []string) {
 	for _, file := range files {
-		// Wait for file to be fully written (check if it's still being modified)
-		time.Sleep(100 * time.Millisecond)
 
-		hasher := getHasher()
+		hasher := getHasher(s.cfg.HashAlgorithm)
 		hash, size, modTime, err := hashFile(file, hasher)
 		if err != nil {
-			if cfg.Verbose {
+			if s.cfg.Verbose {
 				log.Printf("%sCould not hash %s: %v", emoji("⚠️"), file, err)
 			}
 			continue
@@ -1848,71 +3453,91 @@ func processNewFiles(state *WatchModeState, files []string) {
 		}
 
 		// Check for exact duplicates
-		state.mu.RLock()
-		existingFiles, exists := state.hashMap[hash]
-		state.mu.RUnlock()
+		s.mu.RLock()
+		existingFiles, exists := s.hashMap[hash]
+		s.mu.RUnlock()
 
 		var duplicates []FileHash
 		var isDuplicate bool
 
-		if exists && len(existingFiles) > 0 {
-			isDuplicate = true
-			duplicates = existingFiles
+		// A file of another size only shares the hash by accident
+		for _, existing := range existingFiles {
+			if sameContent(existing, fh) {
+				duplicates = append(duplicates, existing)
+			} else if existing.Path != fh.Path {
+				log.Printf("%s%s and %s share a hash but not a size (%s and %s); not treating them as duplicates",
+					emoji("⚠️"), fh.Path, existing.Path, formatBytes(fh.Size), formatBytes(existing.Size))
+			}
 		}
+		isDuplicate = exists && len(duplicates) > 0
 
 		// Check for perceptual duplicates if enabled
 		var perceptualMatches []FileHash
-		if cfg.PerceptualMode && isImageFile(file) {
-			pHash, err := computePerceptualHash(file, cfg.PHashAlgorithm)
+		if s.cfg.perceptualCandidate(file) {
+			pHash, err := computePerceptualHash(file, s.cfg.PHashAlgorithm)
 			if err == nil {
 				fh.PHash = pHash
+				if s.cfg.SameDimensions {
+					fh.Width, fh.Height, _ = imageDimensions(file)
+				}
 
-				state.mu.RLock()
-				pFiles, pExists := state.pHashMap[pHash]
-				state.mu.RUnlock()
+				s.mu.RLock()
+				pFiles, pExists := s.pHashMap[pHash]
+				s.mu.RUnlock()
 
 				// Also check similar hashes (within threshold)
-				state.mu.RLock()
-				for existingPHash, files := range state.pHashMap {
+				s.mu.RLock()
+				for existingPHash, files := range s.pHashMap {
 					if existingPHash == pHash {
 						continue
 					}
 					dist := hammingDistance(pHash, existingPHash)
-					if dist >= 0 && dist <= cfg.SimilarityThreshold {
+					if dist >= 0 && dist <= s.cfg.maxDistance() {
 						perceptualMatches = append(perceptualMatches, files...)
 					}
 				}
-				state.mu.RUnlock()
+				s.mu.RUnlock()
+
+				// Resized copies are not duplicates with -same-dimensions
+				if s.cfg.SameDimensions {
+					perceptualMatches = withDimensionsOf(fh, perceptualMatches)
+					pFiles = withDimensionsOf(fh, pFiles)
+				}
 
 				if pExists && len(pFiles) > 0 {
 					isDuplicate = true
 					perceptualMatches = append(perceptualMatches, pFiles...)
 				}
 
-				state.mu.Lock()
-				state.pHashMap[pHash] = append(state.pHashMap[pHash], fh)
-				state.mu.Unlock()
+				s.mu.Lock()
+				s.pHashMap[pHash] = append(s.pHashMap[pHash], fh)
+				s.mu.Unlock()
 			}
 		}
 
 		// Add to hash map
-		state.mu.Lock()
-		state.hashMap[hash] = append(state.hashMap[hash], fh)
-		state.stats.FilesWatched++
-		state.mu.Unlock()
+		s.mu.Lock()
+		s.hashMap[hash] = append(s.hashMap[hash], fh)
+		s.stats.FilesWatched++
+		s.mu.Unlock()
 
 		// Report and handle duplicate
 		if isDuplicate {
-			state.mu.Lock()
-			state.stats.DuplicatesFound++
-			state.stats.SpaceRecoverable += size
-			state.mu.Unlock()
+			s.mu.Lock()
+			s.stats.DuplicatesFound++
+			s.stats.SpaceRecoverable += size
+			s.recent = append(s.recent, file)
+			if len(s.recent) > maxRecentDuplicates {
+				s.recent = s.recent[1:]
+			}
+			s.mu.Unlock()
 
 			reportDuplicate(file, duplicates, perceptualMatches, size)
 
 			// Handle auto-clean if enabled
-			if cfg.WatchAutoClean {
-				handleAutoClean(file, size)
+			if s.cfg.WatchAutoClean {
+				matches := append(append([]FileHash(nil), duplicates...), perceptualMatches...)
+				s.scheduleAutoClean(fh, matches)
 			}
 		} else {
 			log.Printf("%sNew file: %s (%s)", emoji("📄"), filepath.Base(file), formatBytes(size))
@@ -1920,6 +3545,77 @@ func processNewFiles(state *WatchModeState, files []string) {
 	}
 }
 
+// pendingFile is a watched file waiting to settle
+type pendingFile struct {
+	size    int64
+	modTime time.Time
+	since   time.Time // last event or size/mtime change
+}
+
+// settleQueue debounces watch events per path. A file is ready once it has
+// had no events and kept the same size and modification time for the
+// debounce interval, so a steady stream of other files cannot hold it back.
+type settleQueue struct {
+	debounce time.Duration
+	files    map[string]*pendingFile
+	verbose  bool // log files still being written
+}
+
+// newSettleQueue creates a queue with the given per-file debounce interval
+func newSettleQueue(debounce time.Duration) *settleQueue {
+	return &settleQueue{debounce: debounce, files: make(map[string]*pendingFile)}
+}
+
+// touch records an event for path, restarting its debounce period
+func (q *settleQueue) touch(path string, now time.Time) {
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(q.files, path)
+		return
+	}
+	q.files[path] = &pendingFile{size: info.Size(), modTime: info.ModTime(), since: now}
+}
+
+// ready removes and returns the files that have settled by now. Files that
+// changed since the last check start a new debounce period; files that
+// disappeared are dropped.
+func (q *settleQueue) ready(now time.Time) []string {
+	var settled []string
+	for path, pf := range q.files {
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(q.files, path)
+			continue
+		}
+		if info.Size() != pf.size || !info.ModTime().Equal(pf.modTime) {
+			if q.verbose {
+				log.Printf("%sStill being written: %s", emoji("⏳"), path)
+			}
+			pf.size, pf.modTime, pf.since = info.Size(), info.ModTime(), now
+			continue
+		}
+		if now.Sub(pf.since) >= q.debounce {
+			settled = append(settled, path)
+			delete(q.files, path)
+		}
+	}
+	sort.Strings(settled)
+	return settled
+}
+
+// settleCheckInterval is how often pending files are checked: a fraction of
+// the debounce interval, within sensible bounds
+func settleCheckInterval(debounce time.Duration) time.Duration {
+	interval := debounce / 4
+	if interval < 50*time.Millisecond {
+		return 50 * time.Millisecond
+	}
+	if interval > time.Second {
+		return time.Second
+	}
+	return interval
+}
+
 // reportDuplicate reports a found duplicate
 func reportDuplicate(file string, exactMatches []FileHash, perceptualMatches []FileHash, size int64) {
 	log.Printf("")
@@ -1948,39 +3644,154 @@ func reportDuplicate(file string, exactMatches []FileHash, perceptualMatches []F
 	log.Printf("")
 }
 
-// handleAutoClean automatically handles duplicates
-func handleAutoClean(file string, size int64) {
-	if cfg.MoveTo != "" {
+// scheduleAutoClean cleans fh once -watch-grace has passed, so files still
+// being written or copied on purpose get a chance to change or disappear
+func (s *WatchModeState) scheduleAutoClean(fh FileHash, matches []FileHash) {
+	if s.cfg.WatchGrace <= 0 {
+		s.autoClean(fh, matches)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if timer, ok := s.pending[fh.Path]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(s.cfg.WatchGrace, func() {
+		s.mu.Lock()
+		if s.pending[fh.Path] == timer {
+			delete(s.pending, fh.Path)
+		}
+		s.mu.Unlock()
+		s.autoClean(fh, matches)
+	})
+	s.pending[fh.Path] = timer
+	log.Printf("%sWill clean %s in %v unless it changes", emoji("⏳"), fh.Path, s.cfg.WatchGrace)
+}
+
+// cancelPending stops every auto-clean still in its grace period
+func (s *WatchModeState) cancelPending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cancelled := 0
+	for path, timer := range s.pending {
+		if timer.Stop() {
+			cancelled++
+		}
+		delete(s.pending, path)
+	}
+	return cancelled
+}
+
+// autoClean moves fh to -move-to or the quarantine, provided it is unchanged
+// since it was hashed and one of its matches still exists
+func (s *WatchModeState) autoClean(fh FileHash, matches []FileHash) {
+	info, err := os.Stat(fh.Path)
+	if err != nil {
+		log.Printf("%sNot cleaning %s: it no longer exists", emoji("ℹ️"), fh.Path)
+		return
+	}
+	if info.Size() != fh.Size || !info.ModTime().Equal(fh.ModTime) {
+		log.Printf("%sNot cleaning %s: it changed during the grace period", emoji("ℹ️"), fh.Path)
+		return
+	}
+	original := ""
+	for _, m := range matches {
+		if m.Path == fh.Path {
+			continue
+		}
+		if _, err := os.Stat(m.Path); err == nil {
+			original = m.Path
+			break
+		}
+	}
+	if original == "" {
+		log.Printf("%sNot cleaning %s: no other copy exists any more", emoji("ℹ️"), fh.Path)
+		return
+	}
+	target := s.quarantine.dir
+	if s.cfg.MoveTo != "" {
+		target = s.cfg.MoveTo
+	}
+	if err := s.cfg.checkWritable(fh.Path, target); err != nil {
+		log.Printf("%sNot cleaning %s: %v", emoji("🔒"), fh.Path, err)
+		return
+	}
+
+	if s.cfg.DryRun {
+		action, target := "quarantine", s.quarantine.target(fh)
+		if s.cfg.MoveTo != "" {
+			action, target = "move", uniqueTargetPath(s.cfg.MoveTo, fh.Path)
+		}
+		log.Printf("%sDry run: would %s %s -> %s (copy of %s, %s)", emoji("🧪"), action, fh.Path, target, original, formatBytes(fh.Size))
+		s.mu.Lock()
+		s.dryRun.add(fh, time.Now())
+		s.mu.Unlock()
+		return
+	}
+
+	if s.cfg.MoveTo != "" {
 		// Create move directory if it doesn't exist
-		os.MkdirAll(cfg.MoveTo, 0755)
+		os.MkdirAll(s.cfg.MoveTo, 0755)
 
 		// Move the file
-		targetPath := filepath.Join(cfg.MoveTo, filepath.Base(file))
-		counter := 1
-		for {
-			if _, err := os.Stat(targetPath); os.IsNotExist(err) {
-				break
+		targetPath := uniqueTargetPath(s.cfg.MoveTo, fh.Path)
+
+		if err := s.cfg.journaled("move", fh.Path, targetPath, func() error { return renameFile(fh.Path, targetPath) }); err != nil {
+			log.Printf("%sFailed to move %s: %v", emoji("❌"), fh.Path, err)
+			return
+		}
+		log.Printf("%sAuto-moved: %s -> %s", emoji("📦"), fh.Path, targetPath)
+		if err := logMove(s.cfg.MoveTo, fh, targetPath); err != nil {
+			log.Printf("%s%v", emoji("⚠️"), err)
+		}
+	} else {
+		var targetPath string
+		err := s.cfg.journaled("move", fh.Path, s.quarantine.dir, func() (err error) {
+			targetPath, err = s.quarantine.add(fh)
+			if targetPath != "" {
+				return nil // moved; only the quarantine log failed
 			}
-			ext := filepath.Ext(file)
-			name := strings.TrimSuffix(filepath.Base(file), ext)
-			targetPath = filepath.Join(cfg.MoveTo, fmt.Sprintf("%s_%d%s", name, counter, ext))
-			counter++
+			return err
+		})
+		if targetPath == "" {
+			log.Printf("%sFailed to quarantine %s: %v", emoji("❌"), fh.Path, err)
+			return
+		}
+		if err != nil {
+			log.Printf("%s%v", emoji("⚠️"), err)
 		}
+		log.Printf("%sAuto-quarantined: %s -> %s", emoji("📦"), fh.Path, targetPath)
+	}
+	s.forget(fh)
+}
 
-		if err := os.Rename(file, targetPath); err != nil {
-			log.Printf("%sFailed to move %s: %v", emoji("❌"), file, err)
-		} else {
-			log.Printf("%sAuto-moved: %s -> %s", emoji("📦"), file, targetPath)
+// forget stops tracking a file that auto-clean removed
+func (s *WatchModeState) forget(fh FileHash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashMap[fh.Hash] = withoutPath(s.hashMap[fh.Hash], fh.Path)
+	if len(s.hashMap[fh.Hash]) == 0 {
+		delete(s.hashMap, fh.Hash)
+	}
+	if fh.PHash != "" {
+		s.pHashMap[fh.PHash] = withoutPath(s.pHashMap[fh.PHash], fh.Path)
+		if len(s.pHashMap[fh.PHash]) == 0 {
+			delete(s.pHashMap, fh.PHash)
 		}
-	} else {
-		// Delete the file
-		if err := os.Remove(file); err != nil {
-			log.Printf("%sFailed to delete %s: %v", emoji("❌"), file, err)
-		} else {
-			log.Printf("%sAuto-deleted: %s", emoji("🗑️"), file)
+	}
+}
+
+// withoutPath returns a copy of files without the entries for path
+func withoutPath(files []FileHash, path string) []FileHash {
+	var kept []FileHash
+	for _, f := range files {
+		if f.Path != path {
+			kept = append(kept, f)
 		}
 	}
-	log.Printf("")
+	return kept
 }
 
 // countHashes returns the total number of unique hashes
@@ -1990,6 +3801,17 @@ func (s *WatchModeState) countHashes() int {
 	return len(s.hashMap) + len(s.pHashMap)
 }
 
+// setSettling records how many files are debouncing and, if set, when the
+// last file event arrived
+func (s *WatchModeState) setSettling(n int, lastEvent time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Settling = n
+	if !lastEvent.IsZero() {
+		s.stats.LastEvent = lastEvent
+	}
+}
+
 // printSummary prints the watch mode summary
 func (s *WatchModeState) printSummary() {
 	s.mu.RLock()
@@ -2003,21 +3825,24 @@ func (s *WatchModeState) printSummary() {
 	log.Printf("%sFiles tracked: %d", emoji("📄"), s.stats.FilesWatched)
 	log.Printf("%sDuplicates found: %d", emoji("👯"), s.stats.DuplicatesFound)
 	log.Printf("%sSpace recoverable: %s", emoji("💾"), formatBytes(s.stats.SpaceRecoverable))
+	if s.cfg.WatchAutoClean && s.cfg.DryRun {
+		log.Printf("%sAuto-clean would have cleaned: %d files (%s)", emoji("🧪"), s.dryRun.files, formatBytes(s.dryRun.bytes))
+	}
 	log.Printf("")
 }
 
 // compareImagesCLI handles the -compare flag for comparing two images
-func compareImagesCLI() error {
+func compareImagesCLI(c Config) error {
 	// Parse the compare argument (can be comma-separated or use -compare-with)
 	var img1, img2 string
 
-	if strings.Contains(cfg.CompareImg1, ",") {
-		parts := strings.SplitN(cfg.CompareImg1, ",", 2)
+	if strings.Contains(c.CompareImg1, ",") {
+		parts := strings.SplitN(c.CompareImg1, ",", 2)
 		img1 = strings.TrimSpace(parts[0])
 		img2 = strings.TrimSpace(parts[1])
-	} else if cfg.CompareImg2 != "" {
-		img1 = cfg.CompareImg1
-		img2 = cfg.CompareImg2
+	} else if c.CompareImg2 != "" {
+		img1 = c.CompareImg1
+		img2 = c.CompareImg2
 	} else {
 		return fmt.Errorf("usage: -compare img1,img2 OR -compare img1 -compare-with img2")
 	}
@@ -2035,7 +3860,7 @@ func compareImagesCLI() error {
 	log.Printf("Comparing images...")
 	log.Printf("   Image 1: %s", img1)
 	log.Printf("   Image 2: %s", img2)
-	log.Printf("   Algorithm: %s", cfg.PHashAlgorithm)
+	log.Printf("   Algorithm: %s", c.PHashAlgorithm)
 
 	// Compute hashes for both images using all three algorithms
 	algorithms := []string{"dhash", "ahash", "phash"}
@@ -2058,7 +3883,7 @@ func compareImagesCLI() error {
 		}
 
 		dist := hammingDistance(hash1, hash2)
-		similarity := 100.0 - (float64(dist)/64.0*100.0)
+		similarity := 100.0 - (float64(dist) / 64.0 * 100.0)
 		threshold := thresholds[algo]
 		isSimilar := dist <= threshold
 
@@ -2082,18 +3907,18 @@ func compareImagesCLI() error {
 	fmt.Println(strings.Repeat("=", 70))
 
 	// Use the requested algorithm for final recommendation
-	reqHash1, _ := computePerceptualHash(img1, cfg.PHashAlgorithm)
-	reqHash2, _ := computePerceptualHash(img2, cfg.PHashAlgorithm)
+	reqHash1, _ := computePerceptualHash(img1, c.PHashAlgorithm)
+	reqHash2, _ := computePerceptualHash(img2, c.PHashAlgorithm)
 	reqDist := hammingDistance(reqHash1, reqHash2)
-	reqSimilarity := 100.0 - (float64(reqDist)/64.0*100.0)
+	reqSimilarity := 100.0 - (float64(reqDist) / 64.0 * 100.0)
 
-	if reqDist <= cfg.SimilarityThreshold {
-		fmt.Printf("Images are SIMILAR (using %s, threshold %d)\n",
-			cfg.PHashAlgorithm, cfg.SimilarityThreshold)
+	if reqDist <= c.maxDistance() {
+		fmt.Printf("Images are SIMILAR (using %s, threshold %s)\n",
+			c.PHashAlgorithm, c.thresholdLabel())
 		fmt.Printf("   Similarity: %.1f%% (distance: %d)\n", reqSimilarity, reqDist)
 	} else {
-		fmt.Printf("Images are DIFFERENT (using %s, threshold %d)\n",
-			cfg.PHashAlgorithm, cfg.SimilarityThreshold)
+		fmt.Printf("Images are DIFFERENT (using %s, threshold %s)\n",
+			c.PHashAlgorithm, c.thresholdLabel())
 		fmt.Printf("   Similarity: %.1f%% (distance: %d)\n", reqSimilarity, reqDist)
 	}
 	fmt.Println()
@@ -2103,10 +3928,10 @@ func compareImagesCLI() error {
 
 // getBinaryDir returns the directory where the executable is located.
 // Falls back to current directory on errors or when running via `go run`.
-func getBinaryDir() string {
+func getBinaryDir(verbose bool) string {
 	execPath, err := os.Executable()
 	if err != nil {
-		if cfg.Verbose {
+		if verbose {
 			log.Printf("%sCould not get executable path: %v", emoji("⚠️"), err)
 		}
 		return "" // fallback to default
@@ -2123,7 +3948,7 @@ func getBinaryDir() string {
 	base := filepath.Base(realPath)
 	if strings.Contains(realPath, "go-build") ||
 		strings.HasPrefix(base, "go-build") {
-		if cfg.Verbose {
+		if verbose {
 			log.Printf("%sDetected go run mode, using current directory", emoji("ℹ️"))
 		}
 		return "" // fallback to current directory
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -80,19 +82,19 @@ func TestFindDuplicates(t *testing.T) {
 		},
 	}
 
-	duplicates := findDuplicates(fileHashes)
+	duplicates := testEngine().findDuplicates(fileHashes)
 
 	if len(duplicates) != 1 {
-		t.Errorf("findDuplicates() found %d groups, want 1", len(duplicates))
+		t.Errorf("testEngine().findDuplicates() found %d groups, want 1", len(duplicates))
 	}
 
 	if len(duplicates) > 0 {
 		group := duplicates[0]
 		if group.Hash != "hash1" {
-			t.Errorf("findDuplicates() hash = %s, want hash1", group.Hash)
+			t.Errorf("testEngine().findDuplicates() hash = %s, want hash1", group.Hash)
 		}
 		if len(group.Files) != 3 {
-			t.Errorf("findDuplicates() files = %d, want 3", len(group.Files))
+			t.Errorf("testEngine().findDuplicates() files = %d, want 3", len(group.Files))
 		}
 	}
 }
@@ -153,7 +155,7 @@ func TestScanFiles(t *testing.T) {
 	}
 
 	// Test recursive scan
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -191,7 +193,7 @@ func TestScanFilesNonRecursive(t *testing.T) {
 	}
 
 	// Test non-recursive scan
-	files, err := scanFiles(tmpDir, false)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, false)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -235,7 +237,7 @@ func BenchmarkFindDuplicates(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = findDuplicates(fileHashes)
+		_ = testEngine().findDuplicates(fileHashes)
 	}
 }
 
@@ -251,7 +253,7 @@ func TestScanFilesEmptyDirectory(t *testing.T) {
 	}
 
 	// Scan should succeed with no files
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -278,7 +280,7 @@ func TestScanFilesNestedEmptyDirectories(t *testing.T) {
 	}
 
 	// Scan should succeed
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -303,14 +305,23 @@ func TestScanFilesSymlinkToFile(t *testing.T) {
 		t.Fatalf("Failed to create symlink: %v", err)
 	}
 
-	// Scan should include both the file and the symlink
-	files, err := scanFiles(tmpDir, true)
+	// Links are skipped by default
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
+	if len(files) != 1 {
+		t.Errorf("scanFiles() found %d files, want 1 (the link is skipped)", len(files))
+	}
 
-	// Should find 2 entries (file + symlink)
-	// Note: symlinks are followed by default, so both will be hashed
+	// With -follow-symlinks both are listed, and collapseHardlinks later
+	// sees they are one file
+	c := DefaultConfig()
+	c.FollowSymlinks = true
+	files, err = NewEngine(c, nil).scanFiles(context.Background(), tmpDir, true)
+	if err != nil {
+		t.Fatalf("scanFiles() error = %v", err)
+	}
 	if len(files) != 2 {
 		t.Errorf("scanFiles() found %d files, want 2 (file + symlink)", len(files))
 	}
@@ -334,16 +345,25 @@ func TestScanFilesSymlinkToDirectory(t *testing.T) {
 		t.Fatalf("Failed to create symlink: %v", err)
 	}
 
-	// Scan should follow the symlink and find files
-	files, err := scanFiles(tmpDir, true)
+	// The linked folder is skipped by default
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
+	if len(files) != 1 {
+		t.Errorf("scanFiles() found %d files, want 1", len(files))
+	}
 
-	// Should find files (behavior depends on whether symlinks to dirs are followed)
-	// At minimum, it shouldn't crash or infinite loop
-	if len(files) < 1 {
-		t.Errorf("scanFiles() found %d files, expected at least 1", len(files))
+	// With -follow-symlinks subdir is scanned once, under whichever path
+	// reaches it first
+	c := DefaultConfig()
+	c.FollowSymlinks = true
+	files, err = NewEngine(c, nil).scanFiles(context.Background(), tmpDir, true)
+	if err != nil {
+		t.Fatalf("scanFiles() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("scanFiles() following links found %v, want file.txt once", files)
 	}
 }
 
@@ -357,7 +377,7 @@ func TestScanFilesBrokenSymlink(t *testing.T) {
 	}
 
 	// Scan should handle broken symlinks gracefully
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -384,12 +404,11 @@ func TestScanFilesSymlinkLoop(t *testing.T) {
 		t.Fatalf("Failed to create loop symlink: %v", err)
 	}
 
-	// Scan should handle symlink loops without infinite recursion
-	// filepath.Walk should detect and skip loops
-	_, err := scanFiles(tmpDir, true)
-	if err != nil {
-		// Some error is acceptable (loop detected), but it shouldn't hang
-		t.Logf("scanFiles() returned error for symlink loop (expected): %v", err)
+	// Following links, the loop is noticed rather than walked forever
+	c := DefaultConfig()
+	c.FollowSymlinks = true
+	if _, err := NewEngine(c, nil).scanFiles(context.Background(), tmpDir, true); err != nil {
+		t.Errorf("scanFiles() error = %v", err)
 	}
 }
 
@@ -446,7 +465,7 @@ func TestScanFilesUnicodeFilename(t *testing.T) {
 	}
 
 	// Scan should find all unicode files
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -538,7 +557,7 @@ func TestScanFilesVeryLongPath(t *testing.T) {
 	}
 
 	// Scan should find the file
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -553,9 +572,9 @@ func TestScanFilesVeryLongPath(t *testing.T) {
 
 func TestFindDuplicatesEmptyInput(t *testing.T) {
 	// Empty input should return empty result
-	duplicates := findDuplicates([]FileHash{})
+	duplicates := testEngine().findDuplicates([]FileHash{})
 	if len(duplicates) != 0 {
-		t.Errorf("findDuplicates() with empty input returned %d groups, want 0", len(duplicates))
+		t.Errorf("testEngine().findDuplicates() with empty input returned %d groups, want 0", len(duplicates))
 	}
 }
 
@@ -567,9 +586,9 @@ func TestFindDuplicatesNoDuplicates(t *testing.T) {
 		{Path: "/c.txt", Size: 300, Hash: "hash3", ModTime: time.Now()},
 	}
 
-	duplicates := findDuplicates(fileHashes)
+	duplicates := testEngine().findDuplicates(fileHashes)
 	if len(duplicates) != 0 {
-		t.Errorf("findDuplicates() found %d groups, want 0 (no duplicates)", len(duplicates))
+		t.Errorf("testEngine().findDuplicates() found %d groups, want 0 (no duplicates)", len(duplicates))
 	}
 }
 
@@ -579,9 +598,9 @@ func TestFindDuplicatesSingleFile(t *testing.T) {
 		{Path: "/a.txt", Size: 100, Hash: "hash1", ModTime: time.Now()},
 	}
 
-	duplicates := findDuplicates(fileHashes)
+	duplicates := testEngine().findDuplicates(fileHashes)
 	if len(duplicates) != 0 {
-		t.Errorf("findDuplicates() with single file returned %d groups, want 0", len(duplicates))
+		t.Errorf("testEngine().findDuplicates() with single file returned %d groups, want 0", len(duplicates))
 	}
 }
 
@@ -599,9 +618,9 @@ func TestFindDuplicatesManyGroups(t *testing.T) {
 		{Path: "/c.txt", Size: 300, Hash: "hash3", ModTime: time.Now()},
 	}
 
-	duplicates := findDuplicates(fileHashes)
+	duplicates := testEngine().findDuplicates(fileHashes)
 	if len(duplicates) != 2 {
-		t.Errorf("findDuplicates() found %d groups, want 2", len(duplicates))
+		t.Errorf("testEngine().findDuplicates() found %d groups, want 2", len(duplicates))
 	}
 
 	// Check group sizes
@@ -719,7 +738,7 @@ func TestScanFilesWithHiddenDirectory(t *testing.T) {
 	}
 
 	// Scan should skip hidden directory
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -734,6 +753,45 @@ func TestScanFilesWithHiddenDirectory(t *testing.T) {
 	}
 }
 
+func TestIncludeHiddenLeavesOwnStateOut(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"inbox.mbox":                          "mail",
+		".thunderbird/default/inbox.mbox":     "mail",
+		".deduplicator_quarantine/inbox.mbox": "mail",
+		".deduplicator_undo.json":             "[]",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.JSON = true
+	c.IncludeHidden = true
+	files, err := NewEngine(c, nil).collectFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, fh := range files {
+		rel, _ := filepath.Rel(dir, fh.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != ".thunderbird/default/inbox.mbox" || got[1] != "inbox.mbox" {
+		t.Errorf("collectFiles() = %v, want the profile and inbox.mbox but none of the tool's own files", got)
+	}
+
+	// The quarantine can still be scanned on purpose
+	c.Dir = stringList{filepath.Join(dir, ".deduplicator_quarantine")}
+	if files, err := NewEngine(c, nil).collectFiles(context.Background()); err != nil || len(files) != 1 {
+		t.Errorf("collectFiles() of the quarantine = %d files, %v; want 1", len(files), err)
+	}
+}
+
 func TestScanFilesMultipleExtensions(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -747,7 +805,7 @@ func TestScanFilesMultipleExtensions(t *testing.T) {
 		}
 	}
 
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -779,7 +837,7 @@ func TestScanFilesSpecialCharactersInName(t *testing.T) {
 		}
 	}
 
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -871,8 +929,8 @@ func TestMinSizeFilter(t *testing.T) {
 	}{
 		{"tiny.txt", []byte("x")},
 		{"small.txt", []byte("small content")},
-		{"medium.txt", make([]byte, 2048)},  // 2KB
-		{"large.txt", make([]byte, 10240)},  // 10KB
+		{"medium.txt", make([]byte, 2048)}, // 2KB
+		{"large.txt", make([]byte, 10240)}, // 10KB
 	}
 
 	for _, f := range files {
@@ -883,7 +941,7 @@ func TestMinSizeFilter(t *testing.T) {
 	}
 
 	// Scan all files
-	allFiles, err := scanFiles(tmpDir, true)
+	allFiles, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -911,7 +969,7 @@ func TestFilePatternFilter(t *testing.T) {
 	}
 
 	// Scan all files
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -924,21 +982,20 @@ func TestFilePatternFilter(t *testing.T) {
 
 // Test duplicate detection with different file sizes (shouldn't match)
 func TestFindDuplicatesDifferentSizes(t *testing.T) {
-	// Files with same hash but different sizes shouldn't exist in practice
-	// but we test that size is correctly captured
+	// Files with same hash but different sizes are a misread or a collision,
+	// never copies
 	fileHashes := []FileHash{
 		{Path: "/a.txt", Size: 100, Hash: "hash1", ModTime: time.Now()},
-		{Path: "/b.txt", Size: 200, Hash: "hash1", ModTime: time.Now()}, // Same hash, diff size (impossible but test)
+		{Path: "/b.txt", Size: 200, Hash: "hash1", ModTime: time.Now()}, // Same hash, diff size
 	}
 
-	duplicates := findDuplicates(fileHashes)
-	if len(duplicates) != 1 {
-		t.Errorf("findDuplicates() found %d groups, want 1", len(duplicates))
+	e := testEngine()
+	duplicates := e.findDuplicates(fileHashes)
+	if len(duplicates) != 0 {
+		t.Errorf("findDuplicates() found %d groups, want 0", len(duplicates))
 	}
-
-	// Both should be in the group (they have same hash)
-	if len(duplicates) > 0 && len(duplicates[0].Files) != 2 {
-		t.Errorf("Duplicate group has %d files, want 2", len(duplicates[0].Files))
+	if anomalies := e.HashAnomalies(); len(anomalies) != 1 || len(anomalies[0].Paths) != 2 {
+		t.Errorf("HashAnomalies() = %+v, want hash1 at both sizes", anomalies)
 	}
 }
 
@@ -975,7 +1032,7 @@ func TestScanFilesNoReadPermission(t *testing.T) {
 	defer os.Chmod(subDir, 0755) // Restore for cleanup
 
 	// Scan should continue despite permission error
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 
 	// May get error or may skip the directory depending on implementation
 	// Important: it shouldn't crash
@@ -996,7 +1053,7 @@ func TestScanFilesOnlyHiddenFiles(t *testing.T) {
 	}
 
 	// Scan should find no files (all hidden)
-	files, err := scanFiles(tmpDir, true)
+	files, err := testEngine().scanFiles(context.Background(), tmpDir, true)
 	if err != nil {
 		t.Fatalf("scanFiles() error = %v", err)
 	}
@@ -1012,10 +1069,10 @@ func TestFormatBytesFractional(t *testing.T) {
 		bytes    int64
 		expected string
 	}{
-		{1536, "1.5 KB"},     // 1.5 KB
-		{2560, "2.5 KB"},     // 2.5 KB
-		{1572864, "1.5 MB"},  // 1.5 MB
-		{2621440, "2.5 MB"},  // 2.5 MB
+		{1536, "1.5 KB"},    // 1.5 KB
+		{2560, "2.5 KB"},    // 2.5 KB
+		{1572864, "1.5 MB"}, // 1.5 MB
+		{2621440, "2.5 MB"}, // 2.5 MB
 	}
 
 	for _, tt := range tests {
@@ -1039,12 +1096,7 @@ func TestSelectFileToKeepOldest(t *testing.T) {
 		},
 	}
 
-	// Save original config and restore after test
-	originalKeep := cfg.KeepCriteria
-	defer func() { cfg.KeepCriteria = originalKeep }()
-
-	cfg.KeepCriteria = "oldest"
-	idx := selectFileToKeep(group)
+	idx := selectFileToKeep(group, "oldest")
 	if idx != 1 { // oldest.txt is at index 1
 		t.Errorf("selectFileToKeep(oldest) returned %d, want 1", idx)
 	}
@@ -1062,11 +1114,7 @@ func TestSelectFileToKeepNewest(t *testing.T) {
 		},
 	}
 
-	originalKeep := cfg.KeepCriteria
-	defer func() { cfg.KeepCriteria = originalKeep }()
-
-	cfg.KeepCriteria = "newest"
-	idx := selectFileToKeep(group)
+	idx := selectFileToKeep(group, "newest")
 	if idx != 0 { // newest.txt is at index 0
 		t.Errorf("selectFileToKeep(newest) returned %d, want 0", idx)
 	}
@@ -1083,11 +1131,7 @@ func TestSelectFileToKeepLargest(t *testing.T) {
 		},
 	}
 
-	originalKeep := cfg.KeepCriteria
-	defer func() { cfg.KeepCriteria = originalKeep }()
-
-	cfg.KeepCriteria = "largest"
-	idx := selectFileToKeep(group)
+	idx := selectFileToKeep(group, "largest")
 	if idx != 1 { // large.txt is at index 1
 		t.Errorf("selectFileToKeep(largest) returned %d, want 1", idx)
 	}
@@ -1104,11 +1148,7 @@ func TestSelectFileToKeepSmallest(t *testing.T) {
 		},
 	}
 
-	originalKeep := cfg.KeepCriteria
-	defer func() { cfg.KeepCriteria = originalKeep }()
-
-	cfg.KeepCriteria = "smallest"
-	idx := selectFileToKeep(group)
+	idx := selectFileToKeep(group, "smallest")
 	if idx != 0 { // small.txt is at index 0
 		t.Errorf("selectFileToKeep(smallest) returned %d, want 0", idx)
 	}
@@ -1125,16 +1165,31 @@ func TestSelectFileToKeepPath(t *testing.T) {
 		},
 	}
 
-	originalKeep := cfg.KeepCriteria
-	defer func() { cfg.KeepCriteria = originalKeep }()
-
-	cfg.KeepCriteria = "path:dirB"
-	idx := selectFileToKeep(group)
+	idx := selectFileToKeep(group, "path:dirB")
 	if idx != 1 { // dirB/file.txt is at index 1
 		t.Errorf("selectFileToKeep(path:dirB) returned %d, want 1", idx)
 	}
 }
 
+func TestSelectFileToKeepCanonical(t *testing.T) {
+	now := time.Now()
+	group := DuplicateGroup{
+		Hash: "test",
+		Size: 100,
+		Files: []FileHash{
+			{Path: "/docs/report (1).docx", Size: 100, Hash: "test", ModTime: now.Add(-3 * time.Hour)},
+			{Path: "/docs/report_copy.docx", Size: 100, Hash: "test", ModTime: now.Add(-2 * time.Hour)},
+			{Path: "/backup/report.docx", Size: 100, Hash: "test", ModTime: now},
+			{Path: "/docs/report.docx", Size: 100, Hash: "test", ModTime: now.Add(-time.Hour)},
+		},
+	}
+
+	idx := selectFileToKeep(group, "canonical")
+	if idx != 3 { // the oldest of the unmarked names
+		t.Errorf("selectFileToKeep(canonical) returned %d, want 3", idx)
+	}
+}
+
 func TestSelectFileToKeepPathNotFound(t *testing.T) {
 	group := DuplicateGroup{
 		Hash: "test",
@@ -1145,12 +1200,191 @@ func TestSelectFileToKeepPathNotFound(t *testing.T) {
 		},
 	}
 
-	originalKeep := cfg.KeepCriteria
-	defer func() { cfg.KeepCriteria = originalKeep }()
-
-	cfg.KeepCriteria = "path:nonexistent"
-	idx := selectFileToKeep(group)
+	idx := selectFileToKeep(group, "path:nonexistent")
 	if idx != 0 { // Should default to first file
 		t.Errorf("selectFileToKeep(path:nonexistent) returned %d, want 0 (default)", idx)
 	}
 }
+
+// Test that -max-depth stops the walker descending further
+func TestScanFilesMaxDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// root/a.txt, root/l1/b.txt, root/l1/l2/c.txt
+	deep := filepath.Join(tmpDir, "l1", "l2")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	for _, path := range []string{
+		filepath.Join(tmpDir, "a.txt"),
+		filepath.Join(tmpDir, "l1", "b.txt"),
+		filepath.Join(deep, "c.txt"),
+	} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	for depth, want := range map[int]int{0: 3, 1: 1, 2: 2, 3: 3} {
+		c := DefaultConfig()
+		c.MaxDepth = depth
+		files, err := NewEngine(c, nil).scanFiles(context.Background(), tmpDir, true)
+		if err != nil {
+			t.Fatalf("scanFiles() error = %v", err)
+		}
+		if len(files) != want {
+			t.Errorf("scanFiles() with max depth %d found %d files, want %d", depth, len(files), want)
+		}
+	}
+}
+
+// Test size-only grouping used by -no-hash
+func TestFindSizeGroups(t *testing.T) {
+	fileHashes := []FileHash{
+		{Path: "/a/photo.jpg", Size: 100},
+		{Path: "/b/photo.jpg", Size: 100},
+		{Path: "/b/other.jpg", Size: 100},
+		{Path: "/c/unique.jpg", Size: 200},
+	}
+
+	groups := findSizeGroups(fileHashes, false)
+	if len(groups) != 1 || len(groups[0].Files) != 3 {
+		t.Fatalf("findSizeGroups(sameName=false) = %v, want one group of 3", groups)
+	}
+	if !groups[0].Unverified {
+		t.Error("findSizeGroups() groups should be marked unverified")
+	}
+
+	groups = findSizeGroups(fileHashes, true)
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Fatalf("findSizeGroups(sameName=true) = %v, want one group of 2", groups)
+	}
+}
+
+// Test that a cancelled context stops the pipeline without touching files
+func TestCancelledContext(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{tmpDir}
+	c.MinSize = 1
+	c.JSON = true
+	engine := NewEngine(c, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := engine.scanFiles(ctx, tmpDir, true); err != context.Canceled {
+		t.Errorf("scanFiles() error = %v, want context.Canceled", err)
+	}
+	if _, err := engine.computeHashes(ctx, []string{filepath.Join(tmpDir, "a.txt")}); err != context.Canceled {
+		t.Errorf("computeHashes() error = %v, want context.Canceled", err)
+	}
+	if _, _, _, err := hashFileContext(ctx, filepath.Join(tmpDir, "a.txt"), getHasher(c.HashAlgorithm)); err != context.Canceled {
+		t.Errorf("hashFileContext() error = %v, want context.Canceled", err)
+	}
+
+	duplicates, err := engine.collectDuplicates(context.Background())
+	if err != nil || len(duplicates) != 1 {
+		t.Fatalf("collectDuplicates() = %v, %v; want one group", duplicates, err)
+	}
+	if err := engine.processDuplicates(ctx, duplicates); err != context.Canceled {
+		t.Errorf("processDuplicates() error = %v, want context.Canceled", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("%s should not have been removed", name)
+		}
+	}
+}
+
+// Test scanning several roots at once, including a nested and a hidden root
+func TestScanRoots(t *testing.T) {
+	tmpDir := t.TempDir()
+	rootA := filepath.Join(tmpDir, "a")
+	rootB := filepath.Join(tmpDir, ".b") // hidden roots are scanned when named explicitly
+	nested := filepath.Join(rootA, "sub")
+	for _, path := range []string{
+		filepath.Join(rootA, "1.txt"),
+		filepath.Join(rootA, "2.txt"),
+		filepath.Join(nested, "3.txt"),
+		filepath.Join(rootB, "4.txt"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	files, err := testEngine().scanRoots(context.Background(), []string{rootA, rootB, nested}, true)
+	if err != nil {
+		t.Fatalf("scanRoots() error = %v", err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("scanRoots() found %d files, want 4 (nested root counted once): %v", len(files), files)
+	}
+	if filepath.Dir(files[1]) != rootB {
+		t.Errorf("scanRoots() should interleave roots, got %v", files)
+	}
+}
+
+// testEngine returns an engine with every option at its default
+func testEngine() *Engine {
+	return NewEngine(DefaultConfig(), nil)
+}
+
+func TestSettleQueue(t *testing.T) {
+	dir := t.TempDir()
+	steady, growing := filepath.Join(dir, "steady.bin"), filepath.Join(dir, "growing.bin")
+	for _, path := range []string{steady, growing} {
+		if err := os.WriteFile(path, []byte("start"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	start := time.Now()
+	q := newSettleQueue(2 * time.Second)
+	q.touch(steady, start)
+	q.touch(growing, start)
+	q.touch(filepath.Join(dir, "missing"), start)
+
+	if ready := q.ready(start.Add(time.Second)); len(ready) != 0 {
+		t.Errorf("ready() before the debounce = %v, want none", ready)
+	}
+
+	// growing.bin changes, restarting only its own debounce period
+	if err := os.WriteFile(growing, []byte("start and more"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ready := q.ready(start.Add(3 * time.Second)); len(ready) != 1 || ready[0] != steady {
+		t.Errorf("ready() = %v, want only %s", ready, steady)
+	}
+	if ready := q.ready(start.Add(4 * time.Second)); len(ready) != 0 {
+		t.Errorf("ready() while growing.bin debounces = %v, want none", ready)
+	}
+	if ready := q.ready(start.Add(5 * time.Second)); len(ready) != 1 || ready[0] != growing {
+		t.Errorf("ready() = %v, want %s", ready, growing)
+	}
+	if len(q.files) != 0 {
+		t.Errorf("queue still holds %d files", len(q.files))
+	}
+}
+
+func TestCheckHashAlgorithm(t *testing.T) {
+	for _, name := range []string{"sha256", "SHA1", "md5", "xxh3", "blake3"} {
+		if err := checkHashAlgorithm(name); err != nil {
+			t.Errorf("checkHashAlgorithm(%q) = %v", name, err)
+		}
+	}
+	// Would otherwise hash with sha256 while recording "sha512"
+	if err := checkHashAlgorithm("sha512"); err == nil {
+		t.Error("checkHashAlgorithm(sha512) accepted an algorithm getHasher does not know")
+	}
+}
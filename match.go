@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Values of -match
+const (
+	matchContent  = "content"   // identical bytes, the default
+	matchSize     = "size"      // same size, nothing read (-no-hash)
+	matchNameSize = "name-size" // same basename and size, nothing read (-no-hash -same-name)
+)
+
+// matchMode is the -match flag: a shorthand that sets NoHash and SameName,
+// so every mode shares the size-only triage path
+type matchMode struct{ c *Config }
+
+func (m matchMode) String() string {
+	switch {
+	case m.c == nil || !m.c.NoHash:
+		return matchContent
+	case m.c.SameName:
+		return matchNameSize
+	}
+	return matchSize
+}
+
+func (m matchMode) Set(value string) error {
+	switch strings.ToLower(value) {
+	case matchContent:
+		m.c.NoHash, m.c.SameName = false, false
+	case matchSize:
+		m.c.NoHash, m.c.SameName = true, false
+	case matchNameSize:
+		m.c.NoHash, m.c.SameName = true, true
+	default:
+		return fmt.Errorf("expected content, size or name-size")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchNameSize(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a/photo.jpg": "1234567890",
+		"b/photo.jpg": "abcdefghij", // same name and size, different bytes
+		"c/other.jpg": "1234567890",
+		"d/photo.jpg": "short",
+		"e/PHOTO.JPG": "klmnopqrst",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	base := DefaultConfig()
+	base.Dir = stringList{dir}
+	base.MinSize = 1
+	base.Checkpoint = ""
+	base.JSON = true
+	c, err := configWithFlags(base, map[string]string{"match": "name-size"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.NoHash || !c.SameName {
+		t.Fatalf("-match name-size set NoHash=%v SameName=%v", c.NoHash, c.SameName)
+	}
+
+	groups, err := NewEngine(c, nil).collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || len(groups[0].Files) != 3 || !groups[0].Unverified {
+		t.Errorf("groups = %+v, want one unverified group of the three 10-byte photo.jpg files", groups)
+	}
+
+	for mode, want := range map[string]string{"content": matchContent, "size": matchSize, "NAME-SIZE": matchNameSize} {
+		c, err := configWithFlags(base, map[string]string{"match": mode})
+		if err != nil || (matchMode{&c}).String() != want {
+			t.Errorf("-match %s = %q, %v", mode, (matchMode{&c}).String(), err)
+		}
+	}
+	if _, err := configWithFlags(base, map[string]string{"match": "fuzzy"}); err == nil {
+		t.Error("-match fuzzy should be rejected")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// similarityPair is one row of the -similarity-matrix export
+type similarityPair struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Distance   int     `json:"distance"`
+	Similarity float64 `json:"similarity"`
+	Group      int     `json:"group,omitempty"` // 1-based group both files are in, 0 if none
+}
+
+// similarityMatrix is the JSON form of the -similarity-matrix export
+type similarityMatrix struct {
+	Algorithm   string           `json:"algorithm"`
+	MaxDistance int              `json:"max_distance"`
+	Pairs       []similarityPair `json:"pairs"`
+}
+
+// similarityPairs returns the pairwise perceptual distances between images
+// that share a group or are at most maxDistance apart, closest first
+func similarityPairs(fileHashes []FileHash, duplicates []DuplicateGroup, maxDistance int) []similarityPair {
+	groupOf := make(map[string]int)
+	for i, group := range duplicates {
+		for _, fh := range group.Files {
+			if fh.PHash != "" {
+				groupOf[fh.Path] = i + 1
+			}
+		}
+	}
+
+	var images []FileHash
+	for _, fh := range fileHashes {
+		if fh.PHash != "" {
+			images = append(images, fh)
+		}
+	}
+
+	pairs := []similarityPair{}
+	for i := range images {
+		for j := i + 1; j < len(images); j++ {
+			a, b := images[i], images[j]
+			dist := hammingDistance(a.PHash, b.PHash)
+			if dist < 0 {
+				continue
+			}
+			group := 0
+			if groupOf[a.Path] != 0 && groupOf[a.Path] == groupOf[b.Path] {
+				group = groupOf[a.Path]
+			}
+			if group == 0 && dist > maxDistance {
+				continue
+			}
+			pairs = append(pairs, similarityPair{
+				A:          a.Path,
+				B:          b.Path,
+				Distance:   dist,
+				Similarity: hashSimilarity(float64(dist), len(a.PHash)),
+				Group:      group,
+			})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Distance < pairs[j].Distance })
+	return pairs
+}
+
+// exportSimilarityMatrix writes the -similarity-matrix file, as CSV when its
+// name ends in .csv and as JSON otherwise
+func (e *Engine) exportSimilarityMatrix(fileHashes []FileHash, duplicates []DuplicateGroup) error {
+	maxDistance := e.cfg.MatrixDistance
+	if maxDistance <= 0 {
+		maxDistance = e.cfg.maxDistance()
+	}
+	pairs := similarityPairs(fileHashes, duplicates, maxDistance)
+
+	f, err := createAtomic(e.cfg.SimilarityMatrix, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+
+	if strings.EqualFold(filepath.Ext(e.cfg.SimilarityMatrix), ".csv") {
+		w := csv.NewWriter(f)
+		w.Write([]string{"a", "b", "distance", "similarity", "group"})
+		for _, p := range pairs {
+			w.Write([]string{
+				p.A,
+				p.B,
+				strconv.Itoa(p.Distance),
+				strconv.FormatFloat(p.Similarity, 'f', 1, 64),
+				strconv.Itoa(p.Group),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	} else {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(similarityMatrix{Algorithm: strings.ToLower(e.cfg.PHashAlgorithm), MaxDistance: maxDistance, Pairs: pairs}); err != nil {
+			return err
+		}
+	}
+	if err := f.Commit(); err != nil {
+		return err
+	}
+
+	if !e.cfg.JSON {
+		log.Printf("%sSimilarity matrix with %d pairs exported to %s", emoji("📄"), len(pairs), e.cfg.SimilarityMatrix)
+	}
+	return nil
+}
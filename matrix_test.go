@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSimilarityPairs(t *testing.T) {
+	base := strings.Repeat("0", 64)
+	near := "11" + base[2:]                    // 2 bits from base
+	far := strings.Repeat("1", 20) + base[20:] // 20 bits from base
+	files := []FileHash{
+		{Path: "/a.jpg", PHash: base},
+		{Path: "/b.jpg", PHash: near},
+		{Path: "/c.jpg", PHash: far},
+		{Path: "/notes.txt", Hash: "x"},
+	}
+	groups := []DuplicateGroup{{Files: files[:2]}}
+
+	pairs := similarityPairs(files, groups, 5)
+	if len(pairs) != 1 || pairs[0].A != "/a.jpg" || pairs[0].B != "/b.jpg" || pairs[0].Distance != 2 || pairs[0].Group != 1 {
+		t.Fatalf("similarityPairs() = %+v", pairs)
+	}
+
+	// A looser limit adds ungrouped pairs, closest first
+	pairs = similarityPairs(files, groups, 20)
+	if len(pairs) != 3 || pairs[0].Distance != 2 || pairs[2].Distance != 20 || pairs[2].Group != 0 {
+		t.Errorf("similarityPairs() with max 20 = %+v", pairs)
+	}
+	if pairs[0].Similarity < 96 || pairs[0].Similarity > 97 {
+		t.Errorf("similarity of 2/64 bits = %.2f", pairs[0].Similarity)
+	}
+}
+
+func TestExportSimilarityMatrix(t *testing.T) {
+	dir := t.TempDir()
+	hash := strings.Repeat("01", 32)
+	files := []FileHash{{Path: "/a.jpg", PHash: hash}, {Path: "/b.jpg", PHash: hash}}
+	groups := []DuplicateGroup{{Files: files}}
+
+	c := DefaultConfig()
+	c.JSON = true
+	c.SimilarityMatrix = filepath.Join(dir, "matrix.csv")
+	if err := NewEngine(c, nil).exportSimilarityMatrix(files, groups); err != nil {
+		t.Fatalf("exportSimilarityMatrix() error = %v", err)
+	}
+	f, _ := os.Open(c.SimilarityMatrix)
+	rows, err := csv.NewReader(f).ReadAll()
+	f.Close()
+	if err != nil || len(rows) != 2 || rows[1][0] != "/a.jpg" || rows[1][2] != "0" || rows[1][4] != "1" {
+		t.Errorf("CSV rows = %v, %v", rows, err)
+	}
+
+	c.SimilarityMatrix = filepath.Join(dir, "matrix.json")
+	if err := NewEngine(c, nil).exportSimilarityMatrix(files, groups); err != nil {
+		t.Fatalf("exportSimilarityMatrix() error = %v", err)
+	}
+	data, _ := os.ReadFile(c.SimilarityMatrix)
+	var matrix similarityMatrix
+	if err := json.Unmarshal(data, &matrix); err != nil || matrix.Algorithm != "dhash" || matrix.MaxDistance != c.SimilarityThreshold || len(matrix.Pairs) != 1 {
+		t.Errorf("JSON matrix = %+v, %v", matrix, err)
+	}
+}
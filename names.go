@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
+)
+
+// suggestName picks the canonical name among variants: one without any
+// copy marker if there is one, otherwise the shortest
+func suggestName(names []string) string {
+	best := ""
+	for _, name := range names {
+		plain := dedup.NameStem(name) == strings.ToLower(name)
+		bestPlain := best != "" && dedup.NameStem(best) == strings.ToLower(best)
+		switch {
+		case best == "",
+			plain && !bestPlain,
+			plain == bestPlain && (len(name) < len(best) || len(name) == len(best) && name < best):
+			best = name
+		}
+	}
+	return best
+}
+
+// variantNames returns the distinct base names in files that are variants
+// of another name in files, grouped by stem
+func variantNames(files []FileHash) map[string][]string {
+	byStem := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, fh := range files {
+		name := filepath.Base(fh.Path)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		stem := dedup.NameStem(name)
+		byStem[stem] = append(byStem[stem], name)
+	}
+	for stem, names := range byStem {
+		if len(names) < 2 {
+			delete(byStem, stem)
+		}
+	}
+	return byStem
+}
+
+// annotateNames sets SuggestedName on groups whose copies carry variants of
+// one name, such as "report.docx" and "report (1).docx"
+func annotateNames(groups []DuplicateGroup) {
+	for i := range groups {
+		var best []string
+		for _, names := range variantNames(groups[i].Files) {
+			if len(names) > len(best) {
+				best = names
+			}
+		}
+		if best != nil {
+			groups[i].SuggestedName = suggestName(best)
+		}
+	}
+}
+
+// nameCluster is a set of scanned files whose names are variants of one stem
+type nameCluster struct {
+	Stem      string     `json:"stem"`
+	Suggested string     `json:"suggested_name"`
+	Files     []FileHash `json:"files"`
+}
+
+// nameClusters groups every scanned file by name stem and returns the
+// stems that appear under more than one name, largest first
+func nameClusters(fileHashes []FileHash) []nameCluster {
+	byStem := make(map[string][]FileHash)
+	for _, fh := range fileHashes {
+		stem := dedup.NameStem(filepath.Base(fh.Path))
+		byStem[stem] = append(byStem[stem], fh)
+	}
+
+	var clusters []nameCluster
+	for stem, files := range byStem {
+		names := variantNames(files)[stem]
+		if names == nil {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		clusters = append(clusters, nameCluster{Stem: stem, Suggested: suggestName(names), Files: files})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Files) != len(clusters[j].Files) {
+			return len(clusters[i].Files) > len(clusters[j].Files)
+		}
+		return clusters[i].Stem < clusters[j].Stem
+	})
+	return clusters
+}
+
+// runNameVariants scans and lists files whose names are variants of each
+// other, whether or not their content matches, without changing anything
+func (e *Engine) runNameVariants(ctx context.Context) error {
+	fileHashes, err := e.collectFiles(ctx)
+	if err != nil {
+		return err
+	}
+	clusters := nameClusters(fileHashes)
+
+	if e.cfg.JSON {
+		if clusters == nil {
+			clusters = []nameCluster{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(clusters)
+	}
+
+	if len(clusters) == 0 {
+		log.Printf("%sNo name variants found", emoji("✅"))
+		return nil
+	}
+	log.Println("\n" + strings.Repeat("=", 70))
+	log.Printf("%sNAME VARIANTS", emoji("📝"))
+	log.Println(strings.Repeat("=", 70))
+	for i, cluster := range clusters {
+		log.Printf("\n[%d] %s (suggested name: %s)", i+1, cluster.Stem, cluster.Suggested)
+
+		// Label files with the same content alike so copies stand out
+		labels := make(map[string]string)
+		for _, fh := range cluster.Files {
+			label := "-"
+			if fh.Hash != "" {
+				if labels[fh.Hash] == "" {
+					labels[fh.Hash] = string(rune('A' + len(labels)%26))
+				}
+				label = labels[fh.Hash]
+			}
+			log.Printf("    [%s] %s (%s)", label, fh.Path, formatBytes(fh.Size))
+		}
+	}
+	log.Printf("\nFiles with the same letter have identical content")
+	return nil
+}
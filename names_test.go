@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSuggestName(t *testing.T) {
+	if got := suggestName([]string{"report (1).docx", "Report.docx", "report_final_v2.docx"}); got != "Report.docx" {
+		t.Errorf("suggestName() = %q, want the unmarked name", got)
+	}
+	if got := suggestName([]string{"report_final_v2.docx", "report (1).docx"}); got != "report (1).docx" {
+		t.Errorf("suggestName() = %q, want the shortest variant", got)
+	}
+}
+
+func TestAnnotateNames(t *testing.T) {
+	groups := []DuplicateGroup{
+		{Files: []FileHash{{Path: "/a/report (1).docx"}, {Path: "/b/report.docx"}, {Path: "/c/unrelated.docx"}}},
+		{Files: []FileHash{{Path: "/a/photo.jpg"}, {Path: "/b/photo.jpg"}}},
+	}
+	annotateNames(groups)
+	if groups[0].SuggestedName != "report.docx" {
+		t.Errorf("SuggestedName = %q, want report.docx", groups[0].SuggestedName)
+	}
+	if groups[1].SuggestedName != "" {
+		t.Errorf("identical names need no suggestion, got %q", groups[1].SuggestedName)
+	}
+}
+
+func TestNameClusters(t *testing.T) {
+	fileHashes := []FileHash{
+		{Path: "/a/budget.xlsx", Hash: "1"},
+		{Path: "/b/budget_final.xlsx", Hash: "2"},
+		{Path: "/c/Budget (2).xlsx", Hash: "1"},
+		{Path: "/d/photo.jpg", Hash: "3"},
+		{Path: "/e/photo.jpg", Hash: "4"}, // same name only: not a variant
+	}
+	clusters := nameClusters(fileHashes)
+	if len(clusters) != 1 || clusters[0].Stem != "budget.xlsx" || len(clusters[0].Files) != 3 || clusters[0].Suggested != "budget.xlsx" {
+		t.Errorf("nameClusters() = %+v", clusters)
+	}
+}
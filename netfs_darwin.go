@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"syscall"
+)
+
+// networkFSNames are the f_fstypename values of network and FUSE filesystems
+var networkFSNames = map[string]bool{
+	"nfs":     true,
+	"smbfs":   true,
+	"afpfs":   true,
+	"webdav":  true,
+	"cifs":    true,
+	"osxfuse": true,
+	"macfuse": true,
+	"fusefs":  true,
+}
+
+// networkFSType returns the filesystem name and true if path lives on a
+// network or FUSE mount
+func networkFSType(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		b.WriteByte(byte(c))
+	}
+	name := b.String()
+	return name, networkFSNames[name]
+}
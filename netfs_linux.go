@@ -0,0 +1,30 @@
+package main
+
+import (
+	"syscall"
+)
+
+// Filesystem magic numbers from statfs(2) for network and FUSE filesystems
+var networkFSMagic = map[int64]string{
+	0x6969:     "nfs",
+	0x517B:     "smb",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x65735546: "fuse",
+	0x5346414F: "afs",
+	0x01021997: "9p",
+	0x00C36400: "ceph",
+	0x013111A8: "ibrix",
+	0x47504653: "gpfs",
+}
+
+// networkFSType returns the filesystem name and true if path lives on a
+// network or FUSE mount
+func networkFSType(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	name, ok := networkFSMagic[int64(st.Type)]
+	return name, ok
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package main
+
+// networkFSType is not implemented on this platform; nothing is treated as
+// a network filesystem
+func networkFSType(path string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const driveRemote = 4 // DRIVE_REMOTE from GetDriveTypeW
+
+var procGetDriveType = kernel32.NewProc("GetDriveTypeW")
+
+// networkFSType returns the filesystem name and true if path lives on a
+// network share (UNC path or mapped network drive)
+func networkFSType(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	volume := filepath.VolumeName(abs)
+	if strings.HasPrefix(volume, `\\`) {
+		return "smb", true
+	}
+	root, err := syscall.UTF16PtrFromString(volume + `\`)
+	if err != nil {
+		return "", false
+	}
+	ret, _, _ := procGetDriveType.Call(uintptr(unsafe.Pointer(root)))
+	if ret == driveRemote {
+		return "remote", true
+	}
+	return "", false
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
+)
+
+// Policies for -on-error
+const (
+	onErrorStop  = "stop"  // abort the run at the first file that cannot be read
+	onErrorSkip  = "skip"  // report unreadable files and carry on
+	onErrorRetry = "retry" // read failing files again before skipping them
+)
+
+// defaultRetries is how often a failing read is retried by default
+const defaultRetries = 3
+
+// retryDelay is the pause before the first retry; it doubles for each one after
+const retryDelay = 200 * time.Millisecond
+
+// checkOnError validates an -on-error value
+func checkOnError(policy string) error {
+	switch strings.ToLower(policy) {
+	case onErrorStop, onErrorSkip, onErrorRetry:
+		return nil
+	}
+	return fmt.Errorf("invalid -on-error %q: expected stop, skip or retry", policy)
+}
+
+// stopOnError reports whether the first IO error aborts the run
+func (c Config) stopOnError() bool {
+	return strings.EqualFold(c.OnError, onErrorStop)
+}
+
+// isTransient reports whether a read error may go away by itself, such as
+// EAGAIN or a network filesystem timing out
+func isTransient(err error) bool {
+	return errors.Is(dedup.Classify(err), dedup.ErrTransientIO)
+}
+
+// retryable reports whether a failed read is worth another attempt: transient
+// errors always are, and any other error except a missing file or a
+// permission problem is with -on-error retry
+func (c Config) retryable(err error) bool {
+	if isTransient(err) {
+		return true
+	}
+	return strings.EqualFold(c.OnError, onErrorRetry) &&
+		!errors.Is(err, fs.ErrNotExist) && !errors.Is(err, fs.ErrPermission)
+}
+
+// hashWithPolicy hashes a file, retrying retryable failures up to -retries
+// times with a doubling delay before giving up on the file
+func (e *Engine) hashWithPolicy(ctx context.Context, path string, hasher hash.Hash) (string, int64, time.Time, error) {
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		hasher.Reset()
+		hash, size, modTime, err := hashFileBuffer(ctx, path, hasher, e.cfg.ReadBuffer)
+		if err == nil || attempt >= e.cfg.Retries || ctx.Err() != nil || !e.cfg.retryable(err) {
+			return hash, size, modTime, err
+		}
+		if e.cfg.Verbose {
+			log.Printf("%sRetrying %s in %v: %v", emoji("🔁"), path, delay, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", 0, time.Time{}, ctx.Err()
+		}
+		delay *= 2
+	}
+}
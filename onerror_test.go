@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOnErrorPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping permission test on Windows")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "locked.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	locked := filepath.Join(dir, "locked.txt")
+	if err := os.Chmod(locked, 0000); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0644)
+	if f, err := os.Open(locked); err == nil {
+		f.Close()
+		t.Skip("running with permissions that ignore file modes")
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.Checkpoint = ""
+	c.JSON = true
+
+	fileHashes, err := NewEngine(c, nil).collectFiles(context.Background())
+	if err != nil || len(fileHashes) != 2 {
+		t.Errorf("-on-error skip: collectFiles() = %d files, %v; want 2 files", len(fileHashes), err)
+	}
+
+	c.OnError = onErrorStop
+	if _, err := NewEngine(c, nil).collectFiles(context.Background()); err == nil || !strings.Contains(err.Error(), "-on-error stop") {
+		t.Errorf("-on-error stop: collectFiles() error = %v", err)
+	}
+}
+
+func TestHashWithPolicyRetries(t *testing.T) {
+	dir := t.TempDir() // reading a directory fails without being a permission error
+
+	c := DefaultConfig()
+	c.OnError = onErrorRetry
+	c.Retries = 2
+	start := time.Now()
+	if _, _, _, err := NewEngine(c, nil).hashWithPolicy(context.Background(), dir, sha256.New()); err == nil {
+		t.Fatal("hashWithPolicy() on a directory should fail")
+	}
+	if elapsed := time.Since(start); elapsed < retryDelay+2*retryDelay {
+		t.Errorf("hashWithPolicy() gave up after %v, want 2 retries with backoff", elapsed)
+	}
+
+	c.OnError = onErrorSkip
+	start = time.Now()
+	NewEngine(c, nil).hashWithPolicy(context.Background(), filepath.Join(dir, "missing"), sha256.New())
+	if elapsed := time.Since(start); elapsed >= retryDelay {
+		t.Errorf("-on-error skip should not retry, took %v", elapsed)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	eagain := &os.PathError{Op: "read", Path: "x", Err: syscall.EAGAIN}
+	timeout := &os.PathError{Op: "read", Path: "x", Err: os.ErrDeadlineExceeded}
+	denied := &os.PathError{Op: "open", Path: "x", Err: os.ErrPermission}
+	other := errors.New("read x: is a directory")
+
+	skip := Config{OnError: onErrorSkip}
+	retry := Config{OnError: onErrorRetry}
+	tests := []struct {
+		c    Config
+		err  error
+		want bool
+	}{
+		{skip, eagain, true},
+		{skip, timeout, true},
+		{skip, other, false},
+		{retry, other, true},
+		{retry, denied, false},
+	}
+	for _, tt := range tests {
+		if got := tt.c.retryable(tt.err); got != tt.want {
+			t.Errorf("-on-error %s: retryable(%v) = %v, want %v", tt.c.OnError, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOnError(t *testing.T) {
+	for _, policy := range []string{"stop", "skip", "retry", "Retry"} {
+		if err := checkOnError(policy); err != nil {
+			t.Errorf("checkOnError(%q) = %v", policy, err)
+		}
+	}
+	if err := checkOnError("ignore"); err == nil {
+		t.Error("checkOnError(\"ignore\") should fail")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pauseGate lets a long run be put on hold. Hashing checks it between reads,
+// so pausing takes effect within one buffer even for very large files.
+type pauseGate struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{} // closed when the gate reopens
+}
+
+// newPauseGate creates an open gate
+func newPauseGate() *pauseGate {
+	return &pauseGate{}
+}
+
+// pause closes the gate. It reports false if it was already paused.
+func (g *pauseGate) pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return false
+	}
+	g.paused = true
+	g.resume = make(chan struct{})
+	return true
+}
+
+// unpause reopens the gate. It reports false if it was not paused.
+func (g *pauseGate) unpause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return false
+	}
+	g.paused = false
+	close(g.resume)
+	return true
+}
+
+// toggle pauses an open gate or reopens a paused one, reporting whether it
+// is now paused
+func (g *pauseGate) toggle() bool {
+	if g.pause() {
+		return true
+	}
+	g.unpause()
+	return false
+}
+
+// isPaused reports whether the gate is closed
+func (g *pauseGate) isPaused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// wait blocks while the gate is paused, or until ctx is cancelled
+func (g *pauseGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	paused, resume := g.paused, g.resume
+	g.mu.Unlock()
+	if !paused {
+		return nil
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type pauseGateKey struct{}
+
+// withPauseGate returns a context whose hashing honours g
+func withPauseGate(ctx context.Context, g *pauseGate) context.Context {
+	return context.WithValue(ctx, pauseGateKey{}, g)
+}
+
+// waitIfPaused blocks while the context's pause gate, if any, is paused
+func waitIfPaused(ctx context.Context) error {
+	if g, ok := ctx.Value(pauseGateKey{}).(*pauseGate); ok {
+		return g.wait(ctx)
+	}
+	return nil
+}
+
+// logPauseState reports a pause or resume to the user
+func logPauseState(paused bool) {
+	if paused {
+		log.Printf("%sPaused - send SIGUSR1 or run -control resume to continue", emoji("⏸️ "))
+	} else {
+		log.Printf("%sResumed", emoji("▶️ "))
+	}
+}
+
+// controlReply answers a -control command
+type controlReply struct {
+	Paused bool   `json:"paused"`
+	PID    int    `json:"pid"`
+	Error  string `json:"error,omitempty"`
+}
+
+// serveControl accepts pause, resume and status commands for g on the
+// control socket of dir. The returned function stops serving.
+func serveControl(g *pauseGate, dir string) (func(), error) {
+	socketPath := runSocketPath("control", dir)
+
+	// Another run on the same directory keeps its socket
+	ln, err := listenUnix(socketPath)
+	if errors.Is(err, errSocketInUse) {
+		return nil, fmt.Errorf("another run on %s already owns the control socket", dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					log.Printf("%sControl socket error: %v", emoji("⚠️"), err)
+				}
+				return
+			}
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			reply := controlReply{PID: os.Getpid()}
+			command, _ := bufio.NewReader(conn).ReadString('\n')
+			switch strings.TrimSpace(command) {
+			case "pause":
+				if g.pause() {
+					logPauseState(true)
+				}
+			case "resume":
+				if g.unpause() {
+					logPauseState(false)
+				}
+			case "status":
+			default:
+				reply.Error = fmt.Sprintf("unknown command %q", strings.TrimSpace(command))
+			}
+			reply.Paused = g.isPaused()
+			json.NewEncoder(conn).Encode(reply)
+			conn.Close()
+		}
+	}()
+
+	return func() {
+		ln.Close()
+		os.Remove(socketPath)
+	}, nil
+}
+
+// sendControl sends command to the run working on dir
+func sendControl(dir, command string) (controlReply, error) {
+	var reply controlReply
+	conn, err := net.DialTimeout("unix", runSocketPath("control", dir), 5*time.Second)
+	if err != nil {
+		return reply, fmt.Errorf("no run is in progress for %s", dir)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return reply, err
+	}
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		return reply, fmt.Errorf("invalid reply: %w", err)
+	}
+	if reply.Error != "" {
+		return reply, errors.New(reply.Error)
+	}
+	return reply, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPauseGateHoldsReads(t *testing.T) {
+	gate := newPauseGate()
+	ctx := withPauseGate(context.Background(), gate)
+	if !gate.pause() || gate.pause() {
+		t.Fatal("pause() should report true only for the first call")
+	}
+
+	path := filepath.Join(t.TempDir(), "content")
+	os.WriteFile(path, []byte("content"), 0644)
+	done := make(chan error, 1)
+	go func() {
+		_, _, _, err := hashFileBuffer(ctx, path, getHasher("sha256"), 0)
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("read finished while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if gate.toggle() {
+		t.Error("toggle() on a paused gate should resume it")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("read error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("read still blocked after resume")
+	}
+
+	// Cancelling while paused releases the reader
+	gate.pause()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := waitIfPaused(cancelled); err != context.Canceled {
+		t.Errorf("waitIfPaused() after cancel = %v, want context.Canceled", err)
+	}
+}
+
+func TestControlSocket(t *testing.T) {
+	dir := t.TempDir()
+	gate := newPauseGate()
+	stop, err := serveControl(gate, dir)
+	if err != nil {
+		t.Fatalf("serveControl() error = %v", err)
+	}
+	defer stop()
+
+	if reply, err := sendControl(dir, "pause"); err != nil || !reply.Paused || !gate.isPaused() {
+		t.Errorf("pause: reply = %+v, err = %v", reply, err)
+	}
+	if reply, err := sendControl(dir, "status"); err != nil || !reply.Paused {
+		t.Errorf("status: reply = %+v, err = %v", reply, err)
+	}
+	if reply, err := sendControl(dir, "resume"); err != nil || reply.Paused || gate.isPaused() {
+		t.Errorf("resume: reply = %+v, err = %v", reply, err)
+	}
+	if _, err := sendControl(dir, "explode"); err == nil {
+		t.Error("unknown commands should be rejected")
+	}
+	if _, err := sendControl(t.TempDir(), "status"); err == nil {
+		t.Error("sendControl() with no run in progress should fail")
+	}
+}
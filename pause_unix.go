@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyPauseSignal relays SIGUSR1, which toggles pausing, to c
+func notifyPauseSignal(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR1)
+}
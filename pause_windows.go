@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package main
+
+import "os"
+
+// notifyPauseSignal does nothing: Windows has no SIGUSR1, use -control instead
+func notifyPauseSignal(c chan<- os.Signal) {}
@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
 	"image"
 	"image/color"
+	"image/gif"
 	_ "image/jpeg"
-	_ "image/png"
+	"image/png"
+	"io"
 	"math"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
 	"golang.org/x/image/draw"
 	_ "golang.org/x/image/webp"
 )
@@ -396,6 +403,57 @@ func hammingDistance(hash1, hash2 string) int {
 	return distance
 }
 
+// maxPerceptualSimilarity caps a perceptual score: 100% marks exact matches
+const maxPerceptualSimilarity = 99.9
+
+// hashSimilarity turns a Hamming distance between two hashes of the given
+// length into a percentage
+func hashSimilarity(distance float64, bits int) float64 {
+	if bits == 0 {
+		return 0
+	}
+	return 100.0 - distance/float64(bits)*100.0
+}
+
+// scoreGroup measures a perceptual group from the distances between its
+// images: the group's similarity comes from the average pairwise distance,
+// MaxDistance is its least similar pair, and each file's similarity comes
+// from its average distance to the rest of the group
+func scoreGroup(group *DuplicateGroup) {
+	files := group.Files
+	totals := make([]int, len(files))
+	counts := make([]int, len(files))
+	total, pairs := 0, 0
+	group.MaxDistance = 0
+	for i := range files {
+		for j := i + 1; j < len(files); j++ {
+			dist := hammingDistance(files[i].PHash, files[j].PHash)
+			if dist < 0 {
+				continue
+			}
+			totals[i] += dist
+			totals[j] += dist
+			counts[i]++
+			counts[j]++
+			total += dist
+			pairs++
+			if dist > group.MaxDistance {
+				group.MaxDistance = dist
+			}
+		}
+	}
+	if pairs == 0 {
+		return
+	}
+	bits := len(files[0].PHash)
+	group.Similarity = math.Min(hashSimilarity(float64(total)/float64(pairs), bits), maxPerceptualSimilarity)
+	for i := range files {
+		if counts[i] > 0 {
+			files[i].Similarity = math.Min(hashSimilarity(float64(totals[i])/float64(counts[i]), bits), maxPerceptualSimilarity)
+		}
+	}
+}
+
 // isSimilarImage checks if two hashes are similar within threshold
 // threshold: max Hamming distance to consider images similar (0-64 for 64-bit hashes)
 func isSimilarImage(hash1, hash2 string, threshold int) bool {
@@ -403,20 +461,149 @@ func isSimilarImage(hash1, hash2 string, threshold int) bool {
 	return dist >= 0 && dist <= threshold
 }
 
-// computePerceptualHash computes the perceptual hash for an image file
+// animationFrames is how many frames of an animation or video are hashed
+const animationFrames = 4
+
+// ffmpegCommand extracts video frames for -perceptual-video
+var ffmpegCommand = "ffmpeg"
+
+// computePerceptualHash computes the perceptual hash for an image file.
+// Animated GIFs and videos are hashed by a few representative frames.
 func computePerceptualHash(path string, algorithm string) (string, error) {
+	if isVideoFile(path) {
+		return computeVideoHash(path, algorithm)
+	}
+
 	file, err := os.Open(path)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
-	// Decode image (supports jpeg, png, gif, webp)
+	if strings.ToLower(filepath.Ext(path)) == ".gif" {
+		anim, err := gif.DecodeAll(file)
+		if err != nil {
+			return "", err
+		}
+		return hashFrames(gifFrames(anim, animationFrames), algorithm)
+	}
+
+	// Decode image (supports jpeg, png, webp); other formats fail with dedup.ErrUnsupportedImage
 	img, _, err := image.Decode(file)
 	if err != nil {
-		return "", err
+		return "", dedup.Classify(err)
+	}
+	return hashImage(img, algorithm)
+}
+
+// gifFrames composites an animation and returns up to n frames spread evenly
+// across it, always including the first. GIF frames only hold the pixels that
+// changed, so each is drawn over the previous state like a viewer would.
+func gifFrames(anim *gif.GIF, n int) []image.Image {
+	bounds := image.Rect(0, 0, anim.Config.Width, anim.Config.Height)
+	if bounds.Empty() && len(anim.Image) > 0 {
+		bounds = anim.Image[0].Bounds()
+	}
+	want := make(map[int]bool)
+	for i := 0; i < n && i < len(anim.Image); i++ {
+		want[i*len(anim.Image)/min(n, len(anim.Image))] = true
+	}
+
+	canvas := image.NewRGBA(bounds)
+	var frames []image.Image
+	for i, frame := range anim.Image {
+		var previous *image.RGBA
+		disposal := byte(0)
+		if i < len(anim.Disposal) {
+			disposal = anim.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, bounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if want[i] {
+			snapshot := image.NewRGBA(bounds)
+			draw.Draw(snapshot, bounds, canvas, bounds.Min, draw.Src)
+			frames = append(frames, snapshot)
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
 	}
+	return frames
+}
 
+// computeVideoHash hashes a few frames from the first seconds of a video,
+// extracted with ffmpeg
+func computeVideoHash(path string, algorithm string) (string, error) {
+	cmd := exec.Command(ffmpegCommand, "-v", "error", "-i", path,
+		"-vf", "fps=1,scale=256:-1", "-frames:v", fmt.Sprint(animationFrames),
+		"-f", "image2pipe", "-vcodec", "png", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// The frames arrive as back-to-back PNGs
+	var frames []image.Image
+	r := bufio.NewReader(bytes.NewReader(out))
+	for {
+		if _, err := r.Peek(1); err == io.EOF {
+			break
+		}
+		frame, err := png.Decode(r)
+		if err != nil {
+			return "", fmt.Errorf("ffmpeg frame: %w", err)
+		}
+		frames = append(frames, frame)
+	}
+	return hashFrames(frames, algorithm)
+}
+
+// hashFrames hashes each frame and combines them by a per-bit majority vote,
+// so the result compares with hammingDistance like a single image's hash.
+// Ties go to the first frame's bit.
+func hashFrames(frames []image.Image, algorithm string) (string, error) {
+	if len(frames) == 0 {
+		return "", fmt.Errorf("no frames to hash")
+	}
+	var hashes []string
+	for _, frame := range frames {
+		hash, err := hashImage(frame, algorithm)
+		if err != nil {
+			return "", err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	combined := []byte(hashes[0])
+	for bit := range combined {
+		ones := 0
+		for _, hash := range hashes {
+			if hash[bit] == '1' {
+				ones++
+			}
+		}
+		switch {
+		case ones*2 > len(hashes):
+			combined[bit] = '1'
+		case ones*2 < len(hashes):
+			combined[bit] = '0'
+		}
+	}
+	return string(combined), nil
+}
+
+// hashImage computes the perceptual hash of a decoded image
+func hashImage(img image.Image, algorithm string) (string, error) {
 	// Compute hash based on algorithm
 	switch strings.ToLower(algorithm) {
 	case "dhash", "difference":
@@ -441,14 +628,61 @@ func isImageFile(path string) bool {
 	}
 }
 
+// imageDimensions reads an image's width and height from its header
+func imageDimensions(path string) (int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(bufio.NewReader(file))
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
+
+// sameDimensions reports whether two files have the same image size, for
+// -same-dimensions
+func sameDimensions(a, b FileHash) bool {
+	return a.Width == b.Width && a.Height == b.Height
+}
+
+// withDimensionsOf returns the files with the same image size as fh
+func withDimensionsOf(fh FileHash, files []FileHash) []FileHash {
+	var same []FileHash
+	for _, f := range files {
+		if sameDimensions(fh, f) {
+			same = append(same, f)
+		}
+	}
+	return same
+}
+
+// isVideoFile checks if a file is a video -perceptual-video can hash
+func isVideoFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4", ".m4v", ".mov", ".webm", ".mkv":
+		return true
+	default:
+		return false
+	}
+}
+
+// perceptualCandidate reports whether path gets a perceptual hash under c
+func (c Config) perceptualCandidate(path string) bool {
+	return c.PerceptualMode && (isImageFile(path) || c.PerceptualVideo && isVideoFile(path))
+}
+
 // AdaptiveThreshold returns an appropriate threshold based on hash algorithm
 // and the level of variation expected between similar images
 func AdaptiveThreshold(algorithm string, strictness string) int {
 	// strictness: "strict" (fewer matches), "normal" (balanced), "loose" (more matches)
 	baseThresholds := map[string]int{
-		"dhash":  10,
-		"ahash":  12,
-		"phash":  8,
+		"dhash": 10,
+		"ahash": 12,
+		"phash": 8,
 	}
 
 	multipliers := map[string]float64{
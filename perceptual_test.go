@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"image"
 	"image/color"
+	"image/gif"
+	"image/png"
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -477,9 +482,9 @@ func TestSmallImages(t *testing.T) {
 func TestSolidColorImages(t *testing.T) {
 	// Solid color images should produce consistent hashes
 	colors := []color.RGBA{
-		{255, 0, 0, 255},    // Red
-		{0, 255, 0, 255},    // Green
-		{0, 0, 255, 255},    // Blue
+		{255, 0, 0, 255},     // Red
+		{0, 255, 0, 255},     // Green
+		{0, 0, 255, 255},     // Blue
 		{128, 128, 128, 255}, // Gray
 	}
 
@@ -570,3 +575,223 @@ func BenchmarkPHash(b *testing.B) {
 		_, _ = pHash(img)
 	}
 }
+
+// animatedGIF returns an animation whose frames each fill the canvas with a
+// different left/right split, so every frame hashes differently
+func animatedGIF(frames int) *gif.GIF {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{Config: image.Config{Width: 64, Height: 64, ColorModel: palette}}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 64, 64), palette)
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				if x < 8*(i+1) {
+					frame.SetColorIndex(x, y, 1)
+				}
+			}
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+		anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+	}
+	return anim
+}
+
+func TestGIFFrames(t *testing.T) {
+	frames := gifFrames(animatedGIF(8), 4)
+	if len(frames) != 4 {
+		t.Fatalf("expected 4 frames, got %d", len(frames))
+	}
+	// Frames 0, 2, 4 and 6: the white band ends at x = 8, 24, 40, 56
+	for i, edge := range []int{8, 24, 40, 56} {
+		r, _, _, _ := frames[i].At(edge-1, 0).RGBA()
+		r2, _, _, _ := frames[i].At(edge, 0).RGBA()
+		if r == 0 || r2 != 0 {
+			t.Errorf("frame %d: expected the band to end at x=%d", i, edge)
+		}
+	}
+
+	if frames := gifFrames(animatedGIF(2), 4); len(frames) != 2 {
+		t.Errorf("expected every frame of a short animation, got %d", len(frames))
+	}
+
+	// A frame that only covers part of the canvas is drawn over the last one
+	anim := animatedGIF(1)
+	patch := image.NewPaletted(image.Rect(60, 60, 64, 64), anim.Image[0].Palette)
+	anim.Image = append(anim.Image, patch)
+	anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+	frames = gifFrames(anim, 2)
+	if r, _, _, _ := frames[1].At(0, 0).RGBA(); r == 0 {
+		t.Error("partial frame should keep the previous frame's pixels")
+	}
+}
+
+func TestHashFrames(t *testing.T) {
+	black := image.NewGray(image.Rect(0, 0, 16, 16))
+	stripes := image.NewGray(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x += 2 {
+			stripes.SetGray(x, y, color.Gray{255})
+		}
+	}
+	stripeHash, _ := hashImage(stripes, "dhash")
+
+	// The majority wins, so one odd frame out does not change the hash
+	combined, err := hashFrames([]image.Image{stripes, black, stripes}, "dhash")
+	if err != nil {
+		t.Fatalf("hashFrames() error = %v", err)
+	}
+	if combined != stripeHash {
+		t.Errorf("hashFrames() = %s, want the majority frame's hash %s", combined, stripeHash)
+	}
+
+	if _, err := hashFrames(nil, "dhash"); err == nil {
+		t.Error("expected an error without frames")
+	}
+}
+
+func TestComputePerceptualHashAnimatedGIF(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, anim *gif.GIF) string {
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := gif.EncodeAll(f, anim); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a.gif", animatedGIF(8))
+	b := write("b.gif", animatedGIF(8))
+	first := animatedGIF(8)
+	first.Image = first.Image[:1]
+	first.Delay, first.Disposal = first.Delay[:1], first.Disposal[:1]
+	still := write("still.gif", first)
+
+	hashA, err := computePerceptualHash(a, "dhash")
+	if err != nil {
+		t.Fatalf("computePerceptualHash() error = %v", err)
+	}
+	hashB, _ := computePerceptualHash(b, "dhash")
+	hashStill, _ := computePerceptualHash(still, "dhash")
+	if hashA != hashB {
+		t.Errorf("identical animations hashed differently: %s vs %s", hashA, hashB)
+	}
+	if hashA == hashStill {
+		t.Error("an animation should not hash like its first frame alone")
+	}
+}
+
+func TestComputeVideoHash(t *testing.T) {
+	dir := t.TempDir()
+
+	// A fake ffmpeg that prints two PNG frames back to back
+	var frames bytes.Buffer
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x += 2 {
+		for y := 0; y < 16; y++ {
+			img.SetGray(x, y, color.Gray{255})
+		}
+	}
+	png.Encode(&frames, img)
+	png.Encode(&frames, img)
+	framesPath := filepath.Join(dir, "frames.bin")
+	os.WriteFile(framesPath, frames.Bytes(), 0644)
+	script := filepath.Join(dir, "ffmpeg")
+	os.WriteFile(script, []byte("#!/bin/sh\ncat '"+framesPath+"'\n"), 0755)
+
+	old := ffmpegCommand
+	ffmpegCommand = script
+	defer func() { ffmpegCommand = old }()
+
+	hash, err := computePerceptualHash(filepath.Join(dir, "clip.mp4"), "dhash")
+	if err != nil {
+		t.Fatalf("computePerceptualHash() error = %v", err)
+	}
+	want, _ := hashImage(img, "dhash")
+	if hash != want {
+		t.Errorf("video hash = %s, want %s", hash, want)
+	}
+
+	ffmpegCommand = filepath.Join(dir, "missing")
+	if _, err := computePerceptualHash(filepath.Join(dir, "clip.mp4"), "dhash"); err == nil {
+		t.Error("expected an error without ffmpeg")
+	}
+}
+
+func TestPerceptualCandidate(t *testing.T) {
+	c := Config{PerceptualMode: true}
+	if !c.perceptualCandidate("a.JPG") || c.perceptualCandidate("a.mp4") {
+		t.Error("videos need -perceptual-video")
+	}
+	c.PerceptualVideo = true
+	if !c.perceptualCandidate("a.mp4") || c.perceptualCandidate("a.txt") {
+		t.Error("-perceptual-video should add videos only")
+	}
+}
+
+func TestFindPerceptualDuplicatesSameDimensions(t *testing.T) {
+	hash := "1010101010101010101010101010101010101010101010101010101010101010"
+	files := []FileHash{
+		{Path: "/original.jpg", Hash: "a", PHash: hash, Width: 4000, Height: 3000},
+		{Path: "/copy.jpg", Hash: "b", PHash: hash, Width: 4000, Height: 3000},
+		{Path: "/thumb.jpg", Hash: "c", PHash: hash, Width: 400, Height: 300},
+	}
+
+	c := DefaultConfig()
+	if groups := NewEngine(c, nil).findPerceptualDuplicates(files); len(groups) != 1 || len(groups[0].Files) != 3 {
+		t.Fatalf("without -same-dimensions: %+v", groups)
+	}
+
+	c.SameDimensions = true
+	groups := NewEngine(c, nil).findPerceptualDuplicates(files)
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Fatalf("with -same-dimensions: %+v", groups)
+	}
+	for _, f := range groups[0].Files {
+		if f.Path == "/thumb.jpg" {
+			t.Error("the thumbnail should not be grouped with the originals")
+		}
+	}
+}
+
+func TestImageDimensions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.png")
+	f, _ := os.Create(path)
+	png.Encode(f, image.NewGray(image.Rect(0, 0, 12, 7)))
+	f.Close()
+
+	if w, h, err := imageDimensions(path); err != nil || w != 12 || h != 7 {
+		t.Errorf("imageDimensions() = %d, %d, %v; want 12, 7", w, h, err)
+	}
+}
+
+func TestScoreGroup(t *testing.T) {
+	group := DuplicateGroup{Files: []FileHash{
+		{Path: "a", PHash: "0000000000000000"},
+		{Path: "b", PHash: "0000000000000001"},
+		{Path: "c", PHash: "0000000000000111"},
+	}}
+	scoreGroup(&group)
+
+	// Distances a-b 1, a-c 3, b-c 2 over 16 bits
+	if group.MaxDistance != 3 {
+		t.Errorf("MaxDistance = %d, want 3", group.MaxDistance)
+	}
+	if want := 100 - 2.0/16*100; group.Similarity != want {
+		t.Errorf("Similarity = %.2f, want %.2f from the average distance", group.Similarity, want)
+	}
+	if want := 100 - 2.5/16*100; group.Files[2].Similarity != want {
+		t.Errorf("c similarity = %.2f, want %.2f", group.Files[2].Similarity, want)
+	}
+
+	// Identical hashes still score below an exact match
+	same := DuplicateGroup{Files: []FileHash{{PHash: "0101"}, {PHash: "0101"}}}
+	scoreGroup(&same)
+	if same.Similarity >= 100 || same.Similarity != maxPerceptualSimilarity {
+		t.Errorf("identical hashes scored %.2f, want %.1f", same.Similarity, maxPerceptualSimilarity)
+	}
+}
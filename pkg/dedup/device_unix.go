@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package dedup
+
+import (
+	"os"
+	"syscall"
+)
+
+// identity returns the device and inode of the entry info describes, from
+// the stat filepath.Walk already made
+func identity(path string, info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
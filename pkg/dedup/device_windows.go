@@ -0,0 +1,32 @@
+package dedup
+
+import (
+	"os"
+	"syscall"
+)
+
+// identity returns the volume serial number and file index of path. Unlike
+// on Unix they are not part of a stat, so the entry is opened (without
+// reading it) to ask for them.
+func identity(path string, info os.FileInfo) (fileID, bool) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	handle, err := syscall.CreateFile(name, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+	defer syscall.CloseHandle(handle)
+
+	var data syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(handle, &data); err != nil {
+		return fileID{}, false
+	}
+	return fileID{
+		dev: uint64(data.VolumeSerialNumber),
+		ino: uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow),
+	}, true
+}
@@ -0,0 +1,41 @@
+// Package dedup finds files with identical content. It holds the scanning,
+// hashing, grouping and keep selection behind the file-deduplicator command
+// so other programs can embed duplicate detection:
+//
+//	scanner := dedup.Scanner{Recursive: true}
+//	paths, err := scanner.Scan(ctx, "/photos", "/backup")
+//	if err != nil {
+//		return err
+//	}
+//	hasher, err := dedup.NewHasher("sha256")
+//	if err != nil {
+//		return err
+//	}
+//	groups, err := dedup.Grouper{Hasher: hasher}.Group(ctx, paths)
+//	if err != nil {
+//		return err
+//	}
+//	for _, group := range groups {
+//		keep := dedup.SelectKeep(group.Files, dedup.KeepOldest)
+//		for i, file := range group.Files {
+//			if i != keep {
+//				fmt.Println("duplicate of", group.Files[keep].Path, ":", file.Path)
+//			}
+//		}
+//	}
+//
+// Files that cannot be read are reported with errors that match one of the
+// kinds ErrPermission, ErrTooManyOpenFiles or ErrTransientIO under
+// errors.Is when they are of that kind, so callers can branch on them:
+//
+//	scanner.OnError = func(path string, err error) error {
+//		if errors.Is(err, dedup.ErrTooManyOpenFiles) {
+//			return err // no point going on; raise the limit and rerun
+//		}
+//		return nil // leave the file out
+//	}
+//
+// The package only reads files. Deleting, moving or linking duplicates, and
+// the caches, perceptual matching and reports of the command, stay in the
+// command.
+package dedup
@@ -0,0 +1,74 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io/fs"
+	"syscall"
+)
+
+// Kinds of failure a file can be reported with. Errors from Hasher,
+// Grouper and Scanner, and any error passed through Classify, match their
+// kind with errors.Is, while still matching the underlying error (such as
+// fs.ErrPermission or a syscall.Errno) too. ErrUnsupportedImage is for
+// callers decoding images, such as perceptual hashing in the command.
+var (
+	ErrPermission       = errors.New("permission denied")
+	ErrTooManyOpenFiles = errors.New("too many open files")
+	ErrTransientIO      = errors.New("transient I/O error") // a timeout or a busy or interrupted read, worth retrying
+	ErrUnsupportedImage = errors.New("unsupported image format")
+)
+
+// kindError is an error tagged with the kind Classify found for it
+type kindError struct {
+	kind, err error
+}
+
+func (e kindError) Error() string { return e.err.Error() }
+
+func (e kindError) Unwrap() []error { return []error{e.err, e.kind} }
+
+// Classify returns err tagged with the kind of failure it is, so
+// errors.Is(err, ErrPermission) and the like hold. Errors of no known kind,
+// and nil, are returned as they are.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var tagged kindError
+	if errors.As(err, &tagged) {
+		return err
+	}
+	if kind := kindOf(err); kind != nil {
+		return kindError{kind: kind, err: err}
+	}
+	return err
+}
+
+// kindOf returns the kind err falls under, or nil
+func kindOf(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil // the caller stopped, the file is fine
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermission
+	case errors.Is(err, image.ErrFormat):
+		return ErrUnsupportedImage
+	}
+	for _, errno := range tooManyOpenFiles {
+		if errors.Is(err, errno) {
+			return ErrTooManyOpenFiles
+		}
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return ErrTransientIO
+	}
+	for _, errno := range []syscall.Errno{syscall.EAGAIN, syscall.EINTR, syscall.EBUSY, syscall.ETIMEDOUT} {
+		if errors.Is(err, errno) {
+			return ErrTransientIO
+		}
+	}
+	return nil
+}
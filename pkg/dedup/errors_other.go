@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package dedup
+
+import "syscall"
+
+// tooManyOpenFiles are the per-process and system-wide limits
+var tooManyOpenFiles = []syscall.Errno{syscall.EMFILE, syscall.ENFILE}
@@ -0,0 +1,60 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{&fs.PathError{Op: "open", Path: "/a", Err: fs.ErrPermission}, ErrPermission},
+		{fmt.Errorf("decode: %w", image.ErrFormat), ErrUnsupportedImage},
+		{&fs.PathError{Op: "read", Path: "/a", Err: syscall.EAGAIN}, ErrTransientIO},
+		{fs.ErrNotExist, nil},
+		{context.DeadlineExceeded, nil},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct{ err, kind error }{&fs.PathError{Op: "open", Path: "/a", Err: syscall.EMFILE}, ErrTooManyOpenFiles})
+	}
+	kinds := []error{ErrPermission, ErrTooManyOpenFiles, ErrTransientIO, ErrUnsupportedImage}
+	for _, tt := range tests {
+		got := Classify(tt.err)
+		if !errors.Is(got, tt.err) {
+			t.Errorf("Classify(%v) lost the original error", tt.err)
+		}
+		for _, kind := range kinds {
+			if is := errors.Is(got, kind); is != (kind == tt.kind) {
+				t.Errorf("errors.Is(Classify(%v), %v) = %v", tt.err, kind, is)
+			}
+		}
+		if got.Error() != tt.err.Error() {
+			t.Errorf("Classify(%v) changed the message to %q", tt.err, got)
+		}
+	}
+	if Classify(nil) != nil {
+		t.Error("Classify(nil) should be nil")
+	}
+}
+
+func TestHashFilePermissionKind(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs a file the current user cannot read")
+	}
+	path := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(path, []byte("x"), 0000)
+	_, err := Hasher{}.HashFile(context.Background(), path)
+	if !errors.Is(err, ErrPermission) || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("HashFile() error = %v, want ErrPermission", err)
+	}
+}
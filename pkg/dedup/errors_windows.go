@@ -0,0 +1,6 @@
+package dedup
+
+import "syscall"
+
+// tooManyOpenFiles is ERROR_TOO_MANY_OPEN_FILES
+var tooManyOpenFiles = []syscall.Errno{4}
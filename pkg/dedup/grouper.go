@@ -0,0 +1,169 @@
+package dedup
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// File is a file considered for deduplication
+type File struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Hash    string // hex content hash, empty until hashed
+}
+
+// Group is a set of files with identical content
+type Group struct {
+	Hash  string
+	Size  int64 // size of each file
+	Files []File
+}
+
+// Wasted is the space the group's copies beyond the first take up
+func (g Group) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// Grouper finds the files with identical content among a list of paths.
+// Only files that share their size with another file are read.
+type Grouper struct {
+	Hasher  Hasher
+	Workers int // files hashed at once, runtime.NumCPU() when <= 0
+
+	// OnError decides about files that cannot be read, like
+	// Scanner.OnError: returning nil leaves the file out, returning an
+	// error stops grouping with it. Without OnError such files are left out.
+	// Errors are tagged with their kind, as by Classify.
+	OnError func(path string, err error) error
+}
+
+// Group returns the groups of identical files among paths, the ones wasting
+// the most space first. It stops with ctx's error once ctx is cancelled.
+func (g Grouper) Group(ctx context.Context, paths []string) ([]Group, error) {
+	bySize := make(map[int64][]string)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			if err := g.fail(path, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		bySize[info.Size()] = append(bySize[info.Size()], path)
+	}
+	var candidates []string
+	for _, same := range bySize {
+		if len(same) > 1 {
+			candidates = append(candidates, same...)
+		}
+	}
+	sort.Strings(candidates)
+
+	files, err := g.hashAll(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return GroupByHash(files), nil
+}
+
+// hashAll hashes paths with g.Workers workers, returning the files in the
+// order of paths
+func (g Grouper) hashAll(ctx context.Context, paths []string) ([]File, error) {
+	ctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	workers := g.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]File, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sum := g.Hasher.New()
+			for i := range next {
+				sum.Reset()
+				file, err := g.Hasher.HashFileWith(ctx, paths[i], sum)
+				if err != nil && ctx.Err() == nil {
+					if err := g.fail(paths[i], err); err != nil {
+						stop(err)
+					}
+				}
+				results[i] = file
+			}
+		}()
+	}
+	for i := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+	files := results[:0]
+	for _, file := range results {
+		if file.Hash != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// fail passes a file's error, tagged with its kind, to OnError
+func (g Grouper) fail(path string, err error) error {
+	if g.OnError != nil {
+		return g.OnError(path, Classify(err))
+	}
+	return nil
+}
+
+// GroupByHash groups hashed files by Hash and Size, leaving out files no
+// other file shares both with: copies are always the same size, so files of
+// different sizes sharing a hash were misread or collide, and are never
+// grouped. Groups wasting the most space come first; files keep their
+// order within a group.
+func GroupByHash(files []File) []Group {
+	type key struct {
+		hash string
+		size int64
+	}
+	byKey := make(map[key][]File)
+	var order []key
+	for _, file := range files {
+		if file.Hash == "" {
+			continue
+		}
+		k := key{file.Hash, file.Size}
+		if _, ok := byKey[k]; !ok {
+			order = append(order, k)
+		}
+		byKey[k] = append(byKey[k], file)
+	}
+
+	var groups []Group
+	for _, k := range order {
+		if same := byKey[k]; len(same) > 1 {
+			groups = append(groups, Group{Hash: k.hash, Size: k.size, Files: same})
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Wasted() > groups[j].Wasted()
+	})
+	return groups
+}
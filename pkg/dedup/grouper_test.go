@@ -0,0 +1,71 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestGrouper(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a1": "alpha", "a2": "alpha",
+		"b1": "bravo bravo", "b2": "bravo bravo", "b3": "bravo bravo",
+		"c1": "charl", // same size as alpha, different content
+		"d1": "unique size",
+	})
+	var paths []string
+	for _, name := range []string{"a1", "a2", "b1", "b2", "b3", "c1", "d1", "missing"} {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+
+	var failed []string
+	g := Grouper{Workers: 2, OnError: func(path string, err error) error {
+		failed = append(failed, filepath.Base(path))
+		return nil
+	}}
+	groups, err := g.Group(context.Background(), paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("Group() found %d groups, want 2: %+v", len(groups), groups)
+	}
+	// The bravo copies waste the most space and come first
+	if len(groups[0].Files) != 3 || groups[0].Size != 11 || groups[0].Wasted() != 22 {
+		t.Errorf("first group = %+v", groups[0])
+	}
+	if len(groups[1].Files) != 2 || filepath.Base(groups[1].Files[0].Path) != "a1" {
+		t.Errorf("second group = %+v", groups[1])
+	}
+	if len(failed) != 1 || failed[0] != "missing" {
+		t.Errorf("OnError saw %v, want the missing file", failed)
+	}
+
+	// An error from OnError stops grouping
+	stop := errors.New("stop")
+	g.OnError = func(string, error) error { return stop }
+	if _, err := g.Group(context.Background(), paths); !errors.Is(err, stop) {
+		t.Errorf("Group() error = %v, want OnError's error", err)
+	}
+}
+
+func TestGroupByHash(t *testing.T) {
+	files := []File{
+		{Path: "x", Hash: "1", Size: 1},
+		{Path: "y", Hash: "2", Size: 5},
+		{Path: "z", Hash: "1", Size: 1},
+		{Path: "w", Hash: "2", Size: 5},
+		{Path: "v", Hash: "3", Size: 9},
+		{Path: "u", Size: 9},            // never hashed
+		{Path: "t", Hash: "3", Size: 4}, // v's hash at another size: misread, not a copy
+	}
+	groups := GroupByHash(files)
+	if len(groups) != 2 || groups[0].Hash != "2" || groups[1].Hash != "1" {
+		t.Fatalf("GroupByHash() = %+v", groups)
+	}
+	if groups[1].Files[0].Path != "x" || groups[1].Files[1].Path != "z" {
+		t.Errorf("files lost their order: %+v", groups[1].Files)
+	}
+}
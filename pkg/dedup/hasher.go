@@ -0,0 +1,169 @@
+package dedup
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/zeebo/xxh3"
+	"lukechampine.com/blake3"
+)
+
+// Algorithms are the content hashes a Hasher computes. xxh3 (128-bit) is
+// by far the fastest but not cryptographic: fine for telling files apart,
+// not for files crafted by someone who wants two to collide. blake3 is
+// cryptographic and still several times faster than sha256.
+var Algorithms = []string{"sha256", "sha1", "md5", "xxh3", "blake3"}
+
+// DefaultBufferSize is how much a Hasher reads at a time unless BufferSize
+// says otherwise: large enough that big files are hashed in few syscalls,
+// small enough to keep one per worker cheap
+const DefaultBufferSize = 1 << 20
+
+// Hasher computes content hashes of files. The zero value hashes with
+// sha256 and DefaultBufferSize.
+type Hasher struct {
+	Algorithm  string // one of Algorithms, in any case; sha256 when empty
+	BufferSize int    // bytes read at a time, DefaultBufferSize when <= 0
+
+	// Wait, when set, is called before every read and hashing stops with
+	// its error. The command line uses it to hold reads while paused.
+	Wait func(ctx context.Context) error
+}
+
+// CheckAlgorithm reports an error for a hash algorithm not in Algorithms
+func CheckAlgorithm(algorithm string) error {
+	for _, known := range Algorithms {
+		if strings.ToLower(algorithm) == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown hash algorithm %q: expected %s", algorithm, strings.Join(Algorithms, ", "))
+}
+
+// NewHasher returns a Hasher for algorithm, which must be one of Algorithms
+func NewHasher(algorithm string) (Hasher, error) {
+	if err := CheckAlgorithm(algorithm); err != nil {
+		return Hasher{}, err
+	}
+	return Hasher{Algorithm: strings.ToLower(algorithm)}, nil
+}
+
+// New returns a fresh hash.Hash for the Hasher's algorithm. Names it does
+// not know fall back to sha256; use NewHasher to refuse them instead.
+func (h Hasher) New() hash.Hash {
+	switch strings.ToLower(h.Algorithm) {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "xxh3":
+		return xxh3Hash{xxh3.New()}
+	case "blake3":
+		return blake3.New(32, nil)
+	default:
+		return sha256.New()
+	}
+}
+
+// HashFile hashes the file at path. It gives up between reads once ctx is
+// cancelled, so large files do not delay shutdown.
+func (h Hasher) HashFile(ctx context.Context, path string) (File, error) {
+	return h.HashFileWith(ctx, path, h.New())
+}
+
+// HashFileWith is like HashFile but writes into sum, which must be fresh or
+// Reset, so a worker can reuse one hash.Hash for many files. Failures are
+// tagged with their kind, as by Classify.
+func (h Hasher) HashFileWith(ctx context.Context, path string, sum hash.Hash) (File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return File{}, Classify(err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return File{}, Classify(err)
+	}
+
+	size := h.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	buf := getReadBuffer(size)
+	defer putReadBuffer(buf)
+	if _, err := io.CopyBuffer(sum, gatedReader{ctx, h.Wait, file}, *buf); err != nil {
+		return File{}, Classify(err)
+	}
+
+	return File{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Hash:    hex.EncodeToString(sum.Sum(nil)),
+	}, nil
+}
+
+// gatedReader fails reads once ctx is cancelled and calls wait, when set,
+// before each one
+type gatedReader struct {
+	ctx  context.Context
+	wait func(context.Context) error
+	r    io.Reader
+}
+
+func (g gatedReader) Read(p []byte) (int, error) {
+	if err := g.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if g.wait != nil {
+		if err := g.wait(g.ctx); err != nil {
+			return 0, err
+		}
+	}
+	return g.r.Read(p)
+}
+
+// readBufferPools holds a sync.Pool of reusable read buffers per size, so
+// hashing does not allocate a buffer for every file
+var readBufferPools sync.Map // int -> *sync.Pool
+
+// getReadBuffer returns a buffer of size bytes from the pool
+func getReadBuffer(size int) *[]byte {
+	pool, _ := readBufferPools.LoadOrStore(size, &sync.Pool{
+		New: func() interface{} {
+			buf := make([]byte, size)
+			return &buf
+		},
+	})
+	return pool.(*sync.Pool).Get().(*[]byte)
+}
+
+// putReadBuffer returns a buffer from getReadBuffer to its pool
+func putReadBuffer(buf *[]byte) {
+	if pool, ok := readBufferPools.Load(len(*buf)); ok {
+		pool.(*sync.Pool).Put(buf)
+	}
+}
+
+// xxh3Hash is xxh3's hash.Hash with the 128-bit sum, as 64 bits leave a
+// real chance of a collision among millions of files
+type xxh3Hash struct {
+	*xxh3.Hasher
+}
+
+func (h xxh3Hash) Size() int { return 16 }
+
+func (h xxh3Hash) Sum(b []byte) []byte {
+	sum := h.Sum128().Bytes()
+	return append(b, sum[:]...)
+}
@@ -0,0 +1,78 @@
+package dedup
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHasher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	data := make([]byte, 100000)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	want := hex.EncodeToString(sum[:])
+
+	// Every buffer size, including ones smaller than the file, gives the same hash
+	for _, size := range []int{0, 1, 4096, DefaultBufferSize} {
+		f, err := Hasher{BufferSize: size}.HashFile(context.Background(), path)
+		if err != nil || f.Hash != want || f.Size != int64(len(data)) || f.Path != path {
+			t.Errorf("HashFile() with buffer %d = %+v, %v; want %s", size, f, err, want)
+		}
+	}
+
+	h, err := NewHasher("MD5")
+	if err != nil {
+		t.Fatalf("NewHasher(MD5) error = %v", err)
+	}
+	md5Sum := md5.Sum(data)
+	if f, _ := h.HashFile(context.Background(), path); f.Hash != hex.EncodeToString(md5Sum[:]) {
+		t.Errorf("md5 HashFile() = %s", f.Hash)
+	}
+	// The newer algorithms give their reference digests of empty input
+	for alg, want := range map[string]string{
+		"xxh3":   "99aa06d3014798d86001c324468d497f",
+		"blake3": "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+	} {
+		if got := hex.EncodeToString(Hasher{Algorithm: alg}.New().Sum(nil)); got != want {
+			t.Errorf("%s of nothing = %s, want %s", alg, got, want)
+		}
+	}
+	if _, err := NewHasher("crc32"); err == nil {
+		t.Error("NewHasher() accepted an unknown algorithm")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (Hasher{}).HashFile(ctx, path); err == nil {
+		t.Error("expected an error with a cancelled context")
+	}
+
+	// Wait's error stops hashing
+	stopped := errors.New("stopped")
+	wait := func(context.Context) error { return stopped }
+	if _, err := (Hasher{Wait: wait}).HashFile(context.Background(), path); !errors.Is(err, stopped) {
+		t.Errorf("HashFile() error = %v, want Wait's error", err)
+	}
+}
+
+func TestReadBufferPool(t *testing.T) {
+	buf := getReadBuffer(12345)
+	if len(*buf) != 12345 {
+		t.Fatalf("getReadBuffer(12345) has length %d", len(*buf))
+	}
+	putReadBuffer(buf)
+	if other := getReadBuffer(777); len(*other) != 777 {
+		t.Errorf("buffers of different sizes must not be mixed, got %d", len(*other))
+	}
+}
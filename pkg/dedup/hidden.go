@@ -0,0 +1,27 @@
+package dedup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Hidden reports whether the entry at path, described by info, is hidden the
+// way the platform's file manager means it. On Windows that is the hidden
+// attribute and names starting with a dot are ordinary; elsewhere it is a
+// leading dot, and on macOS also the flag Finder hides files by.
+func Hidden(path string, info os.FileInfo) bool {
+	hidden, _ := attributes(info)
+	if dotHidden {
+		return hidden || strings.HasPrefix(filepath.Base(path), ".")
+	}
+	return hidden
+}
+
+// System reports whether info is a Windows system file, such as desktop.ini
+// or a volume's "System Volume Information" folder. There are none on other
+// platforms.
+func System(info os.FileInfo) bool {
+	_, system := attributes(info)
+	return system
+}
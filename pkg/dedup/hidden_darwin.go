@@ -0,0 +1,18 @@
+package dedup
+
+import (
+	"os"
+	"syscall"
+)
+
+const dotHidden = true
+
+// ufHidden is UF_HIDDEN from sys/stat.h, set by chflags hidden
+const ufHidden = 0x8000
+
+// attributes reports the file flag Finder hides entries by; macOS has no
+// system files
+func attributes(info os.FileInfo) (hidden, system bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && st.Flags&ufHidden != 0, false
+}
@@ -0,0 +1,13 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package dedup
+
+import "os"
+
+const dotHidden = true
+
+// attributes reports nothing: only the leading dot hides a file here
+func attributes(info os.FileInfo) (hidden, system bool) {
+	return false, false
+}
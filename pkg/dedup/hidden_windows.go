@@ -0,0 +1,19 @@
+package dedup
+
+import (
+	"os"
+	"syscall"
+)
+
+// dotHidden is false: Explorer shows names starting with a dot
+const dotHidden = false
+
+// attributes reads the hidden and system attributes os.Lstat and
+// filepath.Walk already fetched, without another call
+func attributes(info os.FileInfo) (hidden, system bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return false, false
+	}
+	return data.FileAttributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0, data.FileAttributes&syscall.FILE_ATTRIBUTE_SYSTEM != 0
+}
@@ -0,0 +1,20 @@
+package dedup
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// markHidden gives the entry at dir/name the hidden attribute, as a leading
+// dot does not hide it here
+func markHidden(t *testing.T, dir, name string) {
+	t.Helper()
+	path, err := syscall.UTF16PtrFromString(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		err = syscall.SetFileAttributes(path, syscall.FILE_ATTRIBUTE_HIDDEN)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,141 @@
+package dedup
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern line of an ignore file
+type ignoreRule struct {
+	segments []string // the pattern split at "/"
+	negate   bool     // "!pattern" lists again what an earlier rule left out
+	dirOnly  bool     // "pattern/" matches only folders
+	anchored bool     // a pattern with an inner or leading "/" matches from the file's folder down
+}
+
+// ignoreRules are the rules of the ignore file in dir, on top of those of
+// the folders above it
+type ignoreRules struct {
+	dir    string
+	rules  []ignoreRule
+	parent *ignoreRules
+}
+
+// loadIgnoreFile reads the ignore file name in dir, on top of parent. It
+// returns parent itself when dir has no such file.
+func loadIgnoreFile(dir, name string, parent *ignoreRules) (*ignoreRules, error) {
+	f, err := os.Open(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return parent, nil
+	}
+	if err != nil {
+		return parent, err
+	}
+	defer f.Close()
+
+	r := &ignoreRules{dir: dir, parent: parent}
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		if rule, ok := parseIgnoreRule(lines.Text()); ok {
+			r.rules = append(r.rules, rule)
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return parent, err
+	}
+	return r, nil
+}
+
+// parseIgnoreRule parses one line with gitignore syntax, reporting false for
+// blank lines and comments
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " \t")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// ignored reports whether the rules leave out the entry at p. As with git,
+// the last matching rule decides, and the rules of a deeper folder come
+// after those of the folders above it.
+func (r *ignoreRules) ignored(p string, isDir bool) bool {
+	var chain []*ignoreRules
+	for ; r != nil; r = r.parent {
+		chain = append(chain, r)
+	}
+	ignored := false
+	for i := len(chain) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(chain[i].dir, p)
+		if err != nil {
+			continue
+		}
+		names := strings.Split(filepath.ToSlash(rel), "/")
+		for _, rule := range chain[i].rules {
+			if rule.matches(names, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// matches reports whether the rule matches an entry at the path names below
+// the ignore file's folder
+func (rule ignoreRule) matches(names []string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+	if !rule.anchored {
+		matched, _ := path.Match(rule.segments[0], names[len(names)-1])
+		return matched
+	}
+	return matchSegments(rule.segments, names)
+}
+
+// matchSegments matches a pattern against a path a segment at a time, with
+// "**" standing for any number of folders
+func matchSegments(pattern, names []string) bool {
+	if len(pattern) == 0 {
+		return len(names) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(names); i++ {
+			if matchSegments(pattern[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], names[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], names[1:])
+}
@@ -0,0 +1,85 @@
+package dedup
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseIgnoreRule(t *testing.T) {
+	tests := []struct {
+		line string
+		want ignoreRule
+		ok   bool
+	}{
+		{"# a comment", ignoreRule{}, false},
+		{"   ", ignoreRule{}, false},
+		{"*.tmp", ignoreRule{segments: []string{"*.tmp"}}, true},
+		{"build/", ignoreRule{segments: []string{"build"}, dirOnly: true}, true},
+		{"/cache", ignoreRule{segments: []string{"cache"}, anchored: true}, true},
+		{"!keep.tmp", ignoreRule{segments: []string{"keep.tmp"}, negate: true}, true},
+		{"docs/**/*.bak", ignoreRule{segments: []string{"docs", "**", "*.bak"}, anchored: true}, true},
+		{`\#notes`, ignoreRule{segments: []string{"#notes"}}, true},
+	}
+	for _, tt := range tests {
+		got, ok := parseIgnoreRule(tt.line)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseIgnoreRule(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestScannerIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".dedupignore":             "*.tmp\n!keep.tmp\nbuild/\n/top.log\n",
+		"a.txt":                    "a",
+		"x.tmp":                    "x",
+		"keep.tmp":                 "k",
+		"top.log":                  "t",
+		"build/out.bin":            "o",
+		"src/top.log":              "t",
+		"src/build":                "a file, not a folder",
+		"src/y.tmp":                "y",
+		"photos/.dedupignore":      "raw/\n!*.tmp\n",
+		"photos/a.jpg":             "p",
+		"photos/z.tmp":             "z",
+		"photos/raw/a.cr2":         "r",
+		"photos/2024/raw/b.cr2":    "r",
+		"photos/2024/notes/c.bak":  "c",
+		"photos/2024/.dedupignore": "**/*.bak\n",
+	})
+
+	var ignored []string
+	s := Scanner{
+		Recursive:     true,
+		IncludeHidden: true,
+		IgnoreFile:    ".dedupignore",
+		Skipped: func(path, reason string) {
+			if reason == SkippedIgnored {
+				ignored = append(ignored, path)
+			}
+		},
+	}
+	files, err := s.Walk(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		".dedupignore", "a.txt", "keep.tmp",
+		"photos/.dedupignore", "photos/2024/.dedupignore", "photos/a.jpg", "photos/z.tmp",
+		"src/build", "src/top.log",
+	}
+	if got := names(t, dir, files); !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() = %v, want %v", got, want)
+	}
+	if got := names(t, dir, ignored); len(got) != 7 {
+		t.Errorf("ignored %v, want 7 entries", got)
+	}
+
+	s.IgnoreFile = ""
+	if files, _ := s.Walk(context.Background(), filepath.Join(dir, "photos")); len(files) != 7 {
+		t.Errorf("Walk() without IgnoreFile found %d files, want 7", len(files))
+	}
+}
@@ -0,0 +1,114 @@
+package dedup
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Keep criteria understood by SelectKeep. KeepPath is a prefix: "path:/photos"
+// keeps the first file whose path contains /photos.
+const (
+	KeepOldest    = "oldest"
+	KeepNewest    = "newest"
+	KeepCanonical = "canonical" // the name without copy markers, then the oldest
+	KeepLargest   = "largest"
+	KeepSmallest  = "smallest"
+	KeepPath      = "path:"
+)
+
+// SelectKeep returns the index of the file to keep among files by criteria.
+// Unknown criteria, and a path: criterion no file matches, keep the first
+// file.
+func SelectKeep(files []File, criteria string) int {
+	if strings.HasPrefix(criteria, KeepPath) {
+		target := strings.TrimPrefix(criteria, KeepPath)
+		for i, f := range files {
+			if strings.Contains(f.Path, target) {
+				return i
+			}
+		}
+		return 0
+	}
+
+	best := 0
+	for i, f := range files {
+		b := files[best]
+		switch strings.ToLower(criteria) {
+		case KeepOldest:
+			if f.ModTime.Before(b.ModTime) {
+				best = i
+			}
+		case KeepNewest:
+			if f.ModTime.After(b.ModTime) {
+				best = i
+			}
+		case KeepCanonical:
+			marked, bestMarked := HasCopyMarker(f.Path), HasCopyMarker(b.Path)
+			if marked != bestMarked {
+				if !marked {
+					best = i
+				}
+			} else if f.ModTime.Before(b.ModTime) {
+				best = i
+			}
+		case KeepLargest:
+			if f.Size > b.Size {
+				best = i
+			}
+		case KeepSmallest:
+			if f.Size < b.Size {
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+// copyMarkers match what copying tools and people add to a file name when
+// they duplicate it. Words only count after a space, underscore or hyphen,
+// so names such as "photocopy" are left alone.
+var copyMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^copy of `),                      // "Copy of report"
+	regexp.MustCompile(`[ _-]*\(\d+\)$`),                     // "report (1)"
+	regexp.MustCompile(`(?i)[ _-]+(copy|duplicate)( \d+)?$`), // "report - Copy", "report copy 2", "report-duplicate"
+}
+
+// versionMarkers match the labels and version numbers people give the
+// variants of a file. They tell nothing about which file is the copy: the
+// "_final" or "_original" one may well be the file to keep.
+var versionMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)[ _-]+(final|draft|old|new|backup|bak|orig|original|edited)$`), // "report_final"
+	regexp.MustCompile(`(?i)[ _-]+v\d+$`),                                                  // "report_v2"
+}
+
+// NameStem reduces a base name to what is left without copy markers,
+// version suffixes and case, so "report_final_v2.docx" and
+// "Report (1).docx" both become "report.docx"
+func NameStem(name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for changed := true; changed; {
+		changed = false
+		for _, marker := range append(copyMarkers, versionMarkers...) {
+			if trimmed := strings.TrimSpace(marker.ReplaceAllString(stem, "")); trimmed != stem && trimmed != "" {
+				stem, changed = trimmed, true
+			}
+		}
+	}
+	return strings.ToLower(stem + ext)
+}
+
+// HasCopyMarker reports whether a file's base name carries a copy marker,
+// as "report (1).docx", "report - Copy.docx" and "Copy of report.docx" do.
+// Version suffixes such as "_v2" or "_final" are not copy markers.
+func HasCopyMarker(path string) bool {
+	name := filepath.Base(path)
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	for _, marker := range copyMarkers {
+		if marker.MatchString(stem) {
+			return true
+		}
+	}
+	return false
+}
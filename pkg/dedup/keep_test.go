@@ -0,0 +1,95 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectKeep(t *testing.T) {
+	now := time.Now()
+	files := []File{
+		{Path: "/docs/report (1).docx", Size: 300, ModTime: now.Add(-3 * time.Hour)},
+		{Path: "/backup/report.docx", Size: 100, ModTime: now},
+		{Path: "/docs/report.docx", Size: 200, ModTime: now.Add(-time.Hour)},
+	}
+	tests := map[string]int{
+		KeepOldest:         0,
+		KeepNewest:         1,
+		KeepCanonical:      2, // the oldest of the unmarked names
+		KeepLargest:        0,
+		KeepSmallest:       1,
+		"NEWEST":           1,
+		KeepPath + "/docs": 0,
+		KeepPath + "/none": 0,
+		"unknown":          0,
+	}
+	for criteria, want := range tests {
+		if got := SelectKeep(files, criteria); got != want {
+			t.Errorf("SelectKeep(%q) = %d, want %d", criteria, got, want)
+		}
+	}
+}
+
+func TestHasCopyMarker(t *testing.T) {
+	tests := map[string]bool{
+		"/docs/report (1).docx":     true,
+		"/docs/report - Copy.docx":  true,
+		"/docs/report copy 2.docx":  true,
+		"/docs/photo-duplicate.jpg": true,
+		"/docs/Copy of report.docx": true,
+		"/docs/report.docx":         false,
+		"/img/nav1.png":             false,
+		"/src/dev2.txt":             false,
+		"/docs/photocopy.pdf":       false,
+		"/docs/report_v2.docx":      false, // versions and labels are not copies
+		"/docs/report_original.doc": false,
+		"/docs/report-final.doc":    false,
+	}
+	for path, want := range tests {
+		if got := HasCopyMarker(path); got != want {
+			t.Errorf("HasCopyMarker(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	now := time.Now()
+	for _, names := range [][2]string{
+		{"/img/nav1.png", "/img/nav1 (1).png"},
+		{"/docs/photo_original.jpg", "/docs/photo (2).jpg"},
+	} {
+		files := []File{
+			{Path: names[1], ModTime: now.Add(-time.Hour)},
+			{Path: names[0], ModTime: now},
+		}
+		if got := SelectKeep(files, KeepCanonical); got != 1 {
+			t.Errorf("SelectKeep(canonical) kept %s, want %s", files[got].Path, names[0])
+		}
+	}
+}
+
+func TestNameStem(t *testing.T) {
+	tests := map[string]string{
+		"report.docx":          "report.docx",
+		"Report (1).docx":      "report.docx",
+		"report_final_v2.docx": "report.docx",
+		"Copy of report.docx":  "report.docx",
+		"report - Copy.docx":   "report.docx",
+		"report copy 2.docx":   "report.docx",
+		"IMG_0001.jpg":         "img_0001.jpg",
+		"final.txt":            "final.txt", // never strip a name down to nothing
+		"v2.txt":               "v2.txt",
+		"archive (3).tar.gz":   "archive (3).tar.gz",
+		"notes-backup.md":      "notes.md",
+		"photo_copy.jpg":       "photo.jpg",
+		"photo-duplicate.jpg":  "photo.jpg",
+		"dev2.txt":             "dev2.txt", // words ending in digits or containing "copy"
+		"nav1.png":             "nav1.png",
+		"photocopy.pdf":        "photocopy.pdf",
+		"reportv2.docx":        "reportv2.docx",
+		"renew.txt":            "renew.txt",
+	}
+	for name, want := range tests {
+		if got := NameStem(name); got != want {
+			t.Errorf("NameStem(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package dedup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Reasons a Scanner leaves an entry out, as passed to Scanner.Skipped
+const (
+	SkippedHiddenDir  = "hidden directory"
+	SkippedHiddenFile = "hidden file"
+	SkippedSystemDir  = "system directory"
+	SkippedSystemFile = "system file"
+	SkippedTooDeep    = "directory beyond max depth"
+	SkippedIgnored    = "listed in an ignore file"
+	SkippedOtherFS    = "directory on another filesystem"
+	SkippedSymlink    = "symbolic link"
+	SkippedVisited    = "directory already scanned through another path"
+)
+
+// Scanner lists the files below one or more roots. Hidden files and
+// directories, as Hidden decides, are left out unless IncludeHidden is set,
+// and so are Windows system files unless IncludeSystem is, and symbolic
+// links unless FollowSymlinks is; a root itself is always walked.
+type Scanner struct {
+	Recursive     bool // descend into subdirectories
+	MaxDepth      int  // like find's -maxdepth, 1 lists only a root's own files; 0 for no limit
+	IncludeHidden bool
+	IncludeSystem bool
+	OneFilesystem bool // like find's -xdev, leave out directories mounted from another filesystem than their root
+
+	// FollowSymlinks lists the files symbolic links point to under the
+	// link's path, and walks the directories they point to as if they were
+	// at the link. A directory reached a second time, through a loop or
+	// another link, is left out.
+	FollowSymlinks bool
+
+	// IgnoreFile names the files, such as ".dedupignore", whose gitignore
+	// style rules leave out entries below the folder holding them, nested
+	// files adding to the rules of the folders above; "" reads none
+	IgnoreFile string
+
+	// SkipDir, when set, is asked about every directory, roots included,
+	// and leaves out those it returns true for
+	SkipDir func(path string) bool
+
+	// OnError decides about entries below a root that cannot be read:
+	// returning nil skips the entry, returning an error stops the scan with
+	// it. Without OnError such entries are skipped. An unreadable root
+	// always fails the scan. Errors are tagged with their kind, as by
+	// Classify.
+	OnError func(path string, err error) error
+
+	// Skipped, when set, is told about every hidden, system, ignored or
+	// symlinked entry and directory beyond MaxDepth, on another filesystem
+	// or already scanned left out, with one of the Skipped reasons
+	Skipped func(path, reason string)
+
+	// Progress, when set, is called for every entry visited. Scan calls it
+	// from one goroutine per root.
+	Progress func()
+}
+
+// Scan walks every root concurrently, so scans of separate drives overlap.
+// Files are interleaved across roots so that hashing them in order reads
+// from all drives at once; a file reachable from more than one root
+// (nested or repeated roots) is listed only once. The files found are
+// returned along with the first root's error, if any.
+func (s Scanner) Scan(ctx context.Context, roots ...string) ([]string, error) {
+	perRoot := make([][]string, len(roots))
+	errs := make([]error, len(roots))
+	var wg sync.WaitGroup
+	for i, root := range roots {
+		wg.Add(1)
+		go func(i int, root string) {
+			defer wg.Done()
+			perRoot[i], errs[i] = s.Walk(ctx, root)
+		}(i, root)
+	}
+	wg.Wait()
+
+	files := interleave(perRoot)
+	for _, err := range errs {
+		if err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+// Walk lists the files below root. It stops with ctx's error once ctx is
+// cancelled.
+func (s Scanner) Walk(ctx context.Context, root string) ([]string, error) {
+	var files []string
+	err := s.WalkFunc(ctx, root, func(path string) error {
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
+
+// WalkFunc calls fn with each file below root as it is found, rather than
+// listing them, so a scan of any size holds no more than one path at a
+// time. An error from fn stops the walk and is returned, as is ctx's once
+// ctx is cancelled.
+func (s Scanner) WalkFunc(ctx context.Context, root string, fn func(path string) error) error {
+	rules := make(map[string]*ignoreRules) // folder -> the IgnoreFile rules applying inside it
+	var rootDev uint64                     // with OneFilesystem, once rootKnown
+	var rootKnown bool
+	visited := make(map[fileID]bool) // directories walked, with FollowSymlinks
+	var visit filepath.WalkFunc
+	visit = func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			err = Classify(err)
+			if path == root {
+				return err
+			}
+			if s.OnError != nil {
+				return s.OnError(path, err)
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if s.Progress != nil {
+			s.Progress()
+		}
+
+		leftOut := s.leftOut(path, info)
+		if leftOut == "" && path != root && rules[filepath.Dir(path)].ignored(path, info.IsDir()) {
+			leftOut = SkippedIgnored
+		}
+		if info.IsDir() {
+			// The root is walked even when hidden, as "." or a Windows drive is
+			if leftOut != "" && path != root {
+				s.skip(path, leftOut)
+				return filepath.SkipDir
+			}
+			if !s.Recursive && path != root {
+				return filepath.SkipDir
+			}
+			if s.TooDeep(root, path) {
+				s.skip(path, SkippedTooDeep)
+				return filepath.SkipDir
+			}
+			if s.OneFilesystem {
+				id, ok := identity(path, info)
+				dev := id.dev
+				if path == root {
+					rootDev, rootKnown = dev, ok
+				} else if ok && rootKnown && dev != rootDev {
+					s.skip(path, SkippedOtherFS)
+					return filepath.SkipDir
+				}
+			}
+			if s.SkipDir != nil && s.SkipDir(path) {
+				return filepath.SkipDir
+			}
+			if s.FollowSymlinks {
+				if id, ok := identity(path, info); ok {
+					if visited[id] {
+						s.skip(path, SkippedVisited)
+						return filepath.SkipDir
+					}
+					visited[id] = true
+				}
+			}
+			return s.loadRules(rules, path)
+		}
+
+		if leftOut != "" {
+			s.skip(path, leftOut)
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return s.symlink(root, path, fn, visit)
+		}
+
+		return fn(path)
+	}
+	return filepath.Walk(root, visit)
+}
+
+// symlink handles the symbolic link at path, which is left out unless
+// FollowSymlinks is set or it is the root. A file it points to is passed to
+// fn under path; a directory is walked with visit as if it were at path. A
+// link that points nowhere is handled like an unreadable entry.
+func (s Scanner) symlink(root, path string, fn func(path string) error, visit filepath.WalkFunc) error {
+	if !s.FollowSymlinks && path != root {
+		s.skip(path, SkippedSymlink)
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return visit(path, nil, err)
+	}
+	if !info.IsDir() {
+		return fn(path)
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return visit(path, nil, err)
+	}
+	return filepath.Walk(target, func(p string, info os.FileInfo, err error) error {
+		rel, relErr := filepath.Rel(target, p)
+		if relErr != nil {
+			return relErr
+		}
+		return visit(filepath.Join(path, rel), info, err)
+	})
+}
+
+// loadRules reads dir's IgnoreFile, if any, into rules. A file that cannot
+// be read is handled like any unreadable entry.
+func (s Scanner) loadRules(rules map[string]*ignoreRules, dir string) error {
+	if s.IgnoreFile == "" {
+		return nil
+	}
+	dir = filepath.Clean(dir)
+	r, err := loadIgnoreFile(dir, s.IgnoreFile, rules[filepath.Dir(dir)])
+	rules[dir] = r
+	if err != nil && s.OnError != nil {
+		return s.OnError(filepath.Join(dir, s.IgnoreFile), Classify(err))
+	}
+	return nil
+}
+
+// leftOut returns the Skipped reason a hidden or system entry is left out
+// for, or "" if it is listed
+func (s Scanner) leftOut(path string, info os.FileInfo) string {
+	switch {
+	case !s.IncludeHidden && Hidden(path, info) && info.IsDir():
+		return SkippedHiddenDir
+	case !s.IncludeHidden && Hidden(path, info):
+		return SkippedHiddenFile
+	case !s.IncludeSystem && System(info) && info.IsDir():
+		return SkippedSystemDir
+	case !s.IncludeSystem && System(info):
+		return SkippedSystemFile
+	}
+	return ""
+}
+
+// TooDeep reports whether files inside dir would sit deeper than MaxDepth
+// levels below root
+func (s Scanner) TooDeep(root, dir string) bool {
+	if s.MaxDepth <= 0 {
+		return false
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return false
+	}
+	depth := len(strings.Split(rel, string(filepath.Separator)))
+	return depth >= s.MaxDepth
+}
+
+// OtherFilesystem reports whether OneFilesystem leaves out dir because it
+// is on another filesystem than root. Folders whose filesystem cannot be
+// told are kept.
+func (s Scanner) OtherFilesystem(root, dir string) bool {
+	if !s.OneFilesystem {
+		return false
+	}
+	rootInfo, err1 := os.Stat(root)
+	dirInfo, err2 := os.Lstat(dir)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	rootID, ok1 := identity(root, rootInfo)
+	id, ok2 := identity(dir, dirInfo)
+	return ok1 && ok2 && id.dev != rootID.dev
+}
+
+// fileID identifies a file or directory whatever path reaches it
+type fileID struct {
+	dev, ino uint64
+}
+
+// skip reports a left-out entry to Skipped
+func (s Scanner) skip(path, reason string) {
+	if s.Skipped != nil {
+		s.Skipped(path, reason)
+	}
+}
+
+// interleave merges per-root file lists round-robin, dropping files already
+// listed through another root
+func interleave(perRoot [][]string) []string {
+	if len(perRoot) == 1 {
+		return perRoot[0]
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for i := 0; ; i++ {
+		added := false
+		for _, list := range perRoot {
+			if i >= len(list) {
+				continue
+			}
+			added = true
+			key := list[i]
+			if abs, err := filepath.Abs(key); err == nil {
+				key = abs
+			}
+			if !seen[key] {
+				seen[key] = true
+				files = append(files, list[i])
+			}
+		}
+		if !added {
+			return files
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package dedup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+// writeFiles creates the given files, and their directories, below dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// names returns the paths relative to dir, sorted
+func names(t *testing.T, dir string, paths []string) []string {
+	t.Helper()
+	var rel []string
+	for _, path := range paths {
+		r, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	sort.Strings(rel)
+	return rel
+}
+
+func TestScannerWalk(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":           "a",
+		".hidden":         "h",
+		"sub/b.txt":       "b",
+		"sub/deep/c.txt":  "c",
+		".git/config":     "g",
+		"skip/ignored.md": "i",
+	})
+	markHidden(t, dir, ".hidden")
+	markHidden(t, dir, ".git")
+
+	var skipped []string
+	s := Scanner{
+		Recursive: true,
+		SkipDir:   func(path string) bool { return filepath.Base(path) == "skip" },
+		Skipped:   func(path, reason string) { skipped = append(skipped, reason) },
+	}
+	files, err := s.Walk(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(t, dir, files); len(got) != 3 || got[0] != "a.txt" || got[1] != "sub/b.txt" || got[2] != "sub/deep/c.txt" {
+		t.Errorf("Walk() = %v", got)
+	}
+	sort.Strings(skipped)
+	if len(skipped) != 2 || skipped[0] != SkippedHiddenDir || skipped[1] != SkippedHiddenFile {
+		t.Errorf("Skipped reasons = %v", skipped)
+	}
+
+	s.IncludeHidden = true
+	if files, _ := s.Walk(context.Background(), dir); len(files) != 5 {
+		t.Errorf("Walk() with IncludeHidden found %d files, want 5", len(files))
+	}
+
+	s = Scanner{Recursive: true, MaxDepth: 2}
+	if files, _ := s.Walk(context.Background(), dir); len(files) != 3 {
+		t.Errorf("Walk() with MaxDepth 2 found %d files, want 3", len(files))
+	}
+	if files, _ := (Scanner{}).Walk(context.Background(), dir); len(names(t, dir, files)) != 1 {
+		t.Errorf("non-recursive Walk() = %v", files)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.Walk(ctx, dir); err != context.Canceled {
+		t.Errorf("Walk() error = %v, want context.Canceled", err)
+	}
+	if _, err := s.Walk(context.Background(), filepath.Join(dir, "missing")); err == nil {
+		t.Error("Walk() of a missing root should fail")
+	}
+}
+
+func TestScannerScan(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/1": "1", "a/2": "2",
+		"b/1": "1", "b/2": "2",
+	})
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+
+	var visited atomic.Int64 // Progress is called from one goroutine per root
+	s := Scanner{Recursive: true, Progress: func() { visited.Add(1) }}
+	files, err := s.Scan(context.Background(), a, b, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Interleaved across roots, the repeated root listed once
+	want := []string{"a/1", "b/1", "a/2", "b/2"}
+	if len(files) != len(want) {
+		t.Fatalf("Scan() = %v, want %v", files, want)
+	}
+	for i, file := range files {
+		if rel, _ := filepath.Rel(dir, file); filepath.ToSlash(rel) != want[i] {
+			t.Errorf("Scan()[%d] = %s, want %s", i, rel, want[i])
+		}
+	}
+	if n := visited.Load(); n != 9 {
+		t.Errorf("Progress called %d times, want 9", n)
+	}
+}
+
+func TestScannerOneFilesystem(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	s := Scanner{Recursive: true, OneFilesystem: true}
+	if files, err := s.Walk(context.Background(), dir); err != nil || len(files) != 2 {
+		t.Errorf("Walk() = %v, %v; want both files on the root's filesystem", files, err)
+	}
+	if s.OtherFilesystem(dir, filepath.Join(dir, "sub")) {
+		t.Error("a folder on the root's filesystem should not count as another")
+	}
+
+	// A separate mount is needed to see one left out
+	root, mount := "/dev", "/dev/shm"
+	rootInfo, err1 := os.Stat(root)
+	mountInfo, err2 := os.Stat(mount)
+	if err1 != nil || err2 != nil || !mountInfo.IsDir() {
+		t.Skip("no /dev/shm mount")
+	}
+	rootID, _ := identity(root, rootInfo)
+	mountID, _ := identity(mount, mountInfo)
+	if rootID.dev == mountID.dev {
+		t.Skip("/dev/shm is not a separate mount here")
+	}
+	if !s.OtherFilesystem(root, mount) {
+		t.Errorf("OtherFilesystem(%s, %s) = false", root, mount)
+	}
+	var skipped []string
+	s.Skipped = func(path, reason string) {
+		if reason == SkippedOtherFS {
+			skipped = append(skipped, path)
+		}
+	}
+	s.Walk(context.Background(), root)
+	found := false
+	for _, path := range skipped {
+		found = found || path == mount
+	}
+	if !found {
+		t.Errorf("Walk(%s) left out %v, want %s among them", root, skipped, mount)
+	}
+}
+
+func TestScannerFollowSymlinks(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	writeFiles(t, dir, map[string]string{"a/one.txt": "1", "a/b/two.txt": "2"})
+	writeFiles(t, outside, map[string]string{"three.txt": "3"})
+	links := map[string]string{
+		"a/b/loop":     filepath.Join(dir, "a"),                // back up the tree
+		"z-again":      filepath.Join(dir, "a"),                // a folder already scanned
+		"shared":       outside,                                // a tree outside the root
+		"one-link.txt": filepath.Join(dir, "a", "one.txt"),     // a file
+		"broken.txt":   filepath.Join(dir, "missing", "x.txt"), // nowhere
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Skipf("symlinks not supported here: %v", err)
+		}
+	}
+	rel := func(files []string) []string {
+		var names []string
+		for _, f := range files {
+			r, _ := filepath.Rel(dir, f)
+			names = append(names, filepath.ToSlash(r))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	skipped := make(map[string]string)
+	s := Scanner{Recursive: true, Skipped: func(path, reason string) { skipped[rel([]string{path})[0]] = reason }}
+	files, err := s.Walk(context.Background(), dir)
+	if got := rel(files); err != nil || len(got) != 2 || got[0] != "a/b/two.txt" || got[1] != "a/one.txt" {
+		t.Errorf("Walk() = %v, %v; want only the real files", got, err)
+	}
+	if skipped["shared"] != SkippedSymlink || skipped["one-link.txt"] != SkippedSymlink {
+		t.Errorf("skipped = %v, want the links left out", skipped)
+	}
+
+	skipped = make(map[string]string)
+	var failed []string
+	s.FollowSymlinks = true
+	s.OnError = func(path string, err error) error {
+		failed = append(failed, filepath.Base(path))
+		return nil
+	}
+	files, err = s.Walk(context.Background(), dir)
+	want := []string{"a/b/two.txt", "a/one.txt", "one-link.txt", "shared/three.txt"}
+	if got := rel(files); err != nil || len(got) != len(want) {
+		t.Errorf("Walk() following links = %v, %v; want %v", got, err, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Walk() following links = %v, want %v", got, want)
+				break
+			}
+		}
+	}
+	if skipped["a/b/loop"] != SkippedVisited || skipped["z-again"] != SkippedVisited {
+		t.Errorf("skipped = %v, want the loop and the second way into a left out", skipped)
+	}
+	if len(failed) != 1 || failed[0] != "broken.txt" {
+		t.Errorf("OnError got %v, want the broken link", failed)
+	}
+
+	// A root that is a link is walked either way
+	s.FollowSymlinks = false
+	if files, err := s.Walk(context.Background(), filepath.Join(dir, "shared")); err != nil || len(files) != 1 {
+		t.Errorf("Walk(link) = %v, %v; want three.txt", files, err)
+	}
+}
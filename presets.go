@@ -0,0 +1,32 @@
+package main
+
+// Tuning of the -screenshots preset
+const (
+	screenshotExtensions = "png,jpg,jpeg,webp"
+	screenshotSimilarity = 4 // UI screenshots share layout, so only near-identical ones match
+	screenshotKeep       = "newest"
+)
+
+// applyScreenshotPreset tunes c for a screenshot folder: PNG-heavy
+// extensions, perceptual matching with a strict threshold and equal
+// dimensions, and keeping the newest copy. Anything set on the command line
+// (as reported by set) or changed from its default in a config file is left
+// alone.
+func applyScreenshotPreset(c *Config, set func(name string) bool) {
+	d := DefaultConfig()
+	if !set("ext") && c.Extensions == d.Extensions {
+		c.Extensions = screenshotExtensions
+	}
+	if !set("perceptual") {
+		c.PerceptualMode = true
+	}
+	if !set("similarity") && c.SimilarityThreshold == d.SimilarityThreshold && c.SimilarityPercent == 0 {
+		c.SimilarityThreshold = screenshotSimilarity
+	}
+	if !set("same-dimensions") {
+		c.SameDimensions = true
+	}
+	if !set("keep") && c.KeepCriteria == d.KeepCriteria {
+		c.KeepCriteria = screenshotKeep
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestApplyScreenshotPreset(t *testing.T) {
+	none := func(string) bool { return false }
+	c := DefaultConfig()
+	applyScreenshotPreset(&c, none)
+	if c.Extensions != screenshotExtensions || !c.PerceptualMode || c.SimilarityThreshold != screenshotSimilarity || !c.SameDimensions || c.KeepCriteria != screenshotKeep {
+		t.Errorf("preset gave ext=%q perceptual=%v similarity=%d same-dimensions=%v keep=%q",
+			c.Extensions, c.PerceptualMode, c.SimilarityThreshold, c.SameDimensions, c.KeepCriteria)
+	}
+
+	// Explicit flags and config file values win over the preset
+	c = DefaultConfig()
+	c.KeepCriteria = "largest" // from a config file
+	c.SameDimensions = false
+	applyScreenshotPreset(&c, func(name string) bool { return name == "same-dimensions" })
+	if c.KeepCriteria != "largest" || c.SameDimensions {
+		t.Errorf("preset overrode keep=%q same-dimensions=%v", c.KeepCriteria, c.SameDimensions)
+	}
+}
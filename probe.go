@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// probeExitDuplicate is the exit status of a -probe run that found a
+// duplicate. 1 is a failed run and 2 a bad command line, as usual for Go
+// programs, so scripts can tell all three apart from 0 (no duplicates).
+const probeExitDuplicate = 3
+
+// probeMatch is the duplicate a -probe run stopped at
+type probeMatch struct {
+	Path        string `json:"path"`
+	DuplicateOf string `json:"duplicate_of"`
+	Size        int64  `json:"size"`
+	Hash        string `json:"hash"`
+}
+
+// probeKey identifies content: files are only duplicates at the same size
+type probeKey struct {
+	size int64
+	hash string
+}
+
+// probeSeen is a piece of content hashed or imported so far
+type probeSeen struct {
+	path  string
+	local bool // some copy may be changed by this run, not only references
+}
+
+// runProbe hashes like a normal exact scan but stops at the first confirmed
+// duplicate, which it returns; it returns nil when the scan finishes without
+// one. With -import-index or -reference-readonly only a local copy of a
+// reference, or two local copies, count: copies among the references alone
+// are not this scan's business. Nothing is reported, exported or changed.
+func (e *Engine) runProbe(ctx context.Context) (*probeMatch, error) {
+	if e.cfg.PerceptualMode || e.cfg.NoHash || len(e.cfg.Remotes) > 0 {
+		return nil, errors.New("-probe needs exact matching of local files, without -perceptual, -no-hash or -remote")
+	}
+	// A probe stops on purpose: nothing to resume, and nothing worth sorting on disk
+	e.cfg.Checkpoint = ""
+	e.cfg.SpillDir = ""
+
+	var err error
+	if e.ignore, err = loadIgnoreList(e.cfg.ignoreListPath()); err != nil {
+		return nil, err
+	}
+	references, err := e.loadIndexes()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[probeKey]probeSeen)
+	for _, fh := range references {
+		seen[probeKey{fh.Size, fh.Hash}] = probeSeen{path: fh.Path}
+	}
+
+	probeCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	var match *probeMatch
+	events := e.events
+	e.events = &Events{
+		OnFileHashed: func(fh FileHash) {
+			events.fileHashed(fh)
+			if match != nil {
+				return
+			}
+			key := probeKey{fh.Size, fh.Hash}
+			local := !e.cfg.underReadOnly(fh.Path)
+			prev, ok := seen[key]
+			if ok && (local || prev.local) && !e.ignore.ignored(DuplicateGroup{Hash: fh.Hash, Similarity: 100.0}) {
+				match = &probeMatch{Path: fh.Path, DuplicateOf: prev.path, Size: fh.Size, Hash: fh.Hash}
+				stop()
+				return
+			}
+			seen[key] = probeSeen{path: fh.Path, local: local || prev.local}
+		},
+	}
+	defer func() { e.events = events }()
+
+	// Files no other scanned file resembles can only match an imported index
+	_, err = e.collectHashes(probeCtx, len(references) == 0)
+	if match != nil {
+		return match, nil
+	}
+	return nil, err
+}
+
+// printProbe reports the outcome of runProbe, as one JSON object on stdout
+// with -json
+func printProbe(match *probeMatch, jsonOut bool) error {
+	if jsonOut {
+		result := struct {
+			Duplicate bool `json:"duplicate"`
+			*probeMatch
+		}{match != nil, match}
+		return json.NewEncoder(os.Stdout).Encode(result)
+	}
+	if match == nil {
+		log.Printf("%sNo duplicates", emoji("✅"))
+		return nil
+	}
+	fmt.Printf("%s is a duplicate of %s\n", match.Path, match.DuplicateOf)
+	return nil
+}
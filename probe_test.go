@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunProbe(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "unique one", "b.txt": "unique two", "c.txt": "unique three"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.JSON = true
+	c.IgnoreList = "off"
+	c.Checkpoint = filepath.Join(t.TempDir(), "checkpoint.jsonl")
+
+	match, err := NewEngine(c, nil).runProbe(context.Background())
+	if err != nil || match != nil {
+		t.Fatalf("runProbe() on unique files = %+v, %v; want no match", match, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "d.txt"), []byte("unique two"), 0644)
+	match, err = NewEngine(c, nil).runProbe(context.Background())
+	if err != nil || match == nil {
+		t.Fatalf("runProbe() = %+v, %v; want a match", match, err)
+	}
+	pair := map[string]bool{filepath.Base(match.Path): true, filepath.Base(match.DuplicateOf): true}
+	if !pair["b.txt"] || !pair["d.txt"] {
+		t.Errorf("match = %+v, want b.txt and d.txt", match)
+	}
+	if _, err := os.Stat(c.Checkpoint); !os.IsNotExist(err) {
+		t.Error("a probe that stopped at a duplicate should not leave a checkpoint")
+	}
+
+	c.PerceptualMode = true
+	if _, err := NewEngine(c, nil).runProbe(context.Background()); err == nil {
+		t.Error("-probe with -perceptual should fail")
+	}
+}
+
+func TestRunProbeReferences(t *testing.T) {
+	archive, current := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(archive, "a.txt"), []byte("archive dup"), 0644)
+	os.WriteFile(filepath.Join(archive, "b.txt"), []byte("archive dup"), 0644)
+	os.WriteFile(filepath.Join(archive, "old.txt"), []byte("archived content"), 0644)
+	os.WriteFile(filepath.Join(current, "new.txt"), []byte("brand new"), 0644)
+
+	c := DefaultConfig()
+	c.Dir = stringList{current}
+	c.ReferenceReadOnly = stringList{archive}
+	c.MinSize = 1
+	c.JSON = true
+	c.IgnoreList = "off"
+
+	// Copies within the reference set do not count
+	match, err := NewEngine(c, nil).runProbe(context.Background())
+	if err != nil || match != nil {
+		t.Fatalf("runProbe() = %+v, %v; want no match", match, err)
+	}
+
+	os.WriteFile(filepath.Join(current, "copy.txt"), []byte("archived content"), 0644)
+	match, err = NewEngine(c, nil).runProbe(context.Background())
+	if err != nil || match == nil {
+		t.Fatalf("runProbe() = %+v, %v; want the local copy of old.txt", match, err)
+	}
+	pair := map[string]bool{filepath.Base(match.Path): true, filepath.Base(match.DuplicateOf): true}
+	if !pair["copy.txt"] || !pair["old.txt"] {
+		t.Errorf("match = %+v, want copy.txt and old.txt", match)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
+)
+
+// errStagedChanged marks a staged file that is no longer the one its
+// quarantine log record describes
+var errStagedChanged = errors.New("changed since it was staged")
+
+// stagedActions are the actions that leave a file's bytes on disk: moved,
+// trashed and quarantined files take up their space until purged or emptied
+var stagedActions = map[string]bool{"moved": true, "trashed": true, "quarantined": true}
+
+// logTotal logs what processing duplicates achieved. Staged files still take
+// up their space, so they are reported as staged, not freed.
+func (c Config) logTotal(count int, size int64) {
+	_, done := c.actionVerbs()
+	switch strings.ToLower(done) {
+	case "moved":
+		log.Printf("\n✅ %s %d files, %s of space staged for recovery in %s (not freed until purged with -purge-staged)", done, count, formatBytes(size), c.MoveTo)
+	case "quarantined":
+		log.Printf("\n✅ %s %d files, %s of space staged for recovery in %s (not freed until purged with -purge-staged)", done, count, formatBytes(size), c.quarantineDir())
+	case "trashed":
+		log.Printf("\n✅ %s %d files, %s of space staged in the trash (not freed until the trash is emptied)", done, count, formatBytes(size))
+	default:
+		log.Printf("\n✅ %s %d files, freed %s of space", done, count, formatBytes(size))
+	}
+}
+
+// stagingDirs are the folders -purge-staged cleans: -dir's quarantine (or
+// -quarantine) and -move-to, when given
+func (c Config) stagingDirs() []string {
+	dirs := []string{c.Quarantine}
+	if c.Quarantine == "" {
+		dirs[0] = filepath.Join(c.roots()[0], quarantineDirName)
+	}
+	if c.MoveTo != "" {
+		dirs = append(dirs, c.MoveTo)
+	}
+	return dirs
+}
+
+// runPurge deletes the files staged in the quarantine and -move-to more
+// than -purge-staged days ago. Only files in a quarantine log are touched;
+// anything else in those folders is left alone.
+func runPurge(c Config) error {
+	cutoff := time.Now().AddDate(0, 0, -c.PurgeStaged)
+	var count int
+	var size int64
+	var errs []string
+	for _, dir := range c.stagingDirs() {
+		purged, err := c.purgeStaged(dir, cutoff)
+		for _, record := range purged {
+			if c.DryRun {
+				log.Printf("%sDry run: would purge %s (staged %s, %s)", emoji("🧪"), record.Quarantined, record.Time.Format("2006-01-02"), formatBytes(record.Size))
+			} else if c.Verbose {
+				log.Printf("✓ Purged %s", record.Quarantined)
+			}
+			count++
+			size += record.Size
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if c.DryRun {
+		log.Printf("\n%sDry run: %d files staged more than %d days ago would be purged, freeing %s", emoji("🧪"), count, c.PurgeStaged, formatBytes(size))
+	} else {
+		log.Printf("\n✅ Purged %d files staged more than %d days ago, freed %s of space", count, c.PurgeStaged, formatBytes(size))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// purgeStaged deletes the files dir's quarantine log records as staged
+// before cutoff and drops them from the log, along with records of files
+// already gone. Files that changed since they were staged are kept and
+// reported. Folders left empty in dir are removed. With -dry-run it only
+// returns the records that would be purged.
+func (c Config) purgeStaged(dir string, cutoff time.Time) ([]quarantineRecord, error) {
+	records, err := readQuarantineLog(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var purged, kept []quarantineRecord
+	var errs []string
+	for _, record := range records {
+		if !record.Time.Before(cutoff) {
+			kept = append(kept, record)
+			continue
+		}
+		if err := checkStaged(record); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, formatFileError(record.Quarantined, err))
+			kept = append(kept, record)
+			continue
+		}
+		if c.DryRun {
+			purged = append(purged, record)
+			continue
+		}
+		err := c.journaled("purge", record.Quarantined, "", func() error {
+			if err := c.checkWritable(record.Quarantined); err != nil {
+				return err
+			}
+			return os.Remove(record.Quarantined)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, formatFileError(record.Quarantined, err))
+			kept = append(kept, record)
+			continue
+		}
+		purged = append(purged, record)
+		removeEmptyParents(filepath.Dir(record.Quarantined), dir)
+	}
+
+	if len(purged) > 0 && !c.DryRun {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, record := range kept {
+			enc.Encode(record)
+		}
+		if err := writeFileAtomic(filepath.Join(dir, quarantineLogName), buf.Bytes(), 0600); err != nil {
+			return purged, fmt.Errorf("purged %d files but could not update the quarantine log: %w", len(purged), err)
+		}
+	}
+	if len(errs) > 0 {
+		return purged, fmt.Errorf("could not purge %d files: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return purged, nil
+}
+
+// checkStaged makes sure the file record points to is still the one that
+// was staged: the same size and, when the record has a hash, the same
+// content. The record does not name its hash algorithm, so each algorithm
+// whose hashes are as long as the recorded one is tried.
+func checkStaged(record quarantineRecord) error {
+	info, err := os.Lstat(record.Quarantined)
+	if err != nil {
+		return err
+	}
+	if info.Size() != record.Size {
+		return fmt.Errorf("%w: size differs", errStagedChanged)
+	}
+	if record.Hash == "" {
+		return nil // staged by -quarantine-deletes, which records no hash
+	}
+	svg := strings.HasPrefix(record.Hash, "svg:")
+	for _, algorithm := range dedup.Algorithms {
+		hasher := getHasher(algorithm)
+		if 2*hasher.Size() != len(strings.TrimPrefix(record.Hash, "svg:")) {
+			continue
+		}
+		var hash string
+		if svg {
+			hash, err = normalizedSVGHash(record.Quarantined, hasher)
+		} else {
+			hash, _, _, err = hashFile(record.Quarantined, hasher)
+		}
+		if err != nil {
+			return err
+		}
+		if hash == record.Hash {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: content differs", errStagedChanged)
+}
+
+// removeEmptyParents removes dir and its parents while they are empty,
+// stopping at root, which stays
+func removeEmptyParents(dir, root string) {
+	root, _ = filepath.Abs(root)
+	for {
+		abs, err := filepath.Abs(dir)
+		if err != nil || abs == root || !strings.HasPrefix(abs, root+string(filepath.Separator)) {
+			return
+		}
+		if os.Remove(abs) != nil {
+			return
+		}
+		dir = filepath.Dir(abs)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPurgeStaged(t *testing.T) {
+	dir := t.TempDir()
+	stage := func(name string, age time.Duration) string {
+		path := filepath.Join(dir, "batch", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		record := quarantineRecord{Original: "/orig/" + name, Quarantined: path, Size: int64(len(name)), Time: time.Now().Add(-age)}
+		if err := appendQuarantineLog(dir, record); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	old := stage("old.txt", 40*24*time.Hour)
+	recent := stage("recent.txt", time.Hour)
+	unlogged := filepath.Join(dir, "unlogged.txt")
+	os.WriteFile(unlogged, []byte("x"), 0644)
+	cutoff := time.Now().AddDate(0, 0, -30)
+
+	c := DefaultConfig()
+	c.Journal = ""
+	c.DryRun = true
+
+	// A dry run lists but keeps
+	purged, err := c.purgeStaged(dir, cutoff)
+	if err != nil || len(purged) != 1 || purged[0].Quarantined != old {
+		t.Fatalf("dry run purged %+v, %v", purged, err)
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Fatal("dry run deleted a file")
+	}
+
+	c.DryRun = false
+	purged, err = c.purgeStaged(dir, cutoff)
+	if err != nil || len(purged) != 1 || purged[0].Quarantined != old {
+		t.Fatalf("purged %+v, %v", purged, err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("old staged file was not deleted")
+	}
+	for _, path := range []string{recent, unlogged} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should have been kept: %v", path, err)
+		}
+	}
+	records, err := readQuarantineLog(dir)
+	if err != nil || len(records) != 1 || records[0].Quarantined != recent {
+		t.Errorf("log after purge = %+v, %v", records, err)
+	}
+}
+
+func TestPurgeStagedChanged(t *testing.T) {
+	dir := t.TempDir()
+	stage := func(name, content, hash string) string {
+		path := filepath.Join(dir, "batch", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if hash == "" {
+			hash, _, _, _ = hashFile(path, getHasher("xxh3"))
+		}
+		record := quarantineRecord{Original: "/orig/" + name, Quarantined: path, Size: 7, Hash: hash, Time: time.Now().AddDate(0, 0, -40)}
+		if err := appendQuarantineLog(dir, record); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	intact := stage("intact.txt", "content", "")
+	swapped := stage("swapped.txt", "CONTENT", "")
+	os.WriteFile(swapped, []byte("changed"), 0644) // same size, other bytes
+	grown := stage("grown.txt", "content and more", "")
+
+	c := DefaultConfig()
+	c.Journal = ""
+	purged, err := c.purgeStaged(dir, time.Now().AddDate(0, 0, -30))
+	if err == nil || !strings.Contains(err.Error(), "2 files") {
+		t.Errorf("purgeStaged() error = %v, want the 2 changed files reported", err)
+	}
+	if len(purged) != 1 || purged[0].Quarantined != intact {
+		t.Errorf("purged %+v, want only intact.txt", purged)
+	}
+	for _, path := range []string{swapped, grown} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s changed since it was staged and should have been kept", filepath.Base(path))
+		}
+	}
+	if records, _ := readQuarantineLog(dir); len(records) != 2 {
+		t.Errorf("log after purge = %+v, want the 2 kept records", records)
+	}
+}
+
+func TestLogTotal(t *testing.T) {
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+
+	for name, want := range map[string]string{
+		"delete":     "Deleted 1 files, freed 4.9 KB of space",
+		"trash":      "Trashed 1 files, 4.9 KB of space staged in the trash (not freed",
+		"quarantine": "Quarantined 1 files, 4.9 KB of space staged for recovery in " + filepath.Join("root", quarantineDirName) + " (not freed",
+		"move":       "Moved 1 files, 4.9 KB of space staged for recovery in moved (not freed",
+	} {
+		c := DefaultConfig()
+		c.Dir = stringList{"root"}
+		switch name {
+		case "trash":
+			c.Trash = true
+		case "quarantine":
+			c.QuarantineDeletes = true
+		case "move":
+			c.MoveTo = "moved"
+		}
+		logs.Reset()
+		c.logTotal(1, 5000)
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("%s: logged %q, want %q", name, logs.String(), want)
+		}
+	}
+}
+
+func TestLogMove(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "copy.txt")
+	if err := logMove(dir, FileHash{Path: "copy.txt", Size: 3, Hash: "abc"}, target); err != nil {
+		t.Fatal(err)
+	}
+	records, err := readQuarantineLog(dir)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("log = %+v, %v", records, err)
+	}
+	if !filepath.IsAbs(records[0].Original) || records[0].Quarantined != target || records[0].Size != 3 {
+		t.Errorf("record = %+v", records[0])
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	quarantineDirName = ".deduplicator_quarantine" // default, inside the scanned root
+	quarantineLogName = "quarantine.jsonl"
+)
+
+// quarantineRecord is one line of the quarantine log
+type quarantineRecord struct {
+	Original    string    `json:"original"`
+	Quarantined string    `json:"quarantined"`
+	Size        int64     `json:"size"`
+	Hash        string    `json:"hash,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// quarantine moves files aside instead of deleting them. Each run gets a
+// timestamped batch folder that mirrors the files' paths relative to root,
+// and every move is appended to the quarantine log so it can be undone.
+type quarantine struct {
+	mu    sync.Mutex
+	dir   string
+	root  string
+	batch string
+}
+
+// newQuarantine creates a quarantine in dir (root/.deduplicator_quarantine
+// when empty) for files under root
+func newQuarantine(dir, root string) *quarantine {
+	if dir == "" {
+		dir = filepath.Join(root, quarantineDirName)
+	}
+	return &quarantine{dir: dir, root: root, batch: time.Now().Format("20060102-150405")}
+}
+
+// target is where fh goes in this run's batch, before any name clash is resolved
+func (q *quarantine) target(fh FileHash) string {
+	rel, err := filepath.Rel(q.root, fh.Path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = filepath.Base(fh.Path)
+	}
+	return filepath.Join(q.dir, q.batch, rel)
+}
+
+// add moves fh into the quarantine and returns its new path
+func (q *quarantine) add(fh FileHash) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	target := q.target(fh)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create quarantine: %w", err)
+	}
+	target = uniqueTargetPath(filepath.Dir(target), target)
+
+	if err := renameFile(fh.Path, target); err != nil {
+		return "", err
+	}
+
+	record := quarantineRecord{Original: fh.Path, Quarantined: target, Size: fh.Size, Hash: fh.Hash, Time: time.Now()}
+	if err := appendQuarantineLog(q.dir, record); err != nil {
+		return target, fmt.Errorf("quarantined to %s but could not log it: %w", target, err)
+	}
+	return target, nil
+}
+
+// logMove records a duplicate moved to target in dir's quarantine log, so
+// -move-to folders can be restored from and purged like the quarantine
+func logMove(dir string, fh FileHash, target string) error {
+	original, err := filepath.Abs(fh.Path)
+	if err != nil {
+		return err
+	}
+	if target, err = filepath.Abs(target); err != nil {
+		return err
+	}
+	record := quarantineRecord{Original: original, Quarantined: target, Size: fh.Size, Hash: fh.Hash, Time: time.Now()}
+	if err := appendQuarantineLog(dir, record); err != nil {
+		return fmt.Errorf("moved %s but could not log it: %w", fh.Path, err)
+	}
+	return nil
+}
+
+// appendQuarantineLog adds record to dir's quarantine log
+func appendQuarantineLog(dir string, record quarantineRecord) error {
+	f, err := os.OpenFile(filepath.Join(dir, quarantineLogName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(record)
+}
+
+// readQuarantineLog returns every record in dir's quarantine log, oldest first
+func readQuarantineLog(dir string) ([]quarantineRecord, error) {
+	f, err := os.Open(filepath.Join(dir, quarantineLogName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []quarantineRecord
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var record quarantineRecord
+		if err := dec.Decode(&record); err != nil {
+			return nil, fmt.Errorf("invalid quarantine log: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// restoreQuarantined moves the quarantined files back to where they came
+// from and drops them from dir's quarantine log. A file whose original path
+// is taken again is left in the quarantine. It returns the records restored.
+func restoreQuarantined(dir string, quarantined []string) ([]quarantineRecord, error) {
+	records, err := readQuarantineLog(dir)
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool)
+	for _, path := range quarantined {
+		want[path] = true
+	}
+
+	var restored, kept []quarantineRecord
+	var errs []string
+	for _, record := range records {
+		if !want[record.Quarantined] {
+			kept = append(kept, record)
+			continue
+		}
+		err := func() error {
+			if _, err := os.Lstat(record.Original); err == nil {
+				return fmt.Errorf("%s already exists", record.Original)
+			}
+			if err := os.MkdirAll(filepath.Dir(record.Original), 0755); err != nil {
+				return err
+			}
+			return renameFile(record.Quarantined, record.Original)
+		}()
+		if err != nil {
+			errs = append(errs, err.Error())
+			kept = append(kept, record)
+			continue
+		}
+		restored = append(restored, record)
+	}
+
+	if len(restored) > 0 {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, record := range kept {
+			enc.Encode(record)
+		}
+		if err := writeFileAtomic(filepath.Join(dir, quarantineLogName), buf.Bytes(), 0600); err != nil {
+			return restored, fmt.Errorf("restored %d files but could not update the quarantine log: %w", len(restored), err)
+		}
+	}
+	if len(errs) > 0 {
+		return restored, fmt.Errorf("could not restore %d files: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return restored, nil
+}
+
+// quarantineDir is where this run quarantines files: -quarantine, or the
+// quarantine folder inside the first scanned root
+func (c Config) quarantineDir() string {
+	if c.Quarantine != "" {
+		return c.Quarantine
+	}
+	return filepath.Join(c.roots()[0], quarantineDirName)
+}
+
+// deleteQuarantine returns this run's quarantine for deleted duplicates:
+// the batch NewEngine started, which every copy of its Config shares. A
+// Config that did not come from an engine gets a new batch each time.
+func (c Config) deleteQuarantine() *quarantine {
+	if c.deletes == nil {
+		return newQuarantine(c.quarantineDir(), absPath(c.roots()[0]))
+	}
+	return c.deletes
+}
+
+// quarantineFile is removeFile with -quarantine-deletes: it moves path into
+// this run's quarantine batch, where -undo can move it back from
+func (c Config) quarantineFile(path string) error {
+	q := c.deleteQuarantine()
+	return c.journaled("move", path, q.dir, func() error {
+		if err := c.checkWritable(path); err != nil {
+			return err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Lstat(abs)
+		if err != nil {
+			return err
+		}
+		target, err := q.add(FileHash{Path: abs, Size: info.Size()})
+		if target == "" {
+			return lockedError(err)
+		}
+		return err
+	})
+}
+
+// restoreBatch moves every file of one quarantine batch, such as
+// 20240301-120000, back to where it came from. It returns the records
+// restored, and an error naming the batches there are if id is none of them.
+func restoreBatch(dir, id string) ([]quarantineRecord, error) {
+	records, err := readQuarantineLog(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Join(dir, id) + string(filepath.Separator)
+	var paths []string
+	batches := make(map[string]bool)
+	for _, record := range records {
+		if strings.HasPrefix(record.Quarantined, prefix) {
+			paths = append(paths, record.Quarantined)
+		}
+		if rel, err := filepath.Rel(dir, record.Quarantined); err == nil {
+			batches[strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]] = true
+		}
+	}
+	if len(paths) == 0 {
+		var ids []string
+		for batch := range batches {
+			ids = append(ids, batch)
+		}
+		sort.Strings(ids)
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("nothing to restore: %s has no quarantined files", dir)
+		}
+		return nil, fmt.Errorf("no quarantined files in batch %q; batches in %s: %s", id, dir, strings.Join(ids, ", "))
+	}
+	return restoreQuarantined(dir, paths)
+}
+
+// undoEntry is what the undo log records for a removed duplicate; files that
+// went to the quarantine name their batch, which -undo restores
+func (c Config) undoEntry(fh FileHash) UndoEntry {
+	entry := UndoEntry{Path: fh.Path, Size: fh.Size, ModTime: fh.ModTime, Action: "deleted", Timestamp: time.Now()}
+	switch {
+	case c.Trash:
+		entry.Action = "trashed"
+	case c.QuarantineDeletes:
+		q := c.deleteQuarantine()
+		entry.Action = "quarantined"
+		entry.Quarantine, entry.Batch = q.dir, q.batch
+	}
+	return entry
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuarantineAdd(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "sub", "copy.txt")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	q := newQuarantine("", root)
+	target, err := q.add(FileHash{Path: path, Size: 7, Hash: "abc"})
+	if err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	want := filepath.Join(root, quarantineDirName, q.batch, "sub", "copy.txt")
+	if target != want {
+		t.Errorf("add() = %s, want %s", target, want)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("original should have been moved")
+	}
+
+	f, err := os.Open(filepath.Join(q.dir, quarantineLogName))
+	if err != nil {
+		t.Fatalf("quarantine log missing: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	var record quarantineRecord
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &record) != nil || record.Original != path || record.Quarantined != target {
+		t.Errorf("quarantine log record = %+v", record)
+	}
+}
+
+func TestWatchAutoClean(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) FileHash {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		info, _ := os.Stat(path)
+		return FileHash{Path: path, Size: info.Size(), Hash: "h", ModTime: info.ModTime()}
+	}
+	original := write("original.txt", "content")
+	copied := write("copy.txt", "content")
+	growing := write("growing.txt", "content")
+
+	state := &WatchModeState{
+		hashMap:    map[string][]FileHash{"h": {original, copied, growing}},
+		pHashMap:   make(map[string][]FileHash),
+		watchedDir: root,
+		quarantine: newQuarantine("", root),
+		pending:    make(map[string]*time.Timer),
+	}
+
+	// Still being written when the grace period ends: kept
+	if err := os.WriteFile(growing.Path, []byte("content and more"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state.autoClean(growing, []FileHash{original})
+	if _, err := os.Stat(growing.Path); err != nil {
+		t.Error("a file that changed during the grace period must be kept")
+	}
+
+	// The only other copy disappeared: kept
+	state.autoClean(copied, []FileHash{{Path: filepath.Join(root, "gone.txt")}})
+	if _, err := os.Stat(copied.Path); err != nil {
+		t.Error("a file whose original is gone must be kept")
+	}
+
+	state.autoClean(copied, []FileHash{original})
+	if _, err := os.Stat(copied.Path); !os.IsNotExist(err) {
+		t.Error("an unchanged duplicate should have been quarantined")
+	}
+	for _, fh := range state.hashMap["h"] {
+		if fh.Path == copied.Path {
+			t.Error("quarantined file should no longer be tracked")
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, quarantineDirName, state.quarantine.batch))
+	if len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), "copy") {
+		t.Errorf("quarantine batch = %v, want copy.txt", entries)
+	}
+}
+
+func TestRestoreQuarantined(t *testing.T) {
+	root := t.TempDir()
+	q := newQuarantine("", root)
+	var targets []string
+	for _, name := range []string{"a.txt", filepath.Join("sub", "b.txt"), "c.txt"} {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		target, err := q.add(FileHash{Path: path, Size: int64(len(name))})
+		if err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+		targets = append(targets, target)
+	}
+	os.RemoveAll(filepath.Join(root, "sub"))
+
+	// c.txt's original path has been taken by a new file
+	taken := filepath.Join(root, "c.txt")
+	os.WriteFile(taken, []byte("new"), 0644)
+
+	restored, err := restoreQuarantined(q.dir, targets[1:])
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error for the taken path, got %v", err)
+	}
+	if len(restored) != 1 || restored[0].Original != filepath.Join(root, "sub", "b.txt") {
+		t.Fatalf("restored = %+v", restored)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "sub", "b.txt")); string(data) != filepath.Join("sub", "b.txt") {
+		t.Error("sub/b.txt was not restored")
+	}
+	if data, _ := os.ReadFile(taken); string(data) != "new" {
+		t.Error("existing file was overwritten")
+	}
+
+	records, err := readQuarantineLog(q.dir)
+	if err != nil {
+		t.Fatalf("readQuarantineLog() error = %v", err)
+	}
+	if len(records) != 2 || records[0].Quarantined != targets[0] || records[1].Quarantined != targets[2] {
+		t.Errorf("log after restore = %+v", records)
+	}
+}
+
+func TestRestoreHistory(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, quarantineDirName)
+	older := &quarantine{dir: dir, root: root, batch: "20240101-120000"}
+	newer := &quarantine{dir: dir, root: root, batch: "20240301-120000"}
+	for i, q := range []*quarantine{older, newer} {
+		path := filepath.Join(root, []string{"old.txt", "new.txt"}[i])
+		os.WriteFile(path, []byte("x"), 0644)
+		if _, err := q.add(FileHash{Path: path, Size: 1}); err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+	}
+
+	undoPath := filepath.Join(root, "undo.json")
+	deleted := time.Date(2024, 2, 1, 12, 0, 0, 0, time.Local)
+	undo, _ := json.Marshal(map[string]interface{}{"entries": 1, "files": []UndoEntry{{Path: "/gone.txt", Size: 5, Action: "deleted", Timestamp: deleted}}})
+	os.WriteFile(undoPath, undo, 0600)
+
+	items, err := restoreHistory(dir, undoPath)
+	if err != nil {
+		t.Fatalf("restoreHistory() error = %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %+v", items)
+	}
+	if items[0].Path != filepath.Join(root, "new.txt") || items[0].Operation != "Quarantined 2024-03-01 12:00:00" || !items[0].Restorable() {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+	if items[1].Path != "/gone.txt" || items[1].Restorable() {
+		t.Errorf("items[1] = %+v, want the unrestorable deletion", items[1])
+	}
+	if items[2].Path != filepath.Join(root, "old.txt") {
+		t.Errorf("items[2] = %+v", items[2])
+	}
+}
+
+func TestQuarantineDeletes(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "sub", "copy.txt")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("content"), 0644)
+
+	c := DefaultConfig()
+	c.Dir = stringList{root}
+	c.QuarantineDeletes = true
+	c = NewEngine(c, nil).cfg
+	if err := c.removeFile(path); err != nil {
+		t.Fatal(err)
+	}
+	entry := c.undoEntry(FileHash{Path: path, Size: 7})
+	if entry.Action != "quarantined" || entry.Quarantine != filepath.Join(root, quarantineDirName) || entry.Batch == "" {
+		t.Fatalf("undoEntry() = %+v", entry)
+	}
+	if _, err := os.Stat(filepath.Join(entry.Quarantine, entry.Batch, "sub", "copy.txt")); err != nil {
+		t.Fatalf("the file should keep its relative path in the batch: %v", err)
+	}
+
+	if _, err := restoreBatch(entry.Quarantine, "19990101-000000"); err == nil || !strings.Contains(err.Error(), entry.Batch) {
+		t.Errorf("restoring an unknown batch = %v, want an error listing %s", err, entry.Batch)
+	}
+	restored, err := restoreBatch(entry.Quarantine, entry.Batch)
+	if err != nil || len(restored) != 1 {
+		t.Fatalf("restoreBatch() = %v, %v", restored, err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "content" {
+		t.Errorf("restored file = %q, %v", data, err)
+	}
+}
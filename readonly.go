@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errReferenceReadOnly marks a path on -reference-readonly media, which no
+// delete, move or link may touch
+var errReferenceReadOnly = errors.New("on read-only reference media")
+
+// scanDirs are the directories a scan walks: -dir plus -reference-readonly
+func (c Config) scanDirs() []string {
+	return append(append([]string{}, c.roots()...), c.ReferenceReadOnly...)
+}
+
+// isRoot reports whether dir is one of the directories scanned
+func (c Config) isRoot(dir string) bool {
+	for _, root := range c.scanDirs() {
+		if absPath(root) == absPath(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// underReadOnly reports whether path lies in a -reference-readonly
+// directory. Symlinks are resolved on both sides, so the media is recognised
+// also when it is reached through another -dir or a link.
+func (c Config) underReadOnly(path string) bool {
+	if len(c.ReferenceReadOnly) == 0 {
+		return false
+	}
+	p := resolvedPath(path)
+	for _, root := range c.ReferenceReadOnly {
+		rel, err := filepath.Rel(resolvedPath(root), p)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvedPath is path made absolute with symlinks resolved. A path that
+// does not exist yet, such as a move target, has its directory resolved.
+func resolvedPath(path string) string {
+	abs := absPath(path)
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		return real
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		return filepath.Join(dir, filepath.Base(abs))
+	}
+	return abs
+}
+
+// checkWritable refuses any of paths on -reference-readonly media. Every
+// delete, move and link goes through it, so the media stays untouched even
+// when a reference file ends up selected for removal.
+func (c Config) checkWritable(paths ...string) error {
+	for _, path := range paths {
+		if c.underReadOnly(path) {
+			return fmt.Errorf("%s is %w", path, errReferenceReadOnly)
+		}
+	}
+	return nil
+}
+
+// checkReferenceReadOnly validates -reference-readonly: each entry must be a
+// directory, and nothing the run writes may go there, the default quarantine
+// included. Unlike the safety lint, -force does not override it.
+func checkReferenceReadOnly(c Config) error {
+	for _, dir := range c.ReferenceReadOnly {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("-reference-readonly %s is not a directory", dir)
+		}
+	}
+	targets := map[string]string{"-move-to": c.MoveTo, "-quarantine": c.Quarantine}
+	if c.Quarantine == "" && (c.QuarantineDeletes || c.WatchAutoClean || c.PurgeStaged > 0) {
+		targets["-quarantine"] = c.quarantineDir() // the default one, inside -dir
+	}
+	for flag, path := range targets {
+		if path != "" && c.underReadOnly(path) {
+			return fmt.Errorf("%s %s is on read-only reference media", flag, path)
+		}
+	}
+	return nil
+}
+
+// markReadOnly marks the files of a scan that live on -reference-readonly
+// media as reference copies: kept in preference to other copies and never
+// modified
+func (e *Engine) markReadOnly(fileHashes []FileHash) {
+	for i := range fileHashes {
+		if e.cfg.underReadOnly(fileHashes[i].Path) {
+			fileHashes[i].Reference = true
+		}
+	}
+}
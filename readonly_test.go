@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReferenceReadOnly(t *testing.T) {
+	local, archive := t.TempDir(), t.TempDir()
+	write := func(dir, name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("same content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	copied := write(local, "copy.txt")
+	original := write(archive, "original.txt")
+	archived := write(archive, "archived twice.txt")
+
+	c := DefaultConfig()
+	c.Dir = stringList{local}
+	c.ReferenceReadOnly = stringList{archive}
+	c.MinSize = 1
+	c.JSON = true
+	c.Checkpoint = ""
+	c.KeepCriteria = "newest" // the read-only copy must win regardless
+	c.MoveTo = filepath.Join(t.TempDir(), "moved")
+	if err := checkReferenceReadOnly(c); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEngine(c, nil)
+	duplicates, err := e.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(duplicates) != 1 || len(duplicates[0].Files) != 3 {
+		t.Fatalf("duplicates = %+v, want one group of 3", duplicates)
+	}
+	if err := e.processDuplicates(context.Background(), duplicates); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(copied); !os.IsNotExist(err) {
+		t.Error("the local copy should have been moved")
+	}
+
+	// Even when a run is told to remove every copy, the media is untouched
+	misconfigured := DuplicateGroup{Hash: duplicates[0].Hash, Size: duplicates[0].Size, Similarity: 100}
+	for _, fh := range duplicates[0].Files {
+		if fh.Path != copied {
+			fh.Reference = false
+			misconfigured.Files = append(misconfigured.Files, fh)
+		}
+	}
+	if err := e.processDuplicates(context.Background(), []DuplicateGroup{misconfigured}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{original, archived} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s on read-only media was touched: %v", filepath.Base(path), err)
+		}
+	}
+
+	if err := c.removeFile(original); !errors.Is(err, errReferenceReadOnly) {
+		t.Errorf("removeFile() error = %v, want errReferenceReadOnly", err)
+	}
+	if err := c.linkFile(copied, archived); !errors.Is(err, errReferenceReadOnly) {
+		t.Errorf("linkFile() error = %v, want errReferenceReadOnly", err)
+	}
+	c.MoveTo = filepath.Join(archive, "dups")
+	if err := checkReferenceReadOnly(c); err == nil {
+		t.Error("a -move-to on the read-only media was accepted")
+	}
+}
+
+func TestReferenceReadOnlyStaging(t *testing.T) {
+	archive := t.TempDir()
+	write := func(path string) FileHash {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("same content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, _ := os.Stat(path)
+		return FileHash{Path: path, Size: info.Size(), Hash: "h", ModTime: info.ModTime()}
+	}
+	original := write(filepath.Join(archive, "original.txt"))
+	copied := write(filepath.Join(archive, "copy.txt"))
+
+	// The default quarantine lies inside -dir, here on the media
+	c := DefaultConfig()
+	c.Dir = stringList{archive}
+	c.ReferenceReadOnly = stringList{archive}
+	for name, set := range map[string]func(c *Config){
+		"-watch-auto-clean":   func(c *Config) { c.WatchAutoClean = true },
+		"-quarantine-deletes": func(c *Config) { c.QuarantineDeletes = true },
+		"-purge-staged":       func(c *Config) { c.PurgeStaged = 30 },
+	} {
+		c := c
+		c.Force = true // -force does not override it
+		set(&c)
+		if err := checkReferenceReadOnly(c); err == nil {
+			t.Errorf("%s with the default quarantine on the media was accepted", name)
+		}
+	}
+
+	// Watch auto-clean leaves the media alone even when started anyway
+	c.Journal = ""
+	state := &WatchModeState{
+		hashMap:    map[string][]FileHash{"h": {original, copied}},
+		pHashMap:   make(map[string][]FileHash),
+		watchedDir: archive,
+		quarantine: newQuarantine("", archive),
+		pending:    make(map[string]*time.Timer),
+		cfg:        c,
+	}
+	state.autoClean(copied, []FileHash{original})
+	if _, err := os.Stat(copied.Path); err != nil {
+		t.Errorf("auto-clean moved %s off the read-only media", copied.Path)
+	}
+
+	// So does a purge of a quarantine found there
+	staged := write(filepath.Join(archive, quarantineDirName, "batch", "old.txt"))
+	record := quarantineRecord{Original: original.Path, Quarantined: staged.Path, Size: staged.Size, Time: time.Now().AddDate(0, 0, -40)}
+	if err := appendQuarantineLog(filepath.Join(archive, quarantineDirName), record); err != nil {
+		t.Fatal(err)
+	}
+	c.Journal = ""
+	if _, err := c.purgeStaged(filepath.Join(archive, quarantineDirName), time.Now().AddDate(0, 0, -30)); err == nil {
+		t.Error("purging a quarantine on read-only media should fail")
+	}
+	if _, err := os.Stat(staged.Path); err != nil {
+		t.Errorf("purge deleted %s from the read-only media", staged.Path)
+	}
+}
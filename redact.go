@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// redactKeyName is the file next to config.json holding the -redact key
+const redactKeyName = "redact.key"
+
+// redactor replaces the private parts of paths in exported reports with
+// pseudonyms for -redact: the home directory becomes ~ and every other path
+// component a keyed hash of itself, so the same name always gets the same
+// pseudonym, in one report and in every later one from this machine, while
+// nobody without the key can guess names back from their pseudonyms. Sizes,
+// hashes, extensions and the shape of the tree are left as they are.
+type redactor struct {
+	key  []byte
+	home string
+}
+
+// newRedactor loads this machine's redaction key, creating it on first use.
+// A key that cannot be saved still keeps one report consistent.
+func newRedactor() *redactor {
+	r := &redactor{}
+	if home, err := os.UserHomeDir(); err == nil {
+		r.home = filepath.Clean(home)
+	}
+	keyPath := ""
+	if configPath := configFile(); configPath != "" {
+		keyPath = filepath.Join(filepath.Dir(configPath), redactKeyName)
+		if key, err := os.ReadFile(keyPath); err == nil && len(key) >= 32 {
+			r.key = key
+			return r
+		}
+	}
+
+	r.key = make([]byte, 32)
+	if _, err := rand.Read(r.key); err != nil {
+		log.Fatalf("❌ Cannot create a -redact key: %v", err)
+	}
+	if keyPath != "" {
+		err := os.MkdirAll(filepath.Dir(keyPath), 0755)
+		if err == nil {
+			err = writeFileAtomic(keyPath, r.key, 0600)
+		}
+		if err != nil {
+			log.Printf("%sCould not save the -redact key, so later reports will use other pseudonyms: %v", emoji("⚠️"), err)
+		}
+	}
+	return r
+}
+
+// pseudonym is the stable stand-in for one name
+func (r *redactor) pseudonym(prefix, name string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(name))
+	return prefix + hex.EncodeToString(mac.Sum(nil)[:4])
+}
+
+// name redacts a file name, keeping its extension
+func (r *redactor) name(name string) string {
+	if name == "" {
+		return ""
+	}
+	ext := filepath.Ext(name)
+	return r.pseudonym("f-", strings.TrimSuffix(name, ext)) + ext
+}
+
+// file redacts the path of a file
+func (r *redactor) file(path string) string {
+	return r.path(path, true)
+}
+
+// dir redacts the path of a directory
+func (r *redactor) dir(path string) string {
+	return r.path(path, false)
+}
+
+// path redacts every component of path but a leading home directory, root
+// or volume, and . and ..; the last one is a file name when isFile is set
+func (r *redactor) path(path string, isFile bool) string {
+	if path == "" {
+		return ""
+	}
+	sep := string(filepath.Separator)
+	clean := filepath.Clean(path)
+	prefix, rest := "", clean
+	switch {
+	case r.home != "" && clean == r.home:
+		return "~"
+	case r.home != "" && strings.HasPrefix(clean, r.home+sep):
+		prefix, rest = "~"+sep, clean[len(r.home)+1:]
+	case filepath.IsAbs(clean):
+		volume := filepath.VolumeName(clean)
+		prefix, rest = volume+sep, strings.TrimPrefix(clean[len(volume):], sep)
+	}
+	if rest == "" {
+		return prefix
+	}
+
+	parts := strings.Split(rest, sep)
+	for i, part := range parts {
+		switch {
+		case part == "." || part == "..":
+		case i == len(parts)-1 && isFile:
+			parts[i] = r.name(part)
+		default:
+			parts[i] = r.pseudonym("d-", part)
+		}
+	}
+	return prefix + strings.Join(parts, sep)
+}
+
+// files redacts a list of file paths
+func (r *redactor) files(paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+	redacted := make([]string, len(paths))
+	for i, path := range paths {
+		redacted[i] = r.file(path)
+	}
+	return redacted
+}
+
+// findings returns redacted copies of what a report lists. Remote host
+// names are replaced as well, and photo metadata, which holds GPS
+// positions, is left out.
+func (r *redactor) findings(duplicates []DuplicateGroup, skipped []SkippedFile, integrity []IntegrityIssue, linked [][]string) ([]DuplicateGroup, []SkippedFile, []IntegrityIssue, [][]string) {
+	groups := make([]DuplicateGroup, len(duplicates))
+	for i, group := range duplicates {
+		group.Files = append([]FileHash(nil), group.Files...)
+		for j := range group.Files {
+			fh := &group.Files[j]
+			fh.Path = r.file(fh.Path)
+			fh.Links = r.files(fh.Links)
+			if fh.Host != "" {
+				fh.Host = r.pseudonym("h-", fh.Host)
+			}
+			fh.Photo = nil
+		}
+		group.SuggestedName = r.name(group.SuggestedName)
+		groups[i] = group
+	}
+
+	var skippedOut []SkippedFile
+	for _, s := range skipped {
+		// Error messages often quote the path
+		s.Reason = strings.ReplaceAll(s.Reason, s.Path, r.file(s.Path))
+		s.Path = r.file(s.Path)
+		skippedOut = append(skippedOut, s)
+	}
+	var integrityOut []IntegrityIssue
+	for _, issue := range integrity {
+		issue.Path = r.file(issue.Path)
+		integrityOut = append(integrityOut, issue)
+	}
+	var linkedOut [][]string
+	for _, set := range linked {
+		linkedOut = append(linkedOut, r.files(set))
+	}
+	return groups, skippedOut, integrityOut, linkedOut
+}
+
+// anomalies returns redacted copies of the hashes found at several sizes
+func (r *redactor) anomalies(list []HashAnomaly) []HashAnomaly {
+	var out []HashAnomaly
+	for _, a := range list {
+		a.Paths = r.files(a.Paths)
+		out = append(out, a)
+	}
+	return out
+}
+
+// config returns a copy of c with the paths and names among its options
+// redacted
+func (r *redactor) config(c Config) Config {
+	dirs := func(list stringList) stringList {
+		var out stringList
+		for _, dir := range list {
+			out = append(out, r.dir(dir))
+		}
+		return out
+	}
+	c.Dir = dirs(c.Dir)
+	c.ReferenceReadOnly = dirs(c.ReferenceReadOnly)
+	c.ImportIndex = stringList(r.files(c.ImportIndex))
+	for _, dir := range []*string{&c.MoveTo, &c.SpillDir, &c.Quarantine} {
+		*dir = r.dir(*dir)
+	}
+	for _, file := range []*string{&c.Checkpoint, &c.Journal, &c.AnswersFile, &c.KnownDB, &c.Cache,
+		&c.ExportDecisions, &c.ApplyDecisions, &c.SimilarityMatrix, &c.Treemap, &c.ExportIndex,
+		&c.ImportTags, &c.CompareImg1, &c.CompareImg2} {
+		*file = r.file(*file)
+	}
+	for _, file := range []*string{&c.IgnoreList, &c.TagFile} {
+		if *file != "off" {
+			*file = r.file(*file)
+		}
+	}
+	if c.RPC != "stdio" {
+		c.RPC = r.file(c.RPC)
+	}
+	var remotes stringList
+	for _, remote := range c.Remotes {
+		remotes = append(remotes, r.pseudonym("h-", remote))
+	}
+	c.Remotes = remotes
+	if c.Machine != "" {
+		c.Machine = r.pseudonym("h-", c.Machine)
+	}
+	if c.PushIndex != "" {
+		c.PushIndex = r.pseudonym("h-", c.PushIndex)
+	}
+	return c
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	r := newRedactor()
+
+	path := filepath.Join(home, "Documents", "taxes", "return 2023.pdf")
+	got := r.file(path)
+	if !strings.HasPrefix(got, "~"+string(filepath.Separator)) || !strings.HasSuffix(got, ".pdf") {
+		t.Errorf("file(%s) = %s, want ~/.../f-xxxxxxxx.pdf", path, got)
+	}
+	if strings.Contains(got, "Documents") || strings.Contains(got, "taxes") || strings.Contains(got, "return") {
+		t.Errorf("file(%s) = %s leaks a name", path, got)
+	}
+	if parts := strings.Split(got, string(filepath.Separator)); len(parts) != 4 {
+		t.Errorf("file(%s) = %s, want the same depth", path, got)
+	}
+
+	// The same names get the same pseudonyms, in this report and the next
+	sibling := r.file(filepath.Join(home, "Documents", "taxes", "other.pdf"))
+	if filepath.Dir(sibling) != filepath.Dir(got) {
+		t.Errorf("files in one folder got different folders: %s, %s", got, sibling)
+	}
+	if again := newRedactor().file(path); again != got {
+		t.Errorf("a second redactor gave %s, want %s", again, got)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "file-deduplicator", redactKeyName)); err != nil {
+		t.Errorf("the key should be saved: %v", err)
+	}
+
+	if got := r.dir(home); got != "~" {
+		t.Errorf("dir(home) = %s, want ~", got)
+	}
+
+	groups, skipped, _, _ := r.findings(
+		[]DuplicateGroup{{Hash: "abc", Size: 10, Files: []FileHash{{Path: path, Size: 10, Hash: "abc", Photo: &photoMeta{GPS: true}}}}},
+		[]SkippedFile{{Path: path, Reason: "open " + path + ": permission denied"}}, nil, nil)
+	if groups[0].Files[0].Path != got || groups[0].Files[0].Photo != nil || groups[0].Hash != "abc" || groups[0].Size != 10 {
+		t.Errorf("findings() group = %+v", groups[0])
+	}
+	if strings.Contains(skipped[0].Reason, "taxes") || !strings.Contains(skipped[0].Reason, got) {
+		t.Errorf("findings() reason = %q", skipped[0].Reason)
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{filepath.Join(home, "Documents")}
+	c.IgnoreList = "off"
+	redacted := r.config(c)
+	if strings.Contains(redacted.Dir[0], "Documents") || redacted.IgnoreList != "off" || c.Dir[0] != filepath.Join(home, "Documents") {
+		t.Errorf("config() Dir = %v, IgnoreList = %q; original Dir = %v", redacted.Dir, redacted.IgnoreList, c.Dir)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sshCommand is the client used to reach -remote hosts
+var sshCommand = "ssh"
+
+// agentHeader is the first line an -agent writes, before its FileHash records
+type agentHeader struct {
+	Agent string `json:"agent"` // version of the remote binary
+	Hash  string `json:"hash"`  // hash algorithm the agent used
+}
+
+// remoteSpec is a parsed -remote target of the form [user@]host:/path
+type remoteSpec struct {
+	host string
+	dir  string
+}
+
+// parseRemote splits a -remote argument into host and directory
+func parseRemote(spec string) (remoteSpec, error) {
+	host, dir, ok := strings.Cut(spec, ":")
+	if !ok || host == "" || dir == "" {
+		return remoteSpec{}, fmt.Errorf("invalid -remote %q: expected [user@]host:/path", spec)
+	}
+	return remoteSpec{host: host, dir: dir}, nil
+}
+
+// runAgent scans and hashes the engine's roots and streams the results to out
+// as line-delimited JSON: an agentHeader, then one FileHash per file. This is
+// what a coordinating instance runs on the far side of -remote.
+func (e *Engine) runAgent(ctx context.Context, out io.Writer) error {
+	enc := json.NewEncoder(out)
+	if err := enc.Encode(agentHeader{Agent: version, Hash: e.cfg.hashName()}); err != nil {
+		return err
+	}
+
+	c := e.cfg
+	c.JSON = true // stdout carries the records
+	agent := NewEngine(c, &Events{OnFileHashed: func(fh FileHash) { enc.Encode(fh) }})
+
+	fileHashes, err := agent.collectFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Size-only files are never hashed, so nothing was streamed yet
+	if c.NoHash {
+		for _, fh := range fileHashes {
+			if err := enc.Encode(fh); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// agentArgs returns the flags that make a remote agent scan the way this
+// engine would, so both sides hash and filter identically
+func (e *Engine) agentArgs(dir string) []string {
+	c := e.cfg
+	args := []string{
+		"-agent",
+		"-dir", dir,
+		"-hash", c.HashAlgorithm,
+		"-recursive=" + strconv.FormatBool(c.Recursive),
+		"-max-depth", strconv.Itoa(c.MaxDepth),
+		"-skip-network-fs=" + strconv.FormatBool(c.SkipNetworkFS),
+		"-min-size", strconv.FormatInt(c.MinSize, 10),
+		"-max-size", strconv.FormatInt(c.MaxSize, 10),
+		"-ext", c.Extensions,
+		"-exclude-ext", c.ExcludeExtensions,
+		"-no-hash=" + strconv.FormatBool(c.NoHash),
+		"-perceptual=" + strconv.FormatBool(c.PerceptualMode),
+		"-phash-algo", c.PHashAlgorithm,
+	}
+	// Only passed when set, so agents from before these flags still work
+	if len(c.MinSizeExt) > 0 {
+		args = append(args, "-min-size-ext", c.MinSizeExt.String())
+	}
+	if len(c.DecodeLimit) > 0 {
+		args = append(args, "-decode-limit", c.DecodeLimit.String())
+	}
+	if c.MinAge > 0 {
+		args = append(args, "-min-age", c.MinAge.String())
+	}
+	if c.MaxAge > 0 {
+		args = append(args, "-max-age", c.MaxAge.String())
+	}
+	if c.PhotoLocation {
+		args = append(args, "-photo-location")
+	}
+	for _, pattern := range c.FilePattern {
+		args = append(args, "-pattern", pattern)
+	}
+	for _, pattern := range c.IgnoreCasePattern {
+		args = append(args, "-ipattern", pattern)
+	}
+	return args
+}
+
+// startRemotes launches one agent per -remote target and returns a function
+// that waits for all of them and returns their combined records
+func (e *Engine) startRemotes(ctx context.Context) func() ([]FileHash, error) {
+	if len(e.cfg.Remotes) == 0 {
+		return func() ([]FileHash, error) { return nil, nil }
+	}
+
+	results := make([][]FileHash, len(e.cfg.Remotes))
+	errs := make([]error, len(e.cfg.Remotes))
+	var wg sync.WaitGroup
+	for i, spec := range e.cfg.Remotes {
+		wg.Add(1)
+		go func(i int, spec string) {
+			defer wg.Done()
+			results[i], errs[i] = e.fetchRemote(ctx, spec)
+		}(i, spec)
+	}
+
+	return func() ([]FileHash, error) {
+		wg.Wait()
+		var all []FileHash
+		for i, fileHashes := range results {
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+			all = append(all, fileHashes...)
+		}
+		return all, nil
+	}
+}
+
+// fetchRemote runs an agent on the remote host over SSH and collects its records
+func (e *Engine) fetchRemote(ctx context.Context, spec string) ([]FileHash, error) {
+	remote, err := parseRemote(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	quoted := make([]string, 0, 32)
+	for _, arg := range e.agentArgs(remote.dir) {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	command := e.cfg.RemoteBin + " " + strings.Join(quoted, " ")
+
+	cmd := exec.CommandContext(ctx, sshCommand, remote.host, command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: cannot start %s: %w", remote.host, sshCommand, err)
+	}
+
+	fileHashes, readErr := e.readAgent(stdout, remote.host)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("%s: agent failed: %v: %s", remote.host, err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("%s: %w", remote.host, readErr)
+	}
+
+	if !e.cfg.JSON {
+		log.Printf("%s%s: received %d files from %s", emoji("🌐"), remote.host, len(fileHashes), remote.dir)
+	}
+	return fileHashes, nil
+}
+
+// readAgent parses an agent's output. Unless host is empty, paths are
+// prefixed with "host:" and Host is set so the files are shown as remote and
+// never modified locally.
+func (e *Engine) readAgent(r io.Reader, host string) ([]FileHash, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("agent sent no output")
+	}
+	var header agentHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Agent == "" {
+		return nil, fmt.Errorf("unexpected agent output %q (is file-deduplicator installed on the remote host?)", scanner.Text())
+	}
+	if local := e.cfg.hashName(); header.Hash != local {
+		return nil, fmt.Errorf("agent v%s hashed with %s, expected %s", header.Agent, header.Hash, local)
+	}
+
+	var fileHashes []FileHash
+	for scanner.Scan() {
+		var fh FileHash
+		if err := json.Unmarshal(scanner.Bytes(), &fh); err != nil {
+			return nil, fmt.Errorf("invalid agent record: %w", err)
+		}
+		if host != "" {
+			fh.Host = host
+			fh.Path = host + ":" + fh.Path
+		}
+		fileHashes = append(fileHashes, fh)
+	}
+	return fileHashes, scanner.Err()
+}
+
+// shellQuote quotes s for a POSIX shell, as run by sshd on the remote host
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=./:,@") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
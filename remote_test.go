@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	remote, err := parseRemote("me@nas:/volume1/photos")
+	if err != nil || remote.host != "me@nas" || remote.dir != "/volume1/photos" {
+		t.Errorf("parseRemote() = %+v, %v", remote, err)
+	}
+	for _, bad := range []string{"nas", "nas:", ":/photos"} {
+		if _, err := parseRemote(bad); err == nil {
+			t.Errorf("parseRemote(%q) should fail", bad)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"-min-size":       "-min-size",
+		"/photos/2024":    "/photos/2024",
+		"":                "''",
+		"*.jpg":           "'*.jpg'",
+		"my photos":       "'my photos'",
+		"it's":            `'it'\''s'`,
+		"-recursive=true": "-recursive=true",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+// writeAgentOutput runs an agent over dir and returns what it wrote
+func writeAgentOutput(t *testing.T, dir string) []byte {
+	t.Helper()
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+
+	var out bytes.Buffer
+	if err := NewEngine(c, nil).runAgent(context.Background(), &out); err != nil {
+		t.Fatalf("runAgent() error = %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestAgentRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	output := writeAgentOutput(t, tmpDir)
+
+	c := DefaultConfig()
+	fileHashes, err := NewEngine(c, nil).readAgent(bytes.NewReader(output), "nas")
+	if err != nil {
+		t.Fatalf("readAgent() error = %v", err)
+	}
+	if len(fileHashes) != 2 {
+		t.Fatalf("readAgent() returned %d files, want 2", len(fileHashes))
+	}
+	for _, fh := range fileHashes {
+		if fh.Host != "nas" || !strings.HasPrefix(fh.Path, "nas:"+tmpDir) || fh.Hash == "" {
+			t.Errorf("readAgent() record = %+v, want a hashed remote file", fh)
+		}
+	}
+
+	c.HashAlgorithm = "md5"
+	if _, err := NewEngine(c, nil).readAgent(bytes.NewReader(output), "nas"); err == nil {
+		t.Error("readAgent() should reject records hashed with another algorithm")
+	}
+	if _, err := NewEngine(c, nil).readAgent(strings.NewReader("bash: file-deduplicator: not found\n"), "nas"); err == nil {
+		t.Error("readAgent() should reject output that is not from an agent")
+	}
+}
+
+// Remote files take part in grouping but are never removed
+func TestCollectDuplicatesWithRemote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh client is a shell script")
+	}
+
+	localDir, remoteDir, binDir := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "local.txt"), []byte("same content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(remoteDir, "remote.txt"), []byte("same content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	// The fake ssh drops the host and runs the command locally; the fake
+	// remote binary prints pre-recorded agent output and ignores its flags
+	records := filepath.Join(binDir, "records.jsonl")
+	if err := os.WriteFile(records, writeAgentOutput(t, remoteDir), 0644); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+	fakeSSH := filepath.Join(binDir, "ssh")
+	if err := os.WriteFile(fakeSSH, []byte("#!/bin/sh\nshift\nexec sh -c \"$*\"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake ssh: %v", err)
+	}
+	savedSSH := sshCommand
+	sshCommand = fakeSSH
+	defer func() { sshCommand = savedSSH }()
+
+	c := DefaultConfig()
+	c.Dir = stringList{localDir}
+	c.Remotes = stringList{"nas:" + remoteDir}
+	c.RemoteBin = "cat " + records + "; :"
+	c.MinSize = 1
+	c.JSON = true
+	c.KeepCriteria = "path:nas:" // keep the remote copy, so the local one is the duplicate
+	c.MoveTo = filepath.Join(t.TempDir(), "moved")
+
+	var actions []ActionEvent
+	engine := NewEngine(c, &Events{OnActionTaken: func(action ActionEvent) { actions = append(actions, action) }})
+	duplicates, err := engine.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatalf("collectDuplicates() error = %v", err)
+	}
+	if len(duplicates) != 1 || len(duplicates[0].Files) != 2 {
+		t.Fatalf("collectDuplicates() = %+v, want one local+remote group", duplicates)
+	}
+
+	if err := engine.processDuplicates(context.Background(), duplicates); err != nil {
+		t.Fatalf("processDuplicates() error = %v", err)
+	}
+	if len(actions) != 1 || actions[0].Path != filepath.Join(localDir, "local.txt") {
+		t.Errorf("actions = %+v, want only the local copy moved", actions)
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, "remote.txt")); err != nil {
+		t.Error("remote copy must never be touched")
+	}
+
+}
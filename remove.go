@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// errFileLocked marks a file another program holds open, which Windows
+// refuses to delete or move until it is closed
+var errFileLocked = errors.New("in use by another program")
+
+// errReadOnly marks a file with the Windows read-only attribute
+var errReadOnly = errors.New("file is read-only")
+
+// lockedRetryDelay is how long a cleanup waits before its retry pass over
+// locked files
+var lockedRetryDelay = 2 * time.Second
+
+// removeFile deletes a duplicate, or with -trash moves it to the system
+// trash and with -quarantine-deletes to the quarantine. Read-only files are
+// only deleted with -clear-readonly, and files held open elsewhere fail with
+// errFileLocked.
+func (c Config) removeFile(path string) error {
+	if c.Trash {
+		return c.trashFile(path)
+	}
+	if c.QuarantineDeletes {
+		return c.quarantineFile(path)
+	}
+	return c.journaled("delete", path, "", func() error {
+		if err := c.checkWritable(path); err != nil {
+			return err
+		}
+		if isReadOnly(path) {
+			if !c.ClearReadOnly {
+				return fmt.Errorf("%w (use -clear-readonly to delete it anyway)", errReadOnly)
+			}
+			if err := clearReadOnly(path); err != nil {
+				return fmt.Errorf("cannot clear read-only attribute: %w", err)
+			}
+		}
+		return lockedError(os.Remove(path))
+	})
+}
+
+// moveFile moves a duplicate, failing with errFileLocked when the file is
+// held open elsewhere
+func (c Config) moveFile(path, target string) error {
+	return c.journaled("move", path, target, func() error {
+		if err := c.checkWritable(path, target); err != nil {
+			return err
+		}
+		return lockedError(renameFile(path, target))
+	})
+}
+
+// renameFile moves path to target like os.Rename, and also to another file
+// system, where renaming fails, by copying it there and removing it after
+func renameFile(path, target string) error {
+	err := os.Rename(path, target)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+	return copyAndRemove(path, target)
+}
+
+// copyAndRemove copies the regular file path to the new file target with
+// its mode and modification time, syncs the copy to disk and only then
+// removes path. If anything fails the copy is removed and path stays.
+func copyAndRemove(path, target string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("cannot move %s to another file system: not a regular file", path)
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		src.Close()
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	src.Close() // before removing it, which Windows refuses while it is open
+	if err == nil {
+		err = os.Chtimes(target, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		os.Remove(target)
+	}
+	return err
+}
+
+// lockedError wraps sharing violations in errFileLocked
+func lockedError(err error) error {
+	if err != nil && isSharingViolation(err) {
+		return fmt.Errorf("%w: %v", errFileLocked, err)
+	}
+	return err
+}
+
+// retryLocked gives files that were open elsewhere one more try after
+// lockedRetryDelay, then logs a single summary of those still locked instead
+// of an error per file
+func retryLocked(ctx context.Context, files []FileHash, try func(i int) error) {
+	if len(files) == 0 {
+		return
+	}
+	if ctx.Err() == nil {
+		log.Printf("%s%d files are in use by another program; trying them again in %v", emoji("🔒"), len(files), lockedRetryDelay)
+		select {
+		case <-time.After(lockedRetryDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	var stillLocked []string
+	for i, fh := range files {
+		if ctx.Err() != nil || errors.Is(try(i), errFileLocked) {
+			stillLocked = append(stillLocked, fh.Path)
+		}
+	}
+	if len(stillLocked) > 0 {
+		log.Printf("%s%d files are still in use and were left in place; close the programs using them and run again:", emoji("🔒"), len(stillLocked))
+		for _, path := range stillLocked {
+			log.Printf("  %s", path)
+		}
+	}
+}
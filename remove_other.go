@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isReadOnly is always false: a read-only mode does not stop deletion here
+func isReadOnly(path string) bool { return false }
+
+// clearReadOnly has nothing to clear on this platform
+func clearReadOnly(path string) error { return nil }
+
+// isSharingViolation is always false: open files can be removed here
+func isSharingViolation(err error) bool { return false }
+
+// isCrossDevice reports whether err means a rename between file systems
+func isCrossDevice(err error) bool { return errors.Is(err, syscall.EXDEV) }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryLocked(t *testing.T) {
+	defer func(d time.Duration) { lockedRetryDelay = d }(lockedRetryDelay)
+	lockedRetryDelay = time.Millisecond
+
+	files := []FileHash{{Path: "a"}, {Path: "b"}}
+	var tried []string
+	retryLocked(context.Background(), files, func(i int) error {
+		tried = append(tried, files[i].Path)
+		if i == 1 {
+			return fmt.Errorf("%w: sharing violation", errFileLocked)
+		}
+		return nil
+	})
+	if strings.Join(tried, ",") != "a,b" {
+		t.Errorf("retry pass tried %v, want every locked file once", tried)
+	}
+
+	// A cancelled run does not retry at all
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tried = nil
+	retryLocked(ctx, files, func(i int) error {
+		tried = append(tried, files[i].Path)
+		return nil
+	})
+	if len(tried) != 0 {
+		t.Errorf("cancelled retry pass tried %v", tried)
+	}
+}
+
+func TestFormatFileErrorLocked(t *testing.T) {
+	locked := formatFileError("x", fmt.Errorf("%w: busy", errFileLocked))
+	if !strings.Contains(locked, "In use by another program") {
+		t.Errorf("formatFileError(locked) = %q", locked)
+	}
+	readOnly := formatFileError("x", fmt.Errorf("%w (hint)", errReadOnly))
+	if !strings.Contains(readOnly, "-clear-readonly") {
+		t.Errorf("formatFileError(read-only) = %q", readOnly)
+	}
+}
+
+func TestCopyAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	dst := filepath.Join(dir, "b.txt")
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.WriteFile(src, []byte("hello"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(src, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyAndRemove(src, dst); err != nil {
+		t.Fatalf("copyAndRemove: %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still there after the move: %v", err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("moved file = %q, %v", data, err)
+	}
+	if info, _ := os.Stat(dst); !info.ModTime().Equal(mtime) {
+		t.Errorf("moved file modified %v, want %v", info.ModTime(), mtime)
+	}
+
+	// An existing target is never overwritten, and the source stays
+	if err := os.WriteFile(src, []byte("other"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyAndRemove(src, dst); err == nil {
+		t.Error("copyAndRemove overwrote an existing file")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "hello" {
+		t.Errorf("existing target changed to %q", data)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source removed after a failed move: %v", err)
+	}
+}
+
+func TestRenameFileCrossDevice(t *testing.T) {
+	other, err := os.MkdirTemp("/dev/shm", "dedup-test-")
+	if err != nil {
+		t.Skip("no second file system to move to:", err)
+	}
+	defer os.RemoveAll(other)
+
+	src := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(other, "a.txt")
+	if err := os.Rename(src, dst); err == nil || !isCrossDevice(err) {
+		t.Skipf("%s is on the same file system as the temp dir", other)
+	}
+	if err := renameFile(src, dst); err != nil {
+		t.Fatalf("renameFile across file systems: %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source still there after the move: %v", err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "hello" {
+		t.Errorf("moved file = %q, %v", data, err)
+	}
+}
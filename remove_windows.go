@@ -0,0 +1,51 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Win32 errors for files another process has open
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+	errorNotSameDevice    syscall.Errno = 17 // a move between volumes
+)
+
+// isReadOnly reports whether path has the read-only attribute, which makes
+// DeleteFile fail with "Access is denied"
+func isReadOnly(path string) bool {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	attrs, err := syscall.GetFileAttributes(name)
+	return err == nil && attrs&syscall.FILE_ATTRIBUTE_READONLY != 0
+}
+
+// clearReadOnly removes the read-only attribute from path
+func clearReadOnly(path string) error {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attrs, err := syscall.GetFileAttributes(name)
+	if err != nil {
+		return err
+	}
+	return syscall.SetFileAttributes(name, attrs&^syscall.FILE_ATTRIBUTE_READONLY)
+}
+
+// isSharingViolation reports whether err means another process has the
+// file open
+func isSharingViolation(err error) bool {
+	return errors.Is(err, errorSharingViolation) || errors.Is(err, errorLockViolation)
+}
+
+// isCrossDevice reports whether err means a rename between volumes
+func isCrossDevice(err error) bool {
+	return errors.Is(err, errorNotSameDevice)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/luinbytes/file-deduplicator/tui"
+)
+
+// restoreOperation is one past run's removed files, for the restore browser
+type restoreOperation struct {
+	name  string
+	time  time.Time
+	items []tui.RestoreItem
+}
+
+// restoreHistory collects the quarantine batches in quarantineDir and the
+// deletions in the undo log, newest operation first
+func restoreHistory(quarantineDir, undoPath string) ([]tui.RestoreItem, error) {
+	records, err := readQuarantineLog(quarantineDir)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := make(map[string]*restoreOperation)
+	for _, record := range records {
+		batch := "unknown"
+		if rel, err := filepath.Rel(quarantineDir, record.Quarantined); err == nil {
+			batch = strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+		}
+		op := operations[batch]
+		if op == nil {
+			op = &restoreOperation{name: "Quarantined " + batch, time: record.Time}
+			if t, err := time.ParseInLocation("20060102-150405", batch, time.Local); err == nil {
+				op.name = "Quarantined " + t.Format("2006-01-02 15:04:05")
+				op.time = t
+			}
+			operations[batch] = op
+		}
+		op.items = append(op.items, tui.RestoreItem{
+			Operation: op.name,
+			Path:      record.Original,
+			Stored:    record.Quarantined,
+			Size:      record.Size,
+			Time:      record.Time.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	// Deleted files cannot come back and trashed ones come back from the
+	// system trash, but both are listed so the history is complete
+	if data, err := os.ReadFile(undoPath); err == nil {
+		var undo struct {
+			Files []UndoEntry `json:"files"`
+		}
+		if err := json.Unmarshal(data, &undo); err != nil {
+			return nil, fmt.Errorf("invalid undo log: %w", err)
+		}
+		// Quarantined files are listed with their batch already
+		if len(undo.Files) > 0 && undo.Files[0].Batch == "" {
+			op := &restoreOperation{time: undo.Files[0].Timestamp}
+			op.name = "Deleted " + op.time.Format("2006-01-02 15:04:05")
+			if undo.Files[0].Action == "trashed" {
+				op.name = "Trashed (restore from the system trash) " + op.time.Format("2006-01-02 15:04:05")
+			}
+			for _, entry := range undo.Files {
+				op.items = append(op.items, tui.RestoreItem{
+					Operation: op.name,
+					Path:      entry.Path,
+					Size:      entry.Size,
+					Time:      entry.Timestamp.Format("2006-01-02 15:04:05"),
+				})
+			}
+			operations["\x00undo"] = op
+		}
+	}
+
+	sorted := make([]*restoreOperation, 0, len(operations))
+	for _, op := range operations {
+		sorted = append(sorted, op)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].time.After(sorted[j].time) })
+
+	var items []tui.RestoreItem
+	for _, op := range sorted {
+		sort.Slice(op.items, func(i, j int) bool { return op.items[i].Path < op.items[j].Path })
+		items = append(items, op.items...)
+	}
+	return items, nil
+}
+
+// runRestore opens the restore browser for -dir's quarantine (or
+// -quarantine) and the undo log, and puts back the files the user picks
+func runRestore(c Config) error {
+	quarantineDir := c.quarantineDir()
+
+	items, err := restoreHistory(quarantineDir, c.UndoLog)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		log.Printf("%sNothing to restore: no quarantine in %s and no undo log", emoji("ℹ️ "), quarantineDir)
+		return nil
+	}
+
+	selected, err := tui.RunRestore(items)
+	if err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+	if len(selected) == 0 {
+		log.Println("❓ No files restored.")
+		return nil
+	}
+
+	var paths []string
+	for _, item := range selected {
+		paths = append(paths, item.Stored)
+	}
+	restored, err := restoreQuarantined(quarantineDir, paths)
+	for _, record := range restored {
+		log.Printf("✓ Restored %s", record.Original)
+	}
+	log.Printf("\n✅ Restored %d of %d files", len(restored), len(selected))
+	return err
+}
+
+// undoRestore moves the files of quarantine batch id in dir back, for -undo
+// and -undo-restore
+func undoRestore(dir, id string) error {
+	restored, err := restoreBatch(dir, id)
+	for _, record := range restored {
+		log.Printf("✓ Restored %s", record.Original)
+	}
+	if len(restored) > 0 {
+		log.Printf("\n✅ Restored %d files from batch %s", len(restored), id)
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// errChangedSinceScan marks a file that no longer matches what the scan saw
+var errChangedSinceScan = errors.New("changed since the scan")
+
+// revalidate re-stats and re-hashes a scanned file just before it is acted
+// on, so nothing is deleted on the strength of a scan that has gone stale
+func revalidate(fh FileHash, algorithm string) error {
+	info, err := os.Stat(fh.Path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != fh.Size || !info.ModTime().Equal(fh.ModTime) {
+		return fmt.Errorf("%w: size or modification time differs", errChangedSinceScan)
+	}
+	if fh.Hash == "" {
+		return nil // size-only triage never read the content
+	}
+
+	hasher := getHasher(algorithm)
+	var hash string
+	if strings.HasPrefix(fh.Hash, "svg:") {
+		hash, err = normalizedSVGHash(fh.Path, hasher)
+	} else {
+		hash, _, _, err = hashFileBuffer(context.Background(), fh.Path, hasher, defaultReadBuffer)
+	}
+	if err != nil {
+		return err
+	}
+	if hash != fh.Hash {
+		return fmt.Errorf("%w: content differs", errChangedSinceScan)
+	}
+	return nil
+}
+
+// checkUnchanged revalidates a local file with -revalidate. Indexed and
+// remote copies cannot be read here and are taken as scanned.
+func (c Config) checkUnchanged(fh FileHash) error {
+	if !c.Revalidate || fh.Reference || fh.Host != "" {
+		return nil
+	}
+	return revalidate(fh, c.HashAlgorithm)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// scanOne hashes path the way a scan would
+func scanOne(t *testing.T, path string) FileHash {
+	t.Helper()
+	hash, size, modTime, err := hashFileContext(context.Background(), path, getHasher("sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FileHash{Path: path, Size: size, Hash: hash, ModTime: modTime}
+}
+
+func TestRevalidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(path, []byte("scanned content"), 0644)
+	fh := scanOne(t, path)
+
+	if err := revalidate(fh, "sha256"); err != nil {
+		t.Errorf("revalidate() of an unchanged file = %v", err)
+	}
+
+	// Same size and mtime, different bytes
+	os.WriteFile(path, []byte("swapped content"), 0644)
+	os.Chtimes(path, fh.ModTime, fh.ModTime)
+	if err := revalidate(fh, "sha256"); !errors.Is(err, errChangedSinceScan) {
+		t.Errorf("revalidate() after a content change = %v", err)
+	}
+
+	os.Chtimes(path, fh.ModTime.Add(time.Minute), fh.ModTime.Add(time.Minute))
+	if err := revalidate(fh, "sha256"); !errors.Is(err, errChangedSinceScan) {
+		t.Errorf("revalidate() after a touch = %v", err)
+	}
+
+	os.Remove(path)
+	if err := revalidate(fh, "sha256"); err == nil {
+		t.Error("revalidate() of a deleted file should fail")
+	}
+
+	if err := (Config{Revalidate: false}).checkUnchanged(fh); err != nil {
+		t.Errorf("-revalidate=false should not check, got %v", err)
+	}
+}
+
+func TestProcessDuplicatesSkipsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	var files []FileHash
+	for i, name := range []string{"keep.txt", "changed.txt", "dup.txt"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte("same content"), 0644)
+		modTime := time.Now().Add(time.Duration(i-10) * time.Hour) // keep.txt is oldest
+		os.Chtimes(path, modTime, modTime)
+		files = append(files, scanOne(t, path))
+	}
+	group := DuplicateGroup{Hash: files[0].Hash, Size: files[0].Size, Files: files, Similarity: 100}
+
+	// Edited after the scan
+	os.WriteFile(files[1].Path, []byte("edited after"), 0644)
+
+	c := DefaultConfig()
+	c.JSON = true
+	c.KeepCriteria = "oldest"
+	c.MoveTo = filepath.Join(t.TempDir(), "moved") // moving keeps the undo log untouched
+
+	var actions []ActionEvent
+	engine := NewEngine(c, &Events{OnActionTaken: func(a ActionEvent) { actions = append(actions, a) }})
+	if err := engine.processDuplicates(context.Background(), []DuplicateGroup{group}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(files[1].Path); err != nil {
+		t.Errorf("a file changed since the scan was moved: %v", err)
+	}
+	if _, err := os.Stat(files[2].Path); !os.IsNotExist(err) {
+		t.Errorf("the unchanged duplicate should have been moved, stat = %v", err)
+	}
+	if len(actions) != 2 || actions[0].Error == "" || actions[1].Action != "moved" {
+		t.Errorf("actions = %+v, want one refusal and one move", actions)
+	}
+
+	// When the kept copy changed, the whole group is left alone
+	os.WriteFile(files[0].Path, []byte("edited keeper"), 0644)
+	os.WriteFile(files[2].Path, []byte("same content"), 0644)
+	os.Chtimes(files[2].Path, files[2].ModTime, files[2].ModTime)
+	actions = nil
+	engine.processDuplicates(context.Background(), []DuplicateGroup{group})
+	if len(actions) != 0 {
+		t.Errorf("actions with a changed keeper = %+v, want none", actions)
+	}
+}
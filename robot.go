@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// controlSession holds scan results shared between commands of the
+// machine-facing front-ends (-robot and -rpc)
+type controlSession struct {
+	mu        sync.Mutex
+	base      Config // settings each scan starts from
+	keep      string // -keep criteria of the current or last scan
+	last      Config // settings of the last scan, to revalidate files before acting
+	groups    []DuplicateGroup
+	owner     map[string]int // path -> index into groups
+	remaining map[int]int    // group index -> local files not yet removed
+}
+
+// newControlSession creates a session whose scans start from base. Human
+// progress output is always disabled because it would corrupt the protocol.
+func newControlSession(base Config) *controlSession {
+	base.JSON = true
+	return &controlSession{base: base}
+}
+
+// scanSummary is reported when a scan finishes
+type scanSummary struct {
+	Groups         int   `json:"groups"`
+	DuplicateFiles int   `json:"duplicate_files"`
+	TotalSpace     int64 `json:"total_space"`
+	ElapsedMs      int64 `json:"elapsed_ms"`
+}
+
+// scan runs a full scan of dir with the given CLI flags applied for this
+// scan only. Progress is reported through ev.
+func (s *controlSession) scan(ctx context.Context, dir string, flags map[string]string, ev *Events) (scanSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := configWithFlags(s.base, flags)
+	if err != nil {
+		return scanSummary{}, err
+	}
+	if dir != "" {
+		c.Dir = stringList{dir}
+	}
+	c.JSON = true
+	s.keep = c.KeepCriteria // set before groups are reported through ev
+
+	startTime := time.Now()
+	engine := NewEngine(c, ev)
+	duplicates, err := engine.collectDuplicates(ctx)
+	if err != nil {
+		return scanSummary{}, err
+	}
+
+	s.groups = duplicates
+	s.last = engine.cfg // with its -quarantine-deletes batch
+	s.owner = make(map[string]int)
+	s.remaining = make(map[int]int)
+
+	summary := scanSummary{Groups: len(duplicates)}
+	for i, group := range duplicates {
+		for _, fh := range group.Files {
+			if fh.Host == "" && !fh.Reference { // remote and indexed copies can never be removed
+				s.owner[fh.Path] = i
+				s.remaining[i]++
+			}
+		}
+		summary.TotalSpace += group.Size * int64(len(group.Files)-1)
+		summary.DuplicateFiles += len(group.Files) - 1
+	}
+	summary.ElapsedMs = time.Since(startTime).Milliseconds()
+
+	return summary, nil
+}
+
+// file returns the scanned entry for a path owned by a group
+func (s *controlSession) file(path string) FileHash {
+	for _, fh := range s.groups[s.owner[path]].Files {
+		if fh.Path == path {
+			return fh
+		}
+	}
+	return FileHash{Path: path}
+}
+
+// apply deletes or moves files from the last scan. Paths that were not part
+// of a duplicate group are refused, as is removing every local copy in a
+// group. Each file's outcome is reported through ev; with -dry-run nothing
+// is changed and the outcomes are what would have happened.
+func (s *controlSession) apply(action string, paths []string, to string, ev *Events) (succeeded, failed int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch action {
+	case "delete":
+	case "move":
+		if to == "" {
+			return 0, 0, fmt.Errorf("move requires a target directory")
+		}
+	default:
+		return 0, 0, fmt.Errorf("unknown action %q", action)
+	}
+
+	// Count how many files of each group this call would remove
+	removing := make(map[int]int)
+	for _, path := range paths {
+		idx, ok := s.owner[path]
+		if !ok {
+			return 0, 0, fmt.Errorf("%s is not a local file in the last scan's duplicate groups", path)
+		}
+		removing[idx]++
+	}
+	for idx, count := range removing {
+		if count >= s.remaining[idx] {
+			return 0, 0, fmt.Errorf("refusing to remove every copy in group %d", idx)
+		}
+	}
+
+	if action == "move" && !s.last.DryRun {
+		if err := os.MkdirAll(to, 0755); err != nil {
+			return 0, 0, fmt.Errorf("failed to create move directory: %w", err)
+		}
+	}
+
+	for _, path := range paths {
+		result := ActionEvent{Path: path, Size: s.groups[s.owner[path]].Size, DryRun: s.last.DryRun}
+		err := s.last.checkUnchanged(s.file(path)) // changed files are left alone
+		if err == nil && s.last.DryRun {
+			result.Action = "deleted"
+			if action == "move" {
+				result.Action, result.Target = "moved", uniqueTargetPath(to, path)
+			} else if s.last.Trash {
+				result.Action = "trashed"
+			}
+		} else if err == nil && action == "move" {
+			target := uniqueTargetPath(to, path)
+			if err = s.last.moveFile(path, target); err == nil {
+				result.Action = "moved"
+				result.Target = target
+				// A move that could not be logged stands; it is only left out of -purge-staged
+				logMove(to, s.file(path), target)
+			}
+		} else if err == nil {
+			if err = s.last.removeFile(path); err == nil {
+				result.Action = "deleted"
+				if s.last.Trash {
+					result.Action = "trashed"
+				}
+			}
+		}
+
+		if err != nil {
+			failed++
+			result.Error = formatFileError(path, err)
+		} else {
+			succeeded++
+			if !s.last.DryRun {
+				s.remaining[s.owner[path]]--
+				delete(s.owner, path)
+			}
+		}
+		ev.actionTaken(result)
+	}
+
+	return succeeded, failed, nil
+}
+
+// groupsWithKeep returns the last scan's groups with their designated keeper
+func (s *controlSession) groupsWithKeep() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]map[string]interface{}, len(s.groups))
+	for i, group := range s.groups {
+		groups[i] = groupPayload(i, group, s.keep)
+	}
+	return groups
+}
+
+// groupPayload is the wire representation of a group for -robot and -rpc
+func groupPayload(index int, group DuplicateGroup, keepCriteria string) map[string]interface{} {
+	return map[string]interface{}{
+		"index": index,
+		"group": group,
+		"keep":  selectFileToKeep(group, keepCriteria),
+	}
+}
+
+// robotCommand is one line of input in -robot mode.
+//
+//	{"id": 1, "cmd": "scan", "dir": "/photos", "flags": {"perceptual": "true"}}
+//	{"id": 2, "cmd": "delete", "paths": ["/photos/a (1).jpg"]}
+//	{"id": 3, "cmd": "move", "paths": ["/photos/b.jpg"], "to": "/dupes"}
+//	{"id": 4, "cmd": "ping"}
+//	{"id": 5, "cmd": "quit"}
+type robotCommand struct {
+	ID    json.RawMessage   `json:"id,omitempty"`
+	Cmd   string            `json:"cmd"`
+	Dir   string            `json:"dir,omitempty"`
+	Flags map[string]string `json:"flags,omitempty"` // any CLI flag, applied for this scan only
+	Paths []string          `json:"paths,omitempty"`
+	To    string            `json:"to,omitempty"`
+}
+
+// robotEvent is one line of output in -robot mode
+type robotEvent struct {
+	Event   string          `json:"event"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Message string          `json:"message,omitempty"`
+	Data    interface{}     `json:"data,omitempty"`
+}
+
+// robotWriter serializes events onto the output stream
+type robotWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// emit writes a single event line
+func (w *robotWriter) emit(id json.RawMessage, event string, data interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.enc.Encode(robotEvent{Event: event, ID: id, Data: data})
+}
+
+// fail writes an error event
+func (w *robotWriter) fail(id json.RawMessage, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.enc.Encode(robotEvent{Event: "error", ID: id, Message: err.Error()})
+}
+
+// runRobot reads line-delimited JSON commands from in and writes
+// line-delimited JSON events to out until "quit" or end of input.
+// Scans start from base with each command's flags applied on top.
+func runRobot(base Config, in io.Reader, out io.Writer) error {
+	w := &robotWriter{enc: json.NewEncoder(out)}
+	session := newControlSession(base)
+	w.emit(nil, "ready", map[string]string{"version": version})
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var cmd robotCommand
+		if err := json.Unmarshal(line, &cmd); err != nil {
+			w.fail(nil, fmt.Errorf("invalid command: %w", err))
+			continue
+		}
+
+		switch cmd.Cmd {
+		case "ping":
+			w.emit(cmd.ID, "pong", nil)
+		case "scan":
+			w.emit(cmd.ID, "scan_started", map[string]string{"dir": cmd.Dir})
+			summary, err := session.scan(context.Background(), cmd.Dir, cmd.Flags, &Events{
+				OnGroupFound: func(i int, group DuplicateGroup) {
+					w.emit(cmd.ID, "group", groupPayload(i, group, session.keep))
+				},
+			})
+			if err != nil {
+				w.fail(cmd.ID, err)
+				continue
+			}
+			w.emit(cmd.ID, "scan_complete", summary)
+		case "delete", "move":
+			succeeded, failed, err := session.apply(cmd.Cmd, cmd.Paths, cmd.To, &Events{
+				OnActionTaken: func(result ActionEvent) {
+					w.emit(cmd.ID, "action", result)
+				},
+			})
+			if err != nil {
+				w.fail(cmd.ID, err)
+				continue
+			}
+			w.emit(cmd.ID, "done", map[string]int{"succeeded": succeeded, "failed": failed})
+		case "quit":
+			w.emit(cmd.ID, "bye", nil)
+			return nil
+		default:
+			w.fail(cmd.ID, fmt.Errorf("unknown command %q", cmd.Cmd))
+		}
+	}
+	return scanner.Err()
+}
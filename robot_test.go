@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunRobot(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	a, b, c := filepath.Join(tmpDir, "a.txt"), filepath.Join(tmpDir, "b.txt"), filepath.Join(tmpDir, "c.txt")
+	commands := strings.Join([]string{
+		`{"id": 1, "cmd": "ping"}`,
+		`{"id": 2, "cmd": "scan", "dir": ` + quote(tmpDir) + `, "flags": {"min-size": "1"}}`,
+		`{"id": 3, "cmd": "delete", "paths": [` + quote(a) + `, ` + quote(b) + `, ` + quote(c) + `]}`,
+		`{"id": 4, "cmd": "delete", "paths": [` + quote(b) + `]}`,
+		`{"id": 5, "cmd": "delete", "paths": ["/not/scanned"]}`,
+		`not json`,
+		`{"id": 6, "cmd": "quit"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := runRobot(DefaultConfig(), strings.NewReader(commands), &out); err != nil {
+		t.Fatalf("runRobot() error = %v", err)
+	}
+
+	var events []string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var ev robotEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("output line is not JSON: %q", scanner.Text())
+		}
+		events = append(events, ev.Event)
+	}
+
+	want := []string{"ready", "pong", "scan_started", "group", "scan_complete", "error", "action", "done", "error", "error", "bye"}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	if _, err := os.Stat(b); !os.IsNotExist(err) {
+		t.Error("b.txt should have been deleted")
+	}
+	if _, err := os.Stat(a); err != nil {
+		t.Error("a.txt should still exist")
+	}
+}
+
+func TestControlSessionApply(t *testing.T) {
+	archive, current := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(archive, "old.txt"), []byte("same content"), 0644)
+	a, b := filepath.Join(current, "a.txt"), filepath.Join(current, "b.txt")
+	for _, path := range []string{a, b} {
+		os.WriteFile(path, []byte("same content"), 0644)
+	}
+	index := filepath.Join(t.TempDir(), "archive.idx")
+	base := DefaultConfig()
+	base.Dir = stringList{archive}
+	base.MinSize = 1
+	base.JSON = true
+	if err := NewEngine(base, nil).exportIndex(context.Background(), index); err != nil {
+		t.Fatal(err)
+	}
+
+	base.DryRun = true
+	session := newControlSession(base)
+	if _, err := session.scan(context.Background(), current, map[string]string{"import-index": index}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The indexed copy does not count as one left behind
+	if _, _, err := session.apply("delete", []string{a, b}, "", nil); err == nil {
+		t.Error("removing both local copies of an indexed file was allowed")
+	}
+
+	var actions []ActionEvent
+	ev := &Events{OnActionTaken: func(a ActionEvent) { actions = append(actions, a) }}
+	moved := filepath.Join(t.TempDir(), "moved")
+	for _, action := range []string{"delete", "move"} {
+		succeeded, failed, err := session.apply(action, []string{b}, moved, ev)
+		if err != nil || succeeded != 1 || failed != 0 {
+			t.Fatalf("dry-run %s = %d, %d, %v; want one file", action, succeeded, failed, err)
+		}
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Error("a dry run removed b.txt")
+	}
+	if _, err := os.Stat(moved); !os.IsNotExist(err) {
+		t.Error("a dry run created the move directory")
+	}
+	if len(actions) != 2 || !actions[0].DryRun || actions[0].Action != "deleted" || actions[1].Action != "moved" || actions[1].Target != filepath.Join(moved, "b.txt") {
+		t.Errorf("actions = %+v, want the delete and move that would have happened", actions)
+	}
+}
+
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
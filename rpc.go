@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// rpcRequest is an incoming JSON-RPC 2.0 request or notification.
+// Requests are framed one per line.
+//
+//	{"jsonrpc": "2.0", "id": 1, "method": "scan", "params": {"dir": "/photos", "flags": {"perceptual": "true"}}}
+//	{"jsonrpc": "2.0", "id": 2, "method": "groups"}
+//	{"jsonrpc": "2.0", "id": 3, "method": "apply", "params": {"action": "move", "paths": ["/photos/b.jpg"], "to": "/dupes"}}
+//	{"jsonrpc": "2.0", "id": 4, "method": "shutdown"}
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is the reply to a request that carried an id
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcNotification is sent by the server while a request is in progress
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// rpcError is a JSON-RPC 2.0 error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcWriter serializes responses and notifications onto one connection
+type rpcWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// reply writes the response to a request
+func (w *rpcWriter) reply(id json.RawMessage, result interface{}, rerr *rpcError) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.enc.Encode(rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rerr})
+}
+
+// notify writes a server-to-client notification
+func (w *rpcWriter) notify(method string, params interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.enc.Encode(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// runRPC serves JSON-RPC 2.0 on stdio ("stdio") or on a unix socket path.
+// Scans start from base with each request's flags applied on top.
+func runRPC(base Config, endpoint string) error {
+	session := newControlSession(base)
+	if endpoint == "stdio" {
+		_, err := serveRPC(session, os.Stdin, os.Stdout)
+		return err
+	}
+	return listenRPC(session, endpoint)
+}
+
+// listenRPC accepts connections on a unix socket until a client calls
+// "shutdown". All connections share the same scan results.
+func listenRPC(session *controlSession, socketPath string) error {
+	ln, err := listenUnix(socketPath)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if shutdown, _ := serveRPC(session, conn, conn); shutdown {
+				ln.Close()
+			}
+		}()
+	}
+}
+
+// serveRPC handles line-delimited requests from in until end of input or a
+// "shutdown" call, which is reported through the shutdown result
+func serveRPC(session *controlSession, in io.Reader, out io.Writer) (shutdown bool, err error) {
+	w := &rpcWriter{enc: json.NewEncoder(out)}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '[' {
+			w.reply(nil, nil, &rpcError{Code: rpcInvalidRequest, Message: "batch requests are not supported"})
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			w.reply(nil, nil, &rpcError{Code: rpcParseError, Message: err.Error()})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			w.reply(req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: `expected "jsonrpc": "2.0" and a method`})
+			continue
+		}
+
+		result, rerr := callRPC(session, w, req)
+		if len(req.ID) > 0 {
+			w.reply(req.ID, result, rerr)
+		}
+		if req.Method == "shutdown" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// callRPC runs a single method. Groups found by "scan" and per-file results
+// of "apply" are streamed as "group" and "action" notifications.
+func callRPC(session *controlSession, w *rpcWriter, req rpcRequest) (interface{}, *rpcError) {
+	switch req.Method {
+	case "ping":
+		return "pong", nil
+
+	case "scan":
+		var params struct {
+			Dir   string            `json:"dir"`
+			Flags map[string]string `json:"flags"` // any CLI flag, applied for this scan only
+		}
+		if rerr := decodeRPCParams(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		summary, err := session.scan(context.Background(), params.Dir, params.Flags, &Events{
+			OnGroupFound: func(i int, group DuplicateGroup) {
+				w.notify("group", groupPayload(i, group, session.keep))
+			},
+		})
+		if err != nil {
+			return nil, &rpcError{Code: rpcServerError, Message: err.Error()}
+		}
+		return summary, nil
+
+	case "groups":
+		return session.groupsWithKeep(), nil
+
+	case "apply":
+		var params struct {
+			Action string   `json:"action"` // "delete" or "move"
+			Paths  []string `json:"paths"`
+			To     string   `json:"to"`
+		}
+		if rerr := decodeRPCParams(req.Params, &params); rerr != nil {
+			return nil, rerr
+		}
+		results := []ActionEvent{}
+		succeeded, failed, err := session.apply(params.Action, params.Paths, params.To, &Events{
+			OnActionTaken: func(result ActionEvent) {
+				results = append(results, result)
+				w.notify("action", result)
+			},
+		})
+		if err != nil {
+			return nil, &rpcError{Code: rpcServerError, Message: err.Error()}
+		}
+		return map[string]interface{}{
+			"succeeded": succeeded,
+			"failed":    failed,
+			"results":   results,
+		}, nil
+
+	case "shutdown":
+		return true, nil
+
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// decodeRPCParams unmarshals by-name params; missing params are allowed
+func decodeRPCParams(raw json.RawMessage, v interface{}) *rpcError {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeRPC(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	a, b := filepath.Join(tmpDir, "a.txt"), filepath.Join(tmpDir, "b.txt")
+	requests := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "scan", "params": {"dir": ` + quote(tmpDir) + `, "flags": {"min-size": "1"}}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "groups"}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "apply", "params": {"action": "delete", "paths": [` + quote(a) + `, ` + quote(b) + `]}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "apply", "params": {"action": "delete", "paths": [` + quote(b) + `]}}`,
+		`{"jsonrpc": "2.0", "method": "ping"}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "nope"}`,
+		`not json`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "shutdown"}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "ping"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	shutdown, err := serveRPC(newControlSession(DefaultConfig()), strings.NewReader(requests), &out)
+	if err != nil {
+		t.Fatalf("serveRPC() error = %v", err)
+	}
+	if !shutdown {
+		t.Error("serveRPC() should report shutdown")
+	}
+
+	// Summarize each line as the method for notifications, "id:ok" or "id:code" for responses
+	var lines []string
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Error  *rpcError       `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("output line is not JSON: %q", scanner.Text())
+		}
+		switch {
+		case msg.Method != "":
+			lines = append(lines, msg.Method)
+		case msg.Error != nil:
+			lines = append(lines, fmt.Sprintf("%s:%d", msg.ID, msg.Error.Code))
+		default:
+			lines = append(lines, string(msg.ID)+":ok")
+		}
+	}
+
+	want := []string{"group", "1:ok", "2:ok", "3:-32000", "action", "4:ok", "5:-32601", "null:-32700", "6:ok"}
+	if strings.Join(lines, ",") != strings.Join(want, ",") {
+		t.Errorf("output = %v, want %v", lines, want)
+	}
+
+	if _, err := os.Stat(b); !os.IsNotExist(err) {
+		t.Error("b.txt should have been deleted")
+	}
+	if _, err := os.Stat(a); err != nil {
+		t.Error("a.txt should still exist")
+	}
+}
+
+func TestListenRPCShutdown(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "dedup.sock")
+
+	done := make(chan error, 1)
+	go func() { done <- listenRPC(newControlSession(DefaultConfig()), socketPath) }()
+
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("unix", socketPath); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "method": "ping"}` + "\n" + `{"jsonrpc": "2.0", "id": 2, "method": "shutdown"}` + "\n"))
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.Contains(line, `"result":"pong"`) {
+		t.Errorf("ping response = %q, %v", line, err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("listenRPC() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listenRPC() did not return after shutdown")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Actions for -integrate-shell
+const (
+	shellInstall = "install" // add "Find duplicates here" to the file manager
+	shellRemove  = "remove"  // take it out again
+)
+
+// shellMenuLabel is the context-menu entry -integrate-shell adds
+const shellMenuLabel = "Find duplicates here"
+
+// checkShellAction validates an -integrate-shell value
+func checkShellAction(action string) error {
+	switch strings.ToLower(action) {
+	case "", shellInstall, shellRemove:
+		return nil
+	}
+	return fmt.Errorf("invalid -integrate-shell %q: expected install or remove", action)
+}
+
+// runIntegrateShell adds or removes the file manager entries that open the
+// TUI on a folder: Explorer's context menu on Windows, a Nautilus script and
+// an "Open With" application elsewhere. The entries run this executable, so
+// install again after moving it.
+func runIntegrateShell(action string) error {
+	if strings.ToLower(action) == shellRemove {
+		removed, err := removeShellIntegration()
+		for _, location := range removed {
+			log.Printf("✓ Removed %s", location)
+		}
+		if err == nil && len(removed) == 0 {
+			log.Printf("%sNo file manager integration to remove", emoji("ℹ️ "))
+		}
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find this executable: %w", err)
+	}
+	installed, err := installShellIntegration(exe)
+	for _, location := range installed {
+		log.Printf("✓ Added %q to %s", shellMenuLabel, location)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("%sRight-click a folder and choose %q to review its duplicates in the TUI", emoji("📂"), shellMenuLabel)
+	return nil
+}
+
+// launchedWithoutTerminal reports whether a -tui run was started by a file
+// manager rather than from a terminal, and needs one of its own
+func launchedWithoutTerminal(c Config) bool {
+	return c.TUI && os.Getenv("_DEDUP_SPAWNED") != "1" && isDoubleClick()
+}
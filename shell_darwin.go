@@ -0,0 +1,15 @@
+package main
+
+import "errors"
+
+// errNoFinderIntegration is returned on macOS, where Finder's Quick Actions
+// are made with Automator rather than by writing a file
+var errNoFinderIntegration = errors.New("-integrate-shell supports Windows Explorer and XDG file managers; on macOS add a Quick Action in Automator that runs file-deduplicator -tui -dir on the folder")
+
+func installShellIntegration(exe string) ([]string, error) {
+	return nil, errNoFinderIntegration
+}
+
+func removeShellIntegration() ([]string, error) {
+	return nil, errNoFinderIntegration
+}
@@ -0,0 +1,76 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// shellKeys are the per-user registry keys of the Explorer context-menu
+// entries: one on folders, and one on the background of an open folder
+var shellKeys = []struct {
+	key string
+	arg string // how Explorer passes the folder
+}{
+	{`Software\Classes\Directory\shell\FileDeduplicator`, "%1"},
+	{`Software\Classes\Directory\Background\shell\FileDeduplicator`, "%V"},
+}
+
+// installShellIntegration adds "Find duplicates here" to Explorer's context
+// menu for the current user, so no administrator rights are needed. It
+// returns the registry keys written.
+func installShellIntegration(exe string) ([]string, error) {
+	var written []string
+	for _, entry := range shellKeys {
+		key, _, err := registry.CreateKey(registry.CURRENT_USER, entry.key, registry.SET_VALUE)
+		if err != nil {
+			return written, fmt.Errorf("cannot create HKCU\\%s: %w", entry.key, err)
+		}
+		err = key.SetStringValue("", shellMenuLabel)
+		if err == nil {
+			err = key.SetStringValue("Icon", exe)
+		}
+		key.Close()
+		if err != nil {
+			return written, fmt.Errorf("cannot write HKCU\\%s: %w", entry.key, err)
+		}
+
+		command, _, err := registry.CreateKey(registry.CURRENT_USER, entry.key+`\command`, registry.SET_VALUE)
+		if err != nil {
+			return written, fmt.Errorf("cannot create HKCU\\%s\\command: %w", entry.key, err)
+		}
+		err = command.SetStringValue("", fmt.Sprintf(`"%s" -tui -dir "%s"`, exe, entry.arg))
+		command.Close()
+		if err != nil {
+			return written, fmt.Errorf("cannot write HKCU\\%s\\command: %w", entry.key, err)
+		}
+		written = append(written, `HKCU\`+entry.key)
+	}
+	return written, nil
+}
+
+// removeShellIntegration deletes the keys installShellIntegration wrote and
+// returns those that existed
+func removeShellIntegration() ([]string, error) {
+	var removed []string
+	for _, entry := range shellKeys {
+		for _, key := range []string{entry.key + `\command`, entry.key} {
+			err := registry.DeleteKey(registry.CURRENT_USER, key)
+			if errors.Is(err, syscall.ERROR_FILE_NOT_FOUND) {
+				continue
+			}
+			if err != nil {
+				return removed, fmt.Errorf("cannot delete HKCU\\%s: %w", key, err)
+			}
+			if key == entry.key {
+				removed = append(removed, `HKCU\`+key)
+			}
+		}
+	}
+	return removed, nil
+}
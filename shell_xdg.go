@@ -0,0 +1,103 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shellFiles returns where the Nautilus script and the desktop entry go,
+// under $XDG_DATA_HOME (default ~/.local/share)
+func shellFiles() (string, string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "nautilus", "scripts", shellMenuLabel),
+		filepath.Join(data, "applications", "file-deduplicator-here.desktop"), nil
+}
+
+// installShellIntegration writes a Nautilus script, listed under Scripts in
+// the context menu, and a desktop entry that offers the TUI in "Open With"
+// for folders in Nautilus, Nemo, Thunar, Dolphin and other XDG file
+// managers. It returns the files written.
+func installShellIntegration(exe string) ([]string, error) {
+	script, desktop, err := shellFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Nautilus runs scripts without a terminal; -tui then opens one
+	scriptBody := fmt.Sprintf(`#!/bin/sh
+# Added by file-deduplicator -integrate-shell install
+dir="${1:-$PWD}"
+exec %s -tui -dir "$dir"
+`, shellQuote(exe))
+	desktopBody := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=%s
+Comment=Review duplicate files in this folder with file-deduplicator
+Exec=%s -tui -dir %%f
+Terminal=true
+MimeType=inode/directory;
+NoDisplay=true
+`, shellMenuLabel, desktopQuote(exe))
+
+	var written []string
+	for _, file := range []struct {
+		path string
+		body string
+		mode os.FileMode
+	}{
+		{script, scriptBody, 0755},
+		{desktop, desktopBody, 0644},
+	} {
+		if err := os.MkdirAll(filepath.Dir(file.path), 0755); err != nil {
+			return written, err
+		}
+		if err := writeFileAtomic(file.path, []byte(file.body), file.mode); err != nil {
+			return written, err
+		}
+		if err := os.Chmod(file.path, file.mode); err != nil {
+			return written, err
+		}
+		written = append(written, file.path)
+	}
+	return written, nil
+}
+
+// removeShellIntegration deletes the files installShellIntegration wrote and
+// returns those that existed
+func removeShellIntegration() ([]string, error) {
+	script, desktop, err := shellFiles()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, path := range []string{script, desktop} {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// desktopQuote quotes s for the Exec key of a desktop entry
+func desktopQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\\\`, `"`, `\\"`, "`", "\\\\`", "$", `\\$`, "%", "%%")
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,60 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellIntegrationXDG(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+
+	exe := "/opt/dedup tools/file-deduplicator"
+	written, err := installShellIntegration(exe)
+	if err != nil || len(written) != 2 {
+		t.Fatalf("installShellIntegration() = %v, %v", written, err)
+	}
+
+	script := filepath.Join(data, "nautilus", "scripts", shellMenuLabel)
+	info, err := os.Stat(script)
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("Nautilus script missing or not executable: %v", err)
+	}
+	body, _ := os.ReadFile(script)
+	if !strings.Contains(string(body), `exec '/opt/dedup tools/file-deduplicator' -tui -dir "$dir"`) {
+		t.Errorf("script does not launch the TUI:\n%s", body)
+	}
+
+	desktop, _ := os.ReadFile(filepath.Join(data, "applications", "file-deduplicator-here.desktop"))
+	if !strings.Contains(string(desktop), `Exec="/opt/dedup tools/file-deduplicator" -tui -dir %f`) ||
+		!strings.Contains(string(desktop), "MimeType=inode/directory;") {
+		t.Errorf("desktop entry does not open folders in the TUI:\n%s", desktop)
+	}
+
+	// Installing again overwrites, removing takes both files away once
+	if _, err := installShellIntegration(exe); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := removeShellIntegration(); err != nil || len(removed) != 2 {
+		t.Errorf("removeShellIntegration() = %v, %v", removed, err)
+	}
+	if removed, err := removeShellIntegration(); err != nil || len(removed) != 0 {
+		t.Errorf("second removeShellIntegration() = %v, %v", removed, err)
+	}
+}
+
+func TestCheckShellAction(t *testing.T) {
+	for _, action := range []string{"", "install", "Remove"} {
+		if err := checkShellAction(action); err != nil {
+			t.Errorf("checkShellAction(%q) = %v", action, err)
+		}
+	}
+	if err := checkShellAction("add"); err == nil {
+		t.Error("checkShellAction(\"add\") should fail")
+	}
+}
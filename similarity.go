@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// similarityFlag is -similarity: a Hamming distance such as 10, or a
+// percentage such as 92% that maxDistance converts for the hash in use.
+// Setting one clears the other.
+type similarityFlag struct {
+	distance *int
+	percent  *float64
+}
+
+func (s similarityFlag) String() string {
+	if s.percent == nil || s.distance == nil {
+		return ""
+	}
+	if *s.percent > 0 {
+		return strconv.FormatFloat(*s.percent, 'f', -1, 64) + "%"
+	}
+	return strconv.Itoa(*s.distance)
+}
+
+func (s similarityFlag) Set(value string) error {
+	value = strings.TrimSpace(value)
+	if number, ok := strings.CutSuffix(value, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return fmt.Errorf("invalid similarity %q: want a percentage above 0%% and up to 100%%, e.g. 92%%", value)
+		}
+		*s.percent = percent
+		return nil
+	}
+	distance, err := strconv.Atoi(value)
+	if err != nil || distance < 0 {
+		return fmt.Errorf("invalid similarity %q: want a distance such as 10 or a percentage such as 92%%", value)
+	}
+	*s.distance = distance
+	*s.percent = 0
+	return nil
+}
+
+// perceptualHashBits is the length of the hashes algorithm produces. Every
+// algorithm so far hashes an 8x8 grid, and animations and videos combine
+// their frames into a hash of the same length.
+func perceptualHashBits(algorithm string) int {
+	return 64
+}
+
+// maxDistance is the largest Hamming distance between two perceptual hashes
+// that still counts as similar: -similarity as given, or its percentage of
+// the hash length rounded down, so 92% of a 64-bit hash allows 5 bits
+func (c Config) maxDistance() int {
+	if c.SimilarityPercent <= 0 {
+		return c.SimilarityThreshold
+	}
+	bits := perceptualHashBits(c.PHashAlgorithm)
+	// The epsilon keeps 75% of 64 at 16 despite float rounding
+	return int(math.Floor(float64(bits)*(100-c.SimilarityPercent)/100 + 1e-9))
+}
+
+// thresholdLabel describes the similarity threshold for logs
+func (c Config) thresholdLabel() string {
+	if c.SimilarityPercent <= 0 {
+		return strconv.Itoa(c.SimilarityThreshold)
+	}
+	return fmt.Sprintf("%s%% = distance %d", strconv.FormatFloat(c.SimilarityPercent, 'f', -1, 64), c.maxDistance())
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestSimilarityFlag(t *testing.T) {
+	c := DefaultConfig()
+	if c.maxDistance() != 10 {
+		t.Errorf("default maxDistance() = %d, want 10", c.maxDistance())
+	}
+
+	for _, tc := range []struct {
+		value string
+		want  int
+	}{
+		{"92%", 5},
+		{"75%", 16},
+		{"100%", 0},
+		{"12", 12},
+		{" 90 % ", 6},
+	} {
+		c := DefaultConfig()
+		if err := (similarityFlag{&c.SimilarityThreshold, &c.SimilarityPercent}).Set(tc.value); err != nil {
+			t.Errorf("Set(%q) = %v", tc.value, err)
+			continue
+		}
+		if got := c.maxDistance(); got != tc.want {
+			t.Errorf("Set(%q): maxDistance() = %d, want %d", tc.value, got, tc.want)
+		}
+	}
+
+	// A distance after a percentage wins
+	c, err := configWithFlags(DefaultConfig(), map[string]string{"similarity": "92%"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err = configWithFlags(c, map[string]string{"similarity": "8"}); err != nil || c.maxDistance() != 8 {
+		t.Errorf("maxDistance() = %d, %v; want 8", c.maxDistance(), err)
+	}
+
+	for _, bad := range []string{"0%", "101%", "-1", "close", "%"} {
+		c := DefaultConfig()
+		if err := (similarityFlag{&c.SimilarityThreshold, &c.SimilarityPercent}).Set(bad); err == nil {
+			t.Errorf("Set(%q) should fail", bad)
+		}
+	}
+}
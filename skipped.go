@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/luinbytes/file-deduplicator/pkg/dedup"
+)
+
+// maxSkippedListed is how many paths the summary lists per category
+const maxSkippedListed = 5
+
+// SkippedFile is a file the scan could not read, with why
+type SkippedFile struct {
+	Path     string `json:"path"`
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+}
+
+// skipLog collects skipped files from every scanning and hashing goroutine
+// so they can be summarized once the run is over. Its methods do nothing on
+// a nil log.
+type skipLog struct {
+	mu    sync.Mutex
+	files []SkippedFile
+}
+
+// skipCategory sorts a read error, or the error of a file that could not be
+// deleted, moved or linked, into one of the summary's categories
+func skipCategory(err error) string {
+	err = dedup.Classify(err)
+	switch {
+	case errors.Is(err, errFileLocked):
+		return "in use by another program"
+	case errors.Is(err, errReadOnly):
+		return "read-only"
+	case errors.Is(err, errReferenceReadOnly):
+		return "on read-only reference media"
+	case errors.Is(err, errChangedSinceScan):
+		return "changed since the scan"
+	case errors.Is(err, dedup.ErrPermission):
+		return "permission denied"
+	case errors.Is(err, fs.ErrNotExist):
+		return "vanished during scan"
+	case errors.Is(err, dedup.ErrTransientIO):
+		return "timed out or busy"
+	case errors.Is(err, dedup.ErrTooManyOpenFiles):
+		return "too many open files"
+	case errors.Is(err, dedup.ErrUnsupportedImage):
+		return "unsupported image format"
+	case errors.Is(err, syscall.EIO):
+		return "I/O error"
+	case errors.Is(err, syscall.EISDIR):
+		return "not a regular file"
+	default:
+		return "unreadable"
+	}
+}
+
+// add records that path was skipped because of err
+func (s *skipLog) add(path string, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = append(s.files, SkippedFile{Path: path, Category: skipCategory(err), Reason: err.Error()})
+}
+
+// list returns the skipped files ordered by category, then path
+func (s *skipLog) list() []SkippedFile {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files := append([]SkippedFile(nil), s.files...)
+	sortByCategory(files)
+	return files
+}
+
+// sortByCategory orders files by category, then path
+func sortByCategory(files []SkippedFile) {
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Category != files[j].Category {
+			return files[i].Category < files[j].Category
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+// Skipped returns the files the engine's scans could not read
+func (e *Engine) Skipped() []SkippedFile {
+	return e.skipped.list()
+}
+
+// printSkippedSummary logs how many files were skipped for each reason,
+// with the first few paths of each
+func printSkippedSummary(skipped []SkippedFile) {
+	if len(skipped) == 0 {
+		return
+	}
+	log.Printf("%sSkipped %d unreadable files:", emoji("⚠️"), len(skipped))
+	logByCategory(skipped)
+}
+
+// logByCategory logs the count and first few paths of each category of
+// files sorted by sortByCategory
+func logByCategory(files []SkippedFile) {
+	for start := 0; start < len(files); {
+		end := start
+		for end < len(files) && files[end].Category == files[start].Category {
+			end++
+		}
+		log.Printf("  %s: %d", files[start].Category, end-start)
+		for i := start; i < end && i < start+maxSkippedListed; i++ {
+			log.Printf("    %s", files[i].Path)
+		}
+		if end-start > maxSkippedListed {
+			log.Printf("    ... and %d more", end-start-maxSkippedListed)
+		}
+		start = end
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func TestSkipCategory(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&os.PathError{Op: "open", Path: "x", Err: os.ErrPermission}, "permission denied"},
+		{fmt.Errorf("wrapped: %w", os.ErrNotExist), "vanished during scan"},
+		{&os.PathError{Op: "read", Path: "x", Err: syscall.EIO}, "I/O error"},
+		{&os.PathError{Op: "read", Path: "x", Err: syscall.EISDIR}, "not a regular file"},
+		{&os.PathError{Op: "read", Path: "x", Err: syscall.EAGAIN}, "timed out or busy"},
+		{fmt.Errorf("decode x: %w", image.ErrFormat), "unsupported image format"},
+		{errors.New("something else"), "unreadable"},
+	}
+	for _, tt := range tests {
+		if got := skipCategory(tt.err); got != tt.want {
+			t.Errorf("skipCategory(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSkippedFilesCollected(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping permission test on Windows")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "locked.txt", "sub/b.txt"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	locked := filepath.Join(dir, "locked.txt")
+	sub := filepath.Join(dir, "sub")
+	os.Chmod(locked, 0000)
+	os.Chmod(sub, 0000)
+	defer os.Chmod(locked, 0644)
+	defer os.Chmod(sub, 0755)
+	if f, err := os.Open(locked); err == nil {
+		f.Close()
+		t.Skip("running with permissions that ignore file modes")
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.Checkpoint = ""
+	c.JSON = true
+	engine := NewEngine(c, nil)
+	if _, err := engine.collectFiles(context.Background()); err != nil {
+		t.Fatalf("collectFiles() error = %v", err)
+	}
+
+	skipped := engine.Skipped()
+	if len(skipped) != 2 {
+		t.Fatalf("Skipped() = %+v, want the locked file and directory", skipped)
+	}
+	for _, s := range skipped {
+		if s.Category != "permission denied" || s.Reason == "" {
+			t.Errorf("skipped %+v, want a permission denied reason", s)
+		}
+	}
+	if skipped[0].Path != locked || skipped[1].Path != sub {
+		t.Errorf("Skipped() not ordered by path: %+v", skipped)
+	}
+	printSkippedSummary(skipped)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// errSocketInUse means another running process serves a unix socket
+var errSocketInUse = errors.New("served by another running process")
+
+// wsaConnRefused is what Windows reports for a socket no process listens on
+const wsaConnRefused = syscall.Errno(10061)
+
+// listenUnix listens on the unix socket at path. A socket left behind by a
+// run that is gone is replaced, but only once dialing it is refused: one
+// another process still answers on is not taken over, and neither is
+// anything that is not a socket.
+func listenUnix(path string) (net.Listener, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s is %w", path, errSocketInUse)
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, wsaConnRefused) {
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on %s: %w", path, err)
+	}
+	return ln, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.sock")
+	ln, err := listenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// A live socket is not taken over
+	if _, err := listenUnix(path); !errors.Is(err, errSocketInUse) {
+		t.Errorf("listening on a served socket = %v, want errSocketInUse", err)
+	}
+
+	// A stale one left by a run that is gone is
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+	if _, err := os.Lstat(path); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("listening on a stale socket = %v", err)
+	}
+	stale.Close()
+
+	// Anything else is left alone
+	os.WriteFile(path, []byte("not a socket"), 0644)
+	if _, err := listenUnix(path); err == nil {
+		t.Error("listening over a regular file should fail")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "not a socket" {
+		t.Error("the regular file was replaced")
+	}
+}
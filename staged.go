@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// partialHashSize is how much of each end of a file -partial-hash compares
+const partialHashSize = 4 << 10
+
+// stagedHashing reports whether collectHashes may rule files out before
+// hashing them in full: only the files sharing a hash with another file are
+// wanted, and -check-integrity does not need every file's hash
+func (e *Engine) stagedHashing(candidatesOnly bool) bool {
+	return candidatesOnly && e.cfg.PartialHash && !e.cfg.CheckIntegrity
+}
+
+// stagedCandidates narrows files, with their sizes from the scan, down to
+// those that can still have a duplicate, and returns them with their sizes.
+// Files are compared by size, then by a hash of their first partialHashSize
+// bytes, then of their last, and each stage only reads the files the
+// previous one left colliding. A file that cannot be read stays a
+// candidate, so hashing reports it as usual.
+func (e *Engine) stagedCandidates(ctx context.Context, files []string, sizes []int64) ([]string, []int64, error) {
+	candidates, candidateSizes, bySize, err := e.narrowCandidates(ctx, files, sizes)
+	if err != nil {
+		return nil, nil, err
+	}
+	e.logPartialHashes(bySize, len(candidates))
+	return candidates, candidateSizes, nil
+}
+
+// logPartialHashes reports how many files -partial-hash left to hash in full
+func (e *Engine) logPartialHashes(bySize, candidates int) {
+	if !e.cfg.JSON {
+		log.Printf("%sPartial hashes: %d files share a size, %d still match at both ends", emoji("🔎"), bySize, candidates)
+	}
+}
+
+// narrowCandidates is stagedCandidates without the log line. It also
+// returns how many of files share a size.
+func (e *Engine) narrowCandidates(ctx context.Context, files []string, sizes []int64) ([]string, []int64, int, error) {
+	keys := make([]string, len(files))
+	all := make([]int, len(files))
+	for i := range files {
+		keys[i] = fmt.Sprint(sizes[i])
+		all[i] = i
+	}
+	alive := colliding(all, keys)
+	bySize := len(alive)
+
+	if err := e.partialHashes(ctx, files, alive, func(int) int64 { return 0 }, keys); err != nil {
+		return nil, nil, 0, err
+	}
+	alive = colliding(alive, keys)
+
+	// Files no larger than one read were compared whole by their head
+	var large []int
+	for _, i := range alive {
+		if sizes[i] > partialHashSize {
+			large = append(large, i)
+		}
+	}
+	tail := func(i int) int64 { return sizes[i] - partialHashSize }
+	if err := e.partialHashes(ctx, files, large, tail, keys); err != nil {
+		return nil, nil, 0, err
+	}
+	alive = colliding(alive, keys)
+
+	candidates := make([]string, len(alive))
+	candidateSizes := make([]int64, len(alive))
+	for n, i := range alive {
+		candidates[n], candidateSizes[n] = files[i], sizes[i]
+	}
+	return candidates, candidateSizes, bySize, nil
+}
+
+// colliding keeps the indices whose key another index shares, in order.
+// An empty key, a file that could not be read, always stays.
+func colliding(indices []int, keys []string) []int {
+	count := make(map[string]int)
+	for _, i := range indices {
+		count[keys[i]]++
+	}
+	var kept []int
+	for _, i := range indices {
+		if keys[i] == "" || count[keys[i]] > 1 {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// partialHashes extends keys[i] of every index with a hash of the
+// partialHashSize bytes of files[i] at offset(i), using -workers readers.
+// A file that cannot be read gets an empty key.
+func (e *Engine) partialHashes(ctx context.Context, files []string, indices []int, offset func(i int) int64, keys []string) error {
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(e.cfg.Workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if keys[i] == "" {
+					continue
+				}
+				sum, err := partialHash(files[i], offset(i), e.cfg.HashAlgorithm)
+				if err != nil {
+					keys[i] = ""
+					continue
+				}
+				keys[i] += ":" + sum
+			}
+		}()
+	}
+	var err error
+	for _, i := range indices {
+		if err = waitIfPaused(ctx); err != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return err
+}
+
+// partialHash hashes up to partialHashSize bytes of path from offset
+func partialHash(path string, offset int64, algorithm string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, partialHashSize)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	hasher := getHasher(algorithm)
+	hasher.Write(buf[:n])
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestStagedCandidates(t *testing.T) {
+	dir := t.TempDir()
+	size := 3 * partialHashSize
+	base := bytes.Repeat([]byte("x"), size)
+	variant := func(at int) []byte {
+		b := bytes.Clone(base)
+		b[at] = 'y'
+		return b
+	}
+	files := map[string][]byte{
+		"same1":  base,
+		"same2":  base,
+		"head":   variant(0),
+		"tail":   variant(size - 1),
+		"middle": variant(size / 2), // only a full hash tells it apart
+		"other":  base[:size-1],
+		"small1": []byte("tiny"),
+		"small2": []byte("tiny"),
+		"small3": []byte("tinz"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.JSON = true
+	c.Checkpoint = ""
+	var hashed []string
+	engine := NewEngine(c, &Events{OnFileHashed: func(fh FileHash) { hashed = append(hashed, filepath.Base(fh.Path)) }})
+	duplicates, err := engine.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(hashed)
+	if got, want := hashed, []string{"middle", "same1", "same2", "small1", "small2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hashed in full %v, want %v", got, want)
+	}
+	if len(duplicates) != 2 {
+		t.Fatalf("got %d groups, want 2: %+v", len(duplicates), duplicates)
+	}
+	for _, group := range duplicates {
+		if len(group.Files) != 2 {
+			t.Errorf("group of %d bytes has %d files, want 2", group.Size, len(group.Files))
+		}
+	}
+
+	// An unreadable file is left for the full hash to report
+	missing := filepath.Join(dir, "gone")
+	candidates, _, err := engine.stagedCandidates(context.Background(),
+		[]string{filepath.Join(dir, "same1"), filepath.Join(dir, "same2"), missing}, []int64{int64(size), int64(size), int64(size)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 3 {
+		t.Errorf("candidates = %v, want all three files", candidates)
+	}
+}
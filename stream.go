@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/luinbytes/file-deduplicator/tui"
+)
+
+// streamDuplicates is collectDuplicates for exact matching that hands each
+// group to found as soon as it is confirmed, which is when the last file of
+// its size has been hashed. Groups come in that order, and the ones already
+// passed to found are returned also when hashing stops early.
+func (e *Engine) streamDuplicates(ctx context.Context, found func(DuplicateGroup)) ([]DuplicateGroup, error) {
+	if !e.streamable() {
+		return nil, errors.New("only exact matching without -known-db, -import-index or -remote can stream its groups")
+	}
+	var err error
+	if e.ignore, err = loadIgnoreList(e.cfg.ignoreListPath()); err != nil {
+		return nil, err
+	}
+	tags, err := e.loadTags()
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []DuplicateGroup
+	e.onGroup = func(files []FileHash) {
+		groups := []DuplicateGroup{{
+			Hash:       files[0].Hash,
+			Size:       files[0].Size,
+			Files:      files,
+			Similarity: 100.0, // Exact match
+		}}
+		groups, _ = e.ignore.filter(groups)
+		tags.apply(groups)
+		if e.cfg.Tagged != "" {
+			groups = withTag(groups, e.cfg.Tagged)
+		}
+		annotateNames(groups)
+		for _, group := range groups {
+			e.events.groupFound(len(duplicates), group)
+			duplicates = append(duplicates, group)
+			found(group)
+		}
+	}
+	defer func() { e.onGroup = nil }()
+
+	_, err = e.collectHashes(ctx, true)
+	return duplicates, err
+}
+
+// runStreamingReview opens the TUI review while hashing is still running
+// and adds each exact duplicate group once it is confirmed, so the first
+// groups of a huge scan can be acted on long before it ends. Finishing the
+// review early stops hashing; with -checkpoint the next run resumes it.
+func (e *Engine) runStreamingReview(ctx context.Context) error {
+	scanCtx, stopScan := context.WithCancel(ctx)
+	defer stopScan()
+
+	// Log lines would tear the review screen; they are shown once it closes
+	out := log.Writer()
+	var held bytes.Buffer
+	log.SetOutput(&held)
+
+	quiet := e.cfg
+	quiet.JSON = true
+	quiet.Verbose = false
+	var scanner *Engine
+	var duplicates []DuplicateGroup
+	var scanErr error
+	scanned := make(chan struct{})
+	review, err := tui.RunStreamingReview(func(stream *tui.ReviewStream) {
+		defer close(scanned)
+		hashed, lastUpdate := 0, time.Now()
+		scanner = NewEngine(quiet, &Events{OnFileHashed: func(FileHash) {
+			hashed++
+			if time.Since(lastUpdate) > progressUpdateInterval {
+				lastUpdate = time.Now()
+				stream.Progress(hashed)
+			}
+		}})
+		duplicates, scanErr = scanner.streamDuplicates(scanCtx, func(group DuplicateGroup) {
+			stream.Add(tuiGroup(group))
+		})
+		stream.Progress(hashed)
+		stream.Done(scanErr)
+	})
+
+	// Groups found after the review ended were never shown
+	stopScan()
+	<-scanned
+	log.SetOutput(out)
+	out.Write(held.Bytes())
+	if err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+
+	switch {
+	case review.Partial:
+		log.Printf("%sThe review ended while hashing was still running; %d groups were shown. Run again to review the rest", emoji("⚠️"), review.Groups)
+	case scanErr != nil:
+		log.Printf("%sHashing stopped early: %v", emoji("⚠️"), scanErr)
+	default:
+		log.Printf("👯 Found %d duplicate groups", len(duplicates))
+	}
+	printSkippedSummary(scanner.Skipped())
+	return e.applyReview(duplicates[:review.Groups], review)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStreamDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a1.txt":     "first pair",
+		"a2.txt":     "first pair",
+		"b1.txt":     "second, longer pair",
+		"b2.txt":     "second, longer pair",
+		"b3.txt":     "second, longer pair",
+		"ignored1":   "a pair marked not a duplicate",
+		"ignored2":   "a pair marked not a duplicate",
+		"unique.txt": "nothing else is like this",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, _, _, err := hashFile(filepath.Join(dir, "ignored1"), getHasher("sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.Checkpoint = ""
+	c.JSON = true
+	c.TagFile = ignoreOff
+	c.IgnoreList = filepath.Join(dir, "ignored.json")
+	list, _ := loadIgnoreList(c.IgnoreList)
+	list.add(DuplicateGroup{Hash: hash, Similarity: 100})
+	if err := list.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var found []DuplicateGroup
+	groups, err := NewEngine(c, nil).streamDuplicates(context.Background(), func(group DuplicateGroup) {
+		found = append(found, group)
+	})
+	if err != nil {
+		t.Fatalf("streamDuplicates() error = %v", err)
+	}
+	if len(found) != 2 || len(groups) != 2 {
+		t.Fatalf("found %d groups and returned %d, want the 2 groups not ignored", len(found), len(groups))
+	}
+	for i, group := range groups {
+		if group.Hash != found[i].Hash || group.Hash == hash || group.Similarity != 100 {
+			t.Errorf("group %d = %+v, want the groups in the order they were found", i, group)
+		}
+	}
+
+	c.PerceptualMode = true
+	if _, err := NewEngine(c, nil).streamDuplicates(context.Background(), func(DuplicateGroup) {}); err == nil {
+		t.Error("perceptual scans cannot stream their groups")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// runSummary is the single line -summary-json prints on stdout when a scan
+// ends, whatever else was printed, so cron wrappers have one object to parse
+type runSummary struct {
+	FilesScanned     int            `json:"files_scanned"`
+	FilesHashed      int            `json:"files_hashed"`
+	Groups           int            `json:"groups"`
+	DuplicateFiles   int            `json:"duplicate_files"`
+	BytesRecoverable int64          `json:"bytes_recoverable"`
+	DryRun           bool           `json:"dry_run"`
+	Actions          map[string]int `json:"actions"` // files "deleted", "trashed", "moved" or "linked"
+	BytesFreed       int64          `json:"bytes_freed"`
+	BytesStaged      int64          `json:"bytes_staged"`      // moved aside, freed only once purged
+	Skipped          int            `json:"skipped"`           // files the scan could not read
+	Failed           int            `json:"failed"`            // duplicates that could not be acted on
+	Errors           map[string]int `json:"errors,omitempty"`  // skipped and failed files by reason
+	Error            string         `json:"error,omitempty"`   // why the run stopped, if it did
+	Partial          string         `json:"partial,omitempty"` // where -max-files or -max-bytes stopped the scan
+	Seconds          float64        `json:"seconds"`
+
+	start time.Time
+	out   io.Writer // stdout
+}
+
+// newRunSummary starts a summary, or returns nil without -summary-json.
+// Its methods do nothing on a nil summary.
+func newRunSummary(c Config) *runSummary {
+	if !c.SummaryJSON {
+		return nil
+	}
+	return &runSummary{DryRun: c.DryRun, Actions: make(map[string]int), start: time.Now(), out: os.Stdout}
+}
+
+// events returns hooks counting the files hashed and acted on
+func (s *runSummary) events() *Events {
+	if s == nil {
+		return nil
+	}
+	return &Events{
+		OnFileHashed: func(FileHash) { s.FilesHashed++ },
+		OnActionTaken: func(action ActionEvent) {
+			if action.Error == "" && action.Action != "" {
+				s.Actions[action.Action]++
+				if stagedActions[action.Action] {
+					s.BytesStaged += action.Size
+				} else {
+					s.BytesFreed += action.Size
+				}
+			}
+		},
+	}
+}
+
+// addGroups counts the groups found and the space their copies take up,
+// as the report does
+func (s *runSummary) addGroups(duplicates []DuplicateGroup, c Config) {
+	if s == nil {
+		return
+	}
+	s.Groups = len(duplicates)
+	for _, group := range duplicates {
+		keepIdx := selectFileToKeep(group, c.KeepCriteria)
+		for j, fh := range group.Files {
+			if j != keepIdx && fh.Host == "" {
+				s.DuplicateFiles++
+				s.BytesRecoverable += group.Size
+			}
+		}
+	}
+}
+
+// print completes the summary from e and prints it. err is what stopped the
+// run, if anything did.
+func (s *runSummary) print(e *Engine, err error) {
+	if s == nil {
+		return
+	}
+	s.FilesScanned = e.scanned
+	s.Partial = e.partial
+	skipped := e.Skipped()
+	s.Skipped, s.Failed = len(skipped), len(e.failed)
+	for _, f := range append(skipped, e.failed...) {
+		if s.Errors == nil {
+			s.Errors = make(map[string]int)
+		}
+		s.Errors[f.Category]++
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.Seconds = math.Round(time.Since(s.start).Seconds()*1000) / 1000
+
+	data, jsonErr := json.Marshal(s)
+	if jsonErr != nil {
+		data = []byte(fmt.Sprintf(`{"error":%q}`, jsonErr.Error()))
+	}
+	fmt.Fprintln(s.out, string(data))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSummary(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a1", "a2", "a3"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("alpha"), 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "unique"), []byte("bravo!"), 0644)
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.MinSize = 1
+	c.JSON = true
+	c.Checkpoint = ""
+	c.MoveTo = filepath.Join(t.TempDir(), "moved")
+	c.SummaryJSON = true
+
+	var out bytes.Buffer
+	summary := newRunSummary(c)
+	summary.out = &out
+	engine := NewEngine(c, summary.events())
+	duplicates, err := engine.collectDuplicates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary.addGroups(duplicates, c)
+	if err := engine.processDuplicates(context.Background(), duplicates); err != nil {
+		t.Fatal(err)
+	}
+	summary.print(engine, nil)
+
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("summary is not one line: %q", out.String())
+	}
+	var got runSummary
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.FilesScanned != 4 || got.FilesHashed != 3 || got.Groups != 1 || got.DuplicateFiles != 2 || got.BytesRecoverable != 10 {
+		t.Errorf("scan counts = %+v", got)
+	}
+	if got.Actions["moved"] != 2 || got.BytesFreed != 0 || got.BytesStaged != 10 || got.Failed != 0 || got.Error != "" {
+		t.Errorf("action counts = %+v", got)
+	}
+
+	// Without -summary-json nothing is counted or printed
+	c.SummaryJSON = false
+	none := newRunSummary(c)
+	if none != nil || none.events() != nil {
+		t.Error("a summary was started without -summary-json")
+	}
+	none.print(engine, nil)
+}
+
+func TestRunSummaryStaged(t *testing.T) {
+	s := &runSummary{Actions: make(map[string]int)}
+	events := s.events()
+	for _, action := range []string{"deleted", "linked", "moved", "trashed", "quarantined"} {
+		events.OnActionTaken(ActionEvent{Path: action, Size: 100, Action: action})
+	}
+	events.OnActionTaken(ActionEvent{Path: "failed", Size: 100, Action: "trashed", Error: "in use"})
+	if s.BytesFreed != 200 || s.BytesStaged != 300 {
+		t.Errorf("freed %d, staged %d; want deleted and linked files freed, moved, trashed and quarantined staged", s.BytesFreed, s.BytesStaged)
+	}
+}
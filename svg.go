@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// editorNamespaces are XML namespaces design tools add to SVGs they save.
+// Elements and attributes in them never affect how the image renders.
+var editorNamespaces = map[string]bool{
+	"http://www.inkscape.org/namespaces/inkscape":        true,
+	"http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd": true,
+	"http://www.bohemiancoding.com/sketch/ns":            true,
+	"http://ns.adobe.com/AdobeIllustrator/10.0/":         true,
+	"http://ns.adobe.com/AdobeSVGViewerExtensions/3.0/":  true,
+	"http://ns.adobe.com/Extensibility/1.0/":             true,
+	"http://ns.adobe.com/Graphs/1.0/":                    true,
+	"http://ns.adobe.com/SaveForWeb/1.0/":                true,
+	"http://ns.adobe.com/Variables/1.0/":                 true,
+	"http://ns.adobe.com/xap/1.0/":                       true,
+	"http://purl.org/dc/elements/1.1/":                   true,
+	"http://creativecommons.org/ns#":                     true,
+	"http://www.w3.org/1999/02/22-rdf-syntax-ns#":        true,
+	"http://www.serif.com/":                              true,
+	"http://www.figma.com/figma/ns":                      true,
+}
+
+// isSVGFile checks if a file is an SVG that -normalize-svg applies to
+func isSVGFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".svg"
+}
+
+// normalizedSVGHash hashes an SVG's markup rather than its bytes: comments,
+// processing instructions, the doctype, indentation, <metadata>, editor
+// namespaces and attribute order are all ignored, so re-exports of the same
+// drawing hash alike.
+func normalizedSVGHash(path string, hasher hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if err := writeNormalizedSVG(f, hasher); err != nil {
+		return "", fmt.Errorf("not a valid SVG: %w", err)
+	}
+	return fmt.Sprintf("svg:%x", hasher.Sum(nil)), nil
+}
+
+// writeNormalizedSVG writes a canonical form of the SVG read from r to w
+func writeNormalizedSVG(r io.Reader, w io.Writer) error {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	skip := 0 // depth inside an element being dropped
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 || editorNamespaces[t.Name.Space] || t.Name.Local == "metadata" {
+				skip++
+				continue
+			}
+			var attrs []string
+			for _, a := range t.Attr {
+				if editorNamespaces[a.Name.Space] || a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					continue
+				}
+				attrs = append(attrs, fmt.Sprintf("%s:%s=%q", a.Name.Space, a.Name.Local, strings.Join(strings.Fields(a.Value), " ")))
+			}
+			sort.Strings(attrs)
+			fmt.Fprintf(w, "<%s:%s %s>", t.Name.Space, t.Name.Local, strings.Join(attrs, " "))
+
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			fmt.Fprintf(w, "</%s:%s>", t.Name.Space, t.Name.Local)
+
+		case xml.CharData:
+			if text := strings.Join(strings.Fields(string(t)), " "); skip == 0 && text != "" {
+				fmt.Fprintf(w, "%q", text)
+			}
+		}
+		// Comments, processing instructions and directives are dropped
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const plainSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><path d="M0 0 L10 10" fill="red"/></svg>`
+
+const inkscapeSVG = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<!-- Created with Inkscape (http://www.inkscape.org/) -->
+<svg
+   height="10"
+   width="10"
+   xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape"
+   xmlns:sodipodi="http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd"
+   xmlns="http://www.w3.org/2000/svg"
+   inkscape:version="1.3"
+   sodipodi:docname="icon.svg">
+  <sodipodi:namedview id="namedview1" inkscape:zoom="8" />
+  <metadata><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"/></metadata>
+  <path
+     fill="red"
+     d="M0 0   L10 10"
+     inkscape:label="line" />
+</svg>
+`
+
+func TestNormalizedSVGHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	plain := write("plain.svg", plainSVG)
+	inkscape := write("inkscape.svg", inkscapeSVG)
+	different := write("different.svg", `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><path d="M0 0 L10 5" fill="red"/></svg>`)
+	broken := write("broken.svg", `<svg><path`)
+
+	hashPlain, err := normalizedSVGHash(plain, getHasher("sha256"))
+	if err != nil {
+		t.Fatalf("normalizedSVGHash() error = %v", err)
+	}
+	hashInkscape, _ := normalizedSVGHash(inkscape, getHasher("sha256"))
+	hashDifferent, _ := normalizedSVGHash(different, getHasher("sha256"))
+	if hashPlain != hashInkscape {
+		t.Errorf("re-exported SVG should hash alike: %s vs %s", hashPlain, hashInkscape)
+	}
+	if hashPlain == hashDifferent {
+		t.Error("SVGs that draw differently should not hash alike")
+	}
+	if _, err := normalizedSVGHash(broken, getHasher("sha256")); err == nil {
+		t.Error("expected an error for broken markup")
+	}
+}
+
+func TestCollectDuplicatesNormalizeSVG(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "plain.svg"), []byte(plainSVG), 0644)
+	os.WriteFile(filepath.Join(dir, "inkscape.svg"), []byte(inkscapeSVG), 0644)
+
+	c := DefaultConfig()
+	c.Dir = stringList{dir}
+	c.JSON = true
+	c.MinSize = 1
+	c.Checkpoint = ""
+
+	duplicates, err := NewEngine(c, nil).collectDuplicates(context.Background())
+	if err != nil || len(duplicates) != 0 {
+		t.Fatalf("without -normalize-svg: %d groups, %v", len(duplicates), err)
+	}
+
+	c.NormalizeSVG = true
+	duplicates, err = NewEngine(c, nil).collectDuplicates(context.Background())
+	if err != nil || len(duplicates) != 1 || len(duplicates[0].Files) != 2 {
+		t.Errorf("with -normalize-svg: %+v, %v", duplicates, err)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tagSeparator joins a group's tags in the CSV export
+const tagSeparator = ";"
+
+// tagStore is the persistent set of tags given to groups during review
+// ("review later", "ask spouse", "safe"), keyed by group signature so a
+// large result set can be reviewed over several sessions
+type tagStore struct {
+	path   string
+	Groups map[string][]string `json:"groups"`
+}
+
+// tagFilePath returns the -tag-file, the default location when none is
+// given, or "" when tags are turned off
+func (c Config) tagFilePath() string {
+	switch c.TagFile {
+	case ignoreOff:
+		return ""
+	case "":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(home, ".config", "file-deduplicator", "tags.json")
+	}
+	return c.TagFile
+}
+
+// loadTagStore opens the tags at path, starting with none if the file does
+// not exist yet. An empty path gives a store that is never saved.
+func loadTagStore(path string) (*tagStore, error) {
+	store := &tagStore{path: path, Groups: make(map[string][]string)}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read tags: %w", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("invalid tag file %s: %w", path, err)
+	}
+	if store.Groups == nil {
+		store.Groups = make(map[string][]string)
+	}
+	return store, nil
+}
+
+// save writes the tags atomically
+func (s *tagStore) save() error {
+	if s.path == "" {
+		return fmt.Errorf("tags are turned off (-tag-file %s)", ignoreOff)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data, 0600)
+}
+
+// set replaces a group's tags; no tags forgets the group
+func (s *tagStore) set(group DuplicateGroup, tags []string) {
+	sig := groupSignature(group)
+	if len(tags) == 0 {
+		delete(s.Groups, sig)
+		return
+	}
+	s.Groups[sig] = normalizeTags(tags)
+}
+
+// apply copies the stored tags onto the groups of a scan
+func (s *tagStore) apply(duplicates []DuplicateGroup) {
+	for i := range duplicates {
+		duplicates[i].Tags = s.Groups[groupSignature(duplicates[i])]
+	}
+}
+
+// normalizeTags trims, de-duplicates and sorts tags
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// readTaggedGroups reads the groups of an earlier JSON report (-json or
+// -export-report) or CSV export (-export-csv), with their tags. Groups are
+// matched by hash, so a report hashed with another algorithm is refused.
+func readTaggedGroups(path, algorithm string) ([]DuplicateGroup, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		var report struct {
+			Duplicates    []DuplicateGroup `json:"duplicates"`
+			HashAlgorithm string           `json:"hash_algorithm"`
+			Config        struct {
+				HashAlgorithm string
+			} `json:"config"`
+		}
+		if err := json.NewDecoder(f).Decode(&report); err != nil {
+			return nil, fmt.Errorf("not a JSON report: %w", err)
+		}
+		// Reports from before hash_algorithm still carry their -hash in config
+		used := report.HashAlgorithm
+		if used == "" {
+			used = report.Config.HashAlgorithm
+		}
+		if used != "" && !strings.EqualFold(used, algorithm) {
+			return nil, fmt.Errorf("the report was hashed with %s, not %s; use -hash %s", used, algorithm, strings.ToLower(used))
+		}
+		return report.Duplicates, nil
+	}
+
+	rows, err := readDecisions(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSheetAlgorithm(rows, algorithm); err != nil {
+		return nil, err
+	}
+	var groups []DuplicateGroup
+	index := make(map[int]int)
+	for _, row := range rows {
+		i, ok := index[row.Group]
+		if !ok {
+			i = len(groups)
+			index[row.Group] = i
+			groups = append(groups, DuplicateGroup{
+				Hash:       row.Hash,
+				Similarity: row.Similarity,
+				Unverified: row.Action == decisionReview,
+			})
+		}
+		// Every row repeats the group's tags; an edited row may add more
+		if row.Tags != "" {
+			groups[i].Tags = normalizeTags(append(groups[i].Tags, strings.Split(row.Tags, tagSeparator)...))
+		}
+		groups[i].Files = append(groups[i].Files, FileHash{Path: row.Path})
+	}
+	return groups, nil
+}
+
+// importTags merges the tags of an earlier export, hashed with algorithm,
+// into the store and returns how many groups carried tags
+func (s *tagStore) importTags(path, algorithm string) (int, error) {
+	groups, err := readTaggedGroups(path, algorithm)
+	if err != nil {
+		return 0, fmt.Errorf("cannot import tags from %s: %w", path, err)
+	}
+	tagged := 0
+	for _, group := range groups {
+		if len(group.Tags) > 0 {
+			s.set(group, group.Tags)
+			tagged++
+		}
+	}
+	return tagged, nil
+}
+
+// withTag keeps the groups carrying tag
+func withTag(duplicates []DuplicateGroup, tag string) []DuplicateGroup {
+	kept := duplicates[:0]
+	for _, group := range duplicates {
+		for _, t := range group.Tags {
+			if t == tag {
+				kept = append(kept, group)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// applyTags imports -import-tags, then tags a scan's groups from the tag
+// file. With the tag file turned off, imported tags last for this run only.
+func (e *Engine) applyTags(duplicates []DuplicateGroup) error {
+	store, err := e.loadTags()
+	if err != nil {
+		return err
+	}
+	store.apply(duplicates)
+	return nil
+}
+
+// loadTags opens the tag file and merges -import-tags into it
+func (e *Engine) loadTags() (*tagStore, error) {
+	store, err := loadTagStore(e.cfg.tagFilePath())
+	if err != nil {
+		return nil, err
+	}
+	if e.cfg.ImportTags != "" {
+		tagged, err := store.importTags(e.cfg.ImportTags, e.cfg.hashName())
+		if err != nil {
+			return nil, err
+		}
+		if tagged > 0 && store.path != "" {
+			if err := store.save(); err != nil {
+				return nil, err
+			}
+		}
+		if !e.cfg.JSON {
+			log.Printf("%sImported tags for %d groups from %s", emoji("🏷️"), tagged, e.cfg.ImportTags)
+		}
+	}
+	return store, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTagsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	c := DefaultConfig()
+	c.TagFile = filepath.Join(dir, "tags.json")
+	c.JSON = true
+
+	photos := DuplicateGroup{Hash: "aaaa", Similarity: 100, Files: []FileHash{{Path: "/p/1.jpg"}, {Path: "/q/1.jpg"}}}
+	similar := DuplicateGroup{Hash: "1010", Similarity: 90, Files: []FileHash{{Path: "/p/2.jpg"}, {Path: "/p/3.jpg"}}}
+
+	// Tagged in one session
+	store, err := loadTagStore(c.tagFilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.set(photos, []string{" review later", "ask spouse", "review later"})
+	if err := store.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Picked up by the next scan
+	groups := []DuplicateGroup{photos, similar}
+	if err := NewEngine(c, nil).applyTags(groups); err != nil {
+		t.Fatal(err)
+	}
+	if got := groups[0].Tags; len(got) != 2 || got[0] != "ask spouse" || got[1] != "review later" {
+		t.Errorf("tags = %q, want [ask spouse review later]", got)
+	}
+	if kept := withTag(groups, "review later"); len(kept) != 1 || kept[0].Hash != "aaaa" {
+		t.Errorf("withTag kept %v", kept)
+	}
+
+	// Re-imported from a CSV export someone else annotated
+	csvFile := filepath.Join(dir, "report.csv")
+	os.WriteFile(csvFile, []byte("group,hash,size,similarity,action,path,modified,tags\n"+
+		"1,1010,5,90.0,keep,/p/2.jpg,,\n"+
+		"1,1010,5,90.0,delete,/p/3.jpg,,safe;keep both\n"+
+		"2,aaaa,5,100.0,keep,/p/1.jpg,,\n"), 0644)
+	c.ImportTags = csvFile
+	groups = []DuplicateGroup{photos, similar}
+	if err := NewEngine(c, nil).applyTags(groups); err != nil {
+		t.Fatal(err)
+	}
+	if got := groups[1].Tags; len(got) != 2 || got[0] != "keep both" || got[1] != "safe" {
+		t.Errorf("imported CSV tags = %q, want [keep both safe]", got)
+	}
+
+	// And from a JSON report
+	jsonFile := filepath.Join(dir, "report.json")
+	photos.Tags = []string{"safe"}
+	data, _ := json.Marshal(map[string]interface{}{"duplicates": []DuplicateGroup{photos}})
+	os.WriteFile(jsonFile, data, 0644)
+	c.ImportTags = jsonFile
+	groups = []DuplicateGroup{photos, similar}
+	if err := NewEngine(c, nil).applyTags(groups); err != nil {
+		t.Fatal(err)
+	}
+	if got := groups[0].Tags; len(got) != 1 || got[0] != "safe" {
+		t.Errorf("imported JSON tags = %q, want [safe]", got)
+	}
+}
@@ -1,3 +1,4 @@
+//go:build !windows
 // +build !windows
 
 package main
@@ -7,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 
 	"github.com/mattn/go-isatty"
 )
@@ -23,13 +25,23 @@ func isDoubleClick() bool {
 	return !isatty.IsTerminal(os.Stdin.Fd())
 }
 
-// spawnTerminal spawns a new terminal window with the executable and --tui flag
-func spawnTerminal() error {
+// spawnTerminal spawns a new terminal window running the executable with
+// args, or with --tui when there are none
+func spawnTerminal(args ...string) error {
 	exe, err := os.Executable()
 	if err != nil {
 		return err
 	}
 
+	if len(args) == 0 {
+		args = []string{"--tui"}
+	}
+	command := append([]string{exe}, args...)
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+
 	// Set environment variable to prevent infinite spawn loop
 	env := append(os.Environ(), "_DEDUP_SPAWNED=1")
 
@@ -38,16 +50,16 @@ func spawnTerminal() error {
 		name string
 		args []string
 	}{
-		{"x-terminal-emulator", []string{"-e", exe, "--tui"}},
-		{"gnome-terminal", []string{"--", exe, "--tui"}},
-		{"konsole", []string{"-e", exe, "--tui"}},
-		{"xfce4-terminal", []string{"-e", exe + " --tui"}},
-		{"xterm", []string{"-e", exe, "--tui"}},
+		{"x-terminal-emulator", append([]string{"-e"}, command...)},
+		{"gnome-terminal", append([]string{"--"}, command...)},
+		{"konsole", append([]string{"-e"}, command...)},
+		{"xfce4-terminal", []string{"-e", strings.Join(quoted, " ")}},
+		{"xterm", append([]string{"-e"}, command...)},
 	}
 
 	// On macOS, use open command
 	if runtime.GOOS == "darwin" {
-		cmd := exec.Command("open", "-a", "Terminal", exe, "--args", "--tui")
+		cmd := exec.Command("open", append([]string{"-a", "Terminal", exe, "--args"}, args...)...)
 		cmd.Env = env
 		return cmd.Start()
 	}
@@ -1,3 +1,4 @@
+//go:build windows
 // +build windows
 
 package main
@@ -9,6 +10,9 @@ import (
 	"unsafe"
 )
 
+// createNewConsole is CREATE_NEW_CONSOLE from the Win32 process creation flags
+const createNewConsole = 0x00000010
+
 var (
 	kernel32 = syscall.NewLazyDLL("kernel32.dll")
 
@@ -27,18 +31,22 @@ func isDoubleClick() bool {
 	return ret == 1
 }
 
-// spawnTerminal spawns a new terminal window with the executable and --tui flag
-func spawnTerminal() error {
+// spawnTerminal spawns a new terminal window running the executable with
+// args, or with --tui when there are none
+func spawnTerminal(args ...string) error {
 	exe, err := os.Executable()
 	if err != nil {
 		return err
 	}
+	if len(args) == 0 {
+		args = []string{"--tui"}
+	}
 
 	// Set environment variable to prevent infinite spawn loop
-	cmd := exec.Command("cmd", "/c", "start", "", exe, "--tui")
+	cmd := exec.Command("cmd", append([]string{"/c", "start", "", exe}, args...)...)
 	cmd.Env = append(os.Environ(), "_DEDUP_SPAWNED=1")
 	cmd.SysProcAttr = &syscall.SysProcAttr{
-		CreationFlags: syscall.CREATE_NEW_CONSOLE,
+		CreationFlags: createNewConsole,
 	}
 	return cmd.Start()
 }
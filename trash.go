@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+// trashFile moves a duplicate to the system trash instead of deleting it, as
+// -trash asks, so it can be restored from the file manager like any other
+// deleted file. Read-only files need -clear-readonly as for deletion.
+func (c Config) trashFile(path string) error {
+	return c.journaled("trash", path, "", func() error {
+		if err := c.checkWritable(path); err != nil {
+			return err
+		}
+		if isReadOnly(path) {
+			if !c.ClearReadOnly {
+				return fmt.Errorf("%w (use -clear-readonly to trash it anyway)", errReadOnly)
+			}
+			if err := clearReadOnly(path); err != nil {
+				return fmt.Errorf("cannot clear read-only attribute: %w", err)
+			}
+		}
+		if err := moveToTrash(path); err != nil {
+			return fmt.Errorf("cannot move to the trash: %w", lockedError(err))
+		}
+		return nil
+	})
+}
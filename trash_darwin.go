@@ -0,0 +1,31 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// moveToTrash asks the Finder to move path to the Trash, so "Put Back"
+// works; moving it into ~/.Trash by hand would lose where it came from, and
+// would copy files on other volumes instead of using their own trash
+func moveToTrash(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`tell application "Finder" to delete POSIX file %s`, appleScriptString(abs))
+	if out, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,66 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSHFileOperationW = windows.NewLazySystemDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// SHFileOperation operation and flags
+const (
+	foDelete          = 0x0003
+	fofSilent         = 0x0004
+	fofNoConfirmation = 0x0010
+	fofAllowUndo      = 0x0040
+	fofNoErrorUI      = 0x0400
+)
+
+// shFileOpStruct is SHFILEOPSTRUCTW as 64-bit Windows lays it out. 32-bit
+// Windows packs it, which only moves the fields after fFlags; those are
+// left zero, and an abort is then reported by the return code alone.
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+// moveToTrash sends path to the Recycle Bin of its drive, without any dialog
+func moveToTrash(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	// pFrom is a list of names ending in an empty one
+	from, err := windows.UTF16FromString(abs)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0)
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI,
+	}
+	ret, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op)))
+	if ret != 0 {
+		// Mostly Win32 error codes, such as a sharing violation for an open file
+		return fmt.Errorf("SHFileOperation: %w", syscall.Errno(ret))
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return fmt.Errorf("moving %s to the Recycle Bin was cancelled", abs)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// moveToTrash moves path to the trash of the freedesktop.org Trash
+// specification, which GNOME, KDE and most other desktops share: the home
+// trash when path is on the same file system as it, otherwise the
+// .Trash-$uid directory at the top of path's own file system, so nothing is
+// copied between devices. The .trashinfo file, written first, lets the file
+// manager put it back where it was.
+func moveToTrash(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return err
+	}
+
+	trash, infoPath, err := trashFor(abs, info)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{filepath.Join(trash, "files"), filepath.Join(trash, "info")} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	// Claim a name by creating its .trashinfo exclusively
+	base := filepath.Base(abs)
+	ext := filepath.Ext(base)
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(base, ext), n, ext)
+		}
+		infoFile := filepath.Join(trash, "info", name+".trashinfo")
+		f, err := os.OpenFile(infoFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(f, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			trashInfoPath(infoPath), time.Now().Format("2006-01-02T15:04:05"))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(abs, filepath.Join(trash, "files", name))
+		}
+		if err != nil {
+			os.Remove(infoFile)
+		}
+		return err
+	}
+}
+
+// trashFor picks the trash directory for abs, and the path its .trashinfo
+// records: absolute for the home trash, relative to the top of the file
+// system for a trash there
+func trashFor(abs string, info os.FileInfo) (string, string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	// A data directory not made yet is made along with the home trash
+	device := deviceOf(info)
+	if homeInfo, err := os.Stat(existingAncestor(dataHome)); err == nil && deviceOf(homeInfo) == device {
+		return filepath.Join(dataHome, "Trash"), abs, nil
+	}
+
+	top := topDirectory(abs, device)
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return "", "", err
+	}
+	uid := strconv.Itoa(os.Getuid())
+	// An administrator-made .Trash is used only if it is sticky and no symlink
+	if shared, err := os.Lstat(filepath.Join(top, ".Trash")); err == nil && shared.IsDir() && shared.Mode()&os.ModeSticky != 0 {
+		return filepath.Join(top, ".Trash", uid), rel, nil
+	}
+	return filepath.Join(top, ".Trash-"+uid), rel, nil
+}
+
+// existingAncestor returns dir, or its nearest parent that exists
+func existingAncestor(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// topDirectory walks up from abs to the top of the file system on device
+func topDirectory(abs string, device uint64) string {
+	dir := filepath.Dir(abs)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		info, err := os.Stat(parent)
+		if err != nil || deviceOf(info) != device {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// deviceOf returns the device a file is on
+func deviceOf(info os.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev)
+	}
+	return 0
+}
+
+// trashInfoPath escapes a path for the Path key of a .trashinfo file, which
+// the specification has URL-encoded with the slashes left as they are
+func trashInfoPath(path string) string {
+	return (&url.URL{Path: path}).EscapedPath()
+}
@@ -0,0 +1,68 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveToTrashXDG(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	dir := t.TempDir()
+
+	var c Config
+	c.Trash = true
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(dir, "dup copy.txt")
+		os.WriteFile(path, []byte("duplicate"), 0644)
+		if err := c.removeFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%s is still in place", path)
+		}
+	}
+
+	trash := filepath.Join(data, "Trash")
+	if _, err := os.Stat(filepath.Join(trash, "files", "dup copy.txt")); err != nil {
+		t.Fatalf("the trash should hold the first file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(trash, "files", "dup copy.2.txt")); err != nil {
+		t.Fatalf("the second file should get a name of its own: %v", err)
+	}
+	info, err := os.ReadFile(filepath.Join(trash, "info", "dup copy.txt.trashinfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Path=" + strings.ReplaceAll(filepath.Join(dir, "dup copy.txt"), " ", "%20")
+	if !strings.HasPrefix(string(info), "[Trash Info]\n") || !strings.Contains(string(info), want+"\n") || !strings.Contains(string(info), "DeletionDate=") {
+		t.Errorf(".trashinfo =\n%s\nwant a %s line", info, want)
+	}
+
+	if entry := c.undoEntry(FileHash{Path: "x"}); entry.Action != "trashed" {
+		t.Errorf("undoEntry().Action = %q, want trashed", entry.Action)
+	}
+}
+
+func TestMoveToTrashNewDataHome(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "home", ".local", "share") // a fresh account
+	t.Setenv("XDG_DATA_HOME", data)
+
+	path := filepath.Join(dir, "dup.txt")
+	os.WriteFile(path, []byte("duplicate"), 0644)
+	if err := moveToTrash(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(data, "Trash", "files", "dup.txt")); err != nil {
+		t.Errorf("the file should be in the home trash, created for it: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(data, "Trash", "info", "dup.txt.trashinfo")); err != nil {
+		t.Errorf("the home trash should hold the .trashinfo: %v", err)
+	}
+}
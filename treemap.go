@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// treemapNode is a directory or file in the -treemap export. Size is the
+// space removing the duplicates beneath it would recover, not its disk usage.
+type treemapNode struct {
+	name     string
+	size     int64
+	children map[string]*treemapNode // nil for files
+}
+
+// recoverableFiles maps the absolute path of every duplicate that would be
+// removed to the bytes removing it recovers. Keepers, remote copies and
+// indexed originals are left out.
+func recoverableFiles(groups []DuplicateGroup, criteria string) map[string]int64 {
+	files := make(map[string]int64)
+	for _, group := range groups {
+		keepIdx := selectFileToKeep(group, criteria)
+		for i, fh := range group.Files {
+			if i == keepIdx || fh.Host != "" || fh.Reference {
+				continue
+			}
+			files[absPath(fh.Path)] = group.Size
+		}
+	}
+	return files
+}
+
+// splitPath splits an absolute path into its elements, the first being the
+// volume ("" for / on Unix)
+func splitPath(path string) []string {
+	return strings.Split(filepath.Clean(path), string(filepath.Separator))
+}
+
+// joinPath reverses splitPath
+func joinPath(parts []string) string {
+	path := strings.Join(parts, string(filepath.Separator))
+	if len(parts) == 1 {
+		path += string(filepath.Separator)
+	}
+	return path
+}
+
+// buildTreemap arranges recoverable files under the deepest directory that
+// holds all of them
+func buildTreemap(files map[string]int64) *treemapNode {
+	var prefix []string
+	first := true
+	for path := range files {
+		dir := splitPath(filepath.Dir(path))
+		if first {
+			prefix, first = dir, false
+			continue
+		}
+		n := 0
+		for n < len(prefix) && n < len(dir) && prefix[n] == dir[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+
+	root := &treemapNode{name: joinPath(prefix), children: make(map[string]*treemapNode)}
+	if len(prefix) == 0 {
+		root.name = "" // files on more than one volume
+	}
+	for path, size := range files {
+		node := root
+		node.size += size
+		rest := splitPath(path)[len(prefix):]
+		for k, name := range rest {
+			child := node.children[name]
+			if child == nil {
+				child = &treemapNode{name: name}
+				if k < len(rest)-1 {
+					child.children = make(map[string]*treemapNode)
+				}
+				node.children[name] = child
+			}
+			child.size += size
+			node = child
+		}
+	}
+	return root
+}
+
+// sortedChildren returns a directory's entries by name
+func (n *treemapNode) sortedChildren() []*treemapNode {
+	children := make([]*treemapNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+	return children
+}
+
+// ncdu returns the node in ncdu's JSON export format: a file is an object,
+// a directory an array of its own object followed by its entries
+func (n *treemapNode) ncdu() interface{} {
+	info := map[string]interface{}{"name": n.name, "asize": n.size, "dsize": n.size}
+	if n.children == nil {
+		return info
+	}
+	delete(info, "dsize")
+	info["asize"] = 0
+	dir := []interface{}{info}
+	for _, child := range n.sortedChildren() {
+		dir = append(dir, child.ncdu())
+	}
+	return dir
+}
+
+// writeDu writes the node like du -ab: one "bytes<TAB>path" line per entry,
+// children before their directory
+func (n *treemapNode) writeDu(w *bufio.Writer, path string) {
+	for _, child := range n.sortedChildren() {
+		child.writeDu(w, filepath.Join(path, child.name))
+	}
+	fmt.Fprintf(w, "%d\t%s\n", n.size, path)
+}
+
+// exportTreemap writes the recoverable space per directory to the -treemap
+// file: ncdu's JSON export format (ncdu -f, gdu -f and other ncdu viewers)
+// when the name ends in .json, du -ab output otherwise
+func (e *Engine) exportTreemap(duplicates []DuplicateGroup) error {
+	root := buildTreemap(recoverableFiles(duplicates, e.cfg.KeepCriteria))
+
+	f, err := createAtomic(e.cfg.Treemap, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+
+	w := bufio.NewWriter(f)
+	if strings.EqualFold(filepath.Ext(e.cfg.Treemap), ".json") {
+		header := map[string]interface{}{"progname": "file-deduplicator", "progver": version, "timestamp": time.Now().Unix()}
+		if err := json.NewEncoder(w).Encode([]interface{}{1, 2, header, root.ncdu()}); err != nil {
+			return err
+		}
+	} else if len(root.children) > 0 {
+		root.writeDu(w, root.name)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Commit(); err != nil {
+		return err
+	}
+
+	if !e.cfg.JSON {
+		log.Printf("%sTreemap of %s recoverable space exported to %s", emoji("📄"), formatBytes(root.size), e.cfg.Treemap)
+	}
+	return nil
+}
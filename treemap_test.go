@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportTreemap(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b", "c")
+	groups := []DuplicateGroup{
+		{Size: 100, Files: []FileHash{{Path: filepath.Join(a, "1")}, {Path: filepath.Join(b, "1")}, {Path: filepath.Join(b, "2")}}},
+		{Size: 7, Files: []FileHash{{Path: filepath.Join(a, "3")}, {Path: filepath.Join(a, "4")}, {Path: filepath.Join(a, "5"), Host: "nas"}}},
+	}
+
+	root := buildTreemap(recoverableFiles(groups, "first"))
+	if root.name != dir || root.size != 207 {
+		t.Fatalf("root = %q with %d bytes, want %q with 207", root.name, root.size, dir)
+	}
+
+	var du strings.Builder
+	w := bufio.NewWriter(&du)
+	root.writeDu(w, root.name)
+	w.Flush()
+	want := strings.Join([]string{
+		"7\t" + filepath.Join(a, "4"),
+		"7\t" + a,
+		"100\t" + filepath.Join(b, "1"),
+		"100\t" + filepath.Join(b, "2"),
+		"200\t" + b,
+		"200\t" + filepath.Dir(b),
+		"207\t" + dir,
+	}, "\n") + "\n"
+	if du.String() != want {
+		t.Errorf("du output:\n%s\nwant:\n%s", du.String(), want)
+	}
+
+	c := DefaultConfig()
+	c.Treemap = filepath.Join(dir, "treemap.json")
+	c.JSON = true
+	if err := NewEngine(c, nil).exportTreemap(groups); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(c.Treemap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export []json.RawMessage
+	if err := json.Unmarshal(data, &export); err != nil || len(export) != 4 {
+		t.Fatalf("not an ncdu export: %v\n%s", err, data)
+	}
+	var tree []json.RawMessage
+	if err := json.Unmarshal(export[3], &tree); err != nil || len(tree) != 3 {
+		t.Fatalf("root directory should hold a and b: %v\n%s", err, export[3])
+	}
+	var file struct {
+		Name  string `json:"name"`
+		Asize int64  `json:"asize"`
+	}
+	var a4 []json.RawMessage
+	if err := json.Unmarshal(tree[1], &a4); err != nil || len(a4) != 2 || json.Unmarshal(a4[1], &file) != nil || file.Name != "4" || file.Asize != 7 {
+		t.Errorf("directory a = %s, want it to hold file 4 of 7 bytes", tree[1])
+	}
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"bufio"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/luinbytes/file-deduplicator/exif"
+	_ "golang.org/x/image/webp"
+)
+
+// ImageInfo is what the preview pane shows about an image file
+type ImageInfo struct {
+	Format string
+	Width  int
+	Height int
+	Camera string // EXIF make and model
+	Taken  string // EXIF capture date
+	HasGPS bool
+}
+
+// readImageInfo decodes an image's dimensions and, for JPEGs, its EXIF
+// camera, capture date and whether it carries a GPS position
+func readImageInfo(path string) (ImageInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	defer f.Close()
+
+	config, format, err := image.DecodeConfig(bufio.NewReader(f))
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	info := ImageInfo{Format: format, Width: config.Width, Height: config.Height}
+
+	if format == "jpeg" {
+		if meta, err := exif.Read(path); err == nil {
+			info.Camera, info.HasGPS = meta.Camera, meta.GPS
+			if meta.Taken != nil {
+				info.Taken = meta.Taken.Format(exif.TimeLayout)
+			}
+		}
+	}
+	return info, nil
+}
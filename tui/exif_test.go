@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildExif returns little-endian EXIF TIFF data with a make, model,
+// capture date and, optionally, a GPS position
+func buildExif(withGPS bool) []byte {
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	put16 := func(v uint16) { binary.Write(&b, le, v) }
+	put32 := func(v uint32) { binary.Write(&b, le, v) }
+	entry := func(tag, typ uint16, count, value uint32) {
+		put16(tag)
+		put16(typ)
+		put32(count)
+		put32(value)
+	}
+
+	// Layout: header, IFD0 (4 entries) at 8, strings, Exif IFD, GPS IFD
+	// and the latitude and longitude rationals
+	const ifd0 = 8
+	const makeAt = ifd0 + 2 + 4*12 + 4
+	const modelAt = makeAt + 6
+	const exifAt = modelAt + 13
+	const dateAt = exifAt + 2 + 12 + 4
+	const gpsAt = dateAt + 20
+	const latAt = gpsAt + 2 + 2*12 + 4
+	const lonAt = latAt + 24
+
+	b.WriteString("II")
+	put16(42)
+	put32(ifd0)
+
+	put16(4)
+	entry(0x010f, 2, 6, makeAt)   // Make
+	entry(0x0110, 2, 13, modelAt) // Model
+	entry(0x8769, 4, 1, exifAt)   // Exif IFD
+	entry(0x8825, 4, 1, gpsAt)    // GPS IFD
+	put32(0)
+
+	b.WriteString("Canon\x00")
+	b.WriteString("Canon EOS 5D\x00")
+
+	put16(1)
+	entry(0x9003, 2, 20, dateAt) // DateTimeOriginal
+	put32(0)
+	b.WriteString("2023:05:01 10:00:00\x00")
+
+	if withGPS {
+		put16(2)
+		entry(0x0002, 5, 3, latAt) // GPSLatitude
+		entry(0x0004, 5, 3, lonAt) // GPSLongitude
+		put32(0)
+		for _, v := range []uint32{51, 1, 30, 1, 0, 1, 7, 1, 5, 1, 0, 1} {
+			put32(v)
+		}
+	} else {
+		put16(0)
+		put32(0)
+	}
+	return b.Bytes()
+}
+
+// writeJPEG writes a JPEG with the given EXIF TIFF data (none when nil)
+func writeJPEG(t *testing.T, path string, tiff []byte) {
+	t.Helper()
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 40, 30)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	out.Write(img.Bytes()[:2]) // SOI
+	if tiff != nil {
+		segment := append([]byte("Exif\x00\x00"), tiff...)
+		out.Write([]byte{0xff, 0xe1})
+		binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+		out.Write(segment)
+	}
+	out.Write(img.Bytes()[2:])
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadImageInfo(t *testing.T) {
+	dir := t.TempDir()
+
+	withExif := filepath.Join(dir, "exif.jpg")
+	writeJPEG(t, withExif, buildExif(true))
+	info, err := readImageInfo(withExif)
+	if err != nil {
+		t.Fatalf("readImageInfo() error = %v", err)
+	}
+	want := ImageInfo{Format: "jpeg", Width: 40, Height: 30, Camera: "Canon EOS 5D", Taken: "2023:05:01 10:00:00", HasGPS: true}
+	if info != want {
+		t.Errorf("readImageInfo() = %+v, want %+v", info, want)
+	}
+
+	noGPS := filepath.Join(dir, "nogps.jpg")
+	writeJPEG(t, noGPS, buildExif(false))
+	if info, _ := readImageInfo(noGPS); info.HasGPS || info.Camera != "Canon EOS 5D" {
+		t.Errorf("readImageInfo() without GPS = %+v", info)
+	}
+
+	plain := filepath.Join(dir, "plain.jpg")
+	writeJPEG(t, plain, nil)
+	if info, _ := readImageInfo(plain); info != (ImageInfo{Format: "jpeg", Width: 40, Height: 30}) {
+		t.Errorf("readImageInfo() without EXIF = %+v", info)
+	}
+
+	pngPath := filepath.Join(dir, "image.png")
+	f, _ := os.Create(pngPath)
+	png.Encode(f, image.NewRGBA(image.Rect(0, 0, 8, 6)))
+	f.Close()
+	if info, _ := readImageInfo(pngPath); info.Width != 8 || info.Height != 6 || info.Format != "png" {
+		t.Errorf("readImageInfo() for PNG = %+v", info)
+	}
+
+	text := filepath.Join(dir, "notes.txt")
+	os.WriteFile(text, []byte("not an image"), 0644)
+	if _, err := readImageInfo(text); err == nil {
+		t.Error("expected an error for a non-image")
+	}
+}
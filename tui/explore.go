@@ -0,0 +1,372 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ExploreFile is one scanned file in the disk usage explorer
+type ExploreFile struct {
+	Path  string // absolute path
+	Size  int64
+	Waste int64 // bytes removing this copy would recover, 0 for the copy kept
+	Group int   // index into the explorer's groups, -1 for unique files
+}
+
+// exploreNode is a directory or file in the explorer tree. Directories add
+// up the sizes and waste of everything beneath them.
+type exploreNode struct {
+	name     string
+	path     string
+	size     int64
+	waste    int64
+	files    int
+	group    int // duplicate group of a file, -1 for directories and unique files
+	parent   *exploreNode
+	children []*exploreNode // nil for files
+	byName   map[string]*exploreNode
+}
+
+// isDir reports whether the node is a directory
+func (n *exploreNode) isDir() bool {
+	return n.byName != nil
+}
+
+// child returns the directory entry with the given name, creating it
+func (n *exploreNode) child(name string, dir bool) *exploreNode {
+	if c := n.byName[name]; c != nil {
+		return c
+	}
+	c := &exploreNode{name: name, path: filepath.Join(n.path, name), group: -1, parent: n}
+	if dir {
+		c.byName = make(map[string]*exploreNode)
+	}
+	n.byName[name] = c
+	n.children = append(n.children, c)
+	return c
+}
+
+// buildExploreTree arranges files under the deepest directory holding all of them
+func buildExploreTree(files []ExploreFile) *exploreNode {
+	root := ""
+	for i, f := range files {
+		if i == 0 {
+			root = filepath.Dir(f.Path)
+			continue
+		}
+		for !isWithin(filepath.Dir(f.Path), root) {
+			parent := filepath.Dir(root)
+			if parent == root {
+				break
+			}
+			root = parent
+		}
+	}
+
+	tree := &exploreNode{name: root, path: root, group: -1, byName: make(map[string]*exploreNode)}
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f.Path)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		node := tree
+		for i, name := range parts {
+			node.size += f.Size
+			node.waste += f.Waste
+			node.files++
+			node = node.child(name, i < len(parts)-1)
+		}
+		node.size, node.waste, node.files, node.group = f.Size, f.Waste, 1, f.Group
+	}
+	return tree
+}
+
+// exploreKeyMap defines keybindings for the explorer
+type exploreKeyMap struct {
+	Up   key.Binding
+	Down key.Binding
+	Open key.Binding
+	Back key.Binding
+	Sort key.Binding
+	Quit key.Binding
+	Help key.Binding
+}
+
+var exploreKeys = exploreKeyMap{
+	Up:   keys.Up,
+	Down: keys.Down,
+	Open: key.NewBinding(key.WithKeys("enter", "right", "l"), key.WithHelp("enter/→", "open")),
+	Back: key.NewBinding(key.WithKeys("left", "h", "backspace", "esc"), key.WithHelp("←/esc", "back")),
+	Sort: key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sort by size/waste")),
+	Quit: key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+	Help: keys.Help,
+}
+
+// ShortHelp returns keybindings to be shown in the mini help view.
+func (k exploreKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Open, k.Back, k.Sort, k.Help, k.Quit}
+}
+
+// FullHelp returns keybindings for the expanded help view.
+func (k exploreKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Open, k.Back},
+		{k.Sort, k.Help, k.Quit},
+	}
+}
+
+// ExploreModel is the disk usage explorer state
+type ExploreModel struct {
+	root        *exploreNode
+	dir         *exploreNode // directory being listed
+	groups      []DuplicateGroup
+	waste       map[string]int64 // waste by path, to tell kept copies apart
+	group       int              // group being shown, -1 while browsing
+	cursor      int
+	cursors     map[*exploreNode]int // cursor position to return to per directory
+	sortByWaste bool
+	showHelp    bool
+	height      int
+	keys        exploreKeyMap
+	help        help.Model
+	statusMsg   string
+}
+
+// NewExplore creates an explorer over every scanned file and the duplicate
+// groups they belong to
+func NewExplore(files []ExploreFile, groups []DuplicateGroup) ExploreModel {
+	m := ExploreModel{
+		root:    buildExploreTree(files),
+		groups:  groups,
+		waste:   make(map[string]int64),
+		group:   -1,
+		cursors: make(map[*exploreNode]int),
+		keys:    exploreKeys,
+		help:    help.New(),
+	}
+	for _, f := range files {
+		m.waste[f.Path] = f.Waste
+	}
+	m.dir = m.root
+	m.sortEntries(m.root)
+	return m
+}
+
+// Init initializes the explorer
+func (m ExploreModel) Init() tea.Cmd {
+	return nil
+}
+
+// sortEntries orders a directory's entries, biggest (or most wasteful) first
+func (m *ExploreModel) sortEntries(dir *exploreNode) {
+	sort.SliceStable(dir.children, func(i, j int) bool {
+		a, b := dir.children[i], dir.children[j]
+		if m.sortByWaste && a.waste != b.waste {
+			return a.waste > b.waste
+		}
+		if a.size != b.size {
+			return a.size > b.size
+		}
+		return a.name < b.name
+	})
+}
+
+// Update handles messages and user input
+func (m ExploreModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.help.Width = msg.Width
+
+	case tea.KeyMsg:
+		m.statusMsg = ""
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = !m.showHelp
+
+		case key.Matches(msg, m.keys.Up):
+			if m.group < 0 && m.cursor > 0 {
+				m.cursor--
+			}
+
+		case key.Matches(msg, m.keys.Down):
+			if m.group < 0 && m.cursor < len(m.dir.children)-1 {
+				m.cursor++
+			}
+
+		case key.Matches(msg, m.keys.Open):
+			if m.group >= 0 || m.cursor >= len(m.dir.children) {
+				break
+			}
+			entry := m.dir.children[m.cursor]
+			switch {
+			case entry.isDir():
+				m.cursors[m.dir] = m.cursor
+				m.dir, m.cursor = entry, m.cursors[entry]
+				m.sortEntries(m.dir)
+			case entry.group >= 0:
+				m.group = entry.group
+			default:
+				m.statusMsg = "No duplicates of this file were found"
+			}
+
+		case key.Matches(msg, m.keys.Back):
+			if m.group >= 0 {
+				m.group = -1
+			} else if m.dir.parent != nil {
+				m.cursors[m.dir] = m.cursor
+				m.dir, m.cursor = m.dir.parent, m.cursors[m.dir.parent]
+				m.sortEntries(m.dir)
+			}
+
+		case key.Matches(msg, m.keys.Sort):
+			m.sortByWaste = !m.sortByWaste
+			m.sortEntries(m.dir)
+			m.cursor = 0
+		}
+	}
+	return m, nil
+}
+
+// View renders the explorer
+func (m ExploreModel) View() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(" Duplicate Explorer "))
+	s.WriteString("\n\n")
+
+	if m.group >= 0 && m.group < len(m.groups) {
+		s.WriteString(m.renderGroup(m.groups[m.group]))
+	} else {
+		s.WriteString(m.renderDir())
+	}
+
+	if m.statusMsg != "" {
+		s.WriteString("\n")
+		s.WriteString(infoStyle.Render(m.statusMsg))
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	if m.showHelp {
+		s.WriteString(m.help.FullHelpView(m.keys.FullHelp()))
+	} else {
+		s.WriteString(m.help.ShortHelpView(m.keys.ShortHelp()))
+	}
+	return s.String()
+}
+
+// renderDir lists the current directory like ncdu, with the share of each
+// entry that is duplicate content
+func (m ExploreModel) renderDir() string {
+	var s strings.Builder
+	dir := m.dir
+	s.WriteString(headerStyle.Render(dir.path))
+	s.WriteString("\n")
+	sortedBy := "size"
+	if m.sortByWaste {
+		sortedBy = "waste"
+	}
+	s.WriteString(infoStyle.Render(fmt.Sprintf("%s in %d files, %s (%.1f%%) recoverable  ·  sorted by %s  ·  # duplicate, = unique",
+		formatBytes(dir.size), dir.files, formatBytes(dir.waste), percent(dir.waste, dir.size), sortedBy)))
+	s.WriteString("\n\n")
+
+	if len(dir.children) == 0 {
+		return s.String() + infoStyle.Render("Empty") + "\n"
+	}
+	var largest int64
+	for _, entry := range dir.children {
+		if entry.size > largest {
+			largest = entry.size
+		}
+	}
+
+	rows := m.height - 10
+	if rows < 5 {
+		rows = 5
+	}
+	start := 0
+	if m.cursor >= rows {
+		start = m.cursor - rows + 1
+	}
+	end := start + rows
+	if end > len(dir.children) {
+		end = len(dir.children)
+	}
+
+	for i := start; i < end; i++ {
+		entry := dir.children[i]
+		name := entry.name
+		if entry.isDir() {
+			name += string(filepath.Separator)
+		}
+		line := fmt.Sprintf("%9s %5.1f%% [%s] %s", formatBytes(entry.size), percent(entry.waste, entry.size), usageBar(entry, largest), name)
+		if i == m.cursor {
+			s.WriteString(selectedItemStyle.Render("> " + line))
+		} else {
+			s.WriteString(itemStyle.Render(line))
+		}
+		s.WriteString("\n")
+	}
+	if end < len(dir.children) {
+		s.WriteString(infoStyle.Render(fmt.Sprintf("    ... %d more", len(dir.children)-end)))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+// renderGroup lists every copy in a duplicate group, marking the one kept
+func (m ExploreModel) renderGroup(group DuplicateGroup) string {
+	var s strings.Builder
+	s.WriteString(headerStyle.Render(fmt.Sprintf("Duplicate group: %d copies of %s", len(group.Files), formatBytes(group.Size))))
+	s.WriteString("\n")
+	s.WriteString(infoStyle.Render(fmt.Sprintf("Hash: %s", shortHash(group.Hash))))
+	s.WriteString("\n\n")
+	for _, f := range group.Files {
+		if waste, ok := m.waste[f.Path]; ok && waste == 0 {
+			s.WriteString(checkedStyle.Render("keep ") + itemStyle.Render(f.Path))
+		} else {
+			s.WriteString(uncheckedStyle.Render("dup  ") + itemStyle.Render(f.Path))
+		}
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+// usageBar draws an entry's size relative to the largest entry beside it,
+// with its duplicate share as # and the rest as =
+func usageBar(entry *exploreNode, largest int64) string {
+	const width = 10
+	filled := 0
+	if largest > 0 {
+		filled = int(entry.size * width / largest)
+	}
+	dup := 0
+	if entry.size > 0 {
+		dup = int(int64(filled) * entry.waste / entry.size)
+	}
+	return strings.Repeat("#", dup) + strings.Repeat("=", filled-dup) + strings.Repeat(" ", width-filled)
+}
+
+// percent returns part as a percentage of whole
+func percent(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(whole)
+}
+
+// RunExplore starts the disk usage explorer
+func RunExplore(files []ExploreFile, groups []DuplicateGroup) error {
+	_, err := tea.NewProgram(NewExplore(files, groups), tea.WithAltScreen()).Run()
+	return err
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestExplore(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "data")
+	photos, docs := filepath.Join(root, "photos"), filepath.Join(root, "docs")
+	files := []ExploreFile{
+		{Path: filepath.Join(photos, "a.jpg"), Size: 100, Group: 0},
+		{Path: filepath.Join(photos, "b.jpg"), Size: 100, Waste: 100, Group: 0},
+		{Path: filepath.Join(docs, "c.txt"), Size: 300, Group: -1},
+	}
+	groups := []DuplicateGroup{{Hash: "abc", Size: 100, Files: []FileInfo{{Path: files[0].Path}, {Path: files[1].Path}}}}
+
+	m := NewExplore(files, groups)
+	if m.root.path != root || m.root.size != 500 || m.root.waste != 100 || m.root.files != 3 {
+		t.Fatalf("root = %s %d bytes %d waste %d files", m.root.path, m.root.size, m.root.waste, m.root.files)
+	}
+	if m.dir.children[0].name != "docs" {
+		t.Errorf("entries should be sorted by size, got %s first", m.dir.children[0].name)
+	}
+
+	press := func(k string) {
+		model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = model.(ExploreModel)
+	}
+	press("s")
+	if m.dir.children[0].name != "photos" {
+		t.Errorf("sorting by waste should put photos first, got %s", m.dir.children[0].name)
+	}
+	if view := m.View(); !strings.Contains(view, "20.0%") {
+		t.Errorf("root view should show a 20%% duplicate share:\n%s", view)
+	}
+
+	press("l") // open photos
+	if m.dir.path != photos {
+		t.Fatalf("opened %s, want %s", m.dir.path, photos)
+	}
+	press("l") // open the first file's group
+	if m.group != 0 {
+		t.Fatalf("group = %d, want 0", m.group)
+	}
+	view := m.View()
+	if !strings.Contains(view, "keep ") || !strings.Contains(view, "dup  ") {
+		t.Errorf("group view should mark the kept copy and the duplicate:\n%s", view)
+	}
+
+	press("h")
+	press("h")
+	if m.group != -1 || m.dir != m.root || m.dir.children[m.cursor].name != "photos" {
+		t.Errorf("going back should return to photos in the root")
+	}
+}
@@ -0,0 +1,395 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RestoreItem is one file in the restore browser
+type RestoreItem struct {
+	Operation string // the run that removed the file, e.g. "quarantine 20060102-150405"
+	Path      string // original location
+	Stored    string // where the file is now, empty if it was deleted
+	Size      int64
+	Time      string
+}
+
+// Restorable reports whether the file still exists somewhere and can be put back
+func (i RestoreItem) Restorable() bool {
+	return i.Stored != ""
+}
+
+// restoreKeyMap defines keybindings for the restore browser
+type restoreKeyMap struct {
+	Up              key.Binding
+	Down            key.Binding
+	Toggle          key.Binding
+	ToggleOperation key.Binding
+	Search          key.Binding
+	Preview         key.Binding
+	Confirm         key.Binding
+	Quit            key.Binding
+	Help            key.Binding
+}
+
+var restoreKeys = restoreKeyMap{
+	Up:              keys.Up,
+	Down:            keys.Down,
+	Toggle:          key.NewBinding(key.WithKeys("space", " "), key.WithHelp("space", "select file")),
+	ToggleOperation: key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "select whole operation")),
+	Search:          key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
+	Preview:         keys.Preview,
+	Confirm:         key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "restore selected")),
+	Quit:            keys.Quit,
+	Help:            keys.Help,
+}
+
+// ShortHelp returns keybindings to be shown in the mini help view.
+func (k restoreKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Toggle, k.Search, k.Confirm, k.Help, k.Quit}
+}
+
+// FullHelp returns keybindings for the expanded help view.
+func (k restoreKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Toggle, k.ToggleOperation},
+		{k.Search, k.Preview, k.Confirm, k.Help, k.Quit},
+	}
+}
+
+// RestoreModel is the restore browser state
+type RestoreModel struct {
+	items       []RestoreItem
+	visible     []int // indexes into items matching the search
+	selected    map[int]bool
+	cursor      int
+	query       string
+	searching   bool
+	showHelp    bool
+	showPreview bool
+	confirmed   bool
+	quitting    bool
+	height      int
+	keys        restoreKeyMap
+	help        help.Model
+	statusMsg   string
+}
+
+// NewRestore creates a restore browser over items, listed in the given order
+func NewRestore(items []RestoreItem) RestoreModel {
+	m := RestoreModel{
+		items:    items,
+		selected: make(map[int]bool),
+		keys:     restoreKeys,
+		help:     help.New(),
+	}
+	m.filter()
+	return m
+}
+
+// Init initializes the restore browser
+func (m RestoreModel) Init() tea.Cmd {
+	return nil
+}
+
+// filter recomputes the visible items from the search query
+func (m *RestoreModel) filter() {
+	query := strings.ToLower(m.query)
+	m.visible = m.visible[:0]
+	for i, item := range m.items {
+		if query == "" || strings.Contains(strings.ToLower(item.Path), query) || strings.Contains(strings.ToLower(item.Operation), query) {
+			m.visible = append(m.visible, i)
+		}
+	}
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// current returns the index of the item under the cursor, or -1
+func (m RestoreModel) current() int {
+	if m.cursor < len(m.visible) {
+		return m.visible[m.cursor]
+	}
+	return -1
+}
+
+// Update handles messages and user input
+func (m RestoreModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.help.Width = msg.Width
+
+	case tea.KeyMsg:
+		if m.searching {
+			switch msg.Type {
+			case tea.KeyEnter, tea.KeyEsc:
+				m.searching = false
+			case tea.KeyBackspace:
+				if m.query != "" {
+					runes := []rune(m.query)
+					m.query = string(runes[:len(runes)-1])
+					m.filter()
+				}
+			case tea.KeyRunes, tea.KeySpace:
+				m.query += string(msg.Runes)
+				m.filter()
+			}
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			m.quitting = true
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = !m.showHelp
+
+		case key.Matches(msg, m.keys.Preview):
+			m.showPreview = !m.showPreview
+
+		case key.Matches(msg, m.keys.Search):
+			m.searching = true
+
+		case key.Matches(msg, m.keys.Up):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+		case key.Matches(msg, m.keys.Down):
+			if m.cursor < len(m.visible)-1 {
+				m.cursor++
+			}
+
+		case key.Matches(msg, m.keys.Toggle):
+			if i := m.current(); i >= 0 {
+				if m.items[i].Restorable() {
+					m.selected[i] = !m.selected[i]
+				} else {
+					m.statusMsg = "That file was deleted and cannot be restored"
+					return m, nil
+				}
+			}
+			m.updateStatus()
+
+		case key.Matches(msg, m.keys.ToggleOperation):
+			if i := m.current(); i >= 0 {
+				m.toggleOperation(m.items[i].Operation)
+			}
+			m.updateStatus()
+
+		case key.Matches(msg, m.keys.Confirm):
+			m.confirmed = true
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// toggleOperation selects every restorable file of an operation, or clears
+// them all if they were already selected
+func (m *RestoreModel) toggleOperation(operation string) {
+	allSelected := true
+	for i, item := range m.items {
+		if item.Operation == operation && item.Restorable() && !m.selected[i] {
+			allSelected = false
+			break
+		}
+	}
+	for i, item := range m.items {
+		if item.Operation == operation && item.Restorable() {
+			m.selected[i] = !allSelected
+		}
+	}
+}
+
+// updateStatus updates the status message
+func (m *RestoreModel) updateStatus() {
+	count, size := 0, int64(0)
+	for i, ok := range m.selected {
+		if ok {
+			count++
+			size += m.items[i].Size
+		}
+	}
+	m.statusMsg = fmt.Sprintf("Selected: %d files (%s)", count, formatBytes(size))
+}
+
+// View renders the restore browser
+func (m RestoreModel) View() string {
+	if m.quitting || m.confirmed {
+		return ""
+	}
+	if len(m.items) == 0 {
+		return "Nothing to restore: no quarantined files or undo history found.\n"
+	}
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(" Restore Files "))
+	s.WriteString("\n\n")
+
+	if m.searching || m.query != "" {
+		cursor := ""
+		if m.searching {
+			cursor = "█"
+		}
+		s.WriteString(headerStyle.Render("Search: ") + m.query + cursor)
+		s.WriteString(infoStyle.Render(fmt.Sprintf("  (%d/%d)", len(m.visible), len(m.items))))
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(m.renderItems())
+
+	if m.showPreview {
+		if i := m.current(); i >= 0 {
+			s.WriteString("\n")
+			s.WriteString(previewStyle.Render(renderRestorePreview(m.items[i])))
+			s.WriteString("\n")
+		}
+	}
+
+	if m.statusMsg != "" {
+		s.WriteString("\n")
+		s.WriteString(infoStyle.Render(m.statusMsg))
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	if m.showHelp {
+		s.WriteString(m.help.FullHelpView(m.keys.FullHelp()))
+	} else {
+		s.WriteString(m.help.ShortHelpView(m.keys.ShortHelp()))
+	}
+	return s.String()
+}
+
+// renderItems renders the visible items under operation headers, scrolled
+// so the cursor stays on screen
+func (m RestoreModel) renderItems() string {
+	if len(m.visible) == 0 {
+		return infoStyle.Render("No matches") + "\n"
+	}
+
+	rows := m.height - 12
+	if m.showPreview {
+		rows -= 10
+	}
+	if rows < 5 {
+		rows = 5
+	}
+	start := 0
+	if m.cursor >= rows {
+		start = m.cursor - rows + 1
+	}
+	end := start + rows
+	if end > len(m.visible) {
+		end = len(m.visible)
+	}
+
+	var s strings.Builder
+	operation := ""
+	for pos := start; pos < end; pos++ {
+		i := m.visible[pos]
+		item := m.items[i]
+		if item.Operation != operation {
+			operation = item.Operation
+			s.WriteString(headerStyle.Render(operation))
+			s.WriteString("\n")
+		}
+
+		var line strings.Builder
+		switch {
+		case !item.Restorable():
+			line.WriteString(uncheckedStyle.Render(" ✗  "))
+		case m.selected[i]:
+			line.WriteString(checkedStyle.Render("[✓] "))
+		default:
+			line.WriteString(uncheckedStyle.Render("[ ] "))
+		}
+		if pos == m.cursor {
+			line.WriteString(selectedItemStyle.Render("> " + item.Path))
+		} else {
+			line.WriteString(itemStyle.Render(item.Path))
+		}
+		line.WriteString(infoStyle.Render(fmt.Sprintf(" (%s)", formatBytes(item.Size))))
+		s.WriteString(line.String())
+		s.WriteString("\n")
+	}
+	if end < len(m.visible) {
+		s.WriteString(infoStyle.Render(fmt.Sprintf("... %d more", len(m.visible)-end)))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+// renderRestorePreview describes an item and shows the start of its
+// content when it is a text file
+func renderRestorePreview(item RestoreItem) string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("Original: %s\n", item.Path))
+	s.WriteString(fmt.Sprintf("Size:     %s\n", formatBytes(item.Size)))
+	s.WriteString(fmt.Sprintf("Removed:  %s\n", item.Time))
+	if !item.Restorable() {
+		s.WriteString("Deleted permanently, only the record remains")
+		return s.String()
+	}
+	s.WriteString(fmt.Sprintf("Stored:   %s", item.Stored))
+
+	f, err := os.Open(item.Stored)
+	if err != nil {
+		s.WriteString(fmt.Sprintf("\n\nCannot read file: %v", err))
+		return s.String()
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	content := string(buf[:n])
+	if n == 0 || strings.ContainsRune(content, 0) {
+		return s.String()
+	}
+	lines := strings.Split(strings.ToValidUTF8(content, "?"), "\n")
+	if len(lines) > 6 {
+		lines = lines[:6]
+	}
+	s.WriteString("\n\n")
+	s.WriteString(strings.Join(lines, "\n"))
+	return s.String()
+}
+
+// Selected returns the items chosen for restoring, in list order
+func (m RestoreModel) Selected() []RestoreItem {
+	var selected []RestoreItem
+	for i, item := range m.items {
+		if m.selected[i] {
+			selected = append(selected, item)
+		}
+	}
+	return selected
+}
+
+// RunRestore starts the restore browser and returns the items to restore.
+// Nothing is returned if the user quits without confirming.
+func RunRestore(items []RestoreItem) ([]RestoreItem, error) {
+	p := tea.NewProgram(NewRestore(items), tea.WithAltScreen())
+	m, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	model := m.(RestoreModel)
+	if !model.confirmed {
+		return nil, nil
+	}
+	return model.Selected(), nil
+}
@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// groupFoundMsg adds a group to a review that opened before the scan ended
+type groupFoundMsg DuplicateGroup
+
+// scanProgressMsg is how many files the scan behind a review has hashed
+type scanProgressMsg int
+
+// scanDoneMsg ends the scan behind a review, with its error if it failed
+type scanDoneMsg struct{ err error }
+
+// ReviewStream feeds a review that is already open while the scan goes on
+type ReviewStream struct {
+	program *tea.Program
+}
+
+// Add appends a newly confirmed group to the review
+func (s *ReviewStream) Add(group DuplicateGroup) {
+	s.program.Send(groupFoundMsg(group))
+}
+
+// Progress shows how many files have been hashed so far
+func (s *ReviewStream) Progress(hashed int) {
+	s.program.Send(scanProgressMsg(hashed))
+}
+
+// Done tells the review that no more groups are coming
+func (s *ReviewStream) Done(err error) {
+	s.program.Send(scanDoneMsg{err})
+}
+
+// updateScan handles the messages of a streaming review
+func (m Model) updateScan(msg tea.Msg) Model {
+	switch msg := msg.(type) {
+	case groupFoundMsg:
+		m.groups = append(m.groups, DuplicateGroup(msg))
+		if len(m.groups) == 1 {
+			m.updateStatus()
+		}
+	case scanProgressMsg:
+		m.scanHashed = int(msg)
+	case scanDoneMsg:
+		m.scanning = false
+		m.scanErr = msg.err
+	}
+	return m
+}
+
+// renderScan is the line telling how far the scan behind the review is,
+// empty once a scan has finished cleanly
+func (m Model) renderScan() string {
+	switch {
+	case m.scanning:
+		return infoStyle.Render(fmt.Sprintf("Still hashing: %d files so far, new groups are added as they are confirmed", m.scanHashed)) + "\n"
+	case m.scanErr != nil:
+		return uncheckedStyle.Render(fmt.Sprintf("Hashing stopped early: %v", m.scanErr)) + "\n"
+	}
+	return ""
+}
+
+// RunStreamingReview opens the review straight away and runs scan beside
+// it. scan adds groups as it confirms them and calls Done when it is
+// finished; groups are shown in the order they arrive. Review.Groups is
+// how many reached the review before it ended.
+func RunStreamingReview(scan func(stream *ReviewStream)) (Review, error) {
+	m := New(nil)
+	m.scanning = true
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	go scan(&ReviewStream{program: p})
+	final, err := p.Run()
+	if err != nil {
+		return Review{}, err
+	}
+	return final.(Model).review(), nil
+}
+
+// renderWaiting is shown until the scan confirms its first group
+func (m Model) renderWaiting() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(" File Deduplicator v3.0.0 "))
+	s.WriteString("\n\n")
+	s.WriteString(headerStyle.Render("Waiting for the first duplicate group..."))
+	s.WriteString("\n")
+	s.WriteString(m.renderScan())
+	s.WriteString("\n")
+	s.WriteString(m.help.ShortHelpView([]key.Binding{m.keys.Finish, m.keys.Quit}))
+	return s.String()
+}
@@ -50,30 +50,44 @@ var (
 
 // FileInfo represents a file in the duplicate group
 type FileInfo struct {
-	Path     string
-	Size     int64
-	ModTime  string
-	Selected bool
+	Path       string
+	Size       int64
+	ModTime    string
+	Selected   bool
+	Hash       string  // Content hash, empty when not computed
+	PHash      string  // Perceptual hash, for images
+	Distance   int     // Hamming distance to the group's first file, -1 for exact matches
+	Similarity float64 // average similarity to the rest of a perceptual group, 0 when not measured
+	Distinct   bool    // marked as a different image from the rest of the group
 }
 
 // DuplicateGroup represents a group of duplicate files
 type DuplicateGroup struct {
-	Hash       string
-	Size       int64
-	Files      []FileInfo
-	Similarity float64
+	Hash        string
+	Size        int64
+	Files       []FileInfo
+	Similarity  float64
+	Ignored     bool     // marked not a duplicate, its files are left alone
+	Tags        []string // review tags such as "review later"
+	MaxDistance int      // distance of a perceptual group's least similar pair
+	Location    string   // how photo locations changed the similarity, if they did
 }
 
 // keyMap defines keybindings for the TUI
 type keyMap struct {
-	Up       key.Binding
-	Down     key.Binding
-	Toggle   key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	Toggle    key.Binding
 	ToggleAll key.Binding
-	Confirm  key.Binding
-	Quit     key.Binding
-	Help     key.Binding
-	Preview  key.Binding
+	Confirm   key.Binding
+	Quit      key.Binding
+	Help      key.Binding
+	Preview   key.Binding
+	Overview  key.Binding
+	Finish    key.Binding
+	Ignore    key.Binding
+	Distinct  key.Binding
+	Tag       key.Binding
 }
 
 var keys = keyMap{
@@ -109,36 +123,67 @@ var keys = keyMap{
 		key.WithKeys("p", "tab"),
 		key.WithHelp("p/tab", "toggle preview"),
 	),
+	Overview: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "list all groups"),
+	),
+	Finish: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "finish review"),
+	),
+	Ignore: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "not a duplicate"),
+	),
+	Distinct: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "different image"),
+	),
+	Tag: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "tag group"),
+	),
 }
 
 // ShortHelp returns keybindings to be shown in the mini help view.
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Help, k.Quit}
+	return []key.Binding{k.Overview, k.Help, k.Quit}
 }
 
 // FullHelp returns keybindings for the expanded help view.
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Toggle, k.ToggleAll},
-		{k.Confirm, k.Preview, k.Help, k.Quit},
+		{k.Up, k.Down, k.Toggle, k.ToggleAll, k.Distinct},
+		{k.Confirm, k.Preview, k.Overview, k.Ignore, k.Tag, k.Finish, k.Help, k.Quit},
 	}
 }
 
 // Model is the TUI state
 type Model struct {
-	groups          []DuplicateGroup
-	currentGroup    int
-	cursor          int
-	showHelp        bool
-	showPreview     bool
-	confirmed       bool
-	quitting        bool
-	width           int
-	height          int
-	keys            keyMap
-	help            help.Model
-	filesToDelete   []string
-	statusMsg       string
+	groups         []DuplicateGroup
+	currentGroup   int
+	cursor         int
+	overview       bool // listing all groups instead of reviewing one
+	overviewCursor int
+	fromOverview   bool // the current group was opened from the overview
+	showHelp       bool
+	showPreview    bool
+	confirmed      bool
+	quitting       bool
+	width          int
+	height         int
+	keys           keyMap
+	help           help.Model
+	filesToDelete  []string
+	statusMsg      string
+	imageInfo      map[string]*ImageInfo // preview details by path, nil when not an image
+	confirming     bool                  // on the consolidated confirmation
+	confirmCursor  int
+	tagging        int // group whose tag is being typed, -1 when not tagging
+	tagInput       string
+	scanning       bool  // groups are still being added by RunStreamingReview
+	scanHashed     int   // files hashed so far by the scan behind the review
+	scanErr        error // why the scan behind the review stopped early
 }
 
 // New creates a new TUI model
@@ -152,6 +197,8 @@ func New(groups []DuplicateGroup) Model {
 		keys:          keys,
 		help:          help.New(),
 		filesToDelete: []string{},
+		imageInfo:     make(map[string]*ImageInfo),
+		tagging:       -1,
 	}
 }
 
@@ -163,17 +210,43 @@ func (m Model) Init() tea.Cmd {
 // Update handles messages and user input
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case groupFoundMsg, scanProgressMsg, scanDoneMsg:
+		return m.updateScan(msg), nil
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		m.help.Width = msg.Width
 
 	case tea.KeyMsg:
+		if m.tagging >= 0 {
+			return m.updateTagInput(msg)
+		}
+		if m.confirming {
+			return m.updateConfirm(msg)
+		}
+
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			m.quitting = true
 			return m, tea.Quit
 
+		case m.overview:
+			return m.updateOverview(msg)
+
+		case key.Matches(msg, m.keys.Overview):
+			if len(m.groups) == 0 {
+				break
+			}
+			m.overview = true
+			m.overviewCursor = m.currentGroup
+			if m.overviewCursor >= len(m.groups) {
+				m.overviewCursor = len(m.groups) - 1
+			}
+
+		case key.Matches(msg, m.keys.Finish):
+			m.startConfirm()
+
 		case key.Matches(msg, m.keys.Help):
 			m.showHelp = !m.showHelp
 
@@ -193,9 +266,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case key.Matches(msg, m.keys.Ignore):
+			if m.currentGroup < len(m.groups) {
+				m.toggleIgnored(m.currentGroup)
+			}
+
+		case key.Matches(msg, m.keys.Tag):
+			if m.currentGroup < len(m.groups) {
+				m.tagging, m.tagInput = m.currentGroup, ""
+			}
+
+		case key.Matches(msg, m.keys.Distinct):
+			if m.currentGroup < len(m.groups) {
+				group := &m.groups[m.currentGroup]
+				switch {
+				case group.Similarity >= 100.0:
+					m.statusMsg = "Only similar images can be marked different"
+				case m.cursor < len(group.Files):
+					file := &group.Files[m.cursor]
+					file.Distinct = !file.Distinct
+					if file.Distinct {
+						file.Selected = false
+						m.statusMsg = "Marked as a different image: it will not be grouped with these again"
+					} else {
+						m.statusMsg = "No longer marked as a different image"
+					}
+				}
+			}
+
 		case key.Matches(msg, m.keys.Toggle):
 			if m.currentGroup < len(m.groups) {
 				group := &m.groups[m.currentGroup]
+				if group.Ignored {
+					m.statusMsg = "Group is marked not a duplicate (i to undo)"
+					return m, nil
+				}
 				if m.cursor < len(group.Files) {
 					group.Files[m.cursor].Selected = !group.Files[m.cursor].Selected
 					m.updateStatus()
@@ -205,6 +310,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.ToggleAll):
 			if m.currentGroup < len(m.groups) {
 				group := &m.groups[m.currentGroup]
+				if group.Ignored {
+					m.statusMsg = "Group is marked not a duplicate (i to undo)"
+					return m, nil
+				}
 				// Check if all are selected
 				allSelected := true
 				for i := range group.Files {
@@ -221,19 +330,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keys.Confirm):
-			if m.currentGroup < len(m.groups) {
-				group := m.groups[m.currentGroup]
-				for _, file := range group.Files {
-					if file.Selected {
-						m.filesToDelete = append(m.filesToDelete, file.Path)
-					}
+			if m.fromOverview {
+				// Back to the list, on the next group
+				m.overview = true
+				if m.overviewCursor < len(m.groups)-1 {
+					m.overviewCursor++
 				}
+				return m, nil
+			}
+			if m.scanning && m.currentGroup == len(m.groups)-1 {
+				m.statusMsg = "That was the last group so far: more appear here as hashing goes on (f to finish now)"
+				return m, nil
+			}
+			if m.currentGroup < len(m.groups) {
 				m.currentGroup++
 				m.cursor = 0
-				
+				m.updateStatus()
+
 				if m.currentGroup >= len(m.groups) {
-					m.confirmed = true
-					return m, tea.Quit
+					m.startConfirm()
 				}
 			}
 		}
@@ -242,6 +357,247 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateOverview handles input on the group overview: moving through the
+// list, opening a group for review, or finishing
+func (m Model) updateOverview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Help):
+		m.showHelp = !m.showHelp
+
+	case key.Matches(msg, m.keys.Up):
+		if m.overviewCursor > 0 {
+			m.overviewCursor--
+		}
+
+	case key.Matches(msg, m.keys.Down):
+		if m.overviewCursor < len(m.groups)-1 {
+			m.overviewCursor++
+		}
+
+	case key.Matches(msg, m.keys.Confirm):
+		if m.overviewCursor < len(m.groups) {
+			m.overview = false
+			m.fromOverview = true
+			m.currentGroup = m.overviewCursor
+			m.cursor = 0
+			m.updateStatus()
+		}
+
+	case key.Matches(msg, m.keys.Overview):
+		if m.currentGroup < len(m.groups) {
+			m.overview = false
+		}
+
+	case key.Matches(msg, m.keys.Ignore):
+		if m.overviewCursor < len(m.groups) {
+			m.toggleIgnored(m.overviewCursor)
+		}
+
+	case key.Matches(msg, m.keys.Tag):
+		if m.overviewCursor < len(m.groups) {
+			m.tagging, m.tagInput = m.overviewCursor, ""
+		}
+
+	case key.Matches(msg, m.keys.Finish):
+		m.startConfirm()
+	}
+	return m, nil
+}
+
+// startConfirm ends the review on the consolidated confirmation, which
+// lists every selected file across all groups
+func (m *Model) startConfirm() {
+	m.confirming = true
+	m.confirmCursor = 0
+	m.statusMsg = ""
+}
+
+// updateConfirm handles input on the confirmation: entries can be taken
+// off the list before everything is committed at once
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	selected := m.selections()
+	switch {
+	case msg.Type == tea.KeyEnter:
+		m.filesToDelete = m.selectedFiles()
+		m.confirmed = true
+		return m, tea.Quit
+
+	case msg.Type == tea.KeyEsc:
+		// Back to the review, on the last group if it had been finished
+		m.confirming = false
+		if m.currentGroup >= len(m.groups) && len(m.groups) > 0 {
+			m.currentGroup = len(m.groups) - 1
+			m.overview = true
+			m.overviewCursor = m.currentGroup
+		}
+
+	case key.Matches(msg, m.keys.Quit):
+		m.quitting = true
+		return m, tea.Quit
+
+	case key.Matches(msg, m.keys.Up):
+		if m.confirmCursor > 0 {
+			m.confirmCursor--
+		}
+
+	case key.Matches(msg, m.keys.Down):
+		if m.confirmCursor < len(selected)-1 {
+			m.confirmCursor++
+		}
+
+	case key.Matches(msg, m.keys.Toggle), msg.String() == "x", msg.Type == tea.KeyDelete, msg.Type == tea.KeyBackspace:
+		if m.confirmCursor < len(selected) {
+			sel := selected[m.confirmCursor]
+			m.groups[sel.group].Files[sel.file].Selected = false
+			if m.confirmCursor >= len(selected)-1 && m.confirmCursor > 0 {
+				m.confirmCursor--
+			}
+		}
+	}
+	return m, nil
+}
+
+// selection is a file marked for deletion, by group and file index
+type selection struct {
+	group, file int
+}
+
+// selections returns every file marked for deletion, in review order
+func (m Model) selections() []selection {
+	var selected []selection
+	for i, group := range m.groups {
+		for j, file := range group.Files {
+			if file.Selected {
+				selected = append(selected, selection{i, j})
+			}
+		}
+	}
+	return selected
+}
+
+// selectionTotal returns how many files are marked across all groups and
+// their combined size
+func (m Model) selectionTotal() (int, int64) {
+	count, size := 0, int64(0)
+	for _, sel := range m.selections() {
+		count++
+		size += m.groups[sel.group].Files[sel.file].Size
+	}
+	return count, size
+}
+
+// renderTotal is the footer line with the selection across all groups
+func (m Model) renderTotal() string {
+	count, size := m.selectionTotal()
+	return infoStyle.Render(fmt.Sprintf("Total: %d files (%s) marked across all groups", count, formatBytes(size)))
+}
+
+// updateTagInput handles typing a tag: enter adds it to the group, or takes
+// it off if the group already has it, and esc cancels
+func (m Model) updateTagInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if tag := strings.TrimSpace(m.tagInput); tag != "" {
+			m.toggleTag(m.tagging, tag)
+		}
+		m.tagging = -1
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.tagging = -1
+	case tea.KeyBackspace:
+		if m.tagInput != "" {
+			runes := []rune(m.tagInput)
+			m.tagInput = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.tagInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// toggleTag adds a tag to a group, or removes it if the group has it
+func (m *Model) toggleTag(i int, tag string) {
+	group := &m.groups[i]
+	for j, t := range group.Tags {
+		if t == tag {
+			group.Tags = append(group.Tags[:j:j], group.Tags[j+1:]...)
+			m.statusMsg = fmt.Sprintf("Removed tag %q", tag)
+			return
+		}
+	}
+	group.Tags = append(group.Tags, tag)
+	m.statusMsg = fmt.Sprintf("Tagged %q", tag)
+}
+
+// renderTagPrompt shows the tag being typed and the group's current tags
+func (m Model) renderTagPrompt() string {
+	var s strings.Builder
+	s.WriteString(headerStyle.Render("Tag: ") + m.tagInput + "█")
+	if tags := m.groups[m.tagging].Tags; len(tags) > 0 {
+		s.WriteString(infoStyle.Render("  (has: " + strings.Join(tags, ", ") + "; typing one removes it)"))
+	}
+	s.WriteString("\n")
+	s.WriteString(infoStyle.Render("enter to apply, esc to cancel"))
+	s.WriteString("\n")
+	return s.String()
+}
+
+// GroupTags returns every group's tags by group index
+func (m Model) GroupTags() map[int][]string {
+	tags := make(map[int][]string)
+	for i, group := range m.groups {
+		tags[i] = group.Tags
+	}
+	return tags
+}
+
+// toggleIgnored marks a group as not a duplicate, clearing its selection,
+// or takes the mark off again
+func (m *Model) toggleIgnored(i int) {
+	group := &m.groups[i]
+	group.Ignored = !group.Ignored
+	if group.Ignored {
+		for j := range group.Files {
+			group.Files[j].Selected = false
+		}
+		m.statusMsg = "Marked not a duplicate: this group will be left out of future scans"
+	} else {
+		m.statusMsg = "No longer marked not a duplicate"
+	}
+}
+
+// DistinctFiles returns, per group index, the files marked as different images
+func (m Model) DistinctFiles() map[int][]int {
+	distinct := make(map[int][]int)
+	for i, group := range m.groups {
+		for j, file := range group.Files {
+			if file.Distinct {
+				distinct[i] = append(distinct[i], j)
+			}
+		}
+	}
+	return distinct
+}
+
+// IgnoredGroups returns the indexes of the groups marked not a duplicate
+func (m Model) IgnoredGroups() []int {
+	var ignored []int
+	for i, group := range m.groups {
+		if group.Ignored {
+			ignored = append(ignored, i)
+		}
+	}
+	return ignored
+}
+
+// selectedFiles returns the files marked for deletion across all groups
+func (m Model) selectedFiles() []string {
+	files := []string{}
+	for _, sel := range m.selections() {
+		files = append(files, m.groups[sel.group].Files[sel.file].Path)
+	}
+	return files
+}
+
 // updateStatus updates the status message
 func (m *Model) updateStatus() {
 	if m.currentGroup >= len(m.groups) {
@@ -264,17 +620,24 @@ func (m Model) View() string {
 	}
 
 	if m.confirmed {
-		return m.renderConfirmation()
+		return ""
 	}
 
-	if len(m.groups) == 0 {
+	if len(m.groups) == 0 && !m.confirming {
+		if m.scanning {
+			return m.renderWaiting()
+		}
 		return "No duplicates found!\n"
 	}
 
-	if m.currentGroup >= len(m.groups) {
+	if m.confirming || m.currentGroup >= len(m.groups) {
 		return m.renderConfirmation()
 	}
 
+	if m.overview {
+		return m.renderOverview()
+	}
+
 	var s strings.Builder
 
 	// Header
@@ -285,26 +648,51 @@ func (m Model) View() string {
 	group := m.groups[m.currentGroup]
 	s.WriteString(headerStyle.Render(fmt.Sprintf("Duplicate Group %d/%d", m.currentGroup+1, len(m.groups))))
 	s.WriteString("\n")
-	
+
+	if group.Ignored {
+		s.WriteString(uncheckedStyle.Render("Marked not a duplicate: left alone now and in future scans"))
+		s.WriteString("\n")
+	}
+	if len(group.Tags) > 0 {
+		s.WriteString(infoStyle.Render("Tags: " + strings.Join(group.Tags, ", ")))
+		s.WriteString("\n")
+	}
+	if group.Location != "" {
+		s.WriteString(infoStyle.Render("Location: " + group.Location))
+		s.WriteString("\n")
+	}
 	if group.Similarity < 100.0 {
-		s.WriteString(infoStyle.Render(fmt.Sprintf("Similarity: %.0f%% | Size: %s", group.Similarity, formatBytes(group.Size))))
+		s.WriteString(infoStyle.Render(fmt.Sprintf("Similarity: %.0f%% (least similar pair %d bits apart) | Size: %s | distances are to the first file", group.Similarity, group.MaxDistance, formatBytes(group.Size))))
 	} else {
 		s.WriteString(infoStyle.Render(fmt.Sprintf("Exact match | Size: %s", formatBytes(group.Size))))
 	}
-	s.WriteString("\n\n")
+	s.WriteString("\n")
+	s.WriteString(m.renderScan())
+	s.WriteString("\n")
 
 	// File list
 	s.WriteString(m.renderFileList(group))
 	s.WriteString("\n")
 
+	// Preview of the highlighted file
+	if m.showPreview && m.cursor < len(group.Files) {
+		s.WriteString(previewStyle.Render(m.renderPreview(group.Files[m.cursor])))
+		s.WriteString("\n")
+	}
+
 	// Status
 	if m.statusMsg != "" {
 		s.WriteString(infoStyle.Render(m.statusMsg))
 		s.WriteString("\n")
 	}
+	s.WriteString(m.renderTotal())
+	s.WriteString("\n")
 
 	// Help
-	if m.showHelp {
+	if m.tagging >= 0 {
+		s.WriteString("\n")
+		s.WriteString(m.renderTagPrompt())
+	} else if m.showHelp {
 		s.WriteString("\n")
 		s.WriteString(m.help.FullHelpView(m.keys.FullHelp()))
 	} else {
@@ -341,6 +729,17 @@ func (m Model) renderFileList(group DuplicateGroup) string {
 		info := fmt.Sprintf(" (%s, %s)", formatBytes(file.Size), file.ModTime)
 		line.WriteString(infoStyle.Render(info))
 
+		// Why the file is in the group
+		if file.Distinct {
+			line.WriteString(uncheckedStyle.Render(" different image"))
+		} else if file.Distance >= 0 && file.Similarity > 0 {
+			line.WriteString(infoStyle.Render(fmt.Sprintf(" distance %d, %.0f%% similar to the rest", file.Distance, file.Similarity)))
+		} else if file.Distance >= 0 {
+			line.WriteString(infoStyle.Render(fmt.Sprintf(" distance %d", file.Distance)))
+		} else if file.Hash != "" {
+			line.WriteString(infoStyle.Render(" " + shortHash(file.Hash)))
+		}
+
 		s.WriteString(line.String())
 		s.WriteString("\n")
 	}
@@ -348,45 +747,264 @@ func (m Model) renderFileList(group DuplicateGroup) string {
 	return s.String()
 }
 
+// renderOverview renders one line per group (size, file count, directory
+// and how many files are marked), scrolled to keep the cursor on screen
+func (m Model) renderOverview() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render(" File Deduplicator v3.0.0 "))
+	s.WriteString("\n\n")
+
+	s.WriteString(headerStyle.Render(fmt.Sprintf("%d Duplicate Groups", len(m.groups))))
+	s.WriteString("\n")
+	s.WriteString(m.renderScan())
+	s.WriteString("\n")
+
+	rows := m.height - 10
+	if rows < 5 {
+		rows = 5
+	}
+	start := 0
+	if m.overviewCursor >= rows {
+		start = m.overviewCursor - rows + 1
+	}
+	end := start + rows
+	if end > len(m.groups) {
+		end = len(m.groups)
+	}
+
+	for i := start; i < end; i++ {
+		group := m.groups[i]
+		selected := 0
+		for _, f := range group.Files {
+			if f.Selected {
+				selected++
+			}
+		}
+
+		line := fmt.Sprintf("%4d  %9s  %3d files  %s", i+1, formatBytes(group.Size), len(group.Files), groupDir(group))
+		if i == m.overviewCursor {
+			s.WriteString(selectedItemStyle.Render("> " + line))
+		} else {
+			s.WriteString(itemStyle.Render(line))
+		}
+		if group.Ignored {
+			s.WriteString(uncheckedStyle.Render("  [not a duplicate]"))
+		} else if selected > 0 {
+			s.WriteString(checkedStyle.Render(fmt.Sprintf("  [%d marked]", selected)))
+		}
+		if len(group.Tags) > 0 {
+			s.WriteString(infoStyle.Render("  #" + strings.Join(group.Tags, " #")))
+		}
+		s.WriteString("\n")
+	}
+	if end < len(m.groups) {
+		s.WriteString(infoStyle.Render(fmt.Sprintf("    ... %d more", len(m.groups)-end)))
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	if m.statusMsg != "" {
+		s.WriteString(infoStyle.Render(m.statusMsg))
+		s.WriteString("\n")
+	}
+	s.WriteString(m.renderTotal())
+	s.WriteString("\n")
+	if m.tagging >= 0 {
+		s.WriteString(m.renderTagPrompt())
+	} else if m.showHelp {
+		s.WriteString(m.help.FullHelpView(m.keys.FullHelp()))
+	} else {
+		open := key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "review group"))
+		s.WriteString(m.help.ShortHelpView([]key.Binding{open, m.keys.Ignore, m.keys.Finish, m.keys.Help, m.keys.Quit}))
+	}
+	return s.String()
+}
+
+// groupDir returns the deepest directory containing every file in group
+func groupDir(group DuplicateGroup) string {
+	if len(group.Files) == 0 {
+		return ""
+	}
+	dir := filepath.Dir(group.Files[0].Path)
+	for _, f := range group.Files[1:] {
+		for !isWithin(filepath.Dir(f.Path), dir) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return dir
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// renderPreview describes a file, adding dimensions, camera, capture date
+// and GPS presence for images so similar photos can be compared
+func (m Model) renderPreview(file FileInfo) string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("Path:       %s\n", file.Path))
+	s.WriteString(fmt.Sprintf("Size:       %s\n", formatBytes(file.Size)))
+	s.WriteString(fmt.Sprintf("Modified:   %s", file.ModTime))
+	if file.Hash != "" {
+		s.WriteString(fmt.Sprintf("\nHash:       %s", file.Hash))
+	}
+	if file.PHash != "" {
+		s.WriteString(fmt.Sprintf("\nPerceptual: %s", file.PHash))
+	}
+	if file.Distance >= 0 {
+		s.WriteString(fmt.Sprintf("\nDistance:   %d bits from the first file", file.Distance))
+	}
+
+	info, ok := m.imageInfo[file.Path]
+	if !ok {
+		if i, err := readImageInfo(file.Path); err == nil {
+			info = &i
+		}
+		m.imageInfo[file.Path] = info
+	}
+	if info == nil {
+		return s.String()
+	}
+
+	s.WriteString(fmt.Sprintf("\nDimensions: %dx%d %s", info.Width, info.Height, strings.ToUpper(info.Format)))
+	if info.Camera != "" {
+		s.WriteString(fmt.Sprintf("\nCamera:     %s", info.Camera))
+	}
+	if info.Taken != "" {
+		s.WriteString(fmt.Sprintf("\nTaken:      %s", info.Taken))
+	}
+	if info.HasGPS {
+		s.WriteString("\nGPS:        yes")
+	} else {
+		s.WriteString("\nGPS:        none")
+	}
+	return s.String()
+}
+
 // renderConfirmation renders the final confirmation screen
 func (m Model) renderConfirmation() string {
 	var s strings.Builder
 
 	s.WriteString(titleStyle.Render(" Confirmation "))
 	s.WriteString("\n\n")
+	if m.scanning {
+		s.WriteString(uncheckedStyle.Render("Still hashing: finishing now stops the scan, and groups found later are left for the next run"))
+		s.WriteString("\n\n")
+	}
 
-	if len(m.filesToDelete) == 0 {
-		s.WriteString("No files selected for deletion.\n")
-	} else {
-		s.WriteString(fmt.Sprintf("About to delete %d files:\n\n", len(m.filesToDelete)))
-		for i, path := range m.filesToDelete {
-			if i >= 10 {
-				s.WriteString(fmt.Sprintf("... and %d more\n", len(m.filesToDelete)-10))
-				break
-			}
-			s.WriteString(fmt.Sprintf("  • %s\n", path))
+	selected := m.selections()
+	if len(selected) == 0 {
+		s.WriteString("No files selected for deletion.\n\n")
+		s.WriteString(infoStyle.Render("enter finish · esc back to review · q quit"))
+		s.WriteString("\n")
+		return s.String()
+	}
+
+	count, size := m.selectionTotal()
+	s.WriteString(headerStyle.Render(fmt.Sprintf("About to delete %d files (%s) from %d groups:", count, formatBytes(size), len(m.groupsWithSelection()))))
+	s.WriteString("\n\n")
+
+	rows := m.height - 10
+	if rows < 5 {
+		rows = 5
+	}
+	start := 0
+	if m.confirmCursor >= rows {
+		start = m.confirmCursor - rows + 1
+	}
+	end := start + rows
+	if end > len(selected) {
+		end = len(selected)
+	}
+	for pos := start; pos < end; pos++ {
+		sel := selected[pos]
+		file := m.groups[sel.group].Files[sel.file]
+		line := fmt.Sprintf("%4d  %9s  %s", sel.group+1, formatBytes(file.Size), file.Path)
+		if pos == m.confirmCursor {
+			s.WriteString(selectedItemStyle.Render("> " + line))
+		} else {
+			s.WriteString(itemStyle.Render(line))
 		}
-		s.WriteString("\nPress Enter to confirm, or Esc to cancel.\n")
+		s.WriteString("\n")
+	}
+	if end < len(selected) {
+		s.WriteString(infoStyle.Render(fmt.Sprintf("    ... %d more", len(selected)-end)))
+		s.WriteString("\n")
 	}
 
+	s.WriteString("\n")
+	s.WriteString(infoStyle.Render("enter confirm · space/x remove from list · esc back to review · q quit"))
+	s.WriteString("\n")
 	return s.String()
 }
 
+// groupsWithSelection returns the indexes of groups with a marked file
+func (m Model) groupsWithSelection() []int {
+	var groups []int
+	for _, sel := range m.selections() {
+		if len(groups) == 0 || groups[len(groups)-1] != sel.group {
+			groups = append(groups, sel.group)
+		}
+	}
+	return groups
+}
+
 // GetFilesToDelete returns the list of files marked for deletion
 func (m Model) GetFilesToDelete() []string {
 	return m.filesToDelete
 }
 
+// Review is the outcome of a TUI session
+type Review struct {
+	Delete   []string         // files selected for deletion
+	Ignored  []int            // indexes of groups marked not a duplicate
+	Distinct map[int][]int    // per group index, files marked as different images
+	Tags     map[int][]string // every group's tags by index, also when the review is abandoned
+	Groups   int              // how many groups the review held
+	Partial  bool             // a streaming review ended before its scan did
+}
+
 // Run starts the TUI and returns the selected files to delete
 func Run(groups []DuplicateGroup) ([]string, error) {
+	review, err := RunReview(groups)
+	return review.Delete, err
+}
+
+// RunReview starts the TUI and returns the files to delete, the groups
+// marked not a duplicate and the group tags. Marks are only returned once
+// the review is confirmed; tags are kept for the next session either way.
+func RunReview(groups []DuplicateGroup) (Review, error) {
 	p := tea.NewProgram(New(groups), tea.WithAltScreen())
 	m, err := p.Run()
 	if err != nil {
-		return nil, err
+		return Review{}, err
 	}
+	return m.(Model).review(), nil
+}
 
-	model := m.(Model)
-	return model.GetFilesToDelete(), nil
+// review collects the outcome of a finished session
+func (m Model) review() Review {
+	review := Review{Delete: m.GetFilesToDelete(), Tags: m.GroupTags(), Groups: len(m.groups), Partial: m.scanning}
+	if m.confirmed {
+		review.Ignored = m.IgnoredGroups()
+		review.Distinct = m.DistinctFiles()
+	}
+	return review
+}
+
+// shortHash abbreviates a hash for the file list
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
 }
 
 // formatBytes formats bytes into human-readable string
@@ -405,9 +1023,12 @@ func formatBytes(bytes int64) string {
 
 // ConvertDuplicateGroup converts the main package's DuplicateGroup to TUI format
 func ConvertDuplicateGroup(hash string, size int64, files []struct {
-	Path    string
-	Size    int64
-	ModTime string
+	Path     string
+	Size     int64
+	ModTime  string
+	Hash     string
+	PHash    string
+	Distance int
 }, similarity float64) DuplicateGroup {
 	convertedFiles := make([]FileInfo, len(files))
 	for i, f := range files {
@@ -416,6 +1037,9 @@ func ConvertDuplicateGroup(hash string, size int64, files []struct {
 			Size:     f.Size,
 			ModTime:  f.ModTime,
 			Selected: false,
+			Hash:     f.Hash,
+			PHash:    f.PHash,
+			Distance: f.Distance,
 		}
 	}
 	return DuplicateGroup{
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTagGroup(t *testing.T) {
+	m := New([]DuplicateGroup{{Hash: "abc", Size: 1, Files: []FileInfo{{Path: "a"}, {Path: "b"}}, Similarity: 100}})
+	send := func(msg tea.KeyMsg) {
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+	typeTag := func(tag string) {
+		send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+		send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tag)})
+		send(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	// q while typing is part of the tag, not quit
+	typeTag("ask q")
+	if m.quitting || len(m.groups[0].Tags) != 1 || m.groups[0].Tags[0] != "ask q" {
+		t.Fatalf("tags = %q (quitting %v), want [ask q]", m.groups[0].Tags, m.quitting)
+	}
+	typeTag("ask q")
+	if len(m.GroupTags()[0]) != 0 {
+		t.Errorf("typing an existing tag should remove it, got %q", m.GroupTags()[0])
+	}
+}
+
+func TestConsolidatedConfirmation(t *testing.T) {
+	m := New([]DuplicateGroup{
+		{Hash: "a", Size: 10, Similarity: 100, Files: []FileInfo{{Path: "a1", Size: 10}, {Path: "a2", Size: 10}}},
+		{Hash: "b", Size: 5, Similarity: 100, Files: []FileInfo{{Path: "b1", Size: 5}, {Path: "b2", Size: 5}}},
+	})
+	send := func(msg tea.KeyMsg) tea.Cmd {
+		model, cmd := m.Update(msg)
+		m = model.(Model)
+		return cmd
+	}
+	keys := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// Mark a2 and b2, committing nothing group by group
+	send(keys("j"))
+	send(keys(" "))
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	send(keys("j"))
+	send(keys(" "))
+	if count, size := m.selectionTotal(); count != 2 || size != 15 {
+		t.Fatalf("total = %d files, %d bytes; want 2 files, 15 bytes", count, size)
+	}
+	if !strings.Contains(m.View(), "Total: 2 files (15 B)") {
+		t.Errorf("footer should show the total across groups:\n%s", m.View())
+	}
+
+	if cmd := send(tea.KeyMsg{Type: tea.KeyEnter}); cmd != nil || !m.confirming {
+		t.Fatal("finishing the last group should open the confirmation, not quit")
+	}
+	send(keys("x")) // take a2 off the list
+	if cmd := send(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil || !m.confirmed {
+		t.Fatal("enter should confirm")
+	}
+	if got := m.GetFilesToDelete(); len(got) != 1 || got[0] != "b2" {
+		t.Errorf("files to delete = %v, want [b2]", got)
+	}
+}
+
+func TestStreamingReview(t *testing.T) {
+	m := New(nil)
+	m.scanning = true
+	send := func(msg tea.Msg) {
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+
+	if !strings.Contains(m.View(), "Waiting for the first duplicate group") {
+		t.Fatalf("an empty streaming review should wait for groups:\n%s", m.View())
+	}
+	send(groupFoundMsg{Hash: "a", Size: 10, Similarity: 100, Files: []FileInfo{{Path: "a1", Size: 10}, {Path: "a2", Size: 10}}})
+	send(scanProgressMsg(42))
+	if view := m.View(); !strings.Contains(view, "Duplicate Group 1/1") || !strings.Contains(view, "42 files so far") {
+		t.Fatalf("the first group should be up with the scan progress:\n%s", view)
+	}
+
+	// Moving past the last group while hashing waits there for more
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.confirming || m.currentGroup != 0 {
+		t.Fatal("the review should not end while groups are still coming")
+	}
+	send(groupFoundMsg{Hash: "b", Size: 5, Similarity: 100, Files: []FileInfo{{Path: "b1", Size: 5}, {Path: "b2", Size: 5}}})
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.currentGroup != 1 {
+		t.Fatalf("current group = %d, want the newly found group", m.currentGroup)
+	}
+
+	send(scanDoneMsg{})
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.confirming {
+		t.Fatal("once hashing is done, finishing the last group should open the confirmation")
+	}
+	if review := m.review(); review.Groups != 2 || review.Partial {
+		t.Errorf("review = %d groups (partial %v), want 2 groups from a finished scan", review.Groups, review.Partial)
+	}
+}
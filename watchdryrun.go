@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// dryRunTally counts what auto-clean would have cleaned with -dry-run, over
+// the whole run and for the day in progress, so a policy can be trialled for
+// a while before it is allowed to move files
+type dryRunTally struct {
+	day      string // the day being counted, as 2006-01-02 in local time
+	dayFiles int
+	dayBytes int64
+	files    int
+	bytes    int64
+}
+
+// add counts a file that would have been cleaned at now. The summary of an
+// earlier day is logged first.
+func (t *dryRunTally) add(fh FileHash, now time.Time) {
+	t.rollOver(now)
+	t.dayFiles++
+	t.dayBytes += fh.Size
+	t.files++
+	t.bytes += fh.Size
+}
+
+// rollOver logs the summary of the day being counted once now is past it
+func (t *dryRunTally) rollOver(now time.Time) {
+	day := now.Format("2006-01-02")
+	if day == t.day {
+		return
+	}
+	if t.day != "" {
+		t.logDay()
+	}
+	t.day, t.dayFiles, t.dayBytes = day, 0, 0
+}
+
+// logDay logs what auto-clean would have done on the day being counted
+func (t *dryRunTally) logDay() {
+	log.Printf("%sDry run summary for %s: auto-clean would have cleaned %d files (%s)", emoji("📅"), t.day, t.dayFiles, formatBytes(t.dayBytes))
+}
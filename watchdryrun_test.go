@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchAutoCleanDryRun(t *testing.T) {
+	c := DefaultConfig()
+	c.DryRun = true
+
+	root := t.TempDir()
+	var files []FileHash
+	for _, name := range []string{"original.txt", "copy.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, _ := os.Stat(path)
+		files = append(files, FileHash{Path: path, Size: info.Size(), Hash: "h", ModTime: info.ModTime()})
+	}
+	state := &WatchModeState{
+		hashMap:    map[string][]FileHash{"h": files},
+		pHashMap:   make(map[string][]FileHash),
+		watchedDir: root,
+		quarantine: newQuarantine("", root),
+		pending:    make(map[string]*time.Timer),
+		cfg:        c,
+	}
+
+	state.autoClean(files[1], files[:1])
+	if _, err := os.Stat(files[1].Path); err != nil {
+		t.Error("a dry run must not clean the duplicate")
+	}
+	if _, err := os.Stat(filepath.Join(root, quarantineDirName)); !os.IsNotExist(err) {
+		t.Error("a dry run must not create the quarantine")
+	}
+	if len(state.hashMap["h"]) != 2 {
+		t.Error("a file left in place should still be tracked")
+	}
+	if state.dryRun.files != 1 || state.dryRun.bytes != 7 {
+		t.Errorf("tally = %d files, %d bytes, want 1 file of 7 bytes", state.dryRun.files, state.dryRun.bytes)
+	}
+}
+
+func TestDryRunTallyRollsOverDaily(t *testing.T) {
+	var tally dryRunTally
+	day := time.Date(2026, 3, 1, 23, 0, 0, 0, time.Local)
+	tally.add(FileHash{Size: 10}, day)
+	tally.add(FileHash{Size: 5}, day.Add(30*time.Minute))
+	if tally.dayFiles != 2 || tally.dayBytes != 15 {
+		t.Errorf("first day = %d files, %d bytes, want 2 files of 15 bytes", tally.dayFiles, tally.dayBytes)
+	}
+
+	tally.rollOver(day.Add(2 * time.Hour))
+	if tally.day != "2026-03-02" || tally.dayFiles != 0 || tally.dayBytes != 0 {
+		t.Errorf("after midnight day = %s with %d files, want a fresh 2026-03-02", tally.day, tally.dayFiles)
+	}
+	if tally.files != 2 || tally.bytes != 15 {
+		t.Errorf("run total = %d files, %d bytes, want 2 files of 15 bytes", tally.files, tally.bytes)
+	}
+}
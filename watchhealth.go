@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchRetryInterval is how often a watcher tries again to watch the folders
+// it could not, such as when the inotify watch limit was reached
+const watchRetryInterval = time.Minute
+
+// watchHealth is what a watcher reports about the events it may have missed
+type watchHealth struct {
+	Overflows   int       `json:"overflows"`             // times the event queue overflowed and events were dropped
+	Errors      int       `json:"errors"`                // other errors from the watcher
+	Unwatchable []string  `json:"unwatchable,omitempty"` // folders that cannot be watched right now
+	Rescans     int       `json:"rescans"`               // folders rescanned to make up for a gap
+	LastGap     *watchGap `json:"last_gap,omitempty"`
+}
+
+// watchGap is what the latest rescan found that the watcher had missed
+type watchGap struct {
+	Dir     string    `json:"dir"`
+	Reason  string    `json:"reason"`
+	Time    time.Time `json:"time"`
+	New     int       `json:"new"`     // files the watcher never saw
+	Changed int       `json:"changed"` // tracked files whose size or modification time changed unseen
+	Gone    int       `json:"gone"`    // tracked files that disappeared unseen
+}
+
+// watchError records an error from the watcher. Events may have been lost
+// with it, so the whole watched folder is rescanned.
+func (s *WatchModeState) watchError(err error) {
+	s.mu.Lock()
+	reason := "a watcher error"
+	if errors.Is(err, fsnotify.ErrEventOverflow) {
+		s.health.Overflows++
+		reason = "dropped events"
+	} else {
+		s.health.Errors++
+	}
+	s.mu.Unlock()
+	log.Printf("%sWatcher error: %v; rescanning %s", emoji("⚠️"), err, s.watchedDir)
+	s.needRescan(s.watchedDir, reason)
+}
+
+// needRescan asks for dir to be rescanned at the next tick, unless a folder
+// above it already is
+func (s *WatchModeState) needRescan(dir, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rescans == nil {
+		s.rescans = make(map[string]string)
+	}
+	for pending := range s.rescans {
+		if pending == dir || strings.HasPrefix(dir, pending+string(filepath.Separator)) {
+			return
+		}
+		if strings.HasPrefix(pending, dir+string(filepath.Separator)) {
+			delete(s.rescans, pending)
+		}
+	}
+	s.rescans[dir] = reason
+}
+
+// unwatchable records a folder the watcher could not add, to be tried again
+// every watchRetryInterval
+func (s *WatchModeState) unwatchable(dir string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unwatched == nil {
+		s.unwatched = make(map[string]bool)
+	}
+	if !s.unwatched[dir] {
+		log.Printf("%sCannot watch %s: %v; changes there are picked up by a rescan once it can be watched", emoji("⚠️"), dir, err)
+	}
+	s.unwatched[dir] = true
+}
+
+// retryUnwatchable tries again to watch the folders that could not be, and
+// rescans each one that now can, for the changes made while it was not
+// watched
+func (s *WatchModeState) retryUnwatchable(watcher *fsnotify.Watcher) {
+	s.mu.Lock()
+	dirs := make([]string, 0, len(s.unwatched))
+	for dir := range s.unwatched {
+		dirs = append(dirs, dir)
+	}
+	s.unwatched = nil
+	s.mu.Unlock()
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue // gone; a rescan of its parent finds what went with it
+		}
+		if err := s.addWatchDir(watcher, s.watchedDir, dir); err != nil {
+			s.unwatchable(dir, err)
+			continue
+		}
+		s.needRescan(dir, "not being watched")
+	}
+}
+
+// runRescans rescans the folders asked for, queueing the files the watcher
+// missed to settle like new ones and forgetting tracked files that are gone.
+// Each rescan that found a gap is logged.
+func (s *WatchModeState) runRescans(filter *fileFilter, pending *settleQueue, now time.Time) {
+	s.mu.Lock()
+	rescans := s.rescans
+	s.rescans = nil
+	s.mu.Unlock()
+
+	dirs := make([]string, 0, len(rescans))
+	for dir := range rescans {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		gap := watchGap{Dir: dir, Reason: rescans[dir], Time: now}
+		tracked := s.trackedIn(dir)
+		for _, file := range s.watchedFiles(s.watchedDir, dir, filter) {
+			fh, known := tracked[file]
+			delete(tracked, file)
+			if _, queued := pending.files[file]; queued {
+				continue // the watcher saw this one
+			}
+			if known {
+				info, err := os.Stat(file)
+				if err != nil || (info.Size() == fh.Size && info.ModTime().Equal(fh.ModTime)) {
+					continue
+				}
+				s.untrack(fh)
+				gap.Changed++
+			} else {
+				gap.New++
+			}
+			pending.touch(file, now)
+		}
+		for _, fh := range tracked {
+			if _, err := os.Stat(fh.Path); os.IsNotExist(err) {
+				s.untrack(fh)
+				gap.Gone++
+			}
+		}
+
+		s.mu.Lock()
+		s.health.Rescans++
+		if gap.New+gap.Changed+gap.Gone > 0 {
+			s.health.LastGap = &gap
+		}
+		s.mu.Unlock()
+		if gap.New+gap.Changed+gap.Gone > 0 {
+			log.Printf("%sRescanned %s after %s: %d new, %d changed and %d removed files had been missed",
+				emoji("🔁"), dir, gap.Reason, gap.New, gap.Changed, gap.Gone)
+		}
+	}
+	s.setSettling(len(pending.files), time.Time{})
+}
+
+// untrack forgets a file the watcher will no longer count as watched
+func (s *WatchModeState) untrack(fh FileHash) {
+	s.forget(fh)
+	s.mu.Lock()
+	s.stats.FilesWatched--
+	s.mu.Unlock()
+}
+
+// trackedIn returns the tracked files below dir by path
+func (s *WatchModeState) trackedIn(dir string) map[string]FileHash {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tracked := make(map[string]FileHash)
+	for _, files := range s.hashMap {
+		for _, fh := range files {
+			if fh.Path == dir || strings.HasPrefix(fh.Path, dir+string(filepath.Separator)) {
+				tracked[fh.Path] = fh
+			}
+		}
+	}
+	return tracked
+}
+
+// healthStatus returns a copy of the watcher's health for -watch-status.
+// The caller holds s.mu.
+func (s *WatchModeState) healthStatus() watchHealth {
+	health := s.health
+	for dir := range s.unwatched {
+		health.Unwatchable = append(health.Unwatchable, dir)
+	}
+	sort.Strings(health.Unwatchable)
+	return health
+}
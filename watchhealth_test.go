@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchRescanFindsMissedFiles(t *testing.T) {
+	c := DefaultConfig()
+	c.MinSize = 1
+	filter, err := newFileFilter(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	kept := write("kept.txt", "kept")
+	changed := write("sub/changed.txt", "before")
+	gone := write("sub/gone.txt", "gone")
+
+	state := &WatchModeState{
+		hashMap:    make(map[string][]FileHash),
+		pHashMap:   make(map[string][]FileHash),
+		watchedDir: root,
+		pending:    make(map[string]*time.Timer),
+		cfg:        c,
+	}
+	if err := state.initialScan(root, filter); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes the watcher never heard about
+	added := write("sub/added.txt", "added")
+	write("sub/changed.txt", "after, and longer")
+	os.Remove(gone)
+	seen := write("seen.txt", "seen")
+
+	pending := newSettleQueue(time.Second)
+	pending.touch(seen, time.Now())
+	state.watchError(fmt.Errorf("%w: 16384 events", fsnotify.ErrEventOverflow))
+	state.runRescans(filter, pending, time.Now())
+
+	for path, want := range map[string]bool{added: true, changed: true, seen: true, kept: false} {
+		if _, queued := pending.files[path]; queued != want {
+			t.Errorf("%s queued = %v, want %v", filepath.Base(path), queued, want)
+		}
+	}
+	if tracked := state.trackedIn(root); len(tracked) != 1 {
+		t.Errorf("tracking %d files after the rescan, want only kept.txt until the rest settle", len(tracked))
+	}
+
+	health := state.healthStatus()
+	if health.Overflows != 1 || health.Rescans != 1 || health.LastGap == nil {
+		t.Fatalf("health = %+v", health)
+	}
+	if gap := *health.LastGap; gap.Dir != root || gap.New != 1 || gap.Changed != 1 || gap.Gone != 1 {
+		t.Errorf("gap = %+v, want 1 new, 1 changed and 1 removed file in %s", gap, root)
+	}
+}
+
+func TestNeedRescanKeepsOutermostFolder(t *testing.T) {
+	state := &WatchModeState{}
+	sep := string(filepath.Separator)
+	state.needRescan(sep+"w"+sep+"a"+sep+"b", "a new folder")
+	state.needRescan(sep+"w"+sep+"a", "not being watched")
+	state.needRescan(sep+"w"+sep+"a"+sep+"c", "a new folder")
+	state.needRescan(sep+"w"+sep+"ab", "a new folder")
+
+	if len(state.rescans) != 2 || state.rescans[sep+"w"+sep+"a"] != "not being watched" || state.rescans[sep+"w"+sep+"ab"] == "" {
+		t.Errorf("rescans = %v, want /w/a and /w/ab", state.rescans)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxRecentDuplicates is how many of the latest duplicates a watcher reports
+const maxRecentDuplicates = 10
+
+// watchStatus is what a running watcher reports to -watch-status
+type watchStatus struct {
+	Dir              string      `json:"dir"`
+	PID              int         `json:"pid"`
+	Started          time.Time   `json:"started"`
+	FilesTracked     int         `json:"files_tracked"`
+	UniqueHashes     int         `json:"unique_hashes"`
+	DuplicatesFound  int         `json:"duplicates_found"`
+	SpaceRecoverable int64       `json:"space_recoverable"`
+	LastEvent        time.Time   `json:"last_event,omitempty"`
+	Settling         int         `json:"settling"`
+	PendingCleans    int         `json:"pending_cleans"`
+	RecentDuplicates []string    `json:"recent_duplicates"`
+	Health           watchHealth `json:"health"`
+}
+
+// runSocketPath is where a run of the given kind on dir serves its local
+// socket. It lives in the temp directory because socket paths are limited
+// to ~100 bytes.
+func runSocketPath(kind, dir string) string {
+	sum := sha256.Sum256([]byte(dir))
+	return filepath.Join(os.TempDir(), fmt.Sprintf("file-deduplicator-%s-%x.sock", kind, sum[:6]))
+}
+
+// status returns a snapshot of the watcher's state
+func (s *WatchModeState) status() watchStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return watchStatus{
+		Dir:              s.watchedDir,
+		PID:              os.Getpid(),
+		Started:          s.started,
+		FilesTracked:     s.stats.FilesWatched,
+		UniqueHashes:     len(s.hashMap),
+		DuplicatesFound:  s.stats.DuplicatesFound,
+		SpaceRecoverable: s.stats.SpaceRecoverable,
+		LastEvent:        s.stats.LastEvent,
+		Settling:         s.stats.Settling,
+		PendingCleans:    len(s.pending),
+		RecentDuplicates: append([]string{}, s.recent...),
+		Health:           s.healthStatus(),
+	}
+}
+
+// serveWatchStatus answers every connection to the watcher's socket with
+// its current status. The returned function stops serving.
+func serveWatchStatus(state *WatchModeState) (func(), error) {
+	socketPath := runSocketPath("watch", state.watchedDir)
+
+	// Another watcher of the same directory keeps its socket
+	ln, err := listenUnix(socketPath)
+	if errors.Is(err, errSocketInUse) {
+		return nil, fmt.Errorf("another watcher of %s is already running", state.watchedDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					log.Printf("%sStatus socket error: %v", emoji("⚠️"), err)
+				}
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			json.NewEncoder(conn).Encode(state.status())
+			conn.Close()
+		}
+	}()
+
+	return func() {
+		ln.Close()
+		os.Remove(socketPath)
+	}, nil
+}
+
+// queryWatchStatus asks the watcher of dir for its status
+func queryWatchStatus(dir string) (watchStatus, error) {
+	var status watchStatus
+	conn, err := net.DialTimeout("unix", runSocketPath("watch", dir), 5*time.Second)
+	if err != nil {
+		return status, fmt.Errorf("no watcher is running for %s", dir)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewDecoder(conn).Decode(&status); err != nil {
+		return status, fmt.Errorf("invalid status from watcher: %w", err)
+	}
+	return status, nil
+}
+
+// runWatchStatus prints the status of the watcher of the first -dir
+func runWatchStatus(c Config) error {
+	dir, err := filepath.Abs(c.roots()[0])
+	if err != nil {
+		return fmt.Errorf("cannot resolve directory: %w", err)
+	}
+	status, err := queryWatchStatus(dir)
+	if err != nil {
+		return err
+	}
+
+	if c.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+
+	fmt.Printf("%sWatching %s (pid %d) since %s\n", emoji("👁️ "), status.Dir, status.PID, status.Started.Format("2006-01-02 15:04:05"))
+	fmt.Printf("%sFiles tracked: %d (%d unique)\n", emoji("📄"), status.FilesTracked, status.UniqueHashes)
+	fmt.Printf("%sDuplicates found: %d, %s recoverable\n", emoji("👯"), status.DuplicatesFound, formatBytes(status.SpaceRecoverable))
+	if status.LastEvent.IsZero() {
+		fmt.Printf("%sLast event: none yet\n", emoji("⏱️"))
+	} else {
+		fmt.Printf("%sLast event: %s (%s ago)\n", emoji("⏱️"), status.LastEvent.Format("2006-01-02 15:04:05"), time.Since(status.LastEvent).Round(time.Second))
+	}
+	if status.Settling > 0 || status.PendingCleans > 0 {
+		fmt.Printf("%sWaiting: %d files settling, %d auto-cleans in grace period\n", emoji("⏳"), status.Settling, status.PendingCleans)
+	}
+	if h := status.Health; h.Overflows+h.Errors > 0 || len(h.Unwatchable) > 0 {
+		fmt.Printf("%sWatcher trouble: %d overflows, %d errors, %d rescans\n", emoji("⚠️"), h.Overflows, h.Errors, h.Rescans)
+		for _, dir := range h.Unwatchable {
+			fmt.Printf("   • cannot watch %s\n", dir)
+		}
+	}
+	if gap := status.Health.LastGap; gap != nil {
+		fmt.Printf("%sLast gap: %s after %s at %s (%d new, %d changed, %d removed files missed)\n", emoji("🔁"),
+			gap.Dir, gap.Reason, gap.Time.Format("2006-01-02 15:04:05"), gap.New, gap.Changed, gap.Gone)
+	}
+	if len(status.RecentDuplicates) > 0 {
+		fmt.Printf("%sRecent duplicates:\n", emoji("🚨"))
+		for i := len(status.RecentDuplicates) - 1; i >= 0; i-- {
+			fmt.Printf("   • %s\n", status.RecentDuplicates[i])
+		}
+	}
+	return nil
+}
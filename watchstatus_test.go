@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchStatusSocket(t *testing.T) {
+	dir := t.TempDir()
+	state := &WatchModeState{
+		hashMap:    map[string][]FileHash{"h": {{Path: dir + "/a"}, {Path: dir + "/b"}}},
+		pHashMap:   make(map[string][]FileHash),
+		watchedDir: dir,
+		pending:    make(map[string]*time.Timer),
+		started:    time.Now(),
+		recent:     []string{dir + "/b"},
+	}
+	state.stats.FilesWatched = 2
+	state.stats.DuplicatesFound = 1
+	state.setSettling(3, time.Now())
+
+	stop, err := serveWatchStatus(state)
+	if err != nil {
+		t.Fatalf("serveWatchStatus() error = %v", err)
+	}
+
+	// A second watcher of the same directory must not take over the socket
+	if _, err := serveWatchStatus(state); err == nil {
+		t.Error("serveWatchStatus() should refuse while another watcher is running")
+	}
+
+	status, err := queryWatchStatus(dir)
+	if err != nil {
+		t.Fatalf("queryWatchStatus() error = %v", err)
+	}
+	if status.Dir != dir || status.FilesTracked != 2 || status.UniqueHashes != 1 || status.DuplicatesFound != 1 ||
+		status.Settling != 3 || status.LastEvent.IsZero() || len(status.RecentDuplicates) != 1 {
+		t.Errorf("queryWatchStatus() = %+v", status)
+	}
+
+	stop()
+	if _, err := queryWatchStatus(dir); err == nil {
+		t.Error("queryWatchStatus() should fail once the watcher has stopped")
+	}
+}